@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/command/views"
 	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/states/statemgr"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
@@ -82,6 +83,11 @@ type Local struct {
 	// If this is nil, local performs normal state loading and storage.
 	Backend backend.Backend
 
+	// integrations tracks the integration processes started for the
+	// operation currently in progress, if any, so that the operation can
+	// shut them down once it completes.
+	integrations *integrations.Manager
+
 	// opLock locks operations
 	opLock sync.Mutex
 }
@@ -8,6 +8,9 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/command/views"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/internal/integrations"
+	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/hashicorp/terraform/states/statemgr"
@@ -58,6 +61,22 @@ func (b *Local) opApply(
 		}
 	}()
 
+	// If any integrations were started for this operation then we must
+	// flush and stop them once the operation completes.
+	defer b.closeIntegrations(op)
+
+	// When applying a saved plan, check that the integrations that were
+	// running when the plan was created are still what this machine would
+	// run, so that an apply elsewhere can't bypass a plan-time policy.
+	if op.PlanFile != nil {
+		moreDiags := b.checkPlanFileIntegrations(op.PlanFile, tfCtx.Config())
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			op.ReportResult(runningOp, diags)
+			return
+		}
+	}
+
 	runningOp.State = tfCtx.State()
 
 	// If we weren't given a plan, then we refresh/plan
@@ -171,6 +190,38 @@ func (b *Local) opApply(
 	op.View.Diagnostics(diags)
 }
 
+// checkPlanFileIntegrations compares the integration set recorded in the
+// given plan file against the set the current configuration and machine
+// would run, returning a warning for each difference, or an error for
+// differences in integrations that set enforce_at_apply.
+func (b *Local) checkPlanFileIntegrations(pf *planfile.Reader, config *configs.Config) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	results, err := pf.ReadIntegrationResults()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid plan file",
+			fmt.Sprintf("Failed to read integration results from plan file: %s.", err),
+		))
+		return diags
+	}
+
+	var recorded []planfile.IntegrationDescriptor
+	if results != nil {
+		recorded = results.Integrations
+	}
+	var current []planfile.IntegrationDescriptor
+	if config != nil && len(config.Module.Integrations) > 0 {
+		current = integrations.DescribeSet(config.Module.Integrations)
+	}
+	if len(recorded) == 0 && len(current) == 0 {
+		return diags
+	}
+
+	return diags.Append(integrations.CheckIntegrationSet(recorded, current))
+}
+
 // backupStateForError is called in a scenario where we're unable to persist the
 // state for some reason, and will attempt to save a backup copy of the state
 // to local disk to help the user recover. This is a "last ditch effort" sort
@@ -2,6 +2,7 @@ package local
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"sort"
@@ -10,10 +11,12 @@ import (
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/configs/configload"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states/statemgr"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/hashicorp/terraform/version"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -112,6 +115,12 @@ func (b *Local) context(op *backend.Operation) (*terraform.Context, *configload.
 	}
 	log.Printf("[TRACE] backend/local: finished building terraform.Context")
 
+	// Now that we have a context we can give the integrations access to the
+	// provider schemas, for summarizing them in hook payloads.
+	if b.integrations != nil {
+		b.integrations.Hook().SetSchemas(tfCtx.Schemas())
+	}
+
 	// If we have an operation, then we automatically do the input/validate
 	// here since every option requires this.
 	if op.Type != backend.OperationTypeInvalid {
@@ -149,6 +158,34 @@ func (b *Local) contextDirect(op *backend.Operation, opts terraform.ContextOpts)
 	}
 	opts.Config = config
 
+	// If the root module configures any integrations then we start them
+	// here so that they can observe the operation, and keep track of them
+	// on the backend so the operation can shut them down when it finishes.
+	if len(config.Module.Integrations) > 0 {
+		mgr, moreDiags := integrations.NewManager(config.Module.Integrations, &integrations.RunContext{
+			TerraformVersion: version.String(),
+			Command:          operationCommandName(op.Type),
+			Workspace:        op.Workspace,
+			BackendType:      "local",
+			ConfigRoot:       op.ConfigDir,
+		}, op.UIOut)
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			return nil, nil, diags
+		}
+		opts.Hooks = append(opts.Hooks, mgr.Hook())
+		b.integrations = mgr
+
+		// Now that the integrations have started and their versions are
+		// known, we can check any version requirements declared by modules
+		// in the configuration.
+		reqDiags := mgr.CheckRequirements(config)
+		diags = diags.Append(reqDiags)
+		if reqDiags.HasErrors() {
+			return nil, nil, diags
+		}
+	}
+
 	var rawVariables map[string]backend.UnparsedVariableValue
 	if op.AllowUnsetVariables {
 		// Rather than prompting for input, we'll just stub out the required
@@ -271,6 +308,43 @@ func (b *Local) contextFromPlanFile(pf *planfile.Reader, opts terraform.ContextO
 	return tfCtx, snap, diags
 }
 
+// operationCommandName maps an operation type to the CLI-style command name
+// we report to integrations.
+func operationCommandName(ty backend.OperationType) string {
+	switch ty {
+	case backend.OperationTypeRefresh:
+		return "refresh"
+	case backend.OperationTypePlan:
+		return "plan"
+	case backend.OperationTypeApply:
+		return "apply"
+	default:
+		return ""
+	}
+}
+
+// closeIntegrations shuts down any integration processes that were started
+// for the operation currently in progress, delivering any buffered events
+// first. It reports any shutdown problems through the operation's view.
+func (b *Local) closeIntegrations(op *backend.Operation) {
+	if b.integrations == nil {
+		return
+	}
+	diags := b.integrations.Close()
+	summaries := b.integrations.Metrics()
+	b.integrations = nil
+	if len(diags) > 0 {
+		op.View.Diagnostics(diags)
+	}
+
+	op.View.IntegrationMetrics(summaries)
+	if buf, err := json.Marshal(summaries); err == nil {
+		// There's no machine-readable UI stream for this yet, so for now
+		// automation can scrape the summary out of the logs.
+		log.Printf("[INFO] integrations: metrics %s", buf)
+	}
+}
+
 // interactiveCollectVariables attempts to complete the given existing
 // map of variables by interactively prompting for any variables that are
 // declared as required but not yet present.
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/zclconf/go-cty/cty"
+
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/jsonconfig"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states/statemgr"
@@ -69,8 +72,27 @@ func (b *Local) opPlan(
 		}
 	}()
 
+	// If any integrations were started for this operation then we must
+	// flush and stop them once the operation completes.
+	defer b.closeIntegrations(op)
+
 	runningOp.State = tfCtx.State()
 
+	// Give the integrations a snapshot of the configuration before the
+	// graph walk begins, so policy engines that validate configuration
+	// (rather than planned changes) have an entry point.
+	if b.integrations != nil {
+		if action := b.planStageBegin(op, tfCtx); action == terraform.HookActionHalt {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Plan halted by integration",
+				"An integration asked Terraform to stop before planning began. See the other diagnostics for details.",
+			))
+			op.ReportResult(runningOp, diags)
+			return
+		}
+	}
+
 	// Perform the plan in a goroutine so we can be interrupted
 	var plan *plans.Plan
 	var planDiags tfdiags.Diagnostics
@@ -96,6 +118,23 @@ func (b *Local) opPlan(
 		return
 	}
 
+	// Deliver any hook calls the integrations batched up during the walk
+	// before we save or render the plan, so that their annotations and
+	// findings are recorded with it. An integration can still reject the
+	// plan as a whole at this point, so we honor a halt verdict the same
+	// way as one delivered mid-walk.
+	if b.integrations != nil {
+		if action := b.integrations.Hook().Flush(); action == terraform.HookActionHalt {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Plan halted by integration",
+				"An integration rejected the planned changes. See the other diagnostics for details.",
+			))
+			op.ReportResult(runningOp, diags)
+			return
+		}
+	}
+
 	// Record whether this plan includes any side-effects that could be applied.
 	runningOp.PlanEmpty = plan.Changes.Empty()
 
@@ -117,8 +156,16 @@ func (b *Local) opPlan(
 		// only write it if this plan is subsequently applied.
 		plannedStateFile := statemgr.PlannedStateUpdate(opState, plan.State)
 
+		// If integrations reported anything during planning then we record
+		// it in the plan file too, so that whoever reviews the saved plan
+		// sees the same results.
+		var integrationResults *planfile.IntegrationResults
+		if b.integrations != nil {
+			integrationResults = b.integrations.PlanFileResults()
+		}
+
 		log.Printf("[INFO] backend/local: writing plan output to: %s", path)
-		err := planfile.Create(path, configSnap, plannedStateFile, plan)
+		err := planfile.Create(path, configSnap, plannedStateFile, plan, integrationResults)
 		if err != nil {
 			diags = diags.Append(tfdiags.Sourceless(
 				tfdiags.Error,
@@ -139,7 +186,11 @@ func (b *Local) opPlan(
 		return
 	}
 
-	// Render the plan
+	// Render the plan, along with any remarks the integrations attached to
+	// the planned changes.
+	if b.integrations != nil {
+		op.View.PlanAnnotations(b.integrations.PlanAnnotations())
+	}
 	op.View.Plan(plan, plan.State, tfCtx.Schemas())
 
 	// If we've accumulated any warnings along the way then we'll show them
@@ -149,3 +200,35 @@ func (b *Local) opPlan(
 
 	op.View.PlanNextStep(op.PlanOutPath)
 }
+
+// planStageBegin delivers the "plan-stage-begin" hook to the integrations,
+// with a JSON snapshot of the configuration, the input variable values
+// (sensitive ones redacted), and any targeting flags for the run.
+func (b *Local) planStageBegin(op *backend.Operation, tfCtx *terraform.Context) terraform.HookAction {
+	config := tfCtx.Config()
+
+	var configJSON []byte
+	if cj, err := jsonconfig.Marshal(config, tfCtx.Schemas()); err == nil {
+		configJSON = cj
+	} else {
+		log.Printf("[WARN] backend/local: failed to marshal configuration for integrations: %s", err)
+	}
+
+	variables := make(map[string]cty.Value)
+	for name, iv := range tfCtx.Variables() {
+		v := iv.Value
+		// Marking sensitive variables makes the payload encoding redact
+		// them, the same way it redacts sensitive resource attributes.
+		if decl, ok := config.Module.Variables[name]; ok && decl.Sensitive {
+			v = v.Mark("sensitive")
+		}
+		variables[name] = v
+	}
+
+	var targets []string
+	for _, target := range op.Targets {
+		targets = append(targets, target.String())
+	}
+
+	return b.integrations.Hook().PlanStageBegin(configJSON, variables, targets)
+}
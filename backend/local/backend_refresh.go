@@ -62,6 +62,10 @@ func (b *Local) opRefresh(
 		}
 	}()
 
+	// If any integrations were started for this operation then we must
+	// flush and stop them once the operation completes.
+	defer b.closeIntegrations(op)
+
 	// Set our state
 	runningOp.State = opState.State()
 	if !runningOp.State.HasResources() {
@@ -10,6 +10,59 @@ import (
 // Test represents the command line arguments for the "terraform test" command.
 type Test struct {
 	Output TestOutput
+
+	// Filter is an optional regular expression selecting which test
+	// scenarios to run, matched against scenario names and qualified
+	// "scenario/step" names. An empty string selects everything. It has
+	// no effect on the older suite directories, which always all run.
+	Filter string
+
+	// Skip is an optional regular expression dropping scenarios and
+	// steps the Filter would otherwise select, matched the same way.
+	Skip string
+
+	// Tags keeps only the scenarios carrying at least one of the given
+	// tags, and ExcludeTags drops the scenarios carrying any of them.
+	// Exclusion wins when both match. They come from the repeatable
+	// -tags and -exclude-tags options.
+	Tags        []string
+	ExcludeTags []string
+
+	// Verbose streams each scenario step's progress as it runs, prefixed
+	// with the scenario and step names, instead of only reporting
+	// results once everything has finished.
+	Verbose bool
+
+	// RerunFailed re-executes only the scenarios that failed in the
+	// previous run, reusing the recorded results of the scenarios that
+	// passed so their dependents still see their outputs. It has no
+	// effect on the older suite directories.
+	RerunFailed bool
+
+	// Parallelism bounds how many test scenarios run at once. Zero
+	// leaves the scenario runner's default in effect; one disables
+	// concurrency entirely. It has no effect on the older suite
+	// directories, which always run serially.
+	Parallelism int
+
+	// NoCleanupOnFailure keeps every failed scenario's resources instead
+	// of running its cleanup steps, writing the final states to a debug
+	// directory with instructions to destroy them, as if each scenario
+	// had set preserve_on_failure = true. It has no effect on the older
+	// suite directories.
+	NoCleanupOnFailure bool
+
+	// UpdateSnapshots rewrites the golden files of steps with snapshot
+	// options from this run's plans instead of comparing against them,
+	// for deliberate behavior changes. It has no effect on the older
+	// suite directories.
+	UpdateSnapshots bool
+
+	// Vars and VarFiles give suite-level fallback values for scenario
+	// input variables, from the repeatable -var and -var-file options.
+	// A scenario's own variables blocks take precedence over them.
+	Vars     []string
+	VarFiles []string
 }
 
 // TestOutput represents a subset of the arguments for "terraform test"
@@ -23,6 +76,16 @@ type TestOutput struct {
 	// is to configure them to look for a separate test result file on disk
 	// after running the tests.)
 	JUnitXMLFile string
+
+	// JSON writes a machine-readable JSON report of the scenario results
+	// to the standard output stream, for consumption by wrapping tools.
+	JSON bool
+
+	// Coverage reports, after the scenarios have run, which resources,
+	// module calls, variables, and outputs of the module under test they
+	// exercised, flagging the resources no scenario ever planned. With
+	// JSON the summary is written as a JSON document too.
+	Coverage bool
 }
 
 // ParseTest interprets a slice of raw command line arguments into a
@@ -39,6 +102,19 @@ func ParseTest(args []string) (Test, tfdiags.Diagnostics) {
 	f.SetOutput(ioutil.Discard)
 	f.Usage = func() {}
 	f.StringVar(&ret.Output.JUnitXMLFile, "junit-xml", "", "Write a JUnit XML file describing the results")
+	f.StringVar(&ret.Filter, "filter", "", "Run only the test scenarios matching this regular expression")
+	f.IntVar(&ret.Parallelism, "parallelism", 0, "Bound how many test scenarios run at once")
+	f.StringVar(&ret.Skip, "skip", "", "Skip the test scenarios and steps matching this regular expression")
+	f.Var((*flagStringSlice)(&ret.Tags), "tags", "Run only the test scenarios carrying at least one of these tags")
+	f.Var((*flagStringSlice)(&ret.ExcludeTags), "exclude-tags", "Skip the test scenarios carrying any of these tags")
+	f.BoolVar(&ret.Verbose, "verbose", false, "Stream scenario step progress while running")
+	f.BoolVar(&ret.RerunFailed, "rerun-failed", false, "Re-run only the scenarios that failed in the previous run")
+	f.BoolVar(&ret.NoCleanupOnFailure, "no-cleanup-on-failure", false, "Keep the resources of failed scenarios instead of running their cleanup steps")
+	f.BoolVar(&ret.UpdateSnapshots, "update-snapshots", false, "Rewrite plan snapshot golden files from this run instead of comparing against them")
+	f.BoolVar(&ret.Output.JSON, "json", false, "Write a JSON report of the scenario results to stdout")
+	f.BoolVar(&ret.Output.Coverage, "coverage", false, "Report which declarations of the module under test the scenarios exercised")
+	f.Var((*flagStringSlice)(&ret.Vars), "var", "Set a fallback value for a scenario input variable")
+	f.Var((*flagStringSlice)(&ret.VarFiles), "var-file", "Load fallback variable values from the given file")
 
 	err := f.Parse(args)
 	if err != nil {
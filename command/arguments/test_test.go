@@ -41,6 +41,18 @@ func TestParseTest(t *testing.T) {
 			},
 			``,
 		},
+		{
+			[]string{"-filter=^network/", "-verbose", "-json"},
+			Test{
+				Output: TestOutput{
+					JUnitXMLFile: "",
+					JSON:         true,
+				},
+				Filter:  "^network/",
+				Verbose: true,
+			},
+			``,
+		},
 		{
 			[]string{"baz"},
 			Test{
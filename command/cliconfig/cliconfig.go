@@ -48,6 +48,12 @@ type Config struct {
 	// configuration, but we decode into a slice here so that we can handle
 	// that validation at validation time rather than initial decode time.
 	ProviderInstallation []*ProviderInstallation
+
+	// RunbookVerification represents any runbook_verification blocks in
+	// the configuration, giving the signing policy for runbook execution.
+	// Only one of these is allowed across the whole configuration, which
+	// we validate rather than enforce at decode time, as above.
+	RunbookVerification []*RunbookVerification `hcl:"runbook_verification"`
 }
 
 // ConfigHost is the structure of the "host" nested block within the CLI
@@ -273,6 +279,13 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		)
 	}
 
+	// Should have zero or one "runbook_verification" blocks
+	if len(c.RunbookVerification) > 1 {
+		diags = diags.Append(
+			fmt.Errorf("No more than one runbook_verification block may be specified"),
+		)
+	}
+
 	if c.PluginCacheDir != "" {
 		_, err := os.Stat(c.PluginCacheDir)
 		if err != nil {
@@ -355,6 +368,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ProviderInstallation = append(result.ProviderInstallation, c2.ProviderInstallation...)
 	}
 
+	if (len(c.RunbookVerification) + len(c2.RunbookVerification)) > 0 {
+		result.RunbookVerification = append(result.RunbookVerification, c.RunbookVerification...)
+		result.RunbookVerification = append(result.RunbookVerification, c2.RunbookVerification...)
+	}
+
 	return &result
 }
 
@@ -0,0 +1,32 @@
+package cliconfig
+
+import (
+	"github.com/hashicorp/terraform/internal/runbooks"
+)
+
+// RunbookVerification is the structure of the "runbook_verification"
+// nested block within the CLI configuration, which sets the signing
+// policy for runbook execution.
+type RunbookVerification struct {
+	// Enforce refuses to execute runbooks that are unsigned or whose
+	// detached signature doesn't verify against one of the trusted keys.
+	Enforce bool `hcl:"enforce"`
+
+	// TrustedKeys are ASCII-armored PGP public keys. A runbook signature
+	// from any one of them is accepted.
+	TrustedKeys []string `hcl:"trusted_keys"`
+}
+
+// RunbookVerificationConfig returns the runbook signing policy from the
+// configuration, in the form the runbooks package consumes, or nil if
+// the configuration has no runbook_verification block.
+func (c *Config) RunbookVerificationConfig() *runbooks.VerificationConfig {
+	if len(c.RunbookVerification) == 0 {
+		return nil
+	}
+	block := c.RunbookVerification[0]
+	return &runbooks.VerificationConfig{
+		Enforce:     block.Enforce,
+		TrustedKeys: block.TrustedKeys,
+	}
+}
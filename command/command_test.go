@@ -184,7 +184,7 @@ func testPlanFile(t *testing.T, configSnap *configload.Snapshot, state *states.S
 	}
 
 	path := testTempFile(t)
-	err := planfile.Create(path, configSnap, stateFile, plan)
+	err := planfile.Create(path, configSnap, stateFile, plan, nil)
 	if err != nil {
 		t.Fatalf("failed to create temporary plan file: %s", err)
 	}
@@ -227,6 +227,19 @@ func (c *ImportCommand) Run(args []string) int {
 		}
 	}()
 
+	// Integrations hear about the import before and after it happens, so
+	// state surgery is as observable as plan and apply. A pre event with
+	// no matching post means the import was aborted before anything was
+	// persisted.
+	stateHook, closeHook, hookDiags := c.stateMutationHook("import")
+	if hookDiags.HasErrors() {
+		c.showDiagnostics(hookDiags)
+		return 1
+	}
+	defer closeHook()
+	hookAddrs := []string{addr.String()}
+	stateHook.PreMutation(hookAddrs)
+
 	// Perform the import. Note that as you can see it is possible for this
 	// API to import more than one resource at once. For now, we only allow
 	// one while we stabilize this feature.
@@ -247,13 +260,16 @@ func (c *ImportCommand) Run(args []string) int {
 	// Persist the final state
 	log.Printf("[INFO] Writing state output to: %s", c.Meta.StateOutPath())
 	if err := state.WriteState(newState); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf("Error writing state file: %s", err))
 		return 1
 	}
 	if err := state.PersistState(); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf("Error writing state file: %s", err))
 		return 1
 	}
+	stateHook.PostMutation(hookAddrs, nil)
 
 	c.Ui.Output(c.Colorize().Color("[reset][green]\n" + importCommandSuccessMsg))
 
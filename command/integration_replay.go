@@ -0,0 +1,130 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/internal/integrations"
+	"github.com/hashicorp/terraform/version"
+)
+
+// IntegrationReplayCommand is a Command implementation that feeds a
+// directory of recorded integration payloads into an integration binary,
+// for offline debugging of integrations.
+type IntegrationReplayCommand struct {
+	Meta
+}
+
+func (c *IntegrationReplayCommand) Run(args []string) int {
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("integration replay")
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return cli.RunResultHelp
+	}
+	args = cmdFlags.Args()
+
+	if len(args) < 2 {
+		c.Ui.Error("The integration replay command expects a recording directory and an integration command to run.")
+		return cli.RunResultHelp
+	}
+	dir := args[0]
+	command := args[1:]
+
+	calls, err := integrations.ReadRecordedCalls(dir)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read recorded calls from %s: %s", dir, err))
+		return 1
+	}
+	if len(calls) == 0 {
+		c.Ui.Error(fmt.Sprintf("No recorded calls found in %s. Record some by running an operation with %s set to a directory path.", dir, integrations.RecordEnvVar))
+		return 1
+	}
+
+	p, err := integrations.StartIntegration(
+		&configs.Integration{
+			Name:    "replay",
+			Command: command,
+		},
+		&integrations.RunContext{
+			TerraformVersion: version.String(),
+			Command:          "integration replay",
+		},
+		nil,
+	)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer p.Close()
+
+	failed := 0
+	for _, call := range calls {
+		switch call.Method {
+		case "initialize", "shutdown":
+			// StartIntegration and Close perform their own handshake, so
+			// replaying the recorded ones would just confuse the
+			// integration.
+			continue
+		}
+
+		var result json.RawMessage
+		err := p.Call(call.Method, call.Params, &result)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("%s: %s", call.Method, err))
+			failed++
+			continue
+		}
+
+		c.Ui.Output(fmt.Sprintf("%s: %s", call.Method, result))
+		if len(call.Result) > 0 && !jsonEqual(call.Result, result) {
+			c.Ui.Warn(fmt.Sprintf("  (recorded response was: %s)", call.Result))
+		}
+	}
+
+	if failed > 0 {
+		c.Ui.Error(fmt.Sprintf("\n%d of %d replayed calls failed.", failed, len(calls)))
+		return 1
+	}
+	return 0
+}
+
+// jsonEqual compares two JSON documents ignoring formatting differences.
+func jsonEqual(a, b json.RawMessage) bool {
+	var ab, bb bytes.Buffer
+	if err := json.Compact(&ab, a); err != nil {
+		return false
+	}
+	if err := json.Compact(&bb, b); err != nil {
+		return false
+	}
+	return ab.String() == bb.String()
+}
+
+func (c *IntegrationReplayCommand) Help() string {
+	helpText := `
+Usage: terraform integration replay DIR COMMAND [ARGS...]
+
+  Replays integration hook payloads previously recorded by running a
+  Terraform operation with the ` + integrations.RecordEnvVar + ` environment
+  variable set to a directory path.
+
+  The given integration command is started and initialized in the same way
+  as during a real operation, and then each recorded hook call is sent to
+  it in the original order. The integration's responses are printed, along
+  with the recorded response wherever the two differ.
+
+  This makes it possible to debug an integration offline, without
+  re-running real plans against real infrastructure.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IntegrationReplayCommand) Synopsis() string {
+	return "Replay recorded hook payloads against an integration"
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/hashicorp/terraform/terraform"
@@ -37,6 +38,11 @@ type plan struct {
 	OutputChanges   map[string]change `json:"output_changes,omitempty"`
 	PriorState      json.RawMessage   `json:"prior_state,omitempty"`
 	Config          json.RawMessage   `json:"configuration,omitempty"`
+
+	// IntegrationResults are what the configured integrations reported
+	// while the plan was being created, as recorded in the plan file.
+	// Only present when showing a saved plan that has results recorded.
+	IntegrationResults *planfile.IntegrationResults `json:"integration_results,omitempty"`
 }
 
 func newPlan() *plan {
@@ -86,14 +92,20 @@ type variable struct {
 }
 
 // Marshal returns the json encoding of a terraform plan.
+//
+// integrationResults, which may be nil, are the integration results
+// recorded in the plan file alongside the plan, for inclusion in the
+// output.
 func Marshal(
 	config *configs.Config,
 	p *plans.Plan,
 	sf *statefile.File,
 	schemas *terraform.Schemas,
+	integrationResults *planfile.IntegrationResults,
 ) ([]byte, error) {
 	output := newPlan()
 	output.TerraformVersion = version.String()
+	output.IntegrationResults = integrationResults
 
 	err := output.marshalPlanVariables(p.VariableValues, schemas)
 	if err != nil {
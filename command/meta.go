@@ -27,6 +27,7 @@ import (
 	"github.com/hashicorp/terraform/command/webbrowser"
 	"github.com/hashicorp/terraform/configs/configload"
 	"github.com/hashicorp/terraform/internal/getproviders"
+	"github.com/hashicorp/terraform/internal/runbooks"
 	"github.com/hashicorp/terraform/internal/terminal"
 	"github.com/hashicorp/terraform/providers"
 	"github.com/hashicorp/terraform/provisioners"
@@ -133,6 +134,11 @@ type Meta struct {
 	// just trusting that someone else did it before running Terraform.
 	UnmanagedProviders map[addrs.Provider]*plugin.ReattachConfig
 
+	// RunbookVerification is the runbook signing policy from the CLI
+	// configuration's runbook_verification block, checked before a
+	// runbook executes. Nil means no policy: runbooks run unsigned.
+	RunbookVerification *runbooks.VerificationConfig
+
 	//----------------------------------------------------------
 	// Protected: commands can set these
 	//----------------------------------------------------------
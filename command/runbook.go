@@ -0,0 +1,415 @@
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/command/arguments"
+	"github.com/hashicorp/terraform/command/views"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/states/statemgr"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/hashicorp/terraform/version"
+)
+
+// RunbookCommand is the implementation of "terraform runbook <name>",
+// which executes a runbook from the runbooks directory.
+type RunbookCommand struct {
+	Meta
+}
+
+func (c *RunbookCommand) Run(args []string) int {
+	var remote, jsonOutput, compact bool
+	var outputFormat string
+	var varArgs FlagStringSlice
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("runbook")
+	cmdFlags.BoolVar(&remote, "remote", false, "remote")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.BoolVar(&compact, "compact", false, "compact")
+	cmdFlags.StringVar(&outputFormat, "output-format", "", "output-format")
+	cmdFlags.Var(&varArgs, "var", "variable")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	vt := arguments.ViewHuman
+	if jsonOutput {
+		vt = arguments.ViewJSON
+	}
+	view := views.NewRunbook(vt, compact, c.View)
+	if human, ok := view.(*views.RunbookHuman); ok {
+		human.OutputFormat = outputFormat
+	}
+
+	var diags tfdiags.Diagnostics
+
+	if cmdFlags.NArg() != 1 {
+		c.Ui.Error("Expected exactly one argument: the name of the runbook to execute.")
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := cmdFlags.Arg(0)
+
+	path := filepath.Join(runbooksDirName, name+".tfbook")
+	if _, err := os.Stat(path); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No such runbook",
+			fmt.Sprintf("There is no runbook named %q: expected to find it at %s.", name, path),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// The signing policy is checked before the runbook is even parsed:
+	// when enforcement is on, an unsigned or tampered-with runbook never
+	// executes, locally or remotely.
+	if err := runbooks.VerifyRunbookFile(path, c.RunbookVerification); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Runbook failed signature verification",
+			fmt.Sprintf("%s.", err),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	variables, moreDiags := c.runbookVariables(path, []string(varArgs))
+	diags = diags.Append(moreDiags)
+	if diags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	if !remote {
+		return c.runLocal(name, path, variables, view)
+	}
+
+	// The runbook loads locally before anything uploads, so a syntax
+	// error or a bad reference fails here in seconds rather than after a
+	// round trip to the remote runner.
+	if _, loadDiags := runbooks.LoadRunbookFile(path, variables, nil); loadDiags.HasErrors() {
+		diags = diags.Append(loadDiags)
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	b, backendDiags := c.Backend(nil)
+	diags = diags.Append(backendDiags)
+	if backendDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+	runner, ok := b.(runbooks.RemoteRunner)
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Backend cannot run runbooks remotely",
+			"The configured backend does not support remote runbook execution. Configure a backend that does, such as one backed by Terraform Cloud or Terraform Enterprise.",
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Executing runbook %q remotely...", name))
+	if err := runbooks.RunRemote(context.Background(), runner, runbooksDirName, name, variables, os.Stdout); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Remote runbook run failed",
+			fmt.Sprintf("The remote run of runbook %q did not complete: %s.", name, err),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	view.Diagnostics(diags)
+	c.Ui.Output(fmt.Sprintf("Runbook %q completed.", name))
+	return 0
+}
+
+// runLocal executes the runbook in this process, reading data through
+// the installed provider plugins. Action implementations live with the
+// remote runners, where production credentials do too, so a local run
+// plans every action instead of invoking it: the run gathers real data,
+// shows exactly what each action would do, and produces the runbook's
+// outputs, without side effects.
+func (c *RunbookCommand) runLocal(name, path string, variables map[string]cty.Value, view views.Runbook) int {
+	var diags tfdiags.Diagnostics
+
+	readers, schemas, closeProviders, moreDiags := c.localReaders()
+	diags = diags.Append(moreDiags)
+	if diags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+	defer closeProviders()
+
+	rb, loadDiags := runbooks.LoadRunbookFile(path, variables, schemas)
+	diags = diags.Append(loadDiags)
+	if loadDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	runID := runbookRunID()
+	engine := &runbooks.Engine{
+		Readers: readers,
+		DryRun:  true,
+		RunID:   runID,
+
+		// The view follows the run step by step through the engine's
+		// hook events.
+		Hooks: []runbooks.Hook{&views.RunbookViewHook{View: view}},
+
+		// Locks with backend = true route through the configured
+		// backend's locking, so they exclude other operators org-wide,
+		// not just other runs on this machine.
+		BackendLock: c.backendLock(),
+	}
+
+	// Every action event of the run is appended to a per-run log file
+	// under the local data directory, so post-incident review can
+	// reconstruct what each action reported and when, independently of
+	// what scrolled past on the terminal.
+	logPath := filepath.Join(c.DataDir(), "runbook-logs", runID+".log")
+	var eventLog *runbooks.ActionEventLog
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Cannot write runbook action log",
+			fmt.Sprintf("Could not create the directory for the action event log at %s: %s. The run will proceed without one.", logPath, err),
+		))
+	} else if logFile, err := os.Create(logPath); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Cannot write runbook action log",
+			fmt.Sprintf("Could not create the action event log at %s: %s. The run will proceed without one.", logPath, err),
+		))
+	} else {
+		defer logFile.Close()
+		eventLog = runbooks.NewActionEventLog(logFile, runID)
+		engine.Hooks = append(engine.Hooks, eventLog)
+		if jv, ok := view.(*views.RunbookJSON); ok {
+			jv.ActionLogPath = logPath
+		}
+	}
+
+	view.Begin(rb.Name, len(rb.Steps))
+	if err := engine.Run(rb); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Runbook run failed",
+			fmt.Sprintf("The local run of runbook %q did not complete: %s.", name, err),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	view.Outputs(rb.AllOutputs())
+	if eventLog != nil {
+		if err := eventLog.Err(); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Runbook action log is incomplete",
+				fmt.Sprintf("Could not record all of the run's action events at %s: %s.", logPath, err),
+			))
+		}
+	}
+	view.Diagnostics(diags)
+	return 0
+}
+
+// runbookRunID returns a fresh random identifier for one local runbook
+// run, tagging its action event log and checkpoint keys so that
+// archived records can be correlated with their runs.
+func runbookRunID() string {
+	var buf [4]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		// Degrading to a fixed ID is better than refusing to run the
+		// runbook just because the ID could not be random.
+		return "local"
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// backendLock returns a runbooks.LockFunc that acquires named locks
+// through the configured backend's state locking. Each lock name gets a
+// workspace of its own, named runbook-lock-<name>, whose state lock
+// stands in for the named lock: the same mechanism that keeps two
+// operators from applying the same state concurrently then keeps two
+// operators from holding the same runbook lock, org-wide. The backend is
+// resolved on first acquisition, so runbooks without backend locks never
+// touch it.
+func (c *RunbookCommand) backendLock() runbooks.LockFunc {
+	return func(name string) (func() error, error) {
+		b, backendDiags := c.Backend(nil)
+		if backendDiags.HasErrors() {
+			return nil, fmt.Errorf("cannot reach the backend for lock %q: %s", name, backendDiags.Err())
+		}
+		sm, err := b.StateMgr("runbook-lock-" + name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot prepare backend lock %q: %s", name, err)
+		}
+		info := statemgr.NewLockInfo()
+		info.Operation = "runbook"
+		lockID, err := statemgr.LockWithContext(context.Background(), sm, info)
+		if err != nil {
+			return nil, err
+		}
+		return func() error { return sm.Unlock(lockID) }, nil
+	}
+}
+
+// localReaders starts the installed provider plugins and returns a
+// reader per data source type they declare, backed by the live
+// providers, along with the data source schemas for schema-aware
+// decoding. Each provider is configured with its empty configuration, so
+// it picks up credentials from the environment the way an unconfigured
+// provider block would. The returned close function stops the providers
+// once the run is over.
+func (c *RunbookCommand) localReaders() (map[string]runbooks.ReadFunc, map[string]*configschema.Block, func(), tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	readers := make(map[string]runbooks.ReadFunc)
+	schemas := make(map[string]*configschema.Block)
+
+	var running []providers.Interface
+	closeProviders := func() {
+		for _, provider := range running {
+			provider.Close()
+		}
+	}
+
+	factories, err := c.providerFactories()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to prepare providers",
+			fmt.Sprintf("Could not prepare the installed provider plugins for the runbook's data reads: %s.", err),
+		))
+		return readers, schemas, closeProviders, diags
+	}
+
+	for addr, factory := range factories {
+		provider, err := factory()
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to start provider",
+				fmt.Sprintf("Could not start %s for the runbook's data reads: %s.", addr, err),
+			))
+			continue
+		}
+		running = append(running, provider)
+
+		resp := provider.GetProviderSchema()
+		if resp.Diagnostics.HasErrors() {
+			diags = diags.Append(resp.Diagnostics)
+			continue
+		}
+		configResp := provider.ConfigureProvider(providers.ConfigureProviderRequest{
+			TerraformVersion: version.String(),
+			Config:           resp.Provider.Block.EmptyValue(),
+		})
+		if configResp.Diagnostics.HasErrors() {
+			diags = diags.Append(configResp.Diagnostics)
+			continue
+		}
+
+		for name, schema := range resp.DataSources {
+			typeName, provider := name, provider
+			schemas[typeName] = schema.Block
+			readers[typeName] = func(config cty.Value) (cty.Value, error) {
+				resp := provider.ReadDataSource(providers.ReadDataSourceRequest{
+					TypeName: typeName,
+					Config:   config,
+				})
+				if resp.Diagnostics.HasErrors() {
+					return cty.NilVal, resp.Diagnostics.Err()
+				}
+				return resp.State, nil
+			}
+		}
+	}
+
+	return readers, schemas, closeProviders, diags
+}
+
+// runbookVariables resolves the values for the runbook's declared
+// variables from the usual sources in the usual order: declaration
+// defaults, the runbook tfvars files next to the runbook, TF_VAR_
+// environment variables, and the -var options.
+func (c *RunbookCommand) runbookVariables(path string, rawVars []string) (map[string]cty.Value, tfdiags.Diagnostics) {
+	decls, diags := runbooks.LoadRunbookVariables(path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	cliValues, moreDiags := runbooks.ParseCLIVariables(rawVars)
+	diags = diags.Append(moreDiags)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	values, moreDiags := runbooks.VariableValues(filepath.Dir(path), decls, os.Environ(), cliValues)
+	diags = diags.Append(moreDiags)
+	return values, diags
+}
+
+func (c *RunbookCommand) Help() string {
+	helpText := `
+Usage: terraform [global options] runbook [options] <name>
+
+  Executes the named runbook from the runbooks directory.
+
+  By default the runbook runs locally: its data reads go through the
+  installed provider plugins, and its actions are planned rather than
+  invoked, so the run shows what each action would do without side
+  effects. With -remote the runbook directory is instead bundled and
+  uploaded to the configured backend, which runs it — actions included —
+  where production credentials are available and streams the logs back,
+  the way remote plan and apply work.
+
+  Values for the runbook's variables come from their defaults, then
+  terraform.runbook.tfvars and *.auto.runbook.tfvars files next to the
+  runbook, then TF_VAR_ environment variables, then -var options, with
+  later sources overriding earlier ones.
+
+Options:
+
+  -remote             Execute through the backend's remote runner,
+                      which invokes the runbook's actions for real.
+
+  -json               Write one machine-readable JSON object per event
+                      instead of the human-readable progress output.
+
+  -compact            Report one line per step, omitting action detail
+                      and output values, for long runbooks driven from
+                      scripts or CI logs.
+
+  -output-format=FMT  Additionally render outputs whose values are
+                      collections of objects in full as "table", "csv",
+                      or "json", after the outputs summary.
+
+  -var 'name=value'   Set a value for one of the runbook's variables.
+                      May be given multiple times.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RunbookCommand) Synopsis() string {
+	return "Execute a runbook"
+}
@@ -0,0 +1,237 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// runbooksDirName is the directory where the runbook commands look for
+// .tfbook files, relative to the working directory.
+const runbooksDirName = "runbooks"
+
+// RunbookInitCommand is the implementation of "terraform runbook init".
+type RunbookInitCommand struct {
+	Meta
+}
+
+func (c *RunbookInitCommand) Run(args []string) int {
+	var check bool
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("runbook init")
+	cmdFlags.BoolVar(&check, "check", false, "check")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	if !check {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Missing -check option",
+			"Currently \"terraform runbook init\" supports only the -check mode, which verifies that the providers installed on this system match the dependency lock file without installing anything.",
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	locks, moreDiags := c.lockedDependencies()
+	diags = diags.Append(moreDiags)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	providerLocks := locks.AllProviders()
+	cacheDir := c.providerLocalCacheDir()
+
+	providers := make([]addrs.Provider, 0, len(providerLocks))
+	for provider := range providerLocks {
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].String() < providers[j].String()
+	})
+
+	for _, provider := range providers {
+		lock := providerLocks[provider]
+		version := lock.Version()
+		cached := cacheDir.ProviderVersion(provider, version)
+		if cached == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Required provider not installed",
+				fmt.Sprintf("The dependency lock file requires %s %s, but there is no package for it in %s. Run \"terraform init\" to install it.", provider, version, cacheDir.BasePath()),
+			))
+			continue
+		}
+		if allowedHashes := lock.PreferredHashes(); len(allowedHashes) != 0 {
+			matched, err := cached.MatchesAnyHash(allowedHashes)
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failed to verify installed provider",
+					fmt.Sprintf("Could not verify the checksum of the package for %s %s in %s: %s.", provider, version, cacheDir.BasePath(), err),
+				))
+				continue
+			}
+			if !matched {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Installed provider does not match lock file",
+					fmt.Sprintf("The installed package for %s %s does not match any of the checksums recorded in the dependency lock file. Run \"terraform init\" to reinstall the locked package.", provider, version),
+				))
+				continue
+			}
+		}
+	}
+
+	// With the installed providers verified, the runbooks themselves are
+	// checked: every data and list block must read a type the providers
+	// actually declare, so a reference broken by a provider upgrade fails
+	// here rather than halfway through an incident.
+	var checkedRunbooks int
+	if !diags.HasErrors() {
+		paths, err := runbooks.RunbookFiles(runbooksDirName)
+		if err != nil && !os.IsNotExist(err) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read runbooks directory",
+				fmt.Sprintf("Could not read the %s directory: %s.", runbooksDirName, err),
+			))
+		}
+		if len(paths) > 0 {
+			types, schemas, moreDiags := c.preflightTypes()
+			diags = diags.Append(moreDiags)
+			for _, path := range paths {
+				if diags.HasErrors() {
+					break
+				}
+				// The provider schemas are in hand anyway, so the loader
+				// decodes each read's configuration against them and any
+				// attribute of the wrong type or name fails the check too.
+				rb, loadDiags := runbooks.LoadRunbookFile(path, nil, schemas)
+				diags = diags.Append(loadDiags)
+				if loadDiags.HasErrors() {
+					continue
+				}
+				for _, err := range runbooks.CheckPreflight(rb, types) {
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Error,
+						fmt.Sprintf("Runbook %q is not executable here", rb.Name),
+						fmt.Sprintf("%s.", err),
+					))
+				}
+				checkedRunbooks++
+			}
+		}
+	}
+
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+
+	switch len(providerLocks) {
+	case 0:
+		c.Ui.Output("The dependency lock file requires no providers.")
+	case 1:
+		c.Ui.Output("The 1 provider required by the dependency lock file is installed and matches its locked checksums.")
+	default:
+		c.Ui.Output(fmt.Sprintf("All %d providers required by the dependency lock file are installed and match their locked checksums.", len(providerLocks)))
+	}
+	switch checkedRunbooks {
+	case 0:
+	case 1:
+		c.Ui.Output("The 1 runbook reads only data source types the installed providers declare.")
+	default:
+		c.Ui.Output(fmt.Sprintf("All %d runbooks read only data source types the installed providers declare.", checkedRunbooks))
+	}
+	return 0
+}
+
+// preflightTypes collects the data source type names the installed
+// providers declare, by asking each one for its schema, along with the
+// schemas themselves for schema-aware decoding. Action types are left
+// nil — the plugin protocol gives providers no way to declare them — so
+// CheckPreflight verifies only the data and list reads.
+func (c *RunbookInitCommand) preflightTypes() (runbooks.PreflightTypes, map[string]*configschema.Block, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	types := runbooks.PreflightTypes{
+		DataSources: make(map[string]bool),
+	}
+	schemas := make(map[string]*configschema.Block)
+
+	factories, err := c.providerFactories()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to prepare providers",
+			fmt.Sprintf("Could not prepare the installed provider plugins to read their schemas: %s.", err),
+		))
+		return types, schemas, diags
+	}
+
+	for addr, factory := range factories {
+		provider, err := factory()
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to start provider",
+				fmt.Sprintf("Could not start %s to read its schema: %s.", addr, err),
+			))
+			continue
+		}
+		resp := provider.GetProviderSchema()
+		provider.Close()
+		if resp.Diagnostics.HasErrors() {
+			diags = diags.Append(resp.Diagnostics)
+			continue
+		}
+		for name, schema := range resp.DataSources {
+			types.DataSources[name] = true
+			schemas[name] = schema.Block
+		}
+	}
+
+	return types, schemas, diags
+}
+
+func (c *RunbookInitCommand) Help() string {
+	helpText := `
+Usage: terraform [global options] runbook init -check
+
+  Verifies that this system can execute runbooks against the current
+  working directory's configuration, without installing anything:
+  every provider recorded in the dependency lock file must already be
+  installed, at its locked version and matching its locked checksums,
+  and every data or list block of the runbooks in the runbooks
+  directory must read a data source type those providers declare.
+
+  This is intended as a fast preflight for CI runners: a failure means
+  "terraform init" needs to run (or the runner image needs updating)
+  before runbooks can execute here.
+
+Options:
+
+  -check              Verify without installing. Currently required;
+                      installation of runbook dependencies is not yet
+                      supported by this command.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RunbookInitCommand) Synopsis() string {
+	return "Verify that runbooks can execute on this system"
+}
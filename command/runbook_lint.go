@@ -0,0 +1,139 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// RunbookLintCommand is the implementation of "terraform runbook lint".
+type RunbookLintCommand struct {
+	Meta
+}
+
+func (c *RunbookLintCommand) Run(args []string) int {
+	var jsonOutput bool
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("runbook lint")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	paths := cmdFlags.Args()
+	if len(paths) == 0 {
+		var err error
+		paths, err = runbooks.RunbookFiles(runbooksDirName)
+		if err != nil && !os.IsNotExist(err) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read runbooks directory",
+				fmt.Sprintf("Could not read the %s directory: %s.", runbooksDirName, err),
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		if len(paths) == 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"No runbooks to lint",
+				fmt.Sprintf("There are no runbook files in the %s directory. Name the files to lint on the command line, or run from a directory with runbooks.", runbooksDirName),
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+	}
+
+	var findings []*runbooks.LintFinding
+	for _, path := range paths {
+		// Each runbook's own lint block decides which rules apply to it,
+		// so a deliberate exception travels with the runbook instead of
+		// living in whoever's CI configuration happens to run the lint.
+		rb, loadDiags := runbooks.LoadRunbookFile(path, nil, nil)
+		diags = diags.Append(loadDiags)
+		if loadDiags.HasErrors() {
+			continue
+		}
+		findings = append(findings, runbooks.Lint(rb, rb.Lint)...)
+	}
+
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+
+	failed := false
+	for _, finding := range findings {
+		if finding.Severity == runbooks.LintError {
+			failed = true
+			break
+		}
+	}
+
+	if jsonOutput {
+		var buf bytes.Buffer
+		if err := runbooks.WriteLintJSON(&buf, findings); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to render lint findings as JSON: %s", err))
+			return 1
+		}
+		c.Ui.Output(strings.TrimSuffix(buf.String(), "\n"))
+	} else {
+		for _, finding := range findings {
+			where := finding.Runbook
+			if finding.Step != "" {
+				where = fmt.Sprintf("%s, step %q", finding.Runbook, finding.Step)
+			}
+			c.Ui.Output(fmt.Sprintf("%s: %s [%s]: %s", where, finding.Severity, finding.Rule, finding.Message))
+		}
+		switch len(findings) {
+		case 0:
+			c.Ui.Output(fmt.Sprintf("No problems found in %d runbooks.", len(paths)))
+		case 1:
+			c.Ui.Output("Found 1 problem.")
+		default:
+			c.Ui.Output(fmt.Sprintf("Found %d problems.", len(findings)))
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func (c *RunbookLintCommand) Help() string {
+	helpText := `
+Usage: terraform [global options] runbook lint [options] [files...]
+
+  Checks runbook files against the built-in lint rules: unpinned
+  providers, unused variables, steps without effect, actions nothing
+  verifies, and missing descriptions. Without file arguments, lints
+  every .tfbook file in the runbooks directory.
+
+  A runbook can disable rules for itself with a lint block naming
+  them, e.g. lint { disabled = ["missing-description"] }.
+
+  The command exits with status 1 if any finding has error severity,
+  so it can gate merges in CI.
+
+Options:
+
+  -json               Write the findings as a JSON array instead of
+                      human-readable text.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RunbookLintCommand) Synopsis() string {
+	return "Check runbooks against the lint rules"
+}
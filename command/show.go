@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/terraform/command/jsonplan"
 	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/command/views"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states/statefile"
@@ -114,12 +115,13 @@ func (c *ShowCommand) Run(args []string) int {
 	var planErr, stateErr error
 	var plan *plans.Plan
 	var stateFile *statefile.File
+	var integrationResults *planfile.IntegrationResults
 
 	// if a path was provided, try to read it as a path to a planfile
 	// if that fails, try to read the cli argument as a path to a statefile
 	if len(args) > 0 {
 		path := args[0]
-		plan, stateFile, planErr = getPlanFromPath(path)
+		plan, stateFile, integrationResults, planErr = getPlanFromPath(path)
 		if planErr != nil {
 			stateFile, stateErr = getStateFromPath(path)
 			if stateErr != nil {
@@ -149,7 +151,7 @@ func (c *ShowCommand) Run(args []string) int {
 	if plan != nil {
 		if jsonOutput {
 			config := ctx.Config()
-			jsonPlan, err := jsonplan.Marshal(config, plan, stateFile, schemas)
+			jsonPlan, err := jsonplan.Marshal(config, plan, stateFile, schemas, integrationResults)
 
 			if err != nil {
 				c.Ui.Error(fmt.Sprintf("Failed to marshal plan to json: %s", err))
@@ -160,7 +162,16 @@ func (c *ShowCommand) Run(args []string) int {
 		}
 
 		view := views.NewShow(arguments.ViewHuman, c.View)
+		if integrationResults != nil {
+			view.PlanAnnotations(integrationAnnotations(integrationResults))
+		}
 		view.Plan(plan, stateFile.State, schemas)
+
+		// Findings recorded at plan time are shown again as diagnostics,
+		// the same way they were shown when the plan was created.
+		if integrationResults != nil {
+			c.showDiagnostics(integrationFindingDiags(integrationResults))
+		}
 		return 0
 	}
 
@@ -210,20 +221,67 @@ func (c *ShowCommand) Synopsis() string {
 }
 
 // getPlanFromPath returns a plan and statefile if the user-supplied path points
-// to a planfile. If both plan and error are nil, the path is likely a
-// directory. An error could suggest that the given path points to a statefile.
-func getPlanFromPath(path string) (*plans.Plan, *statefile.File, error) {
+// to a planfile, along with any integration results recorded in it. If both
+// plan and error are nil, the path is likely a directory. An error could
+// suggest that the given path points to a statefile.
+func getPlanFromPath(path string) (*plans.Plan, *statefile.File, *planfile.IntegrationResults, error) {
 	pr, err := planfile.Open(path)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	plan, err := pr.ReadPlan()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	// Integration results are optional, and missing from plan files created
+	// without integrations configured.
+	integrationResults, err := pr.ReadIntegrationResults()
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	stateFile, err := pr.ReadStateFile()
-	return plan, stateFile, err
+	return plan, stateFile, integrationResults, err
+}
+
+// integrationAnnotations converts the annotations recorded in a plan file
+// into the form the plan renderer consumes.
+func integrationAnnotations(results *planfile.IntegrationResults) map[string][]integrations.Annotation {
+	if len(results.Annotations) == 0 {
+		return nil
+	}
+	ret := make(map[string][]integrations.Annotation, len(results.Annotations))
+	for addr, anns := range results.Annotations {
+		for _, a := range anns {
+			ret[addr] = append(ret[addr], integrations.Annotation{
+				Integration: a.Integration,
+				Address:     addr,
+				Message:     a.Message,
+				Severity:    a.Severity,
+				URL:         a.URL,
+			})
+		}
+	}
+	return ret
+}
+
+// integrationFindingDiags converts the findings recorded in a plan file
+// into diagnostics, mirroring how they were presented at plan time.
+func integrationFindingDiags(results *planfile.IntegrationResults) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for _, f := range results.Findings {
+		severity := tfdiags.Warning
+		if f.Severity == "error" {
+			severity = tfdiags.Error
+		}
+		detail := fmt.Sprintf("The integration %q reported this for %s when the plan was created.", f.Integration, f.Address)
+		if f.Detail != "" {
+			detail = f.Detail + "\n\n" + detail
+		}
+		diags = diags.Append(tfdiags.Sourceless(severity, f.Summary, detail))
+	}
+	return diags
 }
 
 // getStateFromPath returns a statefile if the user-supplied path points to a statefile.
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/internal/integrations"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/hashicorp/terraform/version"
+)
+
+// stateMutationHook starts the integrations declared by the configuration
+// in the current working directory for the duration of a command that
+// edits state directly, and returns a hook for the command's pre/post
+// mutation events along with a function to stop the integrations once
+// the command is done.
+//
+// State surgery is legal outside a configuration directory, so a missing
+// or unloadable configuration, or one that declares no integrations, just
+// yields a nil hook — which is safe to call — and a no-op close function.
+func (m *Meta) stateMutationHook(operation string) (*integrations.StateHook, func(), tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	noop := func() {}
+
+	cfg, cfgDiags := m.loadConfig(".")
+	if cfgDiags.HasErrors() {
+		return nil, noop, diags
+	}
+
+	// Where several modules declare an integration with the same name we
+	// just take whichever we visit first, consistent with integrations
+	// being meaningful only at whatever root is being operated on.
+	integrationConfigs := make(map[string]*configs.Integration)
+	cfg.DeepEach(func(cfg *configs.Config) {
+		for name, ic := range cfg.Module.Integrations {
+			if _, exists := integrationConfigs[name]; !exists {
+				integrationConfigs[name] = ic
+			}
+		}
+	})
+	if len(integrationConfigs) == 0 {
+		return nil, noop, diags
+	}
+
+	workspace, err := m.Workspace()
+	if err != nil {
+		workspace = ""
+	}
+
+	mgr, moreDiags := integrations.NewManager(integrationConfigs, &integrations.RunContext{
+		TerraformVersion: version.String(),
+		Command:          operation,
+		Workspace:        workspace,
+		ConfigRoot:       ".",
+	}, nil)
+	diags = diags.Append(moreDiags)
+	if diags.HasErrors() {
+		return nil, noop, diags
+	}
+
+	close := func() {
+		if diags := mgr.Close(); len(diags) > 0 {
+			log.Printf("[WARN] terraform %s: problems while stopping integrations: %s", operation, diags.Err())
+		}
+	}
+	return mgr.StateHook(), close, diags
+}
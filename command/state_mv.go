@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/terraform/command/arguments"
 	"github.com/hashicorp/terraform/command/clistate"
 	"github.com/hashicorp/terraform/command/views"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/cli"
@@ -139,6 +140,29 @@ func (c *StateMvCommand) Run(args []string) int {
 			fmt.Sprintf("Cannot move %s: does not match anything in the current state.", sourceAddr),
 		))
 	}
+	// Integrations hear about the move before and after it happens, so
+	// state surgery is as observable as plan and apply. A pre event with
+	// no matching post means the move was aborted before anything was
+	// persisted. Dry runs change nothing and so announce nothing.
+	var stateHook *integrations.StateHook
+	var hookAddrs []string
+	if !dryRun && len(sourceAddrs) != 0 {
+		hook, closeHook, hookDiags := c.stateMutationHook("state mv")
+		if hookDiags.HasErrors() {
+			c.showDiagnostics(hookDiags)
+			return 1
+		}
+		defer closeHook()
+		stateHook = hook
+
+		hookAddrs = make([]string, 0, len(sourceAddrs)+1)
+		for _, addr := range sourceAddrs {
+			hookAddrs = append(hookAddrs, addr.String())
+		}
+		hookAddrs = append(hookAddrs, destAddr.String())
+		stateHook.PreMutation(hookAddrs)
+	}
+
 	for _, rawAddrFrom := range sourceAddrs {
 		switch addrFrom := rawAddrFrom.(type) {
 		case addrs.ModuleInstance:
@@ -342,10 +366,12 @@ func (c *StateMvCommand) Run(args []string) int {
 
 	// Write the new state
 	if err := stateToMgr.WriteState(stateTo); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf(errStateRmPersist, err))
 		return 1
 	}
 	if err := stateToMgr.PersistState(); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf(errStateRmPersist, err))
 		return 1
 	}
@@ -353,14 +379,17 @@ func (c *StateMvCommand) Run(args []string) int {
 	// Write the old state if it is different
 	if stateTo != stateFrom {
 		if err := stateFromMgr.WriteState(stateFrom); err != nil {
+			stateHook.PostMutation(hookAddrs, err)
 			c.Ui.Error(fmt.Sprintf(errStateRmPersist, err))
 			return 1
 		}
 		if err := stateFromMgr.PersistState(); err != nil {
+			stateHook.PostMutation(hookAddrs, err)
 			c.Ui.Error(fmt.Sprintf(errStateRmPersist, err))
 			return 1
 		}
 	}
+	stateHook.PostMutation(hookAddrs, nil)
 
 	c.showDiagnostics(diags)
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/terraform/command/arguments"
@@ -116,19 +117,42 @@ func (c *StatePushCommand) Run(args []string) int {
 		srcStateFile = statemgr.NewStateFile()
 	}
 
+	// Integrations hear about the push before and after it happens, so
+	// state surgery is as observable as plan and apply. A push replaces
+	// the workspace's state wholesale, so the affected addresses are all
+	// of the resources in the incoming state.
+	stateHook, closeHook, hookDiags := c.stateMutationHook("state push")
+	if hookDiags.HasErrors() {
+		c.showDiagnostics(hookDiags)
+		return 1
+	}
+	defer closeHook()
+	var hookAddrs []string
+	for _, mod := range srcStateFile.State.Modules {
+		for _, rs := range mod.Resources {
+			hookAddrs = append(hookAddrs, rs.Addr.String())
+		}
+	}
+	sort.Strings(hookAddrs)
+	stateHook.PreMutation(hookAddrs)
+
 	// Import it, forcing through the lineage/serial if requested and possible.
 	if err := statemgr.Import(srcStateFile, stateMgr, flagForce); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf("Failed to write state: %s", err))
 		return 1
 	}
 	if err := stateMgr.WriteState(srcStateFile.State); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf("Failed to write state: %s", err))
 		return 1
 	}
 	if err := stateMgr.PersistState(); err != nil {
+		stateHook.PostMutation(hookAddrs, err)
 		c.Ui.Error(fmt.Sprintf("Failed to persist state: %s", err))
 		return 1
 	}
+	stateHook.PostMutation(hookAddrs, nil)
 
 	return 0
 }
@@ -87,6 +87,28 @@ func (c *StateRmCommand) Run(args []string) int {
 		prefix = "Would remove "
 	}
 
+	// Integrations hear about the removal before and after it happens,
+	// so state surgery is as observable as plan and apply. Dry runs
+	// change nothing and so announce nothing.
+	var persistErr error
+	if !dryRun && len(addrs) != 0 {
+		stateHook, closeHook, hookDiags := c.stateMutationHook("state rm")
+		if hookDiags.HasErrors() {
+			c.showDiagnostics(hookDiags)
+			return 1
+		}
+		defer closeHook()
+
+		addrStrs := make([]string, len(addrs))
+		for i, addr := range addrs {
+			addrStrs[i] = addr.String()
+		}
+		stateHook.PreMutation(addrStrs)
+		defer func() {
+			stateHook.PostMutation(addrStrs, persistErr)
+		}()
+	}
+
 	var isCount int
 	ss := state.SyncWrapper()
 	for _, addr := range addrs {
@@ -106,10 +128,12 @@ func (c *StateRmCommand) Run(args []string) int {
 	}
 
 	if err := stateMgr.WriteState(state); err != nil {
+		persistErr = err
 		c.Ui.Error(fmt.Sprintf(errStateRmPersist, err))
 		return 1
 	}
 	if err := stateMgr.PersistState(); err != nil {
+		persistErr = err
 		c.Ui.Error(fmt.Sprintf(errStateRmPersist, err))
 		return 1
 	}
@@ -19,6 +19,7 @@ import (
 	"github.com/hashicorp/terraform/configs/configload"
 	"github.com/hashicorp/terraform/internal/depsfile"
 	"github.com/hashicorp/terraform/internal/initwd"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/internal/moduletest"
 	"github.com/hashicorp/terraform/internal/providercache"
 	"github.com/hashicorp/terraform/plans"
@@ -26,6 +27,7 @@ import (
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/hashicorp/terraform/version"
 )
 
 // TestCommand is the implementation of "terraform test".
@@ -74,8 +76,13 @@ func (c *TestCommand) Run(rawArgs []string) int {
 		}
 	}
 
+	// Any .tftest scenario files under tests/scenarios run through the
+	// newer scenario runner, as part of the same test run.
+	scenariosFailed, moreDiags := c.runScenarios(ctx, args)
+	view.Diagnostics(moreDiags)
+
 	// Lots of things can possibly have failed
-	if initFailed || resultsFailed || testsFailed {
+	if initFailed || resultsFailed || testsFailed || scenariosFailed {
 		return 1
 	}
 	return 0
@@ -398,7 +405,30 @@ func (c *TestCommand) runTestSuite(ctx context.Context, suiteDirs testCommandSui
 		)
 	}
 
-	plan, diags := c.testSuitePlan(ctx, suiteDirs, providerFactories)
+	// If the configuration under test declares any integrations then we
+	// start them for the duration of the suite, so that e.g. policy checks
+	// are exercised by test runs too. Individual integrations can opt out
+	// with disabled_in_tests.
+	integrationsMgr, diags := c.testSuiteIntegrations(suiteDirs)
+	if diags.HasErrors() {
+		return synthError(
+			"integrations",
+			"start integrations",
+			"failed to start the configured integrations",
+			diags,
+		)
+	}
+	var hooks []terraform.Hook
+	if integrationsMgr != nil {
+		defer func() {
+			if diags := integrationsMgr.Close(); len(diags) > 0 {
+				log.Printf("[WARN] terraform test: problems while stopping integrations for suite %q: %s", suiteDirs.SuiteName, diags.Err())
+			}
+		}()
+		hooks = append(hooks, integrationsMgr.Hook())
+	}
+
+	plan, diags := c.testSuitePlan(ctx, suiteDirs, providerFactories, hooks)
 	if diags.HasErrors() {
 		// It should be unusual to get in here, because testSuitePlan
 		// should rely only on things guaranteed by prepareSuiteDir, but
@@ -419,7 +449,7 @@ func (c *TestCommand) runTestSuite(ctx context.Context, suiteDirs testCommandSui
 	// with so the caller can generate additional loud errors if anything
 	// is left in it.
 
-	state, diags = c.testSuiteApply(ctx, plan, suiteDirs, providerFactories)
+	state, diags = c.testSuiteApply(ctx, plan, suiteDirs, providerFactories, hooks)
 	if diags.HasErrors() {
 		// We don't return here, unlike the others above, because we want to
 		// continue to the destroy below even if there are apply errors.
@@ -437,7 +467,7 @@ func (c *TestCommand) runTestSuite(ctx context.Context, suiteDirs testCommandSui
 	// the destroy operation below won't get tripped up on stale results.
 	ret = testProvider.Reset()
 
-	state, diags = c.testSuiteDestroy(ctx, state, suiteDirs, providerFactories)
+	state, diags = c.testSuiteDestroy(ctx, state, suiteDirs, providerFactories, hooks)
 	if diags.HasErrors() {
 		synthError(
 			"destroy",
@@ -495,7 +525,7 @@ func (c *TestCommand) testSuiteProviders(suiteDirs testCommandSuiteDirs, testPro
 	return ret, diags
 }
 
-func (c *TestCommand) testSuiteContext(suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory, state *states.State, plan *plans.Plan, destroy bool) (*terraform.Context, tfdiags.Diagnostics) {
+func (c *TestCommand) testSuiteContext(suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory, hooks []terraform.Hook, state *states.State, plan *plans.Plan, destroy bool) (*terraform.Context, tfdiags.Diagnostics) {
 	var changes *plans.Changes
 	if plan != nil {
 		changes = plan.Changes
@@ -504,6 +534,7 @@ func (c *TestCommand) testSuiteContext(suiteDirs testCommandSuiteDirs, providerF
 	return terraform.NewContext(&terraform.ContextOpts{
 		Config:    suiteDirs.Config,
 		Providers: providerFactories,
+		Hooks:     hooks,
 
 		// We just use the provisioners from the main Meta here, because
 		// unlike providers provisioner plugins are not automatically
@@ -521,9 +552,9 @@ func (c *TestCommand) testSuiteContext(suiteDirs testCommandSuiteDirs, providerF
 	})
 }
 
-func (c *TestCommand) testSuitePlan(ctx context.Context, suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory) (*plans.Plan, tfdiags.Diagnostics) {
+func (c *TestCommand) testSuitePlan(ctx context.Context, suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory, hooks []terraform.Hook) (*plans.Plan, tfdiags.Diagnostics) {
 	log.Printf("[TRACE] terraform test: create plan for suite %q", suiteDirs.SuiteName)
-	tfCtx, diags := c.testSuiteContext(suiteDirs, providerFactories, nil, nil, false)
+	tfCtx, diags := c.testSuiteContext(suiteDirs, providerFactories, hooks, nil, nil, false)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -546,9 +577,9 @@ func (c *TestCommand) testSuitePlan(ctx context.Context, suiteDirs testCommandSu
 	return plan, diags
 }
 
-func (c *TestCommand) testSuiteApply(ctx context.Context, plan *plans.Plan, suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory) (*states.State, tfdiags.Diagnostics) {
+func (c *TestCommand) testSuiteApply(ctx context.Context, plan *plans.Plan, suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory, hooks []terraform.Hook) (*states.State, tfdiags.Diagnostics) {
 	log.Printf("[TRACE] terraform test: apply plan for suite %q", suiteDirs.SuiteName)
-	tfCtx, diags := c.testSuiteContext(suiteDirs, providerFactories, nil, plan, false)
+	tfCtx, diags := c.testSuiteContext(suiteDirs, providerFactories, hooks, nil, plan, false)
 	if diags.HasErrors() {
 		// To make things easier on the caller, we'll return a valid empty
 		// state even in this case.
@@ -560,9 +591,9 @@ func (c *TestCommand) testSuiteApply(ctx context.Context, plan *plans.Plan, suit
 	return state, diags
 }
 
-func (c *TestCommand) testSuiteDestroy(ctx context.Context, state *states.State, suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory) (*states.State, tfdiags.Diagnostics) {
+func (c *TestCommand) testSuiteDestroy(ctx context.Context, state *states.State, suiteDirs testCommandSuiteDirs, providerFactories map[addrs.Provider]providers.Factory, hooks []terraform.Hook) (*states.State, tfdiags.Diagnostics) {
 	log.Printf("[TRACE] terraform test: plan to destroy any existing objects for suite %q", suiteDirs.SuiteName)
-	tfCtx, diags := c.testSuiteContext(suiteDirs, providerFactories, state, nil, true)
+	tfCtx, diags := c.testSuiteContext(suiteDirs, providerFactories, hooks, state, nil, true)
 	if diags.HasErrors() {
 		return state, diags
 	}
@@ -574,7 +605,7 @@ func (c *TestCommand) testSuiteDestroy(ctx context.Context, state *states.State,
 	}
 
 	log.Printf("[TRACE] terraform test: apply the plan to destroy any existing objects for suite %q", suiteDirs.SuiteName)
-	tfCtx, moreDiags = c.testSuiteContext(suiteDirs, providerFactories, state, plan, true)
+	tfCtx, moreDiags = c.testSuiteContext(suiteDirs, providerFactories, hooks, state, plan, true)
 	diags = diags.Append(moreDiags)
 	if diags.HasErrors() {
 		return state, diags
@@ -585,6 +616,44 @@ func (c *TestCommand) testSuiteDestroy(ctx context.Context, state *states.State,
 	return state, diags
 }
 
+// testSuiteIntegrations starts the integrations declared by the
+// configuration under test, skipping any that set disabled_in_tests. It
+// returns nil if there are none to run. Hook events from test runs carry
+// the suite name in their run context so that integrations can tell them
+// apart from ordinary operations.
+func (c *TestCommand) testSuiteIntegrations(suiteDirs testCommandSuiteDirs) (*integrations.Manager, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	// The test suite configuration is the root here, with the module under
+	// test as one of its children, so we must search the whole tree. Where
+	// several modules declare an integration with the same name we just
+	// take whichever we visit first, consistent with integrations being
+	// meaningful only at whatever root is being operated on.
+	integrationConfigs := make(map[string]*configs.Integration)
+	suiteDirs.Config.DeepEach(func(cfg *configs.Config) {
+		for name, ic := range cfg.Module.Integrations {
+			if ic.DisabledInTests {
+				continue
+			}
+			if _, exists := integrationConfigs[name]; !exists {
+				integrationConfigs[name] = ic
+			}
+		}
+	})
+	if len(integrationConfigs) == 0 {
+		return nil, diags
+	}
+
+	mgr, moreDiags := integrations.NewManager(integrationConfigs, &integrations.RunContext{
+		TerraformVersion: version.String(),
+		Command:          "test",
+		TestSuite:        suiteDirs.SuiteName,
+		ConfigRoot:       suiteDirs.ConfigDir,
+	}, nil)
+	diags = diags.Append(moreDiags)
+	return mgr, diags
+}
+
 func (c *TestCommand) collectSuiteNames() ([]string, error) {
 	items, err := ioutil.ReadDir("tests")
 	if err != nil {
@@ -653,6 +722,11 @@ Usage: terraform test [options]
   writing explicit test assertions which must also all pass in order
   for the test run to be considered successful.
 
+  Additionally, "test" looks for ".tftest" scenario files in a
+  subdirectory called "tests/scenarios". Each scenario describes an
+  ordered sequence of plan and apply steps with explicit assertions,
+  and all of the scenarios run as part of the same test run.
+
   This initial implementation is intended as a minimally-viable
   product to use for further research and experimentation, and in
   particular it currently lacks the following capabilities that we
@@ -678,6 +752,62 @@ Options:
   -compact-warnings  Use a more compact representation for warnings, if
                      this command produces only warnings and no errors.
 
+  -coverage          After the scenarios have run, report which
+                     resources, module calls, variables, and outputs of
+                     the module under test they exercised, listing the
+                     resources no scenario ever planned. With -json the
+                     summary is written as a JSON document too.
+
+  -filter=REGEXP     Run only the test scenarios whose name, or one of
+                     whose "scenario/step" qualified step names, matches
+                     the given regular expression. Test suites always
+                     all run.
+
+  -skip=REGEXP       Skip the test scenarios and steps the other options
+                     would otherwise select, matched against the same
+                     names as -filter.
+
+  -tags=TAG          Run only the test scenarios carrying at least one
+                     of the given tags. May be used multiple times.
+
+  -exclude-tags=TAG  Skip the test scenarios carrying any of the given
+                     tags, even when -tags also matches them. May be
+                     used multiple times.
+
+  -json              In addition to the usual output, write a
+                     machine-readable JSON report of the scenario
+                     results to the standard output stream.
+
+  -no-cleanup-on-failure  Keep the resources of failed scenarios instead
+                     of running their cleanup steps, and write their
+                     final states to a debug directory with instructions
+                     to destroy them, so the failure can be diagnosed.
+
+  -parallelism=N     Bound how many test scenarios run at once. The
+                     default is 10; 1 disables concurrency entirely.
+                     Steps within a scenario still run in order either
+                     way, except those marked parallel = true.
+
+  -rerun-failed      Re-run only the test scenarios that failed in the
+                     previous run, reusing the recorded results of the
+                     scenarios that passed. Test suites always all run.
+
+  -update-snapshots  Rewrite the golden files of steps with snapshot
+                     options from this run's plans instead of comparing
+                     against them, for deliberate behavior changes.
+                     Review the resulting diffs before committing them.
+
+  -verbose           Stream each scenario step's progress as it runs,
+                     prefixed with the scenario and step names, instead
+                     of only reporting results at the end.
+
+  -var 'NAME=VALUE'  Set a suite-level fallback value for a scenario
+                     input variable. A scenario's own variables blocks
+                     take precedence. May be used multiple times.
+
+  -var-file=FILE     Load suite-level fallback variable values from the
+                     given file. May be used multiple times.
+
   -junit-xml=FILE    In addition to the usual output, also write test
                      results to the given file path in JUnit XML format.
                      This format is commonly supported by CI systems, and
@@ -0,0 +1,844 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/command/arguments"
+	"github.com/hashicorp/terraform/command/jsonplan"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/internal/integrations"
+	"github.com/hashicorp/terraform/internal/providermocks"
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/internal/scenarios"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/states/statemgr"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/hashicorp/terraform/version"
+)
+
+// scenariosDirName is the subdirectory of "tests" where "terraform test"
+// looks for .tftest scenario files, alongside the older suite
+// directories.
+const scenariosDirName = "scenarios"
+
+// testResultsFilename is the file under the local data directory where
+// "terraform test" records how each scenario went, so that a later run
+// with -rerun-failed can re-execute only the scenarios that failed.
+const testResultsFilename = "test-results.json"
+
+// runScenarios discovers and executes the scenario files under
+// tests/scenarios, if that directory exists. It returns true if at least
+// one selected scenario failed, so the caller can reflect that in the
+// command's exit status. Scenario diagnostics are accumulated into the
+// returned diagnostics rather than printed directly, so they render the
+// same way as the rest of the command's output.
+func (c *TestCommand) runScenarios(ctx context.Context, args arguments.Test) (bool, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	dir := filepath.Join("tests", scenariosDirName)
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		// Scenario files are optional, just like the suite directories,
+		// so a module without them is not an error.
+		return false, diags
+	}
+
+	scns, moreDiags := scenarios.LoadScenariosDir(dir)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return true, diags
+	}
+
+	filter, moreDiags := scenarios.ParseFilter(args.Filter, args.Skip, args.Tags, args.ExcludeTags)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return true, diags
+	}
+	scns = filter.Select(scns)
+
+	for _, scn := range scns {
+		diags = diags.Append(scn.CheckCompatibility())
+	}
+	if diags.HasErrors() {
+		return true, diags
+	}
+	if len(scns) == 0 {
+		return false, diags
+	}
+
+	recordPath := filepath.Join(c.DataDir(), testResultsFilename)
+	var previous *scenarios.RunRecord
+	if args.RerunFailed {
+		f, err := os.Open(recordPath)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"No previous test run to resume",
+				fmt.Sprintf("The -rerun-failed option re-executes only the scenarios that failed in the previous run, but there is no record of a previous run at %s. Run \"terraform test\" without -rerun-failed first.", recordPath),
+			))
+			return true, diags
+		}
+		previous, err = scenarios.ReadRunRecord(f)
+		f.Close()
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid test results record",
+				fmt.Sprintf("Could not read the record of the previous test run from %s: %s. Run \"terraform test\" without -rerun-failed to rebuild it.", recordPath, err),
+			))
+			return true, diags
+		}
+	}
+
+	suiteVars, moreDiags := scenarios.ParseSuiteVars(args.Vars, args.VarFiles)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return true, diags
+	}
+
+	factories, err := c.providerFactories()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to prepare providers",
+			fmt.Sprintf("Could not prepare the provider plugins for the test scenarios: %s.", err),
+		))
+		return true, diags
+	}
+
+	// Steps acquire their providers through a shared pool, so that
+	// scenarios using the same provider do not each pay the cost of
+	// launching a fresh subprocess for every step.
+	pool := scenarios.NewProviderPool(factories)
+	defer pool.Close()
+
+	exec := &scenarioExecutor{
+		cmd:          c,
+		run:          scenarios.NewRun(""),
+		providers:    pool.Factories(),
+		rawProviders: factories,
+		suiteVars:    suiteVars,
+		states:       make(map[*scenarios.Scenario]*scenarios.StateStore),
+
+		updateSnapshots: args.UpdateSnapshots,
+	}
+	exec.artifactsDir = filepath.Join(c.DataDir(), "test-artifacts", exec.run.ID)
+
+	// Coverage is measured against the module under test, which for this
+	// command is the current working directory. If its configuration does
+	// not load, the steps will report that with better context, so the
+	// run just proceeds without coverage here.
+	if args.Output.Coverage {
+		if cfg, cfgDiags := c.loadConfig("."); !cfgDiags.HasErrors() {
+			exec.coverage = scenarios.NewCoverage(cfg.Module)
+		}
+	}
+
+	runner := &scenarios.Runner{
+		RunStep:           exec.runStep,
+		Parallelism:       args.Parallelism,
+		PreserveOnFailure: args.NoCleanupOnFailure,
+		Verbose:           args.Verbose,
+		Output:            os.Stdout,
+	}
+	exec.runner = runner
+
+	// When resuming, the scenarios that passed last time do not run
+	// again: their recorded results stand in, so their dependents still
+	// see their outputs. A scenario whose steps changed since the record
+	// was written, or whose record cannot be rebuilt, runs as usual.
+	if previous != nil {
+		recorded := make(map[string]*scenarios.ScenarioResult)
+		for _, scn := range scns {
+			if result := previous.Result(scn); result != nil {
+				recorded[scn.Name] = result
+			}
+		}
+		runner.Recorded = recorded
+	}
+
+	// If the configuration under test declares integrations then they
+	// receive the test step lifecycle events too, so external
+	// test-reporting services can follow the run.
+	integrationsMgr, moreDiags := c.scenarioIntegrations()
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return true, diags
+	}
+	if integrationsMgr != nil {
+		defer func() {
+			if diags := integrationsMgr.Close(); len(diags) > 0 {
+				log.Printf("[WARN] terraform test: problems while stopping integrations for scenarios: %s", diags.Err())
+			}
+		}()
+		runner.Hooks = append(runner.Hooks, integrationsMgr.TestHook())
+		exec.runbookHook = integrationsMgr.RunbookHook()
+
+		// Any evaluation functions the integrations declared become
+		// available to assertion and variable expressions, named
+		// integration_<integration>_<function>.
+		exec.run.ExtraFunctions = integrationsMgr.Functions()
+	}
+
+	results := runner.Run(scns)
+
+	failed := false
+	for _, result := range results {
+		diags = diags.Append(result.Diagnostics)
+
+		// Scenarios that opted in get their final states checked for
+		// resources the cleanup steps failed to destroy.
+		if result.Scenario.VerifyCleanup && !result.Skipped && !result.Preserved {
+			if store := exec.states[result.Scenario]; store != nil {
+				cleanupDiags := result.Scenario.CheckCleanup(store.States())
+				diags = diags.Append(cleanupDiags)
+				if cleanupDiags.HasErrors() {
+					failed = true
+				}
+			}
+		}
+
+		// A preserved scenario's final states are written to a debug
+		// directory, with instructions for destroying the leftover
+		// resources once the failure is understood.
+		if result.Preserved {
+			if store := exec.states[result.Scenario]; store != nil {
+				debugBase := filepath.Join(c.DataDir(), "test-debug", exec.run.ID)
+				debugDir, err := scenarios.PreserveStates(debugBase, result.Scenario, store.States())
+				if err != nil {
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Warning,
+						"Failed to preserve scenario states",
+						fmt.Sprintf("The resources of failed scenario %q were kept, but their states could not be written for debugging: %s.", result.Scenario.Name, err),
+					))
+				} else {
+					c.Ui.Output(fmt.Sprintf("scenario %q kept its resources for debugging; see %s for its states and how to destroy them", result.Scenario.Name, debugDir))
+				}
+			}
+		}
+
+		switch {
+		case result.Skipped:
+			c.Ui.Output(fmt.Sprintf("scenario %q skipped: %s", result.Scenario.Name, result.SkipReason))
+		case result.Reused:
+			c.Ui.Output(fmt.Sprintf("scenario %q passed in the previous run; reusing its recorded results", result.Scenario.Name))
+		case result.Diagnostics.HasErrors():
+			failed = true
+			c.Ui.Output(fmt.Sprintf("scenario %q failed after %s", result.Scenario.Name, result.Duration.Round(time.Millisecond)))
+		default:
+			c.Ui.Output(fmt.Sprintf("scenario %q passed after %s", result.Scenario.Name, result.Duration.Round(time.Millisecond)))
+		}
+	}
+
+	// The outcomes are recorded for a later -rerun-failed, merging into
+	// the previous record when resuming so scenarios that were not
+	// selected this time keep their entries. Failing to write the record
+	// does not fail the tests themselves.
+	record := previous
+	if record == nil {
+		record = scenarios.NewRunRecord()
+	}
+	record.Record(results)
+	if err := c.writeTestResults(recordPath, record); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Failed to record test results",
+			fmt.Sprintf("Could not record the scenario results at %s, so a later run cannot use -rerun-failed: %s.", recordPath, err),
+		))
+	}
+
+	// The scenario results are written to the -junit-xml path the same
+	// way the suite results are, so CI systems can annotate pull requests
+	// from them. A module with both suites and scenarios gets the
+	// scenario report: this runs after the suites, and the format has no
+	// way to merge two documents into one file.
+	if args.Output.JUnitXMLFile != "" {
+		if err := c.writeScenarioJUnitXML(args.Output.JUnitXMLFile, results); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to write JUnit XML file",
+				fmt.Sprintf("Could not write the JUnit XML report of the scenario results to %s: %s.", args.Output.JUnitXMLFile, err),
+			))
+		}
+	}
+
+	if args.Output.JSON {
+		var buf bytes.Buffer
+		if err := scenarios.WriteJSONReport(&buf, results); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to render scenario results",
+				fmt.Sprintf("Could not produce the JSON report of the scenario results: %s.", err),
+			))
+		} else {
+			c.Ui.Output(buf.String())
+		}
+	}
+
+	if exec.coverage != nil {
+		var buf bytes.Buffer
+		writeCoverage := exec.coverage.WriteText
+		if args.Output.JSON {
+			writeCoverage = exec.coverage.WriteJSON
+		}
+		if err := writeCoverage(&buf); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to render scenario coverage",
+				fmt.Sprintf("Could not produce the coverage summary of the scenario run: %s.", err),
+			))
+		} else {
+			if !args.Output.JSON {
+				c.Ui.Output("Coverage of the module under test:")
+			}
+			c.Ui.Output(strings.TrimSuffix(buf.String(), "\n"))
+		}
+	}
+
+	return failed, diags
+}
+
+// writeScenarioJUnitXML writes the scenario results as a JUnit-style XML
+// report at the given path.
+func (c *TestCommand) writeScenarioJUnitXML(path string, results []*scenarios.ScenarioResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return scenarios.WriteJUnitXML(f, results)
+}
+
+// writeTestResults writes the run record to the given path under the
+// local data directory, creating the directory if this working
+// directory has never been initialized.
+func (c *TestCommand) writeTestResults(path string, record *scenarios.RunRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return scenarios.WriteRunRecord(f, record)
+}
+
+// scenarioIntegrations starts the integrations declared by the
+// configuration under test for the duration of the scenario run,
+// skipping any that set disabled_in_tests. It returns nil if there are
+// none to run.
+func (c *TestCommand) scenarioIntegrations() (*integrations.Manager, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	cfg, cfgDiags := c.loadConfig(".")
+	if cfgDiags.HasErrors() {
+		// The steps load the configuration themselves and will report
+		// these problems with better context, so just run without
+		// integrations here.
+		return nil, diags
+	}
+
+	integrationConfigs := make(map[string]*configs.Integration)
+	cfg.DeepEach(func(cfg *configs.Config) {
+		for name, ic := range cfg.Module.Integrations {
+			if ic.DisabledInTests {
+				continue
+			}
+			if _, exists := integrationConfigs[name]; !exists {
+				integrationConfigs[name] = ic
+			}
+		}
+	})
+	if len(integrationConfigs) == 0 {
+		return nil, diags
+	}
+
+	mgr, moreDiags := integrations.NewManager(integrationConfigs, &integrations.RunContext{
+		TerraformVersion: version.String(),
+		Command:          "test",
+		ConfigRoot:       ".",
+	}, nil)
+	diags = diags.Append(moreDiags)
+	return mgr, diags
+}
+
+// scenarioExecutor runs scenario steps on behalf of "terraform test",
+// carrying the cross-step context that the scenarios runner itself does
+// not hold: the run identity for expression evaluation and the states
+// shared between steps of the same scenario.
+type scenarioExecutor struct {
+	cmd       *TestCommand
+	runner    *scenarios.Runner
+	run       *scenarios.Run
+	providers map[addrs.Provider]providers.Factory
+	suiteVars map[string]cty.Value
+
+	// rawProviders launches a fresh provider subprocess per call, unlike
+	// the pooled factories in providers. Steps with env blocks use these,
+	// because their environment variables must be in place when the
+	// subprocess launches and the pool's long-lived instances were
+	// launched without them.
+	rawProviders map[addrs.Provider]providers.Factory
+
+	// artifactsDir is the per-run directory into which steps with
+	// artifact patterns have their matched files copied.
+	artifactsDir string
+
+	// coverage, when non-nil, accumulates which declarations of the
+	// module under test the steps exercised, across all scenarios.
+	coverage *scenarios.Coverage
+
+	// runbookHook, when non-nil, observes runbook step and action
+	// events, so configured integrations govern runbook rehearsals the
+	// same way they govern the rest of the test run.
+	runbookHook runbooks.Hook
+
+	// updateSnapshots rewrites the golden files of steps with snapshot
+	// options from this run's plans instead of comparing against them,
+	// from the -update-snapshots option.
+	updateSnapshots bool
+
+	mu     sync.Mutex
+	states map[*scenarios.Scenario]*scenarios.StateStore
+}
+
+// recordCoverage marks the declarations exercised by one step of the
+// module under test. Scenarios run concurrently, so the shared coverage
+// is updated under the executor's lock.
+func (e *scenarioExecutor) recordCoverage(planJSON []byte, variables map[string]cty.Value) {
+	if e.coverage == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.coverage.RecordPlan(planJSON); err != nil {
+		log.Printf("[WARN] terraform test: cannot record coverage for a plan: %s", err)
+	}
+	for name := range variables {
+		e.coverage.RecordVariables(name)
+	}
+}
+
+func (e *scenarioExecutor) stateStore(scenario *scenarios.Scenario) *scenarios.StateStore {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	store, ok := e.states[scenario]
+	if !ok {
+		store = scenarios.NewStateStore()
+		e.states[scenario] = store
+	}
+	return store
+}
+
+// stepProviders returns the provider factories for one step of the given
+// scenario: the shared pool's factories, with the factory for each
+// provider the scenario mocks replaced by one serving the mock's
+// responses. Steps of scenarios without mock_provider blocks share the
+// pool's factories directly. A step with env variables instead launches
+// its own provider subprocesses with those variables set, bypassing the
+// pool, whose instances were launched without them.
+func (e *scenarioExecutor) stepProviders(scenario *scenarios.Scenario, step *scenarios.Step, cfg *configs.Config) (map[addrs.Provider]providers.Factory, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	base := e.providers
+	if env := scenario.StepEnv(step); len(env) != 0 {
+		base = make(map[addrs.Provider]providers.Factory, len(e.rawProviders))
+		for addr, factory := range e.rawProviders {
+			base[addr] = envFactory(factory, env)
+		}
+	}
+
+	if len(scenario.MockProviders) == 0 {
+		return base, diags
+	}
+
+	ret := make(map[addrs.Provider]providers.Factory, len(base))
+	for addr, factory := range base {
+		ret[addr] = factory
+	}
+	for localName, mock := range scenario.MockProviders {
+		addr := cfg.Module.ProviderForLocalConfig(addrs.LocalProviderConfig{LocalName: localName})
+		factory, ok := ret[addr]
+		if !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Cannot mock unused provider",
+				fmt.Sprintf("Scenario %q mocks provider %q, but the module under test does not use provider %s.", scenario.Name, localName, addr),
+			))
+			continue
+		}
+		ret[addr] = providermocks.WrapFactory(mock, factory)
+	}
+	return ret, diags
+}
+
+// providerEnvMu serializes the environment manipulation in envFactory:
+// provider subprocesses inherit this process's environment at launch, so
+// a step's variables are set process-wide just long enough to start the
+// plugin and then restored.
+var providerEnvMu sync.Mutex
+
+// envFactory wraps a provider factory so that the subprocess it launches
+// sees the given environment variables, from the scenario's and step's
+// env blocks, on top of the process environment.
+func envFactory(factory providers.Factory, env map[string]string) providers.Factory {
+	return func() (providers.Interface, error) {
+		providerEnvMu.Lock()
+		defer providerEnvMu.Unlock()
+
+		restore := make(map[string]*string, len(env))
+		for name, value := range env {
+			if prev, ok := os.LookupEnv(name); ok {
+				prev := prev
+				restore[name] = &prev
+			} else {
+				restore[name] = nil
+			}
+			if err := os.Setenv(name, value); err != nil {
+				return nil, fmt.Errorf("cannot set %s for the provider subprocess: %s", name, err)
+			}
+		}
+		defer func() {
+			for name, prev := range restore {
+				if prev == nil {
+					os.Unsetenv(name)
+				} else {
+					os.Setenv(name, *prev)
+				}
+			}
+		}()
+
+		return factory()
+	}
+}
+
+// runRunbookStep executes a step that names a runbook instead of a
+// module. The runbook runs through a dry-run engine backed by the
+// scenario's mock providers, so the test rehearses the operational
+// procedure — its data reads answer from the mocks and its actions are
+// planned, never invoked — without touching real infrastructure. Each
+// runbook step's planned action data becomes an output under that step's
+// name, for the step's assertions and for later steps.
+func (e *scenarioExecutor) runRunbookStep(scenario *scenarios.Scenario, step *scenarios.Step, results map[string]*scenarios.StepResult) (*scenarios.StepResult, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	variables, moreDiags := scenario.StepVariables(step, results, e.run)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	// Suite-level -var/-var-file values fill in only the variables the
+	// scenario does not set itself, the same way they do for module
+	// steps.
+	merged := make(map[string]cty.Value, len(e.suiteVars)+len(variables))
+	for name, val := range e.suiteVars {
+		merged[name] = val
+	}
+	for name, val := range variables {
+		merged[name] = val
+	}
+
+	path := filepath.Join(filepath.Dir(scenario.SourceFile), step.Runbook)
+	rb, moreDiags := runbooks.LoadRunbookFile(path, merged, nil)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	engine := &runbooks.Engine{
+		DryRun:  true,
+		Readers: scenario.MockReaders(),
+		RunID:   e.run.ID,
+
+		// Reads run one at a time so the mocks' call counting — and with
+		// it their scripted errors, transitions, and pagination — stays
+		// deterministic.
+		ReadParallelism: 1,
+
+		// A rehearsal must not take real org-wide locks, so backend
+		// locks are simulated with the process-local mechanism: the
+		// runbook's locking behavior still executes, but only other
+		// steps of this test run are excluded.
+		BackendLock: runbooks.LocalBackendLock,
+	}
+	if e.runbookHook != nil {
+		engine.Hooks = append(engine.Hooks, e.runbookHook)
+	}
+
+	out := e.runner.StepOutput(scenario, step)
+	defer out.Close()
+
+	result, moreDiags := scenarios.RunRunbookStep(engine, rb)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	fmt.Fprintf(out, "runbook %q complete: %d outputs\n", rb.Name, len(result.Outputs))
+
+	diags = diags.Append(scenario.CheckAsserts(step, result, results, e.run))
+	if len(step.Artifacts) != 0 {
+		collected, artifactDiags := scenario.CollectArtifacts(step, filepath.Dir(scenario.SourceFile), e.artifactsDir)
+		diags = diags.Append(artifactDiags)
+		result.Artifacts = collected
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return result, diags
+}
+
+// stopOnCancel asks the given terraform context to stop gracefully when
+// the step's context is cancelled, which happens when the step times
+// out: the in-flight operation winds down and returns instead of running
+// on, so the step's state lock is released and its final state written
+// before any retry begins. The returned function ends the watch; call it
+// once the operation has returned.
+func stopOnCancel(ctx context.Context, tfCtx *terraform.Context) func() {
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			tfCtx.Stop()
+		case <-finished:
+		}
+	}()
+	return func() { close(finished) }
+}
+
+// runStep is the scenarios.StepFunc for "terraform test": it plans, and
+// for apply steps also applies, the step's module using the provider
+// plugins already installed for the configuration under test, and then
+// checks the step's assertions and snapshot against the outcome.
+func (e *scenarioExecutor) runStep(ctx context.Context, scenario *scenarios.Scenario, step *scenarios.Step, results map[string]*scenarios.StepResult) (*scenarios.StepResult, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	if step.Runbook != "" {
+		return e.runRunbookStep(scenario, step, results)
+	}
+
+	// An empty module means the configuration under test, which for this
+	// command is the current working directory; anything else is relative
+	// to the directory containing the scenario file.
+	moduleDir := "."
+	if step.Module != "" {
+		moduleDir = filepath.Join(filepath.Dir(scenario.SourceFile), step.Module)
+	}
+
+	cfg, moreDiags := e.cmd.loadConfig(moduleDir)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	diags = diags.Append(scenarios.CheckInlineProviderConfigs(cfg))
+	diags = diags.Append(scenario.CheckStepProviders(step, cfg.Module))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	variables, moreDiags := scenario.StepVariables(step, results, e.run)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	// Suite-level -var/-var-file values fill in only the variables the
+	// scenario does not set itself.
+	merged := make(map[string]cty.Value, len(e.suiteVars)+len(variables))
+	for name, val := range e.suiteVars {
+		merged[name] = val
+	}
+	for name, val := range variables {
+		merged[name] = val
+	}
+
+	// Input prompting is disabled during test runs, so a required
+	// variable without a value is an error now rather than a hang later.
+	diags = diags.Append(scenario.CheckRequiredVariables(step, cfg.Module, merged))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	inputValues := make(terraform.InputValues, len(merged))
+	for name, val := range merged {
+		inputValues[name] = &terraform.InputValue{
+			Value:      val,
+			SourceType: terraform.ValueFromCLIArg,
+		}
+	}
+
+	// Holding the state's lock for the duration of the step turns two
+	// parallel steps erroneously sharing a state into a clear error
+	// instead of a data race.
+	mgr := e.stateStore(scenario).Manager(step.StateKey())
+	lockID, err := mgr.Lock(statemgr.NewLockInfo())
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to lock scenario state",
+			fmt.Sprintf("Could not lock the state for step %q: %s.", step.Name, err),
+		))
+		return nil, diags
+	}
+	defer mgr.Unlock(lockID)
+
+	stepProviders, moreDiags := e.stepProviders(scenario, step, cfg)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	opts := &terraform.ContextOpts{
+		Config:       cfg,
+		Providers:    stepProviders,
+		Provisioners: e.cmd.provisionerFactories(),
+		Variables:    inputValues,
+		State:        mgr.State(),
+		Meta: &terraform.ContextMeta{
+			Env: "scenario_" + scenario.Name,
+		},
+	}
+	refreshOnly := false
+	if po := step.PlanOptions; po != nil {
+		refreshOnly = po.Mode == scenarios.ModeRefreshOnly
+		opts.SkipRefresh = !po.Refresh
+		opts.Targets = po.Targets
+	}
+
+	tfCtx, ctxDiags := terraform.NewContext(opts)
+	diags = diags.Append(ctxDiags)
+	if ctxDiags.HasErrors() {
+		return nil, diags
+	}
+
+	out := e.runner.StepOutput(scenario, step)
+	defer out.Close()
+
+	diags = diags.Append(tfCtx.Validate())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// A refresh-only step updates the state to match the remote objects
+	// and stops there: nothing is planned or applied, and the step's
+	// assertions run against the refreshed state's outputs.
+	if refreshOnly {
+		stop := stopOnCancel(ctx, tfCtx)
+		state, refreshDiags := tfCtx.Refresh()
+		stop()
+		diags = diags.Append(refreshDiags)
+		result := &scenarios.StepResult{
+			Outputs: map[string]cty.Value{},
+		}
+		if state != nil {
+			mgr.WriteState(state)
+			result.State = state
+			for name, ov := range state.RootModule().OutputValues {
+				result.Outputs[name] = ov.Value
+			}
+		}
+		if refreshDiags.HasErrors() {
+			return nil, diags
+		}
+		fmt.Fprintf(out, "refresh complete: %d outputs\n", len(result.Outputs))
+
+		diags = diags.Append(scenario.CheckAsserts(step, result, results, e.run))
+		if len(step.Artifacts) != 0 {
+			collected, artifactDiags := scenario.CollectArtifacts(step, filepath.Dir(scenario.SourceFile), e.artifactsDir)
+			diags = diags.Append(artifactDiags)
+			result.Artifacts = collected
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		return result, diags
+	}
+
+	stop := stopOnCancel(ctx, tfCtx)
+	plan, planDiags := tfCtx.Plan()
+	stop()
+	diags = diags.Append(planDiags)
+	if planDiags.HasErrors() {
+		return nil, diags
+	}
+
+	planJSON, err := jsonplan.Marshal(cfg, plan, nil, tfCtx.Schemas(), nil)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to render plan",
+			fmt.Sprintf("Could not render the plan for step %q as JSON: %s.", step.Name, err),
+		))
+		return nil, diags
+	}
+
+	// Coverage counts only steps of the module under test: a helper
+	// module planned from a tests directory says nothing about how much
+	// of the module itself the suite exercises.
+	if step.Module == "" {
+		e.recordCoverage(planJSON, merged)
+	}
+
+	result := &scenarios.StepResult{
+		Outputs:  map[string]cty.Value{},
+		PlanJSON: planJSON,
+	}
+	if created, destroyed, err := scenarios.CountPlanChanges(planJSON); err == nil {
+		result.Stats = &scenarios.StepStats{
+			ResourcesCreated:   created,
+			ResourcesDestroyed: destroyed,
+		}
+		fmt.Fprintf(out, "plan: %d to create, %d to destroy\n", created, destroyed)
+	}
+
+	if step.Command != scenarios.CommandPlan {
+		opts.Changes = plan.Changes
+		applyCtx, ctxDiags := terraform.NewContext(opts)
+		diags = diags.Append(ctxDiags)
+		if ctxDiags.HasErrors() {
+			return nil, diags
+		}
+		stop := stopOnCancel(ctx, applyCtx)
+		state, applyDiags := applyCtx.Apply()
+		stop()
+		diags = diags.Append(applyDiags)
+		if state != nil {
+			mgr.WriteState(state)
+			result.State = state
+			for name, ov := range state.RootModule().OutputValues {
+				result.Outputs[name] = ov.Value
+			}
+		}
+		if applyDiags.HasErrors() {
+			return nil, diags
+		}
+		fmt.Fprintf(out, "apply complete: %d outputs\n", len(result.Outputs))
+	}
+
+	diags = diags.Append(scenario.CheckAsserts(step, result, results, e.run))
+	if step.Snapshot != "" {
+		diags = diags.Append(scenario.CheckSnapshot(step, result, filepath.Dir(scenario.SourceFile), e.updateSnapshots))
+	}
+	if len(step.Artifacts) != 0 {
+		collected, artifactDiags := scenario.CollectArtifacts(step, filepath.Dir(scenario.SourceFile), e.artifactsDir)
+		diags = diags.Append(artifactDiags)
+		result.Artifacts = collected
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return result, diags
+}
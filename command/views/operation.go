@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/command/arguments"
 	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/states/statefile"
@@ -26,6 +28,13 @@ type Operation interface {
 	Plan(plan *plans.Plan, baseState *states.State, schemas *terraform.Schemas)
 	PlanNextStep(planPath string)
 
+	// PlanAnnotations provides remarks that integrations attached to
+	// planned changes, keyed by resource instance address, for a
+	// subsequent Plan call to render under the corresponding diffs.
+	PlanAnnotations(annotations map[string][]integrations.Annotation)
+
+	IntegrationMetrics(summaries []integrations.MetricsSummary)
+
 	Diagnostics(diags tfdiags.Diagnostics)
 }
 
@@ -41,6 +50,11 @@ func NewOperation(vt arguments.ViewType, inAutomation bool, view *View) Operatio
 type OperationHuman struct {
 	View
 
+	// planAnnotations are the integration-provided remarks to render
+	// under the corresponding resource diffs, as provided by the most
+	// recent PlanAnnotations call.
+	planAnnotations map[string][]integrations.Annotation
+
 	// inAutomation indicates that commands are being run by an
 	// automated system rather than directly at a command prompt.
 	//
@@ -99,7 +113,48 @@ func (v *OperationHuman) PlanNoChanges() {
 }
 
 func (v *OperationHuman) Plan(plan *plans.Plan, baseState *states.State, schemas *terraform.Schemas) {
-	renderPlan(plan, baseState, schemas, &v.View)
+	renderPlan(plan, baseState, schemas, &v.View, v.planAnnotations)
+}
+
+func (v *OperationHuman) PlanAnnotations(annotations map[string][]integrations.Annotation) {
+	v.planAnnotations = annotations
+}
+
+// IntegrationMetrics summarizes how much time the operation spent waiting on
+// each configured integration, so that users can tell when an integration is
+// slowing their runs down.
+func (v *OperationHuman) IntegrationMetrics(summaries []integrations.MetricsSummary) {
+	any := false
+	for _, s := range summaries {
+		if s.Calls > 0 {
+			any = true
+			break
+		}
+	}
+	if !any {
+		return
+	}
+
+	v.streams.Println("\nIntegration summary:")
+	for _, s := range summaries {
+		if s.Calls == 0 {
+			continue
+		}
+		line := fmt.Sprintf(
+			"- %s: %d calls in %s (avg %s, max %s)",
+			s.Integration, s.Calls,
+			s.TotalTime.Round(time.Millisecond),
+			s.AvgTime.Round(time.Millisecond),
+			s.MaxTime.Round(time.Millisecond),
+		)
+		if s.Failures > 0 {
+			line += fmt.Sprintf(", %d failed", s.Failures)
+		}
+		if s.Timeouts > 0 {
+			line += fmt.Sprintf(", %d timed out", s.Timeouts)
+		}
+		v.streams.Println(line)
+	}
 }
 
 // PlanNextStep gives the user some next-steps, unless we're running in an
@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/command/arguments"
 	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/terraform"
@@ -58,8 +59,10 @@ func (v *PlanHuman) Hooks() []terraform.Hook {
 }
 
 // The plan renderer is used by the Operation view (for plan and apply
-// commands) and the Show view (for the show command).
-func renderPlan(plan *plans.Plan, baseState *states.State, schemas *terraform.Schemas, view *View) {
+// commands) and the Show view (for the show command). annotations may be
+// nil; when it isn't, each resource diff is followed by the integration
+// remarks recorded against its address.
+func renderPlan(plan *plans.Plan, baseState *states.State, schemas *terraform.Schemas, view *View, annotations map[string][]integrations.Annotation) {
 	counts := map[plans.Action]int{}
 	var rChanges []*plans.ResourceInstanceChangeSrc
 	for _, change := range plan.Changes.Resources {
@@ -144,6 +147,10 @@ func renderPlan(plan *plans.Plan, baseState *states.State, schemas *terraform.Sc
 			rSchema,
 			view.colorize,
 		))
+
+		if anns := annotations[rcs.Addr.String()]; len(anns) > 0 {
+			renderPlanAnnotations(view, anns)
+		}
 	}
 
 	// stats is similar to counts above, but:
@@ -184,6 +191,30 @@ func renderPlan(plan *plans.Plan, baseState *states.State, schemas *terraform.Sc
 	}
 }
 
+// renderPlanAnnotations prints the integration remarks recorded against a
+// single resource instance, immediately below that instance's rendered diff.
+func renderPlanAnnotations(view *View, anns []integrations.Annotation) {
+	for _, ann := range anns {
+		var color string
+		switch ann.Severity {
+		case "error":
+			color = "[red]"
+		case "warning":
+			color = "[yellow]"
+		default:
+			color = ""
+		}
+		view.streams.Println(view.colorize.Color(fmt.Sprintf(
+			"    # %s[%s] %s[reset]",
+			color, ann.Integration, ann.Message,
+		)))
+		if ann.URL != "" {
+			view.streams.Printf("    #   See: %s\n", ann.URL)
+		}
+	}
+	view.streams.Println("")
+}
+
 const planHeaderIntro = `
 Terraform used the selected providers to generate the following execution plan. Resource actions are indicated with the following symbols:
 `
@@ -0,0 +1,388 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/command/arguments"
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/repl"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Runbook is the view interface for runbook execution output.
+type Runbook interface {
+	// Begin is called once, before the first step runs.
+	Begin(runbook string, stepCount int)
+
+	// StepBegin and StepComplete bracket the execution of one step.
+	StepBegin(runbook string, step *runbooks.Step)
+	StepComplete(runbook string, step *runbooks.Step, stepErr error)
+
+	// ActionPlanned reports the planned action data for a plan-only or
+	// dry-run step, and ActionResult the result of a real invocation.
+	ActionPlanned(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error)
+	ActionResult(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error)
+
+	// Outputs renders the end-of-run summary of the outputs the run
+	// produced — each step's outputs and then the runbook-level ones, as
+	// collected by runbooks.Runbook.AllOutputs — once every step has
+	// finished.
+	Outputs(outputs []*runbooks.Output)
+
+	Diagnostics(diags tfdiags.Diagnostics)
+}
+
+// NewRunbook returns an initialized Runbook implementation for the given
+// ViewType. The compact flag selects an abbreviated human view that reports
+// one line per step, for long runbooks driven from scripts or CI logs.
+func NewRunbook(vt arguments.ViewType, compact bool, view *View) Runbook {
+	switch vt {
+	case arguments.ViewJSON:
+		return &RunbookJSON{View: *view}
+	case arguments.ViewHuman:
+		if compact {
+			return &RunbookCompact{View: *view}
+		}
+		return &RunbookHuman{View: *view}
+	default:
+		panic(fmt.Sprintf("unknown view type %v", vt))
+	}
+}
+
+// RunbookViewHook adapts a Runbook view to the runbooks.Hook interface, so
+// an engine can drive the view directly as it executes.
+type RunbookViewHook struct {
+	runbooks.NilHook
+	View Runbook
+}
+
+var _ runbooks.Hook = (*RunbookViewHook)(nil)
+
+func (h *RunbookViewHook) PreRunbookStep(runbook string, step *runbooks.Step) error {
+	h.View.StepBegin(runbook, step)
+	return nil
+}
+
+func (h *RunbookViewHook) PostRunbookStep(runbook string, step *runbooks.Step, stepErr error) {
+	h.View.StepComplete(runbook, step, stepErr)
+}
+
+func (h *RunbookViewHook) PostInvokeAction(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error) {
+	h.View.ActionResult(runbook, step, actionType, result, invokeErr)
+}
+
+func (h *RunbookViewHook) PostPlanAction(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error) {
+	h.View.ActionPlanned(runbook, step, actionType, planned, planErr)
+}
+
+// stepDisplayName returns the name to show for a step, including the
+// instance key for steps expanded from for_each.
+func stepDisplayName(step *runbooks.Step) string {
+	if step.EachKey != "" {
+		return fmt.Sprintf("%s[%q]", step.Name, step.EachKey)
+	}
+	return step.Name
+}
+
+// The RunbookHuman implementation renders step-by-step progress in a
+// colorized, human-readable form, in the same register as plan and apply
+// progress output.
+type RunbookHuman struct {
+	View
+
+	// OutputFormat, when set, additionally renders each output whose
+	// value is a collection of objects — a fleet inventory, say — in
+	// full after the summary, as "table", "csv", or "json" (see
+	// runbooks.WriteTabular). The summary's single compacted VALUE cell
+	// is unusable for such outputs.
+	OutputFormat string
+}
+
+var _ Runbook = (*RunbookHuman)(nil)
+
+func (v *RunbookHuman) Begin(runbook string, stepCount int) {
+	v.streams.Println(v.colorize.Color(fmt.Sprintf(
+		"[bold]Running runbook %q[reset] (%d steps)", runbook, stepCount,
+	)))
+}
+
+func (v *RunbookHuman) StepBegin(runbook string, step *runbooks.Step) {
+	msg := fmt.Sprintf("[bold]%s:[reset] starting", stepDisplayName(step))
+	if step.Description != "" {
+		msg = fmt.Sprintf("[bold]%s:[reset] %s", stepDisplayName(step), step.Description)
+	}
+	v.streams.Println(v.colorize.Color(msg))
+}
+
+func (v *RunbookHuman) StepComplete(runbook string, step *runbooks.Step, stepErr error) {
+	if stepErr != nil {
+		v.streams.Eprintln(v.colorize.Color(fmt.Sprintf(
+			"[red]%s: failed:[reset] %s", stepDisplayName(step), stepErr,
+		)))
+		return
+	}
+	v.streams.Println(v.colorize.Color(fmt.Sprintf(
+		"[green]%s: complete[reset]", stepDisplayName(step),
+	)))
+}
+
+func (v *RunbookHuman) ActionPlanned(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error) {
+	if planErr != nil {
+		v.streams.Eprintln(v.colorize.Color(fmt.Sprintf(
+			"[red]%s: failed to plan %s:[reset] %s", stepDisplayName(step), actionType, planErr,
+		)))
+		return
+	}
+	v.streams.Println(v.colorize.Color(fmt.Sprintf(
+		"[bold]%s:[reset] would invoke %s with:", stepDisplayName(step), actionType,
+	)))
+	if planned != cty.NilVal {
+		v.streams.Println(repl.FormatValue(planned, 2))
+	}
+}
+
+func (v *RunbookHuman) ActionResult(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error) {
+	if invokeErr != nil {
+		v.streams.Eprintln(v.colorize.Color(fmt.Sprintf(
+			"[red]%s: %s failed:[reset] %s", stepDisplayName(step), actionType, invokeErr,
+		)))
+		return
+	}
+	v.streams.Println(v.colorize.Color(fmt.Sprintf(
+		"[bold]%s:[reset] invoked %s", stepDisplayName(step), actionType,
+	)))
+}
+
+func (v *RunbookHuman) Outputs(outputs []*runbooks.Output) {
+	if len(outputs) == 0 {
+		return
+	}
+	v.streams.Println(v.colorize.Color("\n[bold][green]Outputs:[reset]\n"))
+
+	// A summary table, so the key findings of a long run are in one
+	// place after the step-by-step progress has scrolled away.
+	rows := make([][4]string, 0, len(outputs))
+	stepWidth, nameWidth, valueWidth := len("STEP"), len("OUTPUT"), len("VALUE")
+	for _, output := range outputs {
+		row := [4]string{output.Step, output.Name, compactValueStr(output.Value), output.Description}
+		if len(row[0]) > stepWidth {
+			stepWidth = len(row[0])
+		}
+		if len(row[1]) > nameWidth {
+			nameWidth = len(row[1])
+		}
+		if len(row[2]) > valueWidth {
+			valueWidth = len(row[2])
+		}
+		rows = append(rows, row)
+	}
+
+	v.streams.Println(fmt.Sprintf("%-*s  %-*s  %-*s  %s", stepWidth, "STEP", nameWidth, "OUTPUT", valueWidth, "VALUE", "DESCRIPTION"))
+	for _, row := range rows {
+		v.streams.Println(strings.TrimRight(fmt.Sprintf("%-*s  %-*s  %-*s  %s", stepWidth, row[0], nameWidth, row[1], valueWidth, row[2], row[3]), " "))
+	}
+
+	if v.OutputFormat == "" {
+		return
+	}
+	for _, output := range outputs {
+		if _, _, ok := runbooks.TabularRows(output.Value); !ok {
+			continue
+		}
+		var buf strings.Builder
+		if err := runbooks.WriteTabular(&buf, output.Value, v.OutputFormat); err != nil {
+			v.streams.Eprintln(fmt.Sprintf("Failed to render output %q as %s: %s", output.Name, v.OutputFormat, err))
+			continue
+		}
+		v.streams.Println(v.colorize.Color(fmt.Sprintf("\n[bold]%s:[reset]", output.Name)))
+		v.streams.Print(buf.String())
+	}
+}
+
+// The RunbookCompact implementation reports one line per step and omits
+// action detail and output values, for runbooks long enough that the full
+// human view becomes noise.
+type RunbookCompact struct {
+	View
+}
+
+var _ Runbook = (*RunbookCompact)(nil)
+
+func (v *RunbookCompact) Begin(runbook string, stepCount int) {
+	v.streams.Println(fmt.Sprintf("%s: %d steps", runbook, stepCount))
+}
+
+func (v *RunbookCompact) StepBegin(runbook string, step *runbooks.Step) {
+}
+
+func (v *RunbookCompact) StepComplete(runbook string, step *runbooks.Step, stepErr error) {
+	if stepErr != nil {
+		v.streams.Eprintln(v.colorize.Color(fmt.Sprintf(
+			"%s: [red]failed[reset]: %s", stepDisplayName(step), stepErr,
+		)))
+		return
+	}
+	v.streams.Println(v.colorize.Color(fmt.Sprintf(
+		"%s: [green]ok[reset]", stepDisplayName(step),
+	)))
+}
+
+func (v *RunbookCompact) ActionPlanned(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error) {
+}
+
+func (v *RunbookCompact) ActionResult(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error) {
+}
+
+func (v *RunbookCompact) Outputs(outputs []*runbooks.Output) {
+	if len(outputs) == 0 {
+		return
+	}
+	v.streams.Println(fmt.Sprintf("outputs: %d", len(outputs)))
+}
+
+// The RunbookJSON implementation renders one JSON object per line for each
+// event, for consumption by software wrapping a runbook run.
+type RunbookJSON struct {
+	View
+
+	// ActionLogPath is the path of the per-run action event log, when the
+	// run is writing one (see runbooks.ActionEventLog). The begin event
+	// carries it so that report consumers can find the full action
+	// progression without scraping the terminal stream.
+	ActionLogPath string
+}
+
+var _ Runbook = (*RunbookJSON)(nil)
+
+func (v *RunbookJSON) Begin(runbook string, stepCount int) {
+	msg := map[string]interface{}{
+		"type":       "begin",
+		"runbook":    runbook,
+		"step_count": stepCount,
+	}
+	if v.ActionLogPath != "" {
+		msg["action_log"] = v.ActionLogPath
+	}
+	v.logLine(msg)
+}
+
+func (v *RunbookJSON) StepBegin(runbook string, step *runbooks.Step) {
+	v.logLine(map[string]interface{}{
+		"type":        "step_begin",
+		"runbook":     runbook,
+		"step":        step.Name,
+		"each_key":    step.EachKey,
+		"description": step.Description,
+	})
+}
+
+func (v *RunbookJSON) StepComplete(runbook string, step *runbooks.Step, stepErr error) {
+	msg := map[string]interface{}{
+		"type":     "step_complete",
+		"runbook":  runbook,
+		"step":     step.Name,
+		"each_key": step.EachKey,
+	}
+	if stepErr != nil {
+		msg["error"] = stepErr.Error()
+	}
+	v.logLine(msg)
+}
+
+func (v *RunbookJSON) ActionPlanned(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error) {
+	msg := map[string]interface{}{
+		"type":        "action_planned",
+		"runbook":     runbook,
+		"step":        step.Name,
+		"each_key":    step.EachKey,
+		"action_type": actionType,
+	}
+	if planErr != nil {
+		msg["error"] = planErr.Error()
+	} else {
+		msg["planned"] = jsonValue(planned)
+	}
+	v.logLine(msg)
+}
+
+func (v *RunbookJSON) ActionResult(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error) {
+	msg := map[string]interface{}{
+		"type":        "action_result",
+		"runbook":     runbook,
+		"step":        step.Name,
+		"each_key":    step.EachKey,
+		"action_type": actionType,
+	}
+	if invokeErr != nil {
+		msg["error"] = invokeErr.Error()
+	} else {
+		msg["result"] = jsonValue(result)
+	}
+	v.logLine(msg)
+}
+
+func (v *RunbookJSON) Outputs(outputs []*runbooks.Output) {
+	entries := make([]map[string]interface{}, 0, len(outputs))
+	for _, output := range outputs {
+		entries = append(entries, map[string]interface{}{
+			"step":        output.Step,
+			"name":        output.Name,
+			"value":       jsonValue(output.Value),
+			"description": output.Description,
+		})
+	}
+	v.logLine(map[string]interface{}{
+		"type":    "outputs",
+		"outputs": entries,
+	})
+}
+
+func (v *RunbookJSON) Diagnostics(diags tfdiags.Diagnostics) {
+	for _, diag := range diags {
+		severity := "error"
+		if diag.Severity() == tfdiags.Warning {
+			severity = "warning"
+		}
+		desc := diag.Description()
+		v.logLine(map[string]interface{}{
+			"type":     "diagnostic",
+			"severity": severity,
+			"summary":  desc.Summary,
+			"detail":   desc.Detail,
+		})
+	}
+}
+
+func (v *RunbookJSON) logLine(msg map[string]interface{}) {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		// Should never happen, since we control all the values above.
+		v.streams.Eprintln(fmt.Sprintf(`{"type":"error","error":%q}`, err.Error()))
+		return
+	}
+	v.streams.Println(string(buf))
+}
+
+// jsonValue encodes a cty value as JSON, rendering the unrepresentable
+// cases (no value at all, or a not-yet-known value) as null.
+func jsonValue(val cty.Value) json.RawMessage {
+	if val == cty.NilVal || !val.IsWhollyKnown() {
+		return json.RawMessage("null")
+	}
+	buf, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(buf)
+}
+
+// compactValueStr renders a value on a single line for the outputs
+// summary table, collapsing the whitespace of multi-line renderings.
+func compactValueStr(val cty.Value) string {
+	return strings.Join(strings.Fields(repl.FormatValue(val, 0)), " ")
+}
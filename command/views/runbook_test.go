@@ -0,0 +1,187 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/command/arguments"
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/internal/terminal"
+)
+
+func testRunbookSteps(view Runbook) {
+	stepA := &runbooks.Step{Name: "create_ticket", Description: "Open a change ticket"}
+	stepB := &runbooks.Step{Name: "page_oncall", EachKey: "platform"}
+
+	view.Begin("incident_response", 2)
+
+	view.StepBegin("incident_response", stepA)
+	view.ActionResult("incident_response", stepA, "http", cty.ObjectVal(map[string]cty.Value{
+		"status": cty.NumberIntVal(201),
+	}), nil)
+	view.StepComplete("incident_response", stepA, nil)
+
+	view.StepBegin("incident_response", stepB)
+	view.StepComplete("incident_response", stepB, errors.New("pager provider unreachable"))
+
+	view.Outputs([]*runbooks.Output{
+		{
+			Step:        "create_ticket",
+			Name:        "ticket_id",
+			Value:       cty.StringVal("CHG-1234"),
+			Description: "Change ticket for the restart",
+		},
+		{
+			Name:  "restarted",
+			Value: cty.True,
+		},
+	})
+}
+
+func TestRunbookHuman(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	view := NewRunbook(arguments.ViewHuman, false, NewView(streams))
+
+	testRunbookSteps(view)
+
+	output := done(t)
+	stdout := output.Stdout()
+	for _, want := range []string{
+		`Running runbook "incident_response" (2 steps)`,
+		"create_ticket: Open a change ticket",
+		"create_ticket: invoked http",
+		"create_ticket: complete",
+		"STEP",
+		"DESCRIPTION",
+		"Change ticket for the restart",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in stdout:\n%s", want, stdout)
+		}
+	}
+	stderr := output.Stderr()
+	if want := `page_oncall["platform"]: failed: pager provider unreachable`; !strings.Contains(stderr, want) {
+		t.Errorf("missing %q in stderr:\n%s", want, stderr)
+	}
+}
+
+func TestRunbookHumanOutputFormat(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	view := NewRunbook(arguments.ViewHuman, false, NewView(streams))
+	view.(*RunbookHuman).OutputFormat = "csv"
+
+	view.Outputs([]*runbooks.Output{
+		{
+			Name: "fleet",
+			Value: cty.TupleVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("i-abc123"),
+					"zone": cty.StringVal("us-east-1a"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("i-def456"),
+					"zone": cty.StringVal("us-east-1b"),
+				}),
+			}),
+		},
+		{
+			Name:  "restarted",
+			Value: cty.True,
+		},
+	})
+
+	output := done(t)
+	stdout := output.Stdout()
+	for _, want := range []string{
+		"fleet:",
+		"id,zone",
+		"i-abc123,us-east-1a",
+		"i-def456,us-east-1b",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in stdout:\n%s", want, stdout)
+		}
+	}
+	// The scalar output has no tabular shape, so only the summary table
+	// mentions it.
+	if strings.Contains(stdout, "restarted:") {
+		t.Errorf("scalar output should not get its own rendering:\n%s", stdout)
+	}
+}
+
+func TestRunbookCompact(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	view := NewRunbook(arguments.ViewHuman, true, NewView(streams))
+
+	testRunbookSteps(view)
+
+	output := done(t)
+	stdout := output.Stdout()
+	for _, want := range []string{
+		"incident_response: 2 steps",
+		"create_ticket: ok",
+		"outputs: 2",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in stdout:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "invoked http") {
+		t.Errorf("compact view should not include action detail:\n%s", stdout)
+	}
+	if want := "page_oncall[\"platform\"]: failed"; !strings.Contains(output.Stderr(), want) {
+		t.Errorf("missing %q in stderr:\n%s", want, output.Stderr())
+	}
+}
+
+func TestRunbookJSON(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	view := NewRunbook(arguments.ViewJSON, false, NewView(streams))
+	view.(*RunbookJSON).ActionLogPath = ".terraform/actions.log"
+
+	testRunbookSteps(view)
+
+	output := done(t)
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(output.Stdout()), "\n") {
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line is not valid JSON: %s\n%s", err, line)
+		}
+		types = append(types, msg["type"].(string))
+
+		switch msg["type"] {
+		case "begin":
+			if msg["action_log"] != ".terraform/actions.log" {
+				t.Errorf("wrong action log path %#v", msg["action_log"])
+			}
+		case "action_result":
+			result := msg["result"].(map[string]interface{})
+			if result["status"] != float64(201) {
+				t.Errorf("wrong action result %#v", msg["result"])
+			}
+		case "step_complete":
+			if msg["step"] == "page_oncall" && msg["error"] != "pager provider unreachable" {
+				t.Errorf("wrong error for failed step: %#v", msg["error"])
+			}
+		case "outputs":
+			outputs := msg["outputs"].([]interface{})
+			if len(outputs) != 2 {
+				t.Fatalf("wrong output count %d", len(outputs))
+			}
+			first := outputs[0].(map[string]interface{})
+			if first["step"] != "create_ticket" || first["name"] != "ticket_id" || first["value"] != "CHG-1234" {
+				t.Errorf("wrong first output %#v", first)
+			}
+		}
+	}
+
+	want := []string{"begin", "step_begin", "action_result", "step_complete", "step_begin", "step_complete", "outputs"}
+	if strings.Join(types, " ") != strings.Join(want, " ") {
+		t.Errorf("wrong event sequence\ngot:  %v\nwant: %v", types, want)
+	}
+}
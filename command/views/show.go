@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/terraform/command/arguments"
+	"github.com/hashicorp/terraform/internal/integrations"
 	"github.com/hashicorp/terraform/plans"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/terraform"
@@ -14,6 +15,12 @@ import (
 // views package.
 type Show interface {
 	Plan(plan *plans.Plan, baseState *states.State, schemas *terraform.Schemas)
+
+	// PlanAnnotations provides remarks that integrations attached to
+	// planned changes when the plan was created, keyed by resource
+	// instance address, for a subsequent Plan call to render under the
+	// corresponding diffs.
+	PlanAnnotations(annotations map[string][]integrations.Annotation)
 }
 
 // FIXME: the show view should support both human and JSON types. This code is
@@ -30,10 +37,18 @@ func NewShow(vt arguments.ViewType, view *View) Show {
 
 type ShowHuman struct {
 	View
+
+	// planAnnotations are the integration-provided remarks recorded in
+	// the plan file, as provided by the most recent PlanAnnotations call.
+	planAnnotations map[string][]integrations.Annotation
 }
 
 var _ Show = (*ShowHuman)(nil)
 
 func (v *ShowHuman) Plan(plan *plans.Plan, baseState *states.State, schemas *terraform.Schemas) {
-	renderPlan(plan, baseState, schemas, &v.View)
+	renderPlan(plan, baseState, schemas, &v.View, v.planAnnotations)
+}
+
+func (v *ShowHuman) PlanAnnotations(annotations map[string][]integrations.Annotation) {
+	v.planAnnotations = annotations
 }
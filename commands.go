@@ -101,6 +101,8 @@ func initCommands(
 		ProviderSource:       providerSrc,
 		ProviderDevOverrides: providerDevOverrides,
 		UnmanagedProviders:   unmanagedProviders,
+
+		RunbookVerification: config.RunbookVerificationConfig(),
 	}
 
 	// The command list is included in the terraform -help
@@ -194,6 +196,18 @@ func initCommands(
 			}, nil
 		},
 
+		"integration check": func() (cli.Command, error) {
+			return &command.IntegrationCheckCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"integration replay": func() (cli.Command, error) {
+			return &command.IntegrationReplayCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"login": func() (cli.Command, error) {
 			return &command.LoginCommand{
 				Meta: meta,
@@ -254,6 +268,24 @@ func initCommands(
 			}, nil
 		},
 
+		"runbook": func() (cli.Command, error) {
+			return &command.RunbookCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"runbook init": func() (cli.Command, error) {
+			return &command.RunbookInitCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"runbook lint": func() (cli.Command, error) {
+			return &command.RunbookLintCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"show": func() (cli.Command, error) {
 			return &command.ShowCommand{
 				Meta: meta,
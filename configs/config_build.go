@@ -24,6 +24,7 @@ func BuildConfig(root *Module, walker ModuleWalker) (*Config, hcl.Diagnostics) {
 	cfg.Children, diags = buildChildModules(cfg, walker)
 
 	diags = append(diags, validateProviderConfigs(nil, cfg, false)...)
+	diags = append(diags, validateIntegrationRequirements(cfg)...)
 
 	return cfg, diags
 }
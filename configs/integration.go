@@ -0,0 +1,500 @@
+package configs
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Integration represents an "integration" block in a module.
+//
+// An integration is an external program that Terraform launches for the
+// duration of an operation and notifies about lifecycle events, such as
+// planned resource changes, over a JSON-RPC protocol on the program's
+// stdin/stdout. Integrations can observe an operation and, for some events,
+// veto it.
+type Integration struct {
+	Name    string
+	Command []string
+
+	// Source optionally gives a registry-style address for the
+	// integration, in "namespace/name" form, as an alternative to running
+	// a local command directly. Terraform selects among the installed
+	// versions of a source-addressed integration using Version.
+	Source string
+
+	// Version is the version constraint for a source-addressed
+	// integration. It may be set only together with Source.
+	Version VersionConstraint
+
+	// Concurrency is the maximum number of requests Terraform will have
+	// in flight against the integration at once. Zero means a default
+	// chosen by the integrations runtime.
+	Concurrency int
+
+	// MaxPayloadBytes bounds the encoded size of a single hook call's
+	// parameters. Payloads over the limit have their bulky parts (such as
+	// resource before/after values) truncated, or are spilled to a file
+	// for integrations that negotiate that at initialize. Zero means a
+	// default chosen by the integrations runtime.
+	MaxPayloadBytes int
+
+	// Order controls the sequence in which hook events are delivered when
+	// several integrations are configured: lower values are notified
+	// first, and a halt from an earlier integration prevents delivery to
+	// later ones. Integrations with equal order run in name order.
+	Order int
+
+	// Env gives environment variables to set for the integration process.
+	// The process does not inherit Terraform's own environment (aside from
+	// a small baseline needed to run programs at all), so any variable the
+	// integration needs must be listed here explicitly.
+	Env map[string]string
+
+	// Workdir is the working directory to run the integration in. If
+	// empty, the integration runs in Terraform's own working directory.
+	Workdir string
+
+	// DrainTimeoutSeconds is how long Terraform waits at shutdown for
+	// in-flight requests to drain and for the integration process to exit
+	// before killing it. Zero means a default chosen by the integrations
+	// runtime.
+	DrainTimeoutSeconds int
+
+	// CacheResults, when true, lets Terraform reuse an earlier hook result
+	// from the same run when an identical change for the same resource
+	// comes up again, instead of calling the integration a second time.
+	// Only appropriate for integrations whose responses depend solely on
+	// the payload they're given.
+	CacheResults bool
+
+	// DisabledInTests, when true, keeps Terraform from starting the
+	// integration during "terraform test" runs, for integrations that only
+	// make sense against real infrastructure.
+	DisabledInTests bool
+
+	// EnforceAtApply, when true, makes applying a saved plan an error
+	// rather than a warning if this integration is missing, or is a
+	// different version or executable, on the machine running the apply.
+	// This prevents bypassing the integration's plan-time policy by
+	// applying the plan somewhere the integration isn't installed.
+	EnforceAtApply bool
+
+	// Stderr chooses what happens to the integration's stderr output:
+	// "log" (the default) writes it to Terraform's logs, "ui" forwards it
+	// to the user interface prefixed with the integration name, and
+	// "discard" throws it away.
+	Stderr string
+
+	// Sandbox, if non-nil, gives restrictions to apply to the integration
+	// process when launching it.
+	Sandbox *IntegrationSandbox
+
+	DeclRange hcl.Range
+}
+
+// IntegrationSandbox represents a "sandbox" block inside an integration
+// block, describing restrictions to place on the integration process.
+//
+// Sandbox settings are fail-closed: if Terraform can't enforce a requested
+// restriction on the current platform then the integration doesn't start at
+// all, rather than silently running unrestricted.
+type IntegrationSandbox struct {
+	// DisableNetwork requests that the process run without network access,
+	// which is enforced with a network namespace where the platform
+	// supports that.
+	DisableNetwork bool
+
+	// ReadOnlyFilesystem requests that the process be unable to modify the
+	// filesystem.
+	ReadOnlyFilesystem bool
+
+	// MemoryLimitMB bounds the process's address space, in megabytes.
+	// Zero means no limit.
+	MemoryLimitMB int
+
+	// CPUTimeLimitSeconds bounds the process's total CPU time, in seconds.
+	// Zero means no limit.
+	CPUTimeLimitSeconds int
+
+	DeclRange hcl.Range
+}
+
+func decodeIntegrationBlock(block *hcl.Block) (*Integration, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	i := &Integration{
+		Name:      block.Labels[0],
+		DeclRange: block.DefRange,
+	}
+
+	if !hclsyntax.ValidIdentifier(i.Name) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid integration name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+
+	content, moreDiags := block.Body.Content(integrationBlockSchema)
+	diags = append(diags, moreDiags...)
+
+	if attr, exists := content.Attributes["command"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Command)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && len(i.Command) == 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid integration command",
+				Detail:   "The \"command\" argument must contain at least one element, giving the name of the program to run.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["source"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Source)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && !validIntegrationSource(i.Source) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid integration source",
+				Detail:   "The \"source\" argument must be a registry-style address of the form \"namespace/name\".",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["version"]; exists {
+		ver, verDiags := decodeVersionConstraint(attr)
+		diags = append(diags, verDiags...)
+		i.Version = ver
+
+		if i.Source == "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid version constraint",
+				Detail:   "The \"version\" argument may be set only for integrations that have a \"source\" address.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	switch {
+	case len(i.Command) != 0 && i.Source != "":
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Conflicting integration arguments",
+			Detail:   "The \"command\" and \"source\" arguments may not both be set; use \"command\" for a local program or \"source\" for an installed integration.",
+			Subject:  &i.DeclRange,
+		})
+	case len(i.Command) == 0 && i.Source == "" && !diags.HasErrors():
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing integration program",
+			Detail:   "An integration block must set either \"command\", giving a program to run, or \"source\", giving the address of an installed integration.",
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	if attr, exists := content.Attributes["order"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Order)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["concurrency"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Concurrency)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && i.Concurrency < 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid integration concurrency",
+				Detail:   "The \"concurrency\" argument must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["max_payload_bytes"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.MaxPayloadBytes)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && i.MaxPayloadBytes < 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid payload size limit",
+				Detail:   "The \"max_payload_bytes\" argument must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["env"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Env)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["workdir"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Workdir)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["drain_timeout_seconds"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.DrainTimeoutSeconds)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && i.DrainTimeoutSeconds < 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid drain timeout",
+				Detail:   "The \"drain_timeout_seconds\" argument must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["cache_results"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.CacheResults)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["disabled_in_tests"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.DisabledInTests)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["enforce_at_apply"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.EnforceAtApply)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["stderr"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &i.Stderr)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() {
+			switch i.Stderr {
+			case "log", "ui", "discard":
+				// ok
+			default:
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid stderr mode",
+					Detail:   "The \"stderr\" argument must be one of \"log\", \"ui\", or \"discard\".",
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			}
+		}
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "sandbox":
+			if i.Sandbox != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate sandbox block",
+					Detail:   "An integration block may have only one sandbox block.",
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			sandbox, moreDiags := decodeIntegrationSandboxBlock(block)
+			diags = append(diags, moreDiags...)
+			i.Sandbox = sandbox
+		}
+	}
+
+	return i, diags
+}
+
+// validIntegrationSource checks that a source address has the expected
+// "namespace/name" shape, with both parts being usable identifiers.
+func validIntegrationSource(source string) bool {
+	parts := strings.Split(source, "/")
+	if len(parts) != 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !hclsyntax.ValidIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeIntegrationSandboxBlock(block *hcl.Block) (*IntegrationSandbox, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	sb := &IntegrationSandbox{
+		DeclRange: block.DefRange,
+	}
+
+	content, moreDiags := block.Body.Content(integrationSandboxBlockSchema)
+	diags = append(diags, moreDiags...)
+
+	if attr, exists := content.Attributes["network"]; exists {
+		network := true
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &network)
+		diags = append(diags, valDiags...)
+		sb.DisableNetwork = !network
+	}
+
+	if attr, exists := content.Attributes["readonly_filesystem"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sb.ReadOnlyFilesystem)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, exists := content.Attributes["memory_limit_mb"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sb.MemoryLimitMB)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && sb.MemoryLimitMB < 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid memory limit",
+				Detail:   "The \"memory_limit_mb\" argument may not be negative.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["cpu_time_limit_seconds"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sb.CPUTimeLimitSeconds)
+		diags = append(diags, valDiags...)
+
+		if !valDiags.HasErrors() && sb.CPUTimeLimitSeconds < 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid CPU time limit",
+				Detail:   "The \"cpu_time_limit_seconds\" argument may not be negative.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	return sb, diags
+}
+
+// merge overwrites the reciever's fields with any fields set in the given
+// override, in the usual way for our "override file" behavior.
+func (i *Integration) merge(oi *Integration) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if len(oi.Command) != 0 {
+		i.Command = oi.Command
+	}
+	if oi.Source != "" {
+		i.Source = oi.Source
+	}
+	if oi.Version.Required != nil {
+		i.Version = oi.Version
+	}
+	if oi.Concurrency != 0 {
+		i.Concurrency = oi.Concurrency
+	}
+	if oi.MaxPayloadBytes != 0 {
+		i.MaxPayloadBytes = oi.MaxPayloadBytes
+	}
+	if oi.Order != 0 {
+		i.Order = oi.Order
+	}
+	if len(oi.Env) != 0 {
+		i.Env = oi.Env
+	}
+	if oi.Workdir != "" {
+		i.Workdir = oi.Workdir
+	}
+	if oi.DrainTimeoutSeconds != 0 {
+		i.DrainTimeoutSeconds = oi.DrainTimeoutSeconds
+	}
+	if oi.CacheResults {
+		i.CacheResults = oi.CacheResults
+	}
+	if oi.DisabledInTests {
+		i.DisabledInTests = oi.DisabledInTests
+	}
+	if oi.EnforceAtApply {
+		i.EnforceAtApply = oi.EnforceAtApply
+	}
+	if oi.Stderr != "" {
+		i.Stderr = oi.Stderr
+	}
+	if oi.Sandbox != nil {
+		i.Sandbox = oi.Sandbox
+	}
+
+	return diags
+}
+
+var integrationBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "command",
+		},
+		{
+			Name: "source",
+		},
+		{
+			Name: "version",
+		},
+		{
+			Name: "concurrency",
+		},
+		{
+			Name: "max_payload_bytes",
+		},
+		{
+			Name: "order",
+		},
+		{
+			Name: "env",
+		},
+		{
+			Name: "workdir",
+		},
+		{
+			Name: "drain_timeout_seconds",
+		},
+		{
+			Name: "cache_results",
+		},
+		{
+			Name: "disabled_in_tests",
+		},
+		{
+			Name: "enforce_at_apply",
+		},
+		{
+			Name: "stderr",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "sandbox",
+		},
+	},
+}
+
+var integrationSandboxBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "network",
+		},
+		{
+			Name: "readonly_filesystem",
+		},
+		{
+			Name: "memory_limit_mb",
+		},
+		{
+			Name: "cpu_time_limit_seconds",
+		},
+	},
+}
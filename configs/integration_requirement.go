@@ -0,0 +1,122 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// IntegrationRequirement represents one entry in an
+// "integration_requirements" block: a declaration that the module expects a
+// particular integration to be enabled in the root module, optionally
+// within a version constraint.
+//
+// Integrations run for a whole operation and are configured only in the
+// root module, so a module written to operate under a particular governance
+// integration has no way to enable that integration itself. Declaring a
+// requirement instead makes using the module without the integration a
+// configuration error, rather than a silent policy gap.
+type IntegrationRequirement struct {
+	Name string
+
+	// Requirement is the version constraint the root module's integration
+	// must satisfy. An empty constraint accepts any version, which is also
+	// the only option for integrations that run a local command and so
+	// have no version at all.
+	Requirement VersionConstraint
+
+	DeclRange hcl.Range
+}
+
+// decodeIntegrationRequirementsBlock decodes an "integration_requirements"
+// block, in which each attribute names a required integration and gives its
+// version constraint as a string. An empty string requires only that the
+// integration be enabled, regardless of version.
+func decodeIntegrationRequirementsBlock(block *hcl.Block) ([]*IntegrationRequirement, hcl.Diagnostics) {
+	attrs, diags := block.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var reqs []*IntegrationRequirement
+	for name, attr := range attrs {
+		if !hclsyntax.ValidIdentifier(name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid integration name",
+				Detail:   badIdentifierDetail,
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+
+		req := &IntegrationRequirement{
+			Name:      name,
+			DeclRange: attr.Range,
+		}
+
+		if val, err := attr.Expr.Value(nil); err == nil && val.Type() == cty.String && !val.IsNull() && val.AsString() == "" {
+			// An empty constraint requires only that the integration be
+			// enabled; there is nothing further to decode.
+		} else {
+			vc, vcDiags := decodeVersionConstraint(attr)
+			diags = append(diags, vcDiags...)
+			req.Requirement = vc
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, diags
+}
+
+// validateIntegrationRequirements walks the configuration tree checking the
+// integration_requirements declared by each module against the integrations
+// enabled in the root module. Requirements anywhere in the tree can be
+// satisfied only by root-level integration blocks, because integrations are
+// configured only at the root.
+//
+// Whether the version of a source-addressed integration actually satisfies
+// a requirement's constraint can't be known until the integration is
+// resolved at the start of an operation, so that part is checked separately
+// by the integrations runtime.
+func validateIntegrationRequirements(cfg *Config) (diags hcl.Diagnostics) {
+	rootIntegrations := cfg.Root.Module.Integrations
+
+	cfg.DeepEach(func(c *Config) {
+		names := make([]string, 0, len(c.Module.IntegrationRequirements))
+		for name := range c.Module.IntegrationRequirements {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			req := c.Module.IntegrationRequirements[name]
+
+			integ, enabled := rootIntegrations[req.Name]
+			if !enabled {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Required integration not enabled",
+					Detail:   fmt.Sprintf("This module may be used only when the integration %q is enabled, but the root module does not enable an integration of that name.", req.Name),
+					Subject:  &req.DeclRange,
+				})
+				continue
+			}
+
+			if req.Requirement.Required != nil && integ.Source == "" {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid integration version requirement",
+					Detail:   fmt.Sprintf("This module requires integration %q version %s, but the root module runs that integration from a local command, which has no version. Version requirements can be satisfied only by source-addressed integrations.", req.Name, req.Requirement.Required.String()),
+					Subject:  &req.DeclRange,
+				})
+			}
+		}
+	})
+
+	return diags
+}
@@ -42,6 +42,9 @@ type Module struct {
 
 	ManagedResources map[string]*Resource
 	DataResources    map[string]*Resource
+
+	Integrations            map[string]*Integration
+	IntegrationRequirements map[string]*IntegrationRequirement
 }
 
 // File describes the contents of a single configuration file.
@@ -73,6 +76,9 @@ type File struct {
 
 	ManagedResources []*Resource
 	DataResources    []*Resource
+
+	Integrations            []*Integration
+	IntegrationRequirements []*IntegrationRequirement
 }
 
 // NewModule takes a list of primary files and a list of override files and
@@ -95,6 +101,9 @@ func NewModule(primaryFiles, overrideFiles []*File) (*Module, hcl.Diagnostics) {
 		ManagedResources:   map[string]*Resource{},
 		DataResources:      map[string]*Resource{},
 		ProviderMetas:      map[addrs.Provider]*ProviderMeta{},
+		Integrations:       map[string]*Integration{},
+
+		IntegrationRequirements: map[string]*IntegrationRequirement{},
 	}
 
 	// Process the required_providers blocks first, to ensure that all
@@ -328,6 +337,30 @@ func (m *Module) appendFile(file *File) hcl.Diagnostics {
 		}
 	}
 
+	for _, i := range file.Integrations {
+		if existing, exists := m.Integrations[i.Name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate integration definition",
+				Detail:   fmt.Sprintf("An integration named %q was already defined at %s. Integration names must be unique within a module.", existing.Name, existing.DeclRange),
+				Subject:  &i.DeclRange,
+			})
+		}
+		m.Integrations[i.Name] = i
+	}
+
+	for _, r := range file.IntegrationRequirements {
+		if existing, exists := m.IntegrationRequirements[r.Name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate integration requirement",
+				Detail:   fmt.Sprintf("A requirement for integration %q was already declared at %s.", existing.Name, existing.DeclRange),
+				Subject:  &r.DeclRange,
+			})
+		}
+		m.IntegrationRequirements[r.Name] = r
+	}
+
 	return diags
 }
 
@@ -482,6 +515,37 @@ func (m *Module) mergeFile(file *File) hcl.Diagnostics {
 		diags = append(diags, mergeDiags...)
 	}
 
+	for _, i := range file.Integrations {
+		existing, exists := m.Integrations[i.Name]
+		if !exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing integration to override",
+				Detail:   fmt.Sprintf("There is no integration named %q. An override file can only override an integration that was defined in a primary configuration file.", i.Name),
+				Subject:  &i.DeclRange,
+			})
+			continue
+		}
+		mergeDiags := existing.merge(i)
+		diags = append(diags, mergeDiags...)
+	}
+
+	for _, r := range file.IntegrationRequirements {
+		existing, exists := m.IntegrationRequirements[r.Name]
+		if !exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing integration requirement to override",
+				Detail:   fmt.Sprintf("There is no requirement for integration %q. An override file can only override a requirement that was declared in a primary configuration file.", r.Name),
+				Subject:  &r.DeclRange,
+			})
+			continue
+		}
+		if r.Requirement.Required != nil {
+			existing.Requirement = r.Requirement
+		}
+	}
+
 	return diags
 }
 
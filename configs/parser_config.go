@@ -148,6 +148,18 @@ func (p *Parser) loadConfigFile(path string, override bool) (*File, hcl.Diagnost
 				file.DataResources = append(file.DataResources, cfg)
 			}
 
+		case "integration":
+			cfg, cfgDiags := decodeIntegrationBlock(block)
+			diags = append(diags, cfgDiags...)
+			if cfg != nil {
+				file.Integrations = append(file.Integrations, cfg)
+			}
+
+		case "integration_requirements":
+			reqs, reqDiags := decodeIntegrationRequirementsBlock(block)
+			diags = append(diags, reqDiags...)
+			file.IntegrationRequirements = append(file.IntegrationRequirements, reqs...)
+
 		default:
 			// Should never happen because the above cases should be exhaustive
 			// for all block type names in our schema.
@@ -235,6 +247,13 @@ var configFileSchema = &hcl.BodySchema{
 			Type:       "data",
 			LabelNames: []string{"type", "name"},
 		},
+		{
+			Type:       "integration",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "integration_requirements",
+		},
 	},
 }
 
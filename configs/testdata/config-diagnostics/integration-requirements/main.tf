@@ -0,0 +1,7 @@
+integration "policy_check" {
+  command = ["./bin/policy-check"]
+}
+
+module "mod" {
+  source = "./mod"
+}
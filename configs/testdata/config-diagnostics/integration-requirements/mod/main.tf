@@ -0,0 +1,7 @@
+# This module requires policy_check at a particular version, but the root
+# module runs it from a local command, which has no version. It also
+# requires audit_log, which the root module doesn't enable at all.
+integration_requirements {
+  policy_check = ">= 1.0.0"
+  audit_log    = ""
+}
@@ -0,0 +1,13 @@
+integration "policy_check" {
+  command = ["./bin/policy-check", "--strict"]
+  order   = 1
+  workdir = "./policy"
+
+  env = {
+    POLICY_ENDPOINT = "https://policy.example.com/"
+  }
+}
+
+integration "notifier" {
+  command = ["notify-team"]
+}
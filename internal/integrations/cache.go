@@ -0,0 +1,58 @@
+package integrations
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// resultCache remembers hook results by a fingerprint of their inputs, so
+// that an integration with cache_results enabled isn't asked the same
+// question twice in one run. In particular, a plan followed by an apply in
+// the same operation produces identical payloads for unchanged resources,
+// and the cached answer covers both.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]HookResult
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{
+		entries: make(map[string]HookResult),
+	}
+}
+
+// fingerprint derives the cache key for a hook call. The run context and
+// trace headers are deliberately excluded: they vary between calls without
+// changing what the integration is being asked about.
+func fingerprint(hook string, change *resourceChange) string {
+	buf, err := json.Marshal(struct {
+		Hook     string      `json:"hook"`
+		Address  string      `json:"address"`
+		Action   string      `json:"action"`
+		Provider string      `json:"provider"`
+		Before   interface{} `json:"before"`
+		After    interface{} `json:"after"`
+	}{hook, change.Address, change.Action, change.Provider, change.Before, change.After})
+	if err != nil {
+		// The payload came from json-marshalable values in the first
+		// place, so this shouldn't happen; an empty key disables caching
+		// for this call.
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(buf))
+}
+
+func (c *resultCache) lookup(key string) (HookResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.entries[key]
+	return result, ok
+}
+
+func (c *resultCache) store(key string, result HookResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = result
+}
@@ -0,0 +1,51 @@
+package integrations
+
+import (
+	"testing"
+)
+
+func TestResultCache(t *testing.T) {
+	cache := newResultCache()
+
+	change := resourceChange{
+		Address: "test_thing.a",
+		Action:  "update",
+		Before:  map[string]interface{}{"v": "old"},
+		After:   map[string]interface{}{"v": "new"},
+	}
+	key := fingerprint(hookPostPlanResource, &change)
+	if key == "" {
+		t.Fatal("fingerprint returned an empty key")
+	}
+
+	if _, ok := cache.lookup(key); ok {
+		t.Fatal("lookup succeeded before anything was stored")
+	}
+
+	cache.store(key, HookResult{Status: "ok", Message: "fine"})
+	result, ok := cache.lookup(key)
+	if !ok {
+		t.Fatal("lookup failed after store")
+	}
+	if got, want := result.Message, "fine"; got != want {
+		t.Errorf("wrong cached message %q; want %q", got, want)
+	}
+
+	// The run context and trace headers must not affect the fingerprint,
+	// but the payload must.
+	withRun := change
+	withRun.Run = &RunContext{RunID: "abc123"}
+	withRun.Trace = map[string]string{"traceparent": "00-aa-bb-01"}
+	if fingerprint(hookPostPlanResource, &withRun) != key {
+		t.Error("run context or trace headers changed the fingerprint")
+	}
+
+	differentAfter := change
+	differentAfter.After = map[string]interface{}{"v": "other"}
+	if fingerprint(hookPostPlanResource, &differentAfter) == key {
+		t.Error("different planned values produced the same fingerprint")
+	}
+	if fingerprint(hookPreDestroyResource, &change) == key {
+		t.Error("different hooks produced the same fingerprint")
+	}
+}
@@ -0,0 +1,231 @@
+package integrations
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkHookOrder lists every hook method "terraform integration check"
+// exercises, in protocol declaration order so the report reads the same
+// way the protocol documentation does.
+var checkHookOrder = []string{
+	hookPrePlanResource,
+	hookPostPlanResource,
+	hookPostPlanResources,
+	hookPreDestroyResource,
+	hookPreRefreshResource,
+	hookPostRefreshResource,
+	hookPreApplyResource,
+	hookPostApplyResource,
+	hookPlanStageBegin,
+	hookPostPlanDeferred,
+	hookPreRunbookStep,
+	hookPostRunbookStep,
+	hookPreInvokeAction,
+	hookPostInvokeAction,
+	hookPostPlanAction,
+	hookResourceProgress,
+	hookTestStepStarted,
+	hookTestStepFinished,
+	hookTestAssertionFailed,
+	hookPreStateMutation,
+	hookPostStateMutation,
+}
+
+// HookCheck is the outcome of exercising one hook method against an
+// integration with a synthetic payload.
+type HookCheck struct {
+	// Hook is the hook method name.
+	Hook string
+
+	// Latency is how long the integration took to respond.
+	Latency time.Duration
+
+	// Err is the call failure or response validation failure, nil when
+	// the hook responded with a well-formed result.
+	Err error
+
+	// SkipReason is set for capability-gated hooks the integration did
+	// not declare, which are therefore never sent to it.
+	SkipReason string
+}
+
+// CheckHooks sends a canned payload for every hook method to the
+// integration and validates each response against the protocol, so that
+// malformed responses surface here rather than being silently discarded
+// during a real operation. Hooks behind a capability the integration did
+// not declare are reported as skipped. The results come back in protocol
+// declaration order, one per hook, with the integration's response
+// latency for each.
+func (p *IntegrationProcess) CheckHooks(run *RunContext) []HookCheck {
+	canned := cannedHookParams(run)
+
+	ret := make([]HookCheck, 0, len(checkHookOrder))
+	for _, hook := range checkHookOrder {
+		check := HookCheck{Hook: hook}
+
+		switch hook {
+		case hookPostPlanResources:
+			if !p.Capable(capabilityBatchedPlanResources) {
+				check.SkipReason = fmt.Sprintf("capability %q not declared", capabilityBatchedPlanResources)
+			}
+		case hookPreDestroyResource:
+			if !p.Capable(capabilityDestroyProtection) {
+				check.SkipReason = fmt.Sprintf("capability %q not declared", capabilityDestroyProtection)
+			}
+		}
+		if check.SkipReason != "" {
+			ret = append(ret, check)
+			continue
+		}
+
+		var result HookResult
+		start := time.Now()
+		err := p.Call(hook, canned[hook], &result)
+		check.Latency = time.Since(start)
+		if err != nil {
+			check.Err = err
+		} else {
+			check.Err = validateHookResult(&result)
+		}
+		ret = append(ret, check)
+	}
+	return ret
+}
+
+// cannedHookParams builds a synthetic payload for each hook method, in
+// the same shapes a real operation would send. The payloads describe an
+// imaginary null_resource so that integrations with resource-matching
+// policy see plausible data, and each carries the given run context just
+// as real calls do.
+func cannedHookParams(run *RunContext) map[string]interface{} {
+	after := map[string]interface{}{
+		"id": "canary",
+		"triggers": map[string]interface{}{
+			"source": "terraform integration check",
+		},
+	}
+
+	create := resourceChange{
+		Address:     "null_resource.canary",
+		Action:      "create",
+		Provider:    "registry.terraform.io/hashicorp/null",
+		Before:      nil,
+		After:       after,
+		Correlation: "check-canary",
+		Run:         run,
+	}
+	destroy := create
+	destroy.Action = "delete"
+	destroy.Before = after
+	destroy.After = nil
+	refresh := create
+	refresh.Action = "read"
+	refresh.Before = after
+	refresh.After = after
+
+	step := runbookStepParams{
+		Runbook: "check",
+		Step:    "canary",
+		Config:  map[string]interface{}{},
+		Run:     run,
+	}
+	invoke := step
+	invoke.Action = "null_noop"
+	invoked := invoke
+	invoked.Result = map[string]interface{}{"status": "ok"}
+	plannedAction := invoke
+	plannedAction.Planned = map[string]interface{}{}
+
+	test := testStepParams{
+		Scenario: "check",
+		Step:     "canary",
+		Run:      run,
+	}
+	testFinished := test
+	testFinished.Status = "passed"
+	testFinished.DurationMS = 1
+	testFinished.Attempts = 1
+	testAssertion := test
+	testAssertion.Error = "synthetic assertion failure"
+	testAssertion.Message = "this assertion failure is synthetic, sent by \"terraform integration check\""
+
+	mutation := stateMutationParams{
+		Operation: "integration check",
+		Addresses: []string{"null_resource.canary"},
+		Run:       run,
+	}
+
+	return map[string]interface{}{
+		hookPrePlanResource:     create,
+		hookPostPlanResource:    create,
+		hookPostPlanResources:   resourceChangesParams{Changes: []resourceChange{create}, Run: run},
+		hookPreDestroyResource:  destroy,
+		hookPreRefreshResource:  refresh,
+		hookPostRefreshResource: refresh,
+		hookPreApplyResource:    create,
+		hookPostApplyResource:   create,
+		hookPlanStageBegin: planStageBeginParams{
+			Variables: map[string]interface{}{"source": "terraform integration check"},
+			Run:       run,
+		},
+		hookPostPlanDeferred: deferredChangeParams{
+			Address: "null_resource.canary",
+			Reason:  "synthetic deferral, sent by \"terraform integration check\"",
+			Run:     run,
+		},
+		hookPreRunbookStep:   step,
+		hookPostRunbookStep:  step,
+		hookPreInvokeAction:  invoke,
+		hookPostInvokeAction: invoked,
+		hookPostPlanAction:   plannedAction,
+		hookResourceProgress: resourceProgressParams{
+			Address:        "null_resource.canary",
+			Action:         "create",
+			ElapsedSeconds: 10,
+			Run:            run,
+		},
+		hookTestStepStarted:     test,
+		hookTestStepFinished:    testFinished,
+		hookTestAssertionFailed: testAssertion,
+		hookPreStateMutation:    mutation,
+		hookPostStateMutation:   mutation,
+	}
+}
+
+// validateHookResult checks a hook response against the protocol's
+// allowed shapes. During real operations malformed fields are mostly
+// ignored, so this is where an integration author finds out about them.
+func validateHookResult(result *HookResult) error {
+	switch result.Status {
+	case "", "ok", "failed":
+	default:
+		return fmt.Errorf("invalid status %q; must be \"ok\" or \"failed\"", result.Status)
+	}
+	switch result.Action {
+	case "", "continue", "halt":
+	default:
+		return fmt.Errorf("invalid action %q; must be \"continue\" or \"halt\"", result.Action)
+	}
+	for _, a := range result.Annotations {
+		switch a.Severity {
+		case "", "info", "warning", "error":
+		default:
+			return fmt.Errorf("invalid annotation severity %q; must be \"info\", \"warning\", or \"error\"", a.Severity)
+		}
+		if a.Message == "" {
+			return fmt.Errorf("annotation with no message")
+		}
+	}
+	for _, f := range result.Findings {
+		switch f.Severity {
+		case "", "warning", "error":
+		default:
+			return fmt.Errorf("invalid finding severity %q; must be \"warning\" or \"error\"", f.Severity)
+		}
+		if f.Summary == "" {
+			return fmt.Errorf("finding with no summary")
+		}
+	}
+	return nil
+}
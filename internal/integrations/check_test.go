@@ -0,0 +1,97 @@
+package integrations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serveHookResults runs a fake integration on the given pipe ends,
+// answering each request with the HookResult-shaped response configured
+// for its method, or a plain ok.
+func serveHookResults(t *testing.T, in io.Reader, out io.WriteCloser, responses map[string]string) {
+	var writeMu sync.Mutex
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			t.Errorf("server: invalid request line: %s", err)
+			continue
+		}
+		if req.ID == 0 {
+			continue // notification, e.g. shutdown
+		}
+
+		body := responses[req.Method]
+		if body == "" {
+			body = `{"status":"ok","action":"continue"}`
+		}
+		resp := rpcResponse{
+			Version: jsonrpcVersion,
+			ID:      req.ID,
+			Result:  json.RawMessage(body),
+		}
+		buf, _ := json.Marshal(&resp)
+		writeMu.Lock()
+		fmt.Fprintf(out, "%s\n", buf)
+		writeMu.Unlock()
+	}
+	out.Close()
+}
+
+func TestCheckHooks(t *testing.T) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+	go serveHookResults(t, toServerR, fromServerW, map[string]string{
+		hookPreRefreshResource: `{"status":"definitely"}`,
+		hookPostApplyResource:  `{"status":"ok","findings":[{"severity":"fatal","summary":"x"}]}`,
+	})
+
+	p := &IntegrationProcess{Name: "check"}
+	p.initTransport(toServerW, fromServerR, 1)
+	p.capabilities = map[string]bool{capabilityBatchedPlanResources: true}
+	defer p.Close()
+
+	checks := p.CheckHooks(&RunContext{RunID: "run-check"})
+	if got, want := len(checks), len(checkHookOrder); got != want {
+		t.Fatalf("wrong check count %d; want %d", got, want)
+	}
+
+	byHook := make(map[string]HookCheck, len(checks))
+	for i, check := range checks {
+		if check.Hook != checkHookOrder[i] {
+			t.Errorf("check %d is for %q; want %q", i, check.Hook, checkHookOrder[i])
+		}
+		byHook[check.Hook] = check
+	}
+
+	// The batched-plan capability was declared, so that hook runs; the
+	// destroy-protection one wasn't, so its hook is skipped.
+	if check := byHook[hookPostPlanResources]; check.SkipReason != "" || check.Err != nil {
+		t.Errorf("post-plan-resources should have been exercised: %+v", check)
+	}
+	if check := byHook[hookPreDestroyResource]; !strings.Contains(check.SkipReason, capabilityDestroyProtection) {
+		t.Errorf("pre-destroy-resource should have been skipped: %+v", check)
+	}
+
+	if check := byHook[hookPreRefreshResource]; check.Err == nil || !strings.Contains(check.Err.Error(), `"definitely"`) {
+		t.Errorf("invalid status was not reported: %+v", check)
+	}
+	if check := byHook[hookPostApplyResource]; check.Err == nil || !strings.Contains(check.Err.Error(), `"fatal"`) {
+		t.Errorf("invalid finding severity was not reported: %+v", check)
+	}
+
+	for _, hook := range []string{hookPrePlanResource, hookPlanStageBegin, hookPostStateMutation} {
+		check := byHook[hook]
+		if check.Err != nil {
+			t.Errorf("%s: unexpected error: %s", hook, check.Err)
+		}
+		if check.Latency <= 0 {
+			t.Errorf("%s: no latency recorded", hook)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Package integrations manages the external integration processes that
+// Terraform can notify about lifecycle events during an operation.
+//
+// Each integration is an executable program configured with an "integration"
+// block in the root module. Terraform launches the program at the start of
+// an operation and exchanges newline-delimited JSON-RPC 2.0 messages with it
+// over the program's stdin and stdout: an "initialize" handshake that
+// negotiates capabilities, a series of hook calls as the operation
+// progresses, and finally a "shutdown" notification when the operation
+// completes.
+package integrations
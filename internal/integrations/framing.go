@@ -0,0 +1,137 @@
+package integrations
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The protocol's default message transport is newline-delimited JSON, which
+// is easy to implement but puts a ceiling on message size in any
+// line-oriented reader. An integration can declare the
+// "content-length-framing" capability at initialize to switch to
+// Content-Length framed messages (in the style of the Language Server
+// Protocol) instead: a "Content-Length: N" header line, a blank line, and
+// then exactly N bytes of JSON.
+//
+// On the read side Terraform detects the framing of each message by its
+// first byte, so an integration may frame its output as soon as it has
+// declared the capability — including the initialize result itself.
+// Terraform frames its own requests only after a successful initialize.
+
+// capabilityContentLengthFraming is the capability an integration declares
+// to exchange Content-Length framed messages instead of newline-delimited
+// ones.
+const capabilityContentLengthFraming = "content-length-framing"
+
+// maxMessageBytes bounds the size of a single protocol message in either
+// framing, so a corrupt length header or an endless unterminated line
+// can't consume arbitrary memory. Oversize messages fail the transport
+// loudly rather than being silently dropped.
+const maxMessageBytes = 64 << 20 // 64 MiB
+
+// readMessage reads one protocol message from the given reader, accepting
+// either framing: messages beginning with a Content-Length header are read
+// by length, and anything else is read as a newline-delimited line. Blank
+// lines between messages are skipped.
+func readMessage(br *bufio.Reader) ([]byte, error) {
+	for {
+		first, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if first[0] == '\n' || first[0] == '\r' {
+			br.ReadByte()
+			continue
+		}
+		if first[0] == 'C' || first[0] == 'c' {
+			return readFramedMessage(br)
+		}
+		return readLineMessage(br)
+	}
+}
+
+// readFramedMessage reads one Content-Length framed message, positioned at
+// the start of its header block.
+func readFramedMessage(br *bufio.Reader) ([]byte, error) {
+	var length int64 = -1
+	for {
+		line, err := readLineMessage(br)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			break // blank line ends the headers
+		}
+		parts := strings.SplitN(string(line), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid message header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(parts[0]), "Content-Length") {
+			length, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("framed message has no Content-Length header")
+	}
+	if length > maxMessageBytes {
+		return nil, fmt.Errorf("message length %d exceeds the %d byte limit", length, maxMessageBytes)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readLineMessage reads one newline-terminated message, without the usual
+// bufio.Scanner ceiling: the line may grow up to maxMessageBytes
+// regardless of the reader's buffer size. The trailing newline (and any
+// carriage return) is stripped.
+func readLineMessage(br *bufio.Reader) ([]byte, error) {
+	var buf []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		if err == bufio.ErrBufferFull {
+			if len(buf) > maxMessageBytes {
+				return nil, fmt.Errorf("message exceeds the %d byte limit", maxMessageBytes)
+			}
+			continue
+		}
+		if err != nil {
+			// An unterminated final line is still a message, as long as
+			// it isn't empty.
+			if err == io.EOF && len(buf) > 0 {
+				break
+			}
+			return nil, err
+		}
+		break
+	}
+	return bytes.TrimRight(buf, "\r\n"), nil
+}
+
+// writeMessage sends one encoded message to the integration, using
+// Content-Length framing once that has been negotiated and a
+// newline-delimited line otherwise. It serializes concurrent writers.
+func (p *IntegrationProcess) writeMessage(buf []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if p.framedWrites {
+		if _, err := fmt.Fprintf(p.stdin, "Content-Length: %d\r\n\r\n", len(buf)); err != nil {
+			return err
+		}
+		_, err := p.stdin.Write(buf)
+		return err
+	}
+	_, err := p.stdin.Write(append(buf, '\n'))
+	return err
+}
@@ -0,0 +1,177 @@
+package integrations
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadMessage(t *testing.T) {
+	t.Run("line-delimited", func(t *testing.T) {
+		br := bufio.NewReader(strings.NewReader("{\"a\":1}\n{\"b\":2}\n"))
+		for _, want := range []string{`{"a":1}`, `{"b":2}`} {
+			got, err := readMessage(br)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != want {
+				t.Errorf("wrong message %q; want %q", got, want)
+			}
+		}
+	})
+
+	t.Run("content-length framed", func(t *testing.T) {
+		body := `{"a":1}`
+		input := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+		br := bufio.NewReader(strings.NewReader(input))
+		got, err := readMessage(br)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(got) != body {
+			t.Errorf("wrong message %q; want %q", got, body)
+		}
+	})
+
+	t.Run("mixed framings", func(t *testing.T) {
+		body := `{"b":2}`
+		input := fmt.Sprintf("{\"a\":1}\nContent-Length: %d\r\n\r\n%s{\"c\":3}\n", len(body), body)
+		br := bufio.NewReader(strings.NewReader(input))
+		for _, want := range []string{`{"a":1}`, body, `{"c":3}`} {
+			got, err := readMessage(br)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != want {
+				t.Errorf("wrong message %q; want %q", got, want)
+			}
+		}
+	})
+
+	t.Run("line larger than the reader buffer", func(t *testing.T) {
+		// bufio.Scanner's default limit is 64KiB; a line several times the
+		// reader's buffer size must still come through in one piece.
+		big := `{"v":"` + strings.Repeat("x", 300*1024) + `"}`
+		br := bufio.NewReaderSize(strings.NewReader(big+"\n"), 4096)
+		got, err := readMessage(br)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(got) != big {
+			t.Errorf("large line was mangled (got %d bytes, want %d)", len(got), len(big))
+		}
+	})
+
+	t.Run("oversize declared length", func(t *testing.T) {
+		input := fmt.Sprintf("Content-Length: %d\r\n\r\n", maxMessageBytes+1)
+		br := bufio.NewReader(strings.NewReader(input))
+		_, err := readMessage(br)
+		if err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Fatalf("wrong error for oversize message: %v", err)
+		}
+	})
+
+	t.Run("missing content-length", func(t *testing.T) {
+		br := bufio.NewReader(strings.NewReader("Content-Type: application/json\r\n\r\n{}"))
+		_, err := readMessage(br)
+		if err == nil || !strings.Contains(err.Error(), "no Content-Length") {
+			t.Fatalf("wrong error for missing length header: %v", err)
+		}
+	})
+}
+
+func TestIntegrationProcessFramedTransport(t *testing.T) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+
+	// This server speaks Content-Length framing in both directions, as an
+	// integration does after declaring the capability.
+	requests := make(chan *rpcRequest, 4)
+	go func() {
+		br := bufio.NewReader(toServerR)
+		for {
+			msg, err := readMessage(br)
+			if err != nil {
+				break
+			}
+			var req rpcRequest
+			if err := json.Unmarshal(msg, &req); err != nil || req.ID == 0 {
+				continue
+			}
+			requests <- &req
+			resp := rpcResponse{
+				Version: jsonrpcVersion,
+				ID:      req.ID,
+				Result:  json.RawMessage(`{"ok":true}`),
+			}
+			buf, _ := json.Marshal(&resp)
+			fmt.Fprintf(fromServerW, "Content-Length: %d\r\n\r\n", len(buf))
+			fromServerW.Write(buf)
+		}
+		fromServerW.Close()
+	}()
+
+	p := &IntegrationProcess{Name: "test", framedWrites: true}
+	p.initTransport(toServerW, fromServerR, 1)
+	defer p.Close()
+
+	var result map[string]bool
+	if err := p.Call(hookPostPlanResource, &resourceChange{Address: "a.b"}, &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !result["ok"] {
+		t.Errorf("wrong result %#v", result)
+	}
+
+	req := <-requests
+	if req.Method != hookPostPlanResource {
+		t.Errorf("wrong method %q", req.Method)
+	}
+}
+
+func TestIntegrationProcessLargeResponse(t *testing.T) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+
+	// A newline-delimited response much larger than bufio.Scanner's
+	// default 64KiB limit must not kill the read loop.
+	big := strings.Repeat("x", 1<<20)
+	go func() {
+		br := bufio.NewReader(toServerR)
+		for {
+			msg, err := readMessage(br)
+			if err != nil {
+				break
+			}
+			var req rpcRequest
+			if err := json.Unmarshal(msg, &req); err != nil || req.ID == 0 {
+				continue
+			}
+			body, _ := json.Marshal(map[string]string{"message": big})
+			resp := rpcResponse{
+				Version: jsonrpcVersion,
+				ID:      req.ID,
+				Result:  body,
+			}
+			buf, _ := json.Marshal(&resp)
+			fromServerW.Write(append(buf, '\n'))
+		}
+		fromServerW.Close()
+	}()
+
+	p := &IntegrationProcess{Name: "test"}
+	p.initTransport(toServerW, fromServerR, 1)
+	defer p.Close()
+
+	var result map[string]string
+	if err := p.Call(hookPostPlanResource, nil, &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal([]byte(result["message"]), []byte(big)) {
+		t.Errorf("large response was mangled (got %d bytes, want %d)", len(result["message"]), len(big))
+	}
+}
@@ -0,0 +1,195 @@
+package integrations
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/internal/runbooks"
+)
+
+// methodCallFunction is the JSON-RPC method that evaluates one of the
+// functions an integration declared at initialize. Unlike the hook methods
+// it is a question rather than a notification: the integration's answer
+// becomes the value of an expression in a scenario or runbook file.
+const methodCallFunction = "call-function"
+
+// callFunctionParams is the params payload for a "call-function" request.
+type callFunctionParams struct {
+	// Name is the function being called, as the integration declared it —
+	// without the integration_<name>_ prefix expressions use.
+	Name string `json:"name"`
+
+	// Args are the call's arguments, encoded the same way as resource
+	// values in hook payloads.
+	Args []interface{} `json:"args"`
+
+	// Run identifies the Terraform run this call belongs to.
+	Run *RunContext `json:"run,omitempty"`
+}
+
+// callFunctionResult is the result payload of a "call-function" request:
+// the function's value, as JSON.
+type callFunctionResult struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// ProvidedFunctions returns the names of the evaluation functions the
+// integration declared during the initialize handshake, sorted.
+func (p *IntegrationProcess) ProvidedFunctions() []string {
+	return p.functions
+}
+
+// CallFunction asks the integration to evaluate one of its declared
+// functions with the given arguments, returning the value it produces.
+//
+// Declared functions must be pure — their result may depend only on their
+// arguments — so results are cached per process and an identical call is
+// answered without consulting the integration again. Unknown arguments
+// produce an unknown result without a call, and sensitive arguments are an
+// error: sensitive values never leave the Terraform process, and silently
+// redacting an argument would change what the function was asked.
+func (p *IntegrationProcess) CallFunction(name string, args []cty.Value, run *RunContext) (cty.Value, error) {
+	wireArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.ContainsMarked() {
+			return cty.NilVal, fmt.Errorf("argument %d to function %q is sensitive, and sensitive values are never sent to integrations", i+1, name)
+		}
+		if arg == cty.NilVal || !arg.IsWhollyKnown() {
+			return cty.UnknownVal(cty.DynamicPseudoType), nil
+		}
+		wireArgs[i] = marshalCtyValue(arg)
+	}
+
+	key := functionFingerprint(name, wireArgs)
+	if key != "" {
+		p.funcMu.Lock()
+		cached, ok := p.funcResults[key]
+		p.funcMu.Unlock()
+		if ok {
+			return cached, nil
+		}
+	}
+
+	var result callFunctionResult
+	err := p.Call(methodCallFunction, &callFunctionParams{
+		Name: name,
+		Args: wireArgs,
+		Run:  run,
+	}, &result)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	if len(result.Value) == 0 {
+		return cty.NilVal, fmt.Errorf("integration %q returned no value from function %q", p.Name, name)
+	}
+
+	ty, err := ctyjson.ImpliedType(result.Value)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value from function %q of integration %q: %s", name, p.Name, err)
+	}
+	val, err := ctyjson.Unmarshal(result.Value, ty)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value from function %q of integration %q: %s", name, p.Name, err)
+	}
+
+	if key != "" {
+		p.funcMu.Lock()
+		if p.funcResults == nil {
+			p.funcResults = make(map[string]cty.Value)
+		}
+		p.funcResults[key] = val
+		p.funcMu.Unlock()
+	}
+	return val, nil
+}
+
+// functionFingerprint derives the cache key for a function call. As with
+// hook fingerprints, an empty key disables caching for that call.
+func functionFingerprint(name string, args []interface{}) string {
+	buf, err := json.Marshal(struct {
+		Name string        `json:"name"`
+		Args []interface{} `json:"args"`
+	}{name, args})
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(buf))
+}
+
+// ctyFunction wraps one declared function of the integration as a cty
+// function for use in an HCL evaluation context. The wrapper accepts any
+// number of arguments of any type and leaves argument policy — unknowns,
+// sensitive values — to CallFunction, so the behavior is the same however
+// the function is reached.
+func (p *IntegrationProcess) ctyFunction(name string, run *RunContext) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{
+			Name:             "args",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+			AllowMarked:      true,
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			// The value's type is known only once the integration answers.
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return p.CallFunction(name, args, run)
+		},
+	})
+}
+
+// functionCallName is the name configuration expressions use for a declared
+// function: the function's own name namespaced by its integration, so two
+// integrations can declare the same function without colliding.
+func functionCallName(integration, fn string) string {
+	return "integration_" + integration + "_" + fn
+}
+
+// Functions returns the evaluation functions declared by the managed
+// integrations, keyed by the name expressions call them by:
+// integration_<integration>_<function>, so e.g. the budget_for function of
+// a "costcheck" integration is called as
+// integration_costcheck_budget_for(...). Returns nil if no integration
+// declared any.
+func (m *Manager) Functions() map[string]function.Function {
+	var ret map[string]function.Function
+	for _, p := range m.processes {
+		for _, name := range p.ProvidedFunctions() {
+			if ret == nil {
+				ret = make(map[string]function.Function)
+			}
+			ret[functionCallName(p.Name, name)] = p.ctyFunction(name, m.run)
+		}
+	}
+	return ret
+}
+
+// RegisterRunbookFunctions makes the declared functions of the managed
+// integrations available to runbook expressions, under the same
+// integration_<integration>_<function> names that scenario expressions
+// use. Call it once after the integrations have started, before any
+// runbook is evaluated.
+func (m *Manager) RegisterRunbookFunctions() error {
+	funcs := m.Functions()
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names) // deterministic registration order, for stable errors
+
+	for _, name := range names {
+		if err := runbooks.RegisterFunction(name, funcs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package integrations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// serveFunctions runs a fake integration on the given pipe ends, answering
+// each "call-function" request with the JSON value configured for the
+// called function's name and counting the calls per function.
+func serveFunctions(t *testing.T, in io.Reader, out io.WriteCloser, values map[string]string, calls map[string]int, callsMu *sync.Mutex) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			t.Errorf("server: invalid request line: %s", err)
+			continue
+		}
+		if req.ID == 0 || req.Method != methodCallFunction {
+			continue
+		}
+
+		buf, _ := json.Marshal(req.Params)
+		var params callFunctionParams
+		if err := json.Unmarshal(buf, &params); err != nil {
+			t.Errorf("server: invalid call-function params: %s", err)
+			continue
+		}
+		callsMu.Lock()
+		calls[params.Name]++
+		callsMu.Unlock()
+
+		resp := rpcResponse{
+			Version: jsonrpcVersion,
+			ID:      req.ID,
+			Result:  json.RawMessage(fmt.Sprintf(`{"value":%s}`, values[params.Name])),
+		}
+		respBuf, _ := json.Marshal(&resp)
+		fmt.Fprintf(out, "%s\n", respBuf)
+	}
+	out.Close()
+}
+
+func testFunctionProcess(t *testing.T, values map[string]string, calls map[string]int, callsMu *sync.Mutex) *IntegrationProcess {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+	go serveFunctions(t, toServerR, fromServerW, values, calls, callsMu)
+
+	p := &IntegrationProcess{Name: "costcheck"}
+	p.initTransport(toServerW, fromServerR, 1)
+	p.functions = []string{"budget_for"}
+	return p
+}
+
+func TestCallFunction(t *testing.T) {
+	calls := make(map[string]int)
+	var callsMu sync.Mutex
+	p := testFunctionProcess(t, map[string]string{
+		"budget_for": `{"limit":500,"currency":"USD"}`,
+	}, calls, &callsMu)
+	defer p.Close()
+
+	run := &RunContext{RunID: "run-func"}
+	args := []cty.Value{cty.ObjectVal(map[string]cty.Value{
+		"team": cty.StringVal("platform"),
+	})}
+
+	val, err := p.CallFunction("budget_for", args, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := cty.ObjectVal(map[string]cty.Value{
+		"limit":    cty.NumberIntVal(500),
+		"currency": cty.StringVal("USD"),
+	})
+	if !val.RawEquals(want) {
+		t.Errorf("wrong value %#v; want %#v", val, want)
+	}
+
+	// An identical call is answered from the cache, and a call with
+	// different arguments is not.
+	if _, err := p.CallFunction("budget_for", args, run); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := calls["budget_for"]; got != 1 {
+		t.Errorf("identical call was not cached: %d calls", got)
+	}
+	otherArgs := []cty.Value{cty.ObjectVal(map[string]cty.Value{
+		"team": cty.StringVal("data"),
+	})}
+	if _, err := p.CallFunction("budget_for", otherArgs, run); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := calls["budget_for"]; got != 2 {
+		t.Errorf("differing call was answered from the cache: %d calls", got)
+	}
+}
+
+func TestCallFunctionUnknownAndSensitive(t *testing.T) {
+	calls := make(map[string]int)
+	var callsMu sync.Mutex
+	p := testFunctionProcess(t, nil, calls, &callsMu)
+	defer p.Close()
+
+	// An unknown argument yields an unknown result without a call.
+	val, err := p.CallFunction("budget_for", []cty.Value{cty.UnknownVal(cty.String)}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if val.IsKnown() {
+		t.Errorf("unknown argument gave known result %#v", val)
+	}
+
+	// A sensitive argument is refused outright.
+	_, err = p.CallFunction("budget_for", []cty.Value{cty.StringVal("hunter2").Mark("sensitive")}, nil)
+	if err == nil || !strings.Contains(err.Error(), "sensitive") {
+		t.Fatalf("wrong error %v for sensitive argument", err)
+	}
+
+	if len(calls) != 0 {
+		t.Errorf("the integration was consulted: %v", calls)
+	}
+}
+
+func TestManagerFunctions(t *testing.T) {
+	calls := make(map[string]int)
+	var callsMu sync.Mutex
+	p := testFunctionProcess(t, map[string]string{
+		"budget_for": `1200`,
+	}, calls, &callsMu)
+	defer p.Close()
+
+	m := &Manager{
+		processes: []*IntegrationProcess{p},
+		run:       &RunContext{RunID: "run-func"},
+	}
+	funcs := m.Functions()
+	fn, ok := funcs["integration_costcheck_budget_for"]
+	if !ok {
+		t.Fatalf("missing namespaced function; got %v", funcs)
+	}
+
+	val, err := fn.Call([]cty.Value{cty.StringVal("platform")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := cty.NumberIntVal(1200); !val.RawEquals(want) {
+		t.Errorf("wrong value %#v; want %#v", val, want)
+	}
+}
+
+func TestCallFunctionNoValue(t *testing.T) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+	go serveHookResults(t, toServerR, fromServerW, map[string]string{
+		methodCallFunction: `{}`,
+	})
+
+	p := &IntegrationProcess{Name: "costcheck"}
+	p.initTransport(toServerW, fromServerR, 1)
+	defer p.Close()
+
+	_, err := p.CallFunction("budget_for", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "returned no value") {
+		t.Fatalf("wrong error %v for missing value", err)
+	}
+}
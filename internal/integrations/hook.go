@@ -0,0 +1,746 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// batchSize is how many resource changes we accumulate before sending a
+// "post-plan-resources" batch to an integration that supports batching.
+// Batches stream out as the plan walk produces changes, so an integration
+// never needs to wait for the whole plan before seeing the first chunk.
+const batchSize = 50
+
+// IntegrationHook is a terraform.Hook implementation that forwards
+// lifecycle events to a set of running integration processes.
+type IntegrationHook struct {
+	terraform.NilHook
+
+	processes []*IntegrationProcess
+
+	// run identifies the Terraform run, and is attached to every hook
+	// payload.
+	run *RunContext
+
+	// mu guards pending. The graph walk can call hook methods from many
+	// goroutines at once.
+	mu sync.Mutex
+
+	// pending buffers planned resource changes for each integration that
+	// declared the batching capability. Chunks are flushed once they reach
+	// batchSize, and any remainder is flushed by Flush at the end of the
+	// plan walk.
+	pending map[*IntegrationProcess][]resourceChange
+
+	// annotations collects the remarks integrations attach to planned
+	// changes, keyed by resource instance address, for the plan renderer
+	// to show under the corresponding diffs.
+	annotations map[string][]Annotation
+
+	// findings accumulates the diagnostics converted from the findings
+	// integrations return, for display at the end of the operation.
+	findings tfdiags.Diagnostics
+
+	// rawFindings keeps the findings in their original form, with the
+	// reporting integration's name attached, so that they can be recorded
+	// in a saved plan file.
+	rawFindings []Finding
+
+	// schemas gives access to the provider schemas, for summarizing them
+	// in hook payloads. It's nil until SetSchemas is called, since the
+	// schemas aren't known yet when the integrations start.
+	schemas *terraform.Schemas
+
+	// correlations tracks the correlation tokens handed out to "pre" hook
+	// events that are still waiting for their "post" counterparts, and
+	// nextCorrelation numbers the tokens.
+	correlations    map[string]string
+	nextCorrelation int64
+
+	// replacements records the replacement context ApplyingReplacement
+	// reported for resource instances, keyed by address, for attaching to
+	// the apply events that follow.
+	replacements map[string]*replacementContext
+
+	// progress tracks the stop channels of the periodic progress emitters
+	// for resource instances currently being applied, keyed by address,
+	// and progressInterval is how often they emit. Zero means
+	// defaultProgressInterval.
+	progress         map[string]chan struct{}
+	progressInterval time.Duration
+
+	// allowDestroyOverride records whether the user set the destroy
+	// override flag (the TF_INTEGRATION_ALLOW_DESTROY environment
+	// variable) for this run, which permits destroys that an integration
+	// marked as requiring an override.
+	allowDestroyOverride bool
+}
+
+var _ terraform.Hook = (*IntegrationHook)(nil)
+var _ terraform.HookWithConfig = (*IntegrationHook)(nil)
+var _ terraform.HookDeferred = (*IntegrationHook)(nil)
+var _ terraform.HookReplacement = (*IntegrationHook)(nil)
+
+// replacementContext is what ApplyingReplacement reported about a
+// resource instance, held until the corresponding apply events arrive.
+type replacementContext struct {
+	deposedKey          string
+	requiredReplace     []string
+	createBeforeDestroy bool
+}
+
+// NewIntegrationHook returns a hook that delivers events to all of the given
+// integration processes, tagging each event with the given run context.
+func NewIntegrationHook(processes []*IntegrationProcess, run *RunContext) *IntegrationHook {
+	return &IntegrationHook{
+		processes:            processes,
+		run:                  run,
+		pending:              make(map[*IntegrationProcess][]resourceChange),
+		annotations:          make(map[string][]Annotation),
+		replacements:         make(map[string]*replacementContext),
+		correlations:         make(map[string]string),
+		allowDestroyOverride: os.Getenv("TF_INTEGRATION_ALLOW_DESTROY") != "",
+	}
+}
+
+func (h *IntegrationHook) PreDiff(addr addrs.AbsResourceInstance, gen states.Generation, priorState, proposedNewState cty.Value) (terraform.HookAction, error) {
+	return h.prePlanResource(addr, priorState, proposedNewState, nil)
+}
+
+func (h *IntegrationHook) PreDiffWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, priorState, proposedNewState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	return h.prePlanResource(addr, priorState, proposedNewState, config)
+}
+
+func (h *IntegrationHook) prePlanResource(addr addrs.AbsResourceInstance, priorState, proposedNewState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	before, beforePaths := marshalCtyValueSensitive(priorState)
+	after, afterPaths := marshalCtyValueSensitive(proposedNewState)
+	change := resourceChange{
+		Address:              addr.String(),
+		Before:               before,
+		After:                after,
+		BeforeSensitivePaths: beforePaths,
+		AfterSensitivePaths:  afterPaths,
+		Correlation:          h.correlationID("plan", addr, false),
+		Run:                  h.run,
+	}
+	if config != nil {
+		change.Provider = config.Provider.String()
+	}
+
+	for _, p := range h.processes {
+		var result HookResult
+		err := h.callHook(p, hookPrePlanResource, addr.String(), change, &result)
+		if action := h.processIntegrationResults(p, hookPrePlanResource, addr.String(), &result, err); action == terraform.HookActionHalt {
+			return terraform.HookActionHalt, nil
+		}
+	}
+	return terraform.HookActionContinue, nil
+}
+
+func (h *IntegrationHook) PostDiff(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value) (terraform.HookAction, error) {
+	return h.postPlanResource(addr, action, priorState, plannedNewState, nil)
+}
+
+func (h *IntegrationHook) PostDiffWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	return h.postPlanResource(addr, action, priorState, plannedNewState, config)
+}
+
+func (h *IntegrationHook) postPlanResource(addr addrs.AbsResourceInstance, action plans.Action, priorState, plannedNewState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	before, beforePaths := marshalCtyValueSensitive(priorState)
+	after, afterPaths := marshalCtyValueSensitive(plannedNewState)
+	change := resourceChange{
+		Address:              addr.String(),
+		Action:               actionName(action),
+		Before:               before,
+		After:                after,
+		BeforeSensitivePaths: beforePaths,
+		AfterSensitivePaths:  afterPaths,
+		Correlation:          h.correlationID("plan", addr, true),
+		Run:                  h.run,
+	}
+	if config != nil {
+		change.Provider = config.Provider.String()
+		change.Schema = h.schemaDigest(config.Provider, addr.ContainingResource().Resource)
+	}
+
+	// Destroys and replacements additionally pass through the
+	// destroy-protection hook, which can block just this destruction
+	// rather than the whole operation.
+	if action == plans.Delete || action.IsReplace() {
+		if err := h.preDestroyResource(addr, change); err != nil {
+			return terraform.HookActionHalt, err
+		}
+	}
+
+	for _, p := range h.processes {
+		if p.Capable(capabilityBatchedPlanResources) {
+			if hookAction := h.appendPending(p, change); hookAction == terraform.HookActionHalt {
+				return terraform.HookActionHalt, nil
+			}
+			continue
+		}
+
+		var result HookResult
+		err := h.callHook(p, hookPostPlanResource, addr.String(), change, &result)
+		if hookAction := h.processIntegrationResults(p, hookPostPlanResource, addr.String(), &result, err); hookAction == terraform.HookActionHalt {
+			return terraform.HookActionHalt, nil
+		}
+	}
+	return terraform.HookActionContinue, nil
+}
+
+// PlanStageBegin tells each integration that the plan graph walk is about
+// to start, with a snapshot of the configuration in jsonconfig format, the
+// input variable values (sensitive ones should arrive marked so that the
+// payload encoding redacts them), and any targeting flags for the run.
+//
+// The backend calls this directly rather than through terraform.Hook,
+// since the core hook interface only describes per-resource events.
+func (h *IntegrationHook) PlanStageBegin(configJSON json.RawMessage, variables map[string]cty.Value, targets []string) terraform.HookAction {
+	vars := make(map[string]interface{}, len(variables))
+	for name, v := range variables {
+		vars[name] = marshalCtyValue(v)
+	}
+	params := planStageBeginParams{
+		Config:    configJSON,
+		Variables: vars,
+		Targets:   targets,
+		Run:       h.run,
+	}
+
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hookPlanStageBegin, "")
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hookPlanStageBegin, &params, &result)
+		endHookSpan(span, &result, err)
+		if action := h.processIntegrationResults(p, hookPlanStageBegin, "", &result, err); action == terraform.HookActionHalt {
+			return terraform.HookActionHalt
+		}
+	}
+	return terraform.HookActionContinue
+}
+
+// PostPlanDeferred tells each integration that a resource couldn't be
+// fully planned, with the reason, so that policy tools can treat "Terraform
+// couldn't predict this" differently from a normal change.
+func (h *IntegrationHook) PostPlanDeferred(addr addrs.AbsResource, reason string) (terraform.HookAction, error) {
+	params := deferredChangeParams{
+		Address: addr.String(),
+		Reason:  reason,
+		Run:     h.run,
+	}
+
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hookPostPlanDeferred, addr.String())
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hookPostPlanDeferred, &params, &result)
+		endHookSpan(span, &result, err)
+		if hookAction := h.processIntegrationResults(p, hookPostPlanDeferred, addr.String(), &result, err); hookAction == terraform.HookActionHalt {
+			return terraform.HookActionHalt, nil
+		}
+	}
+	return terraform.HookActionContinue, nil
+}
+
+// preDestroyResource delivers the "pre-destroy-resource" hook to each
+// integration that declared the destroy-protection capability. A non-nil
+// error means the destruction must not be planned; the caller turns that
+// into an error on just the affected resource, leaving the rest of the plan
+// intact.
+func (h *IntegrationHook) preDestroyResource(addr addrs.AbsResourceInstance, change resourceChange) error {
+	for _, p := range h.processes {
+		if !p.Capable(capabilityDestroyProtection) {
+			continue
+		}
+
+		var result HookResult
+		err := h.callHook(p, hookPreDestroyResource, addr.String(), change, &result)
+		if err != nil {
+			log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hookPreDestroyResource, p.Name, err)
+			continue
+		}
+
+		if result.Action == "halt" {
+			if result.Message != "" {
+				return fmt.Errorf("integration %q blocked destroying %s: %s", p.Name, addr, result.Message)
+			}
+			return fmt.Errorf("integration %q blocked destroying %s", p.Name, addr)
+		}
+		if result.RequiresOverride && !h.allowDestroyOverride {
+			return fmt.Errorf("integration %q requires an explicit override to destroy %s; set TF_INTEGRATION_ALLOW_DESTROY=1 to allow it for this run", p.Name, addr)
+		}
+		if result.RequiresOverride {
+			log.Printf("[INFO] integrations: destroy of %s allowed by override despite integration %q requiring one", addr, p.Name)
+		}
+	}
+	return nil
+}
+
+// appendPending adds a change to the batch buffer for the given process,
+// flushing a full chunk if the buffer has reached batchSize.
+func (h *IntegrationHook) appendPending(p *IntegrationProcess, change resourceChange) terraform.HookAction {
+	h.mu.Lock()
+	h.pending[p] = append(h.pending[p], change)
+	var chunk []resourceChange
+	if len(h.pending[p]) >= batchSize {
+		chunk = h.pending[p]
+		h.pending[p] = nil
+	}
+	h.mu.Unlock()
+
+	if chunk == nil {
+		return terraform.HookActionContinue
+	}
+	return h.sendBatch(p, chunk)
+}
+
+// Flush delivers any remaining buffered changes to integrations that use
+// the batched hook. The backend calls this once the plan walk is complete.
+func (h *IntegrationHook) Flush() terraform.HookAction {
+	h.mu.Lock()
+	pending := h.pending
+	h.pending = make(map[*IntegrationProcess][]resourceChange)
+	h.mu.Unlock()
+
+	ret := terraform.HookActionContinue
+	for _, p := range h.processes {
+		chunk := pending[p]
+		if len(chunk) == 0 {
+			continue
+		}
+		if action := h.sendBatch(p, chunk); action == terraform.HookActionHalt {
+			ret = terraform.HookActionHalt
+		}
+	}
+	return ret
+}
+
+func (h *IntegrationHook) sendBatch(p *IntegrationProcess, chunk []resourceChange) terraform.HookAction {
+	ctx, span := startHookSpan(p.Name, hookPostPlanResources, "")
+	params := &resourceChangesParams{
+		Changes: chunk,
+		Run:     h.run,
+		Trace:   traceFields(ctx),
+	}
+	var result HookResult
+	err := p.Call(hookPostPlanResources, params, &result)
+	endHookSpan(span, &result, err)
+	return h.processIntegrationResults(p, hookPostPlanResources, "", &result, err)
+}
+
+// ApplyingReplacement records the replacement context for a resource
+// instance so the apply events that follow can carry it, letting an
+// integration connect the create and destroy halves of a replacement
+// instead of seeing two unrelated events.
+func (h *IntegrationHook) ApplyingReplacement(addr addrs.AbsResourceInstance, dk states.DeposedKey, requiredReplace cty.PathSet, createBeforeDestroy bool) {
+	rc := &replacementContext{
+		createBeforeDestroy: createBeforeDestroy,
+	}
+	if dk != states.NotDeposed {
+		rc.deposedKey = dk.String()
+	}
+	for _, path := range requiredReplace.List() {
+		rc.requiredReplace = append(rc.requiredReplace, pathPointer(path))
+	}
+	sort.Strings(rc.requiredReplace)
+
+	h.mu.Lock()
+	h.replacements[addr.String()] = rc
+	h.mu.Unlock()
+}
+
+// attachReplacement copies any recorded replacement context for the
+// address into the change payload, removing the record when the apply is
+// over.
+func (h *IntegrationHook) attachReplacement(change *resourceChange, addr string, done bool) {
+	h.mu.Lock()
+	rc, ok := h.replacements[addr]
+	if ok && done {
+		delete(h.replacements, addr)
+	}
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	change.Replace = true
+	change.Deposed = rc.deposedKey
+	change.CreateBeforeDestroy = rc.createBeforeDestroy
+	change.ReplaceRequiredBy = rc.requiredReplace
+}
+
+func (h *IntegrationHook) PreApply(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value) (terraform.HookAction, error) {
+	return h.preApplyResource(addr, gen, action, priorState, plannedNewState, nil, cty.NilVal)
+}
+
+func (h *IntegrationHook) PreApplyWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value, config *configs.Resource, configVal cty.Value) (terraform.HookAction, error) {
+	return h.preApplyResource(addr, gen, action, priorState, plannedNewState, config, configVal)
+}
+
+func (h *IntegrationHook) preApplyResource(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value, config *configs.Resource, configVal cty.Value) (terraform.HookAction, error) {
+	before, beforePaths := marshalCtyValueSensitive(priorState)
+	after, afterPaths := marshalCtyValueSensitive(plannedNewState)
+	change := resourceChange{
+		Address:              addr.String(),
+		Action:               actionName(action),
+		Before:               before,
+		After:                after,
+		BeforeSensitivePaths: beforePaths,
+		AfterSensitivePaths:  afterPaths,
+		Config:               marshalCtyValue(configVal),
+		Correlation:          h.correlationID("apply", addr, false),
+		Run:                  h.run,
+	}
+	if config != nil {
+		change.Provider = config.Provider.String()
+	}
+	if dk, ok := gen.(states.DeposedKey); ok && dk != states.NotDeposed {
+		change.Deposed = dk.String()
+	}
+	h.attachReplacement(&change, addr.String(), false)
+
+	for _, p := range h.processes {
+		var result HookResult
+		err := h.callHook(p, hookPreApplyResource, addr.String(), change, &result)
+		if hookAction := h.processIntegrationResults(p, hookPreApplyResource, addr.String(), &result, err); hookAction == terraform.HookActionHalt {
+			return terraform.HookActionHalt, nil
+		}
+	}
+
+	h.startProgress(addr.String(), actionName(action))
+	return terraform.HookActionContinue, nil
+}
+
+func (h *IntegrationHook) PostApply(addr addrs.AbsResourceInstance, gen states.Generation, newState cty.Value, applyErr error) (terraform.HookAction, error) {
+	return h.postApplyResource(addr, gen, newState, applyErr, nil, cty.NilVal)
+}
+
+func (h *IntegrationHook) PostApplyWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, newState cty.Value, applyErr error, config *configs.Resource, configVal cty.Value) (terraform.HookAction, error) {
+	return h.postApplyResource(addr, gen, newState, applyErr, config, configVal)
+}
+
+func (h *IntegrationHook) postApplyResource(addr addrs.AbsResourceInstance, gen states.Generation, newState cty.Value, applyErr error, config *configs.Resource, configVal cty.Value) (terraform.HookAction, error) {
+	h.stopProgress(addr.String())
+
+	params := struct {
+		resourceChange
+		Error string `json:"error,omitempty"`
+	}{
+		resourceChange: resourceChange{
+			Address: addr.String(),
+			Run:     h.run,
+		},
+	}
+	params.After, params.AfterSensitivePaths = marshalCtyValueSensitive(newState)
+	params.Config = marshalCtyValue(configVal)
+	params.Correlation = h.correlationID("apply", addr, true)
+	if config != nil {
+		params.Provider = config.Provider.String()
+	}
+	if applyErr != nil {
+		params.Error = applyErr.Error()
+	}
+	if dk, ok := gen.(states.DeposedKey); ok && dk != states.NotDeposed {
+		params.Deposed = dk.String()
+	}
+	h.attachReplacement(&params.resourceChange, addr.String(), true)
+
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hookPostApplyResource, addr.String())
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hookPostApplyResource, &params, &result)
+		endHookSpan(span, &result, err)
+		if hookAction := h.processIntegrationResults(p, hookPostApplyResource, addr.String(), &result, err); hookAction == terraform.HookActionHalt {
+			return terraform.HookActionHalt, nil
+		}
+	}
+	return terraform.HookActionContinue, nil
+}
+
+func (h *IntegrationHook) PreRefreshWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, priorState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	return h.refreshResource(hookPreRefreshResource, addr, priorState, cty.NilVal, config)
+}
+
+func (h *IntegrationHook) PostRefreshWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, priorState cty.Value, newState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	return h.refreshResource(hookPostRefreshResource, addr, priorState, newState, config)
+}
+
+// refreshResource delivers the refresh hooks, so that drift-analysis
+// integrations can compare refreshed state against the configuration
+// rather than just against the prior state.
+func (h *IntegrationHook) refreshResource(hook string, addr addrs.AbsResourceInstance, priorState, newState cty.Value, config *configs.Resource) (terraform.HookAction, error) {
+	before, beforePaths := marshalCtyValueSensitive(priorState)
+	after, afterPaths := marshalCtyValueSensitive(newState)
+	change := resourceChange{
+		Address:              addr.String(),
+		Before:               before,
+		After:                after,
+		BeforeSensitivePaths: beforePaths,
+		AfterSensitivePaths:  afterPaths,
+		Correlation:          h.correlationID("refresh", addr, hook == hookPostRefreshResource),
+		Run:                  h.run,
+	}
+	if config != nil {
+		change.Provider = config.Provider.String()
+		change.Schema = h.schemaDigest(config.Provider, addr.ContainingResource().Resource)
+	}
+
+	for _, p := range h.processes {
+		var result HookResult
+		err := h.callHook(p, hook, addr.String(), change, &result)
+		if action := h.processIntegrationResults(p, hook, addr.String(), &result, err); action == terraform.HookActionHalt {
+			return terraform.HookActionHalt, nil
+		}
+	}
+	return terraform.HookActionContinue, nil
+}
+
+// correlationID returns the correlation token for one pre/post hook pair.
+// The "pre" half of a pair generates and remembers the token, and the
+// "post" half consumes it; the phase keeps plan, apply, and refresh events
+// for the same resource from sharing a token. A "post" event with no
+// matching "pre" (e.g. a data source read that skips the pre hook) still
+// gets a fresh token of its own.
+func (h *IntegrationHook) correlationID(phase string, addr addrs.AbsResourceInstance, post bool) string {
+	key := phase + "|" + addr.String()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if id, ok := h.correlations[key]; ok {
+		if post {
+			delete(h.correlations, key)
+		}
+		return id
+	}
+
+	h.nextCorrelation++
+	id := fmt.Sprintf("op-%d", h.nextCorrelation)
+	if !post {
+		h.correlations[key] = id
+	}
+	return id
+}
+
+// SetSchemas provides the hook with the operation's provider schemas,
+// which the backend calls once the Terraform context has been built. Until
+// then hook payloads simply omit their schema digests.
+func (h *IntegrationHook) SetSchemas(schemas *terraform.Schemas) {
+	h.mu.Lock()
+	h.schemas = schemas
+	h.mu.Unlock()
+}
+
+// schemaDigest summarizes the given provider's schema for the given
+// resource type, or returns nil if the schemas aren't available.
+func (h *IntegrationHook) schemaDigest(provider addrs.Provider, res addrs.Resource) *schemaDigest {
+	h.mu.Lock()
+	schemas := h.schemas
+	h.mu.Unlock()
+	if schemas == nil {
+		return nil
+	}
+
+	ps := schemas.ProviderSchema(provider)
+	if ps == nil {
+		return nil
+	}
+	block, version := ps.SchemaForResourceAddr(res)
+	if block == nil {
+		return nil
+	}
+
+	digest := &schemaDigest{
+		Provider:   provider.String(),
+		Version:    version,
+		Attributes: make(map[string]schemaAttribute, len(block.Attributes)),
+	}
+	for name, attr := range block.Attributes {
+		digest.Attributes[name] = schemaAttribute{
+			Computed:  attr.Computed,
+			Sensitive: attr.Sensitive,
+		}
+	}
+	return digest
+}
+
+// Annotations returns the remarks integrations have attached to planned
+// changes so far, keyed by resource instance address.
+func (h *IntegrationHook) Annotations() map[string][]Annotation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ret := make(map[string][]Annotation, len(h.annotations))
+	for addr, anns := range h.annotations {
+		ret[addr] = append([]Annotation(nil), anns...)
+	}
+	return ret
+}
+
+// Findings returns the findings integrations have reported so far, in
+// their original form rather than converted to diagnostics, with each
+// finding's Integration and Address fields filled in.
+func (h *IntegrationHook) Findings() []Finding {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Finding(nil), h.rawFindings...)
+}
+
+// callHook performs one resource-change hook call against one integration,
+// wrapped in a tracing span. The change is passed by value because each
+// integration gets its own span, and therefore its own trace context in the
+// payload.
+func (h *IntegrationHook) callHook(p *IntegrationProcess, hook, addr string, change resourceChange, result *HookResult) error {
+	var cacheKey string
+	if p.cache != nil {
+		cacheKey = fingerprint(hook, &change)
+		if cached, ok := p.cache.lookup(cacheKey); ok && cacheKey != "" {
+			log.Printf("[DEBUG] integrations: reusing cached %s result from integration %q for %s", hook, p.Name, addr)
+			*result = cached
+			return nil
+		}
+	}
+
+	ctx, span := startHookSpan(p.Name, hook, addr)
+	change.Trace = traceFields(ctx)
+	err := p.Call(hook, &change, result)
+	endHookSpan(span, result, err)
+
+	if err == nil && cacheKey != "" {
+		p.cache.store(cacheKey, *result)
+	}
+	return err
+}
+
+// processIntegrationResults interprets the result of a single hook call,
+// deciding whether the operation should continue.
+//
+// A call that fails outright (err is non-nil) is logged but does not stop
+// the operation, on the theory that a misbehaving integration shouldn't
+// leave an operation half-finished. A successful call whose result asks to
+// halt does stop the operation.
+func (h *IntegrationHook) processIntegrationResults(p *IntegrationProcess, hook string, addr string, result *HookResult, err error) terraform.HookAction {
+	if err != nil {
+		log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hook, p.Name, err)
+		return terraform.HookActionContinue
+	}
+
+	if result.Message != "" {
+		log.Printf("[INFO] integrations: integration %q responded to %s for %s: %s", p.Name, hook, addr, result.Message)
+	}
+
+	for _, a := range result.Annotations {
+		a.Integration = p.Name
+		if a.Address == "" {
+			// For single-resource hooks the subject is implied; batched
+			// responses must say which resource each annotation is about.
+			a.Address = addr
+		}
+		if a.Address == "" {
+			log.Printf("[WARN] integrations: integration %q sent an annotation with no resource address; ignoring it", p.Name)
+			continue
+		}
+		h.mu.Lock()
+		h.annotations[a.Address] = append(h.annotations[a.Address], a)
+		h.mu.Unlock()
+	}
+
+	for _, f := range result.Findings {
+		f.Integration = p.Name
+		if f.Address == "" {
+			f.Address = addr
+		}
+		h.mu.Lock()
+		h.findings = h.findings.Append(findingDiagnostic(p.Name, f.Address, &f))
+		h.rawFindings = append(h.rawFindings, f)
+		h.mu.Unlock()
+	}
+
+	if result.Action == "halt" {
+		log.Printf("[WARN] integrations: integration %q halted the operation during %s for %s", p.Name, hook, addr)
+
+		// Record the halt as an error diagnostic so that the user sees who
+		// stopped the run and why, rather than just a cancelled operation.
+		where := fmt.Sprintf("The integration %q asked Terraform to stop the operation during the %s step", p.Name, hook)
+		if addr != "" {
+			where += fmt.Sprintf(" for %s", addr)
+		}
+		detail := where + "."
+		if result.Message != "" {
+			detail = fmt.Sprintf("%s:\n\n%s", where, result.Message)
+		}
+		h.mu.Lock()
+		h.findings = h.findings.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			fmt.Sprintf("Operation halted by integration %q", p.Name),
+			detail,
+		))
+		h.mu.Unlock()
+
+		// There's no machine-readable UI stream yet, so automation that
+		// wants the halt reason can scrape it from the logs, like the
+		// metrics summary.
+		if buf, err := json.Marshal(map[string]string{
+			"integration": p.Name,
+			"hook":        hook,
+			"address":     addr,
+			"message":     result.Message,
+		}); err == nil {
+			log.Printf("[INFO] integrations: halt %s", buf)
+		}
+
+		return terraform.HookActionHalt
+	}
+	return terraform.HookActionContinue
+}
+
+// findingDiagnostic converts one finding from the named integration into a
+// diagnostic. Findings with an attribute path become attribute diagnostics
+// so that callers with access to the configuration can attach source
+// locations; the path is also rendered into the detail text because that
+// elaboration isn't always possible.
+func findingDiagnostic(integration, subject string, f *Finding) tfdiags.Diagnostic {
+	severity := tfdiags.Warning
+	if f.Severity == "error" {
+		severity = tfdiags.Error
+	}
+
+	where := subject
+	path := f.ctyPath()
+	if len(path) > 0 {
+		where += tfdiags.FormatCtyPath(path)
+	}
+	detail := fmt.Sprintf("The integration %q reported this for %s.", integration, where)
+	if f.Detail != "" {
+		detail = f.Detail + "\n\n" + detail
+	}
+
+	if len(path) > 0 {
+		return tfdiags.AttributeValue(severity, f.Summary, detail, path)
+	}
+	return tfdiags.Sourceless(severity, f.Summary, detail)
+}
+
+// Diagnostics returns the diagnostics produced from the findings that
+// integrations have returned so far.
+func (h *IntegrationHook) Diagnostics() tfdiags.Diagnostics {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.findings
+}
@@ -0,0 +1,90 @@
+package integrations
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestIntegrationHookReplacementContext(t *testing.T) {
+	p, recorded := recordingProcess(t)
+	defer p.Close()
+
+	h := NewIntegrationHook([]*IntegrationProcess{p}, nil)
+
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "aws_instance",
+		Name: "web",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	dk := states.DeposedKey("00000001")
+
+	h.ApplyingReplacement(addr, dk, cty.NewPathSet(cty.GetAttrPath("ami")), true)
+	if _, err := h.PreApply(addr, dk.Generation(), plans.Delete, cty.NullVal(cty.EmptyObject), cty.NullVal(cty.EmptyObject)); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := recorded()
+	if len(reqs) == 0 {
+		t.Fatal("no hook calls recorded")
+	}
+	last := reqs[len(reqs)-1]
+	if last.Method != hookPreApplyResource {
+		t.Fatalf("wrong method %q", last.Method)
+	}
+	buf, err := json.Marshal(last.Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var change resourceChange
+	if err := json.Unmarshal(buf, &change); err != nil {
+		t.Fatal(err)
+	}
+	if !change.Replace {
+		t.Error("change is not marked as part of a replacement")
+	}
+	if !change.CreateBeforeDestroy {
+		t.Error("create_before_destroy not set")
+	}
+	if change.Deposed != dk.String() {
+		t.Errorf("wrong deposed key %q; want %q", change.Deposed, dk)
+	}
+	if len(change.ReplaceRequiredBy) != 1 || change.ReplaceRequiredBy[0] != "/ami" {
+		t.Errorf("wrong replace_required_by %#v", change.ReplaceRequiredBy)
+	}
+
+	// The post event carries the context too, and consumes it: a later
+	// apply of the same address is not a replacement.
+	if _, err := h.PostApply(addr, dk.Generation(), cty.NullVal(cty.EmptyObject), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.PreApply(addr, states.CurrentGen, plans.Update, cty.NullVal(cty.EmptyObject), cty.NullVal(cty.EmptyObject)); err != nil {
+		t.Fatal(err)
+	}
+	reqs = recorded()
+	post, pre := reqs[len(reqs)-2], reqs[len(reqs)-1]
+	if post.Method != hookPostApplyResource {
+		t.Fatalf("wrong method %q", post.Method)
+	}
+	buf, _ = json.Marshal(post.Params)
+	change = resourceChange{}
+	if err := json.Unmarshal(buf, &change); err != nil {
+		t.Fatal(err)
+	}
+	if !change.Replace || change.Deposed != dk.String() {
+		t.Errorf("post-apply event lost the replacement context: %+v", change)
+	}
+	buf, _ = json.Marshal(pre.Params)
+	change = resourceChange{}
+	if err := json.Unmarshal(buf, &change); err != nil {
+		t.Fatal(err)
+	}
+	if change.Replace || change.Deposed != "" {
+		t.Errorf("replacement context leaked into a later change: %+v", change)
+	}
+}
@@ -0,0 +1,256 @@
+package integrations
+
+import (
+	"fmt"
+	"sort"
+
+	uuid "github.com/hashicorp/go-uuid"
+	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans/planfile"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Manager starts and tracks the integration processes for a single
+// Terraform operation.
+type Manager struct {
+	processes []*IntegrationProcess
+	hook      *IntegrationHook
+	run       *RunContext
+
+	// finalMetrics preserves the last metrics snapshot once Close has
+	// discarded the processes themselves.
+	finalMetrics []MetricsSummary
+}
+
+// NewManager launches one process for each of the given integration
+// configurations, which should be the Integrations map from the root
+// module. The given run context describes the operation to the
+// integrations; if its RunID isn't set then NewManager generates one.
+//
+// If any integration fails to start then error diagnostics are returned and
+// any integrations that did start are shut down again, since running an
+// operation with only a subset of its configured integrations could bypass
+// a policy the user is relying on.
+//
+// ui, which may be nil, receives stderr output from integrations that are
+// configured to show it in the UI.
+func NewManager(integrationConfigs map[string]*configs.Integration, run *RunContext, ui terraform.UIOutput) (*Manager, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	if run == nil {
+		run = &RunContext{}
+	}
+	if run.RunID == "" {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to generate run ID for integrations",
+				fmt.Sprintf("Terraform could not generate a run ID: %s.", err),
+			))
+			return nil, diags
+		}
+		run.RunID = id
+	}
+
+	// Hook events are delivered to integrations in their configured order,
+	// with name order as the tiebreaker, so that e.g. a policy integration
+	// can be guaranteed to run (and potentially halt the operation) before
+	// a notification integration hears about anything.
+	ordered := make([]*configs.Integration, 0, len(integrationConfigs))
+	for _, ic := range integrationConfigs {
+		ordered = append(ordered, ic)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Order != ordered[j].Order {
+			return ordered[i].Order < ordered[j].Order
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	m := &Manager{run: run}
+	for _, ic := range ordered {
+		p, err := StartIntegration(ic, run, ui)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to start integration",
+				fmt.Sprintf("Terraform could not start the integration %q: %s.", ic.Name, err),
+			))
+			continue
+		}
+		m.processes = append(m.processes, p)
+	}
+	if diags.HasErrors() {
+		m.Close()
+		return nil, diags
+	}
+
+	m.hook = NewIntegrationHook(m.processes, run)
+	return m, diags
+}
+
+// Hook returns the terraform.Hook implementation that delivers events to
+// the managed integrations.
+func (m *Manager) Hook() *IntegrationHook {
+	return m.hook
+}
+
+// PlanAnnotations returns the remarks integrations have attached to planned
+// changes, keyed by resource instance address, for display alongside the
+// rendered plan.
+func (m *Manager) PlanAnnotations() map[string][]Annotation {
+	if m.hook == nil {
+		return nil
+	}
+	return m.hook.Annotations()
+}
+
+// PlanFileResults returns what the integrations have reported so far in
+// the form the planfile package records in a saved plan file, so that
+// someone reviewing the plan later sees the same results, along with
+// descriptors for the integrations themselves so an apply of the saved
+// plan can detect if the set has changed. Returns nil if there is nothing
+// to record.
+func (m *Manager) PlanFileResults() *planfile.IntegrationResults {
+	if m.hook == nil {
+		return nil
+	}
+
+	results := &planfile.IntegrationResults{}
+	for _, p := range m.processes {
+		results.Integrations = append(results.Integrations, p.Describe())
+	}
+	for addr, anns := range m.hook.Annotations() {
+		for _, a := range anns {
+			if results.Annotations == nil {
+				results.Annotations = make(map[string][]planfile.IntegrationAnnotation)
+			}
+			results.Annotations[addr] = append(results.Annotations[addr], planfile.IntegrationAnnotation{
+				Integration: a.Integration,
+				Message:     a.Message,
+				Severity:    a.Severity,
+				URL:         a.URL,
+			})
+		}
+	}
+	for _, f := range m.hook.Findings() {
+		results.Findings = append(results.Findings, planfile.IntegrationFinding{
+			Integration: f.Integration,
+			Address:     f.Address,
+			Severity:    f.Severity,
+			Summary:     f.Summary,
+			Detail:      f.Detail,
+		})
+	}
+
+	if results.Empty() {
+		return nil
+	}
+	return results
+}
+
+// CheckRequirements verifies the integration_requirements declared by the
+// modules in the given configuration against the integrations this manager
+// is running. Configuration loading already guarantees that every required
+// integration is enabled in the root module, so the remaining question is
+// whether the version selected for each source-addressed integration
+// satisfies the constraints of the modules that require it, which can't be
+// known until the integration is resolved.
+func (m *Manager) CheckRequirements(cfg *configs.Config) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	byName := make(map[string]*IntegrationProcess, len(m.processes))
+	for _, p := range m.processes {
+		byName[p.Name] = p
+	}
+
+	cfg.DeepEach(func(c *configs.Config) {
+		names := make([]string, 0, len(c.Module.IntegrationRequirements))
+		for name := range c.Module.IntegrationRequirements {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			req := c.Module.IntegrationRequirements[name]
+			if req.Requirement.Required == nil {
+				continue
+			}
+			p, running := byName[req.Name]
+			if !running || p.version == "" {
+				// A missing integration, or one with no version to check,
+				// is diagnosed at configuration load instead.
+				continue
+			}
+			v, err := version.NewVersion(p.version)
+			if err != nil {
+				continue
+			}
+			if !req.Requirement.Required.Check(v) {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Integration requirement not satisfied",
+					Detail:   fmt.Sprintf("This module requires integration %q version %s, but version %s is running.", req.Name, req.Requirement.Required.String(), p.version),
+					Subject:  &req.DeclRange,
+				})
+			}
+		}
+	})
+
+	return diags
+}
+
+// TestHook returns a scenarios.Hook implementation that delivers test
+// step lifecycle events to this manager's integrations.
+func (m *Manager) TestHook() *TestHook {
+	return NewTestHook(m.processes, m.run)
+}
+
+// RunbookHook returns a runbooks.Hook implementation that delivers runbook
+// execution events to the managed integrations.
+func (m *Manager) RunbookHook() *RunbookHook {
+	return NewRunbookHook(m.processes, m.run)
+}
+
+// Metrics returns the per-integration call statistics accumulated so far,
+// in the integrations' configured order.
+func (m *Manager) Metrics() []MetricsSummary {
+	if m.processes == nil {
+		return m.finalMetrics
+	}
+	ret := make([]MetricsSummary, 0, len(m.processes))
+	for _, p := range m.processes {
+		ret = append(ret, p.Metrics())
+	}
+	return ret
+}
+
+// Close flushes any pending batched hook calls and then shuts down all of
+// the managed integration processes.
+func (m *Manager) Close() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if m.hook != nil {
+		m.hook.Flush()
+		diags = diags.Append(m.hook.Diagnostics())
+	}
+
+	for _, p := range m.processes {
+		if err := p.Close(); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Failed to stop integration",
+				fmt.Sprintf("The integration %q did not shut down cleanly: %s.", p.Name, err),
+			))
+		}
+	}
+	m.finalMetrics = m.Metrics()
+	m.processes = nil
+
+	return diags
+}
@@ -0,0 +1,73 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// MetricsSummary describes the hook call activity of a single integration
+// over the course of an operation. It is printed at the end of a run so that
+// users can see when an integration is slowing their operations down, and
+// its JSON encoding is emitted into the logs for machine consumption.
+type MetricsSummary struct {
+	Integration string        `json:"integration"`
+	Calls       int           `json:"calls"`
+	Failures    int           `json:"failures"`
+	Timeouts    int           `json:"timeouts"`
+	TotalTime   time.Duration `json:"totalTimeNs"`
+	AvgTime     time.Duration `json:"avgTimeNs"`
+	MaxTime     time.Duration `json:"maxTimeNs"`
+}
+
+// metrics accumulates call statistics for a single integration process. The
+// zero value is ready to use.
+type metrics struct {
+	mu        sync.Mutex
+	calls     int
+	failures  int
+	timeouts  int
+	totalTime time.Duration
+	maxTime   time.Duration
+}
+
+// record accounts for one completed call that took the given duration and
+// ended with the given error, if any.
+func (m *metrics) record(d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	m.totalTime += d
+	if d > m.maxTime {
+		m.maxTime = d
+	}
+	if err != nil {
+		m.failures++
+		if errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err) {
+			m.timeouts++
+		}
+	}
+}
+
+// summary returns a snapshot of the accumulated statistics, labelled with
+// the given integration name.
+func (m *metrics) summary(name string) MetricsSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := MetricsSummary{
+		Integration: name,
+		Calls:       m.calls,
+		Failures:    m.failures,
+		Timeouts:    m.timeouts,
+		TotalTime:   m.totalTime,
+		MaxTime:     m.maxTime,
+	}
+	if m.calls > 0 {
+		ret.AvgTime = m.totalTime / time.Duration(m.calls)
+	}
+	return ret
+}
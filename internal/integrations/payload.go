@@ -0,0 +1,89 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+)
+
+// defaultMaxPayloadBytes is the limit on the encoded size of a single hook
+// call's params when the integration block doesn't set
+// "max_payload_bytes". Resource objects can embed multi-megabyte values
+// (file contents, rendered templates, and the like), and shoving those
+// through a single protocol line tends to break line-oriented integration
+// implementations.
+const defaultMaxPayloadBytes = 1 << 20 // 1 MiB
+
+// limitPayload brings an encoded params payload within the integration's
+// size limit, returning the replacement params and, when the payload was
+// spilled to a file, the path of that file for the caller to delete once
+// the call completes.
+//
+// Integrations that declared the "payload-file" capability get the full
+// params in a temporary file, referenced by a spilledParams value.
+// Otherwise the bulky parts of the payload — resource before/after values
+// — are dropped and flagged as truncated.
+func (p *IntegrationProcess) limitPayload(method string, raw json.RawMessage) (json.RawMessage, string, error) {
+	if p.Capable(capabilityPayloadFile) {
+		f, err := ioutil.TempFile("", "terraform-integration-payload")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to spill oversize %q payload for integration %q: %s", method, p.Name, err)
+		}
+		if _, err := f.Write(raw); err != nil {
+			f.Close()
+			return nil, "", fmt.Errorf("failed to spill oversize %q payload for integration %q: %s", method, p.Name, err)
+		}
+		if err := f.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to spill oversize %q payload for integration %q: %s", method, p.Name, err)
+		}
+		replacement, err := json.Marshal(&spilledParams{
+			PayloadFile:   f.Name(),
+			OriginalBytes: int64(len(raw)),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		log.Printf("[DEBUG] integrations: spilled %d-byte %q payload for integration %q to %s", len(raw), method, p.Name, f.Name())
+		return replacement, f.Name(), nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		// Shouldn't happen, since we encoded this ourselves.
+		return nil, "", fmt.Errorf("invalid %q payload: %s", method, err)
+	}
+	truncatePayloadValues(decoded)
+	replacement, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, "", err
+	}
+	log.Printf("[DEBUG] integrations: truncated %q payload for integration %q from %d to %d bytes", method, p.Name, len(raw), len(replacement))
+	return replacement, "", nil
+}
+
+// truncatePayloadValues walks a decoded JSON payload and drops the
+// "before" and "after" members of any object that has them, setting the
+// corresponding truncation flags, so that the rest of the payload stays
+// intact. This covers both single-resource payloads and batched ones,
+// where each element of "changes" is such an object.
+func truncatePayloadValues(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if before, ok := v["before"]; ok && before != nil {
+			v["before"] = nil
+			v["beforeTruncated"] = true
+		}
+		if after, ok := v["after"]; ok && after != nil {
+			v["after"] = nil
+			v["afterTruncated"] = true
+		}
+		for _, elem := range v {
+			truncatePayloadValues(elem)
+		}
+	case []interface{}:
+		for _, elem := range v {
+			truncatePayloadValues(elem)
+		}
+	}
+}
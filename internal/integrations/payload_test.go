@@ -0,0 +1,204 @@
+package integrations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testPayloadServer answers every request with an empty result, keeping
+// each request's decoded params for the test to inspect.
+type testPayloadServer struct {
+	requests chan *rpcRequest
+}
+
+func (s *testPayloadServer) serve(t *testing.T, in io.Reader, out io.WriteCloser) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 1<<20), 1<<24)
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			t.Errorf("server: invalid request line: %s", err)
+			continue
+		}
+		if req.ID == 0 {
+			continue
+		}
+		s.requests <- &req
+		resp := rpcResponse{
+			Version: jsonrpcVersion,
+			ID:      req.ID,
+			Result:  json.RawMessage(`{}`),
+		}
+		buf, _ := json.Marshal(&resp)
+		fmt.Fprintf(out, "%s\n", buf)
+	}
+	out.Close()
+}
+
+func testPayloadProcess(t *testing.T, maxPayload int64, capabilities ...string) (*IntegrationProcess, *testPayloadServer) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+
+	server := &testPayloadServer{requests: make(chan *rpcRequest, 4)}
+	go server.serve(t, toServerR, fromServerW)
+
+	p := &IntegrationProcess{Name: "test", maxPayload: maxPayload}
+	p.capabilities = make(map[string]bool)
+	for _, name := range capabilities {
+		p.capabilities[name] = true
+	}
+	p.initTransport(toServerW, fromServerR, 1)
+	return p, server
+}
+
+func TestIntegrationProcessPayloadTruncation(t *testing.T) {
+	p, server := testPayloadProcess(t, 512)
+	defer p.Close()
+
+	change := &resourceChange{
+		Address: "aws_instance.big",
+		Action:  "update",
+		Before:  strings.Repeat("x", 4096),
+		After:   strings.Repeat("y", 4096),
+	}
+	if err := p.Call(hookPostPlanResource, change, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req := <-server.requests
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		t.Fatalf("params are %T; want an object", req.Params)
+	}
+	if got := params["address"]; got != "aws_instance.big" {
+		t.Errorf("address was not preserved: %#v", got)
+	}
+	if params["before"] != nil || params["after"] != nil {
+		t.Errorf("oversize values were not dropped: %#v", params)
+	}
+	if params["beforeTruncated"] != true || params["afterTruncated"] != true {
+		t.Errorf("truncation flags not set: %#v", params)
+	}
+}
+
+func TestIntegrationProcessPayloadSmallEnough(t *testing.T) {
+	p, server := testPayloadProcess(t, 4096)
+	defer p.Close()
+
+	change := &resourceChange{
+		Address: "aws_instance.small",
+		Action:  "update",
+		Before:  "a",
+		After:   "b",
+	}
+	if err := p.Call(hookPostPlanResource, change, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req := <-server.requests
+	params := req.Params.(map[string]interface{})
+	if params["before"] != "a" || params["after"] != "b" {
+		t.Errorf("payload within the limit was modified: %#v", params)
+	}
+}
+
+func TestIntegrationProcessPayloadSpill(t *testing.T) {
+	p, server := testPayloadProcess(t, 512, capabilityPayloadFile)
+	defer p.Close()
+
+	bigAfter := strings.Repeat("y", 4096)
+	change := &resourceChange{
+		Address: "aws_instance.big",
+		Action:  "update",
+		After:   bigAfter,
+	}
+	if err := p.Call(hookPostPlanResource, change, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req := <-server.requests
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		t.Fatalf("params are %T; want an object", req.Params)
+	}
+	file, _ := params["payloadFile"].(string)
+	if file == "" {
+		t.Fatalf("no payloadFile in params: %#v", params)
+	}
+	if got := params["originalBytes"].(float64); got < 4096 {
+		t.Errorf("implausible originalBytes %v", got)
+	}
+
+	// The file only lives for the duration of the call; reading its
+	// contents in time is covered by TestIntegrationProcessPayloadSpillContents.
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("spill file %s still exists after the call completed", file)
+	}
+}
+
+func TestIntegrationProcessPayloadSpillContents(t *testing.T) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+
+	bigAfter := strings.Repeat("y", 4096)
+	contents := make(chan []byte, 1)
+
+	// This server reads the spilled file while handling the request,
+	// before responding, as a real integration must.
+	go func() {
+		scanner := bufio.NewScanner(toServerR)
+		for scanner.Scan() {
+			var req rpcRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				continue
+			}
+			if req.ID == 0 {
+				continue
+			}
+			if params, ok := req.Params.(map[string]interface{}); ok {
+				if file, ok := params["payloadFile"].(string); ok {
+					buf, err := ioutil.ReadFile(file)
+					if err == nil {
+						contents <- buf
+					}
+				}
+			}
+			resp := rpcResponse{
+				Version: jsonrpcVersion,
+				ID:      req.ID,
+				Result:  json.RawMessage(`{}`),
+			}
+			buf, _ := json.Marshal(&resp)
+			fmt.Fprintf(fromServerW, "%s\n", buf)
+		}
+		fromServerW.Close()
+	}()
+
+	p := &IntegrationProcess{Name: "test", maxPayload: 512}
+	p.capabilities = map[string]bool{capabilityPayloadFile: true}
+	p.initTransport(toServerW, fromServerR, 1)
+	defer p.Close()
+
+	change := &resourceChange{
+		Address: "aws_instance.big",
+		Action:  "update",
+		After:   bigAfter,
+	}
+	if err := p.Call(hookPostPlanResource, change, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var spilled resourceChange
+	if err := json.Unmarshal(<-contents, &spilled); err != nil {
+		t.Fatalf("invalid spilled payload: %s", err)
+	}
+	if spilled.After != bigAfter {
+		t.Errorf("spilled payload doesn't carry the full after value")
+	}
+}
@@ -0,0 +1,572 @@
+package integrations
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// shutdownGrace is the default drain timeout: how long Close waits for
+// in-flight requests to finish and for the integration to exit after being
+// asked to shut down, when the integration block doesn't set
+// "drain_timeout_seconds".
+const shutdownGrace = 5 * time.Second
+
+// defaultConcurrency is the maximum number of in-flight requests per
+// integration when the integration block doesn't set "concurrency".
+const defaultConcurrency = 4
+
+// IntegrationProcess represents a single running integration program, with
+// which we communicate over newline-delimited JSON-RPC on its stdin/stdout.
+//
+// Multiple requests may be in flight at once, up to the concurrency limit
+// from the integration's configuration. Responses are matched to requests
+// by their JSON-RPC id, so integrations may answer out of order.
+type IntegrationProcess struct {
+	// Name is the label of the integration block that configured this
+	// process.
+	Name string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	// writeMu serializes writes to stdin so that concurrent requests
+	// can't interleave partial messages. framedWrites, guarded by the
+	// same mutex, switches outgoing messages to Content-Length framing
+	// once that has been negotiated at initialize.
+	writeMu      sync.Mutex
+	framedWrites bool
+
+	nextID int64
+
+	// slots bounds the number of in-flight requests. Callers acquire a
+	// slot before sending, so a burst of hook calls queues here rather
+	// than overwhelming the integration.
+	slots chan struct{}
+
+	// pending tracks the response channel for each in-flight request id.
+	// The reader goroutine delivers each response to its caller, and
+	// closes all remaining channels if the integration's output ends.
+	pendingMu sync.Mutex
+	pending   map[int64]chan *rpcResponse
+	readErr   error
+	closed    bool
+	closing   bool
+
+	capabilities map[string]bool
+
+	// functions are the names of the evaluation functions the integration
+	// declared at initialize, sorted. funcMu and funcResults cache their
+	// results by input fingerprint; declared functions must be pure, so a
+	// cached answer is as good as asking again.
+	funcMu      sync.Mutex
+	functions   []string
+	funcResults map[string]cty.Value
+
+	// shutdownTimeout bounds how long Close waits for in-flight requests
+	// to drain and for the process to exit. Zero means the default.
+	shutdownTimeout time.Duration
+
+	// rec, if non-nil, records every request/response exchange to disk.
+	rec *recorder
+
+	// metrics accumulates call counts and latencies for the end-of-run
+	// summary.
+	metrics metrics
+
+	// cache, if non-nil, remembers hook results by input fingerprint so
+	// identical calls can be answered without consulting the integration
+	// again. Set only when the integration enables cache_results.
+	cache *resultCache
+
+	// maxPayload bounds the encoded size of a single call's params. Calls
+	// over the limit are truncated or spilled to a file, per the
+	// capabilities negotiated at initialize. Zero disables the limit.
+	maxPayload int64
+
+	// source and version identify the installed integration release for
+	// source-addressed integrations; both are empty for command-based
+	// ones. enforceAtApply records the block's enforce_at_apply setting.
+	// These are kept so the integration set can be recorded in a saved
+	// plan file.
+	source         string
+	version        string
+	enforceAtApply bool
+}
+
+// StartIntegration launches the program configured by the given integration
+// block and performs the "initialize" handshake with it, returning a process
+// that is ready to receive hook calls.
+//
+// The given UI output receives the integration's stderr output if the
+// integration is configured with stderr = "ui"; it may be nil, in which
+// case that output goes to the logs instead.
+func StartIntegration(config *configs.Integration, run *RunContext, ui terraform.UIOutput) (*IntegrationProcess, error) {
+	command, selectedVersion, err := resolveCommand(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = integrationEnv(config.Env)
+	cmd.Dir = config.Workdir
+
+	if err := applySandbox(cmd, config.Sandbox); err != nil {
+		return nil, fmt.Errorf("can't sandbox integration %q: %s", config.Name, err)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin for integration %q: %s", config.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout for integration %q: %s", config.Name, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stderr for integration %q: %s", config.Name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start integration %q: %s", config.Name, err)
+	}
+	log.Printf("[DEBUG] integrations: started integration %q (pid %d)", config.Name, cmd.Process.Pid)
+
+	if err := applySandboxLimits(cmd.Process.Pid, config.Sandbox); err != nil {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("can't sandbox integration %q: %s", config.Name, err)
+	}
+
+	// Anything the integration prints to stderr is consumed according to
+	// the integration's configured stderr mode, with the logs as the
+	// default destination.
+	go forwardStderr(config, ui, stderr)
+
+	concurrency := config.Concurrency
+	if concurrency == 0 {
+		concurrency = defaultConcurrency
+	}
+
+	rec, err := newRecorderFromEnv()
+	if err != nil {
+		cmd.Process.Kill()
+		return nil, err
+	}
+
+	p := &IntegrationProcess{
+		Name:           config.Name,
+		cmd:            cmd,
+		rec:            rec,
+		source:         config.Source,
+		version:        selectedVersion,
+		enforceAtApply: config.EnforceAtApply,
+	}
+	if config.CacheResults {
+		p.cache = newResultCache()
+	}
+	p.maxPayload = int64(config.MaxPayloadBytes)
+	if p.maxPayload == 0 {
+		p.maxPayload = defaultMaxPayloadBytes
+	}
+	if config.DrainTimeoutSeconds > 0 {
+		p.shutdownTimeout = time.Duration(config.DrainTimeoutSeconds) * time.Second
+	}
+	p.initTransport(stdin, stdout, concurrency)
+
+	if err := p.initialize(run); err != nil {
+		p.cmd.Process.Kill()
+		return nil, fmt.Errorf("failed to initialize integration %q: %s", config.Name, err)
+	}
+
+	return p, nil
+}
+
+// baselineEnvVars are the variables an integration process receives from
+// Terraform's own environment even though environments aren't inherited:
+// without these most programs can't run at all. Everything else must be set
+// explicitly with the integration block's "env" argument, so that e.g.
+// cloud credentials in Terraform's environment don't leak into every
+// integration binary.
+var baselineEnvVars = []string{
+	"PATH",
+	"HOME",
+	"USER",
+	"LANG",
+	"TMPDIR",
+	"SystemRoot", // required for many processes on Windows
+	"TEMP",
+	"TMP",
+}
+
+// integrationEnv builds the environment for an integration process from the
+// baseline variables plus the explicitly-configured ones.
+func integrationEnv(config map[string]string) []string {
+	env := make([]string, 0, len(baselineEnvVars)+len(config))
+	for _, name := range baselineEnvVars {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names) // deterministic order, for easier debugging
+	for _, name := range names {
+		env = append(env, name+"="+config[name])
+	}
+	return env
+}
+
+// initTransport prepares the request bookkeeping and starts the goroutine
+// that reads responses from the given stream. It is separate from
+// StartIntegration so that tests can drive the protocol over in-memory
+// pipes.
+func (p *IntegrationProcess) initTransport(stdin io.WriteCloser, stdout io.Reader, concurrency int) {
+	p.stdin = stdin
+	p.slots = make(chan struct{}, concurrency)
+	p.pending = make(map[int64]chan *rpcResponse)
+	go p.readResponses(stdout)
+}
+
+// readResponses owns the integration's output stream, decoding each response
+// message and delivering it to whichever caller is waiting on its request
+// id. Messages may be newline-delimited or Content-Length framed; the
+// framing of each one is detected from its first byte.
+func (p *IntegrationProcess) readResponses(stdout io.Reader) {
+	br := bufio.NewReader(stdout)
+	var transportErr error
+	for {
+		msg, err := readMessage(br)
+		if err != nil {
+			if err != io.EOF {
+				transportErr = fmt.Errorf("failed to read response from integration %q: %s", p.Name, err)
+			}
+			break
+		}
+		var resp rpcResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			log.Printf("[WARN] integrations: invalid response message from integration %q: %s", p.Name, err)
+			continue
+		}
+
+		p.pendingMu.Lock()
+		ch, ok := p.pending[resp.ID]
+		if ok {
+			delete(p.pending, resp.ID)
+		}
+		p.pendingMu.Unlock()
+
+		if !ok {
+			log.Printf("[WARN] integrations: integration %q sent a response for unknown request id %d", p.Name, resp.ID)
+			continue
+		}
+		ch <- &resp
+	}
+
+	// The stream has ended, either because the integration exited or
+	// because its output failed. Any callers still waiting get an error.
+	p.pendingMu.Lock()
+	p.readErr = transportErr
+	if p.readErr == nil {
+		p.readErr = fmt.Errorf("integration %q closed its output stream", p.Name)
+	}
+	for id, ch := range p.pending {
+		delete(p.pending, id)
+		close(ch)
+	}
+	p.closed = true
+	p.pendingMu.Unlock()
+}
+
+// initialize performs the handshake that begins the protocol, learning which
+// capabilities the integration supports.
+func (p *IntegrationProcess) initialize(run *RunContext) error {
+	var result initializeResult
+	err := p.Call("initialize", &initializeParams{
+		RunContext:      run,
+		MaxPayloadBytes: p.maxPayload,
+	}, &result)
+	if err != nil {
+		return err
+	}
+
+	p.capabilities = make(map[string]bool, len(result.Capabilities))
+	for _, name := range result.Capabilities {
+		p.capabilities[name] = true
+	}
+	log.Printf("[DEBUG] integrations: integration %q declared capabilities %v", p.Name, result.Capabilities)
+
+	if len(result.Functions) > 0 {
+		p.functions = append([]string(nil), result.Functions...)
+		sort.Strings(p.functions)
+		log.Printf("[DEBUG] integrations: integration %q declared functions %v", p.Name, p.functions)
+	}
+
+	// Once the integration has asked for Content-Length framing, all of
+	// our subsequent messages to it use that instead of line delimiting.
+	if p.capabilities[capabilityContentLengthFraming] {
+		p.writeMu.Lock()
+		p.framedWrites = true
+		p.writeMu.Unlock()
+		log.Printf("[DEBUG] integrations: using Content-Length framing for integration %q", p.Name)
+	}
+
+	return nil
+}
+
+// Capable returns true if the integration declared the given capability
+// during the initialize handshake.
+func (p *IntegrationProcess) Capable(name string) bool {
+	return p.capabilities[name]
+}
+
+// Metrics returns a snapshot of the call statistics accumulated so far for
+// this integration.
+func (p *IntegrationProcess) Metrics() MetricsSummary {
+	return p.metrics.summary(p.Name)
+}
+
+// Call performs a single JSON-RPC request against the integration, blocking
+// until it responds, and decodes the response body into result if result
+// is non-nil.
+//
+// Up to the configured concurrency limit, calls from separate goroutines
+// proceed in parallel; beyond the limit, callers queue until a slot frees
+// up.
+func (p *IntegrationProcess) Call(method string, params, result interface{}) error {
+	start := time.Now()
+	raw, err := p.call(method, params)
+	p.metrics.record(time.Since(start), err)
+	if p.rec != nil {
+		if recErr := p.rec.record(p.Name, method, params, raw, err); recErr != nil {
+			log.Printf("[WARN] integrations: failed to record %q call to integration %q: %s", method, p.Name, recErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, result); err != nil {
+			return fmt.Errorf("invalid %q result from integration %q: %s", method, p.Name, err)
+		}
+	}
+	return nil
+}
+
+// call sends the request and returns the raw result payload.
+func (p *IntegrationProcess) call(method string, params interface{}) (json.RawMessage, error) {
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	id := atomic.AddInt64(&p.nextID, 1)
+	req := rpcRequest{
+		Version: jsonrpcVersion,
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}
+	buf, err := json.Marshal(&req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %q request: %s", method, err)
+	}
+
+	// If the request exceeds the payload size limit then replace its
+	// params with a reduced form, spilling the original to a file for
+	// integrations that negotiated that at initialize.
+	var spillFile string
+	if p.maxPayload > 0 && int64(len(buf)) > p.maxPayload {
+		rawParams, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %q request: %s", method, err)
+		}
+		reduced, file, err := p.limitPayload(method, rawParams)
+		if err != nil {
+			return nil, err
+		}
+		spillFile = file
+		if spillFile != "" {
+			defer os.Remove(spillFile)
+		}
+		req.Params = reduced
+		buf, err = json.Marshal(&req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %q request: %s", method, err)
+		}
+	}
+
+	respCh := make(chan *rpcResponse, 1)
+	p.pendingMu.Lock()
+	if p.closed || p.closing {
+		err := p.readErr
+		if err == nil {
+			err = fmt.Errorf("integration %q is shutting down", p.Name)
+		}
+		p.pendingMu.Unlock()
+		return nil, fmt.Errorf("can't send %q request: %s", method, err)
+	}
+	p.pending[id] = respCh
+	p.pendingMu.Unlock()
+
+	if err := p.writeMessage(buf); err != nil {
+		p.pendingMu.Lock()
+		delete(p.pending, id)
+		p.pendingMu.Unlock()
+		return nil, fmt.Errorf("failed to send %q request to integration %q: %s", method, p.Name, err)
+	}
+
+	resp, ok := <-respCh
+	if !ok {
+		p.pendingMu.Lock()
+		err := p.readErr
+		p.pendingMu.Unlock()
+		return nil, fmt.Errorf("failed to read response from integration %q: %s", p.Name, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("integration %q failed to handle %q: %s (code %d)", p.Name, method, resp.Error.Message, resp.Error.Code)
+	}
+
+	return resp.Result, nil
+}
+
+// Close shuts the integration down: it refuses any new requests, waits for
+// in-flight requests to drain, sends a "shutdown" request so the
+// integration can flush anything it has buffered, and then waits for the
+// process to exit, killing it if it outstays the drain timeout.
+//
+// A non-nil error describes anything unclean about the shutdown, including
+// a non-zero exit status; the caller reports it as a diagnostic.
+func (p *IntegrationProcess) Close() error {
+	timeout := p.shutdownTimeout
+	if timeout <= 0 {
+		timeout = shutdownGrace
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	p.pendingMu.Lock()
+	p.closing = true
+	alreadyDead := p.closed
+	p.pendingMu.Unlock()
+
+	var closeErr error
+	if !alreadyDead {
+		if err := p.drain(ctx); err != nil {
+			closeErr = err
+		}
+		if err := p.sendShutdown(ctx); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	p.stdin.Close()
+
+	if p.cmd != nil {
+		exitCh := make(chan error, 1)
+		go func() { exitCh <- p.cmd.Wait() }()
+
+		var waitErr error
+		select {
+		case waitErr = <-exitCh:
+		case <-ctx.Done():
+			p.cmd.Process.Kill()
+			waitErr = <-exitCh
+			if closeErr == nil {
+				closeErr = fmt.Errorf("integration %q didn't exit within %s of being asked to shut down, so it was killed", p.Name, timeout)
+			}
+		}
+		if exitErr, ok := waitErr.(*exec.ExitError); ok && closeErr == nil {
+			closeErr = fmt.Errorf("integration %q exited with %s", p.Name, exitErr.ProcessState)
+		}
+	}
+
+	log.Printf("[DEBUG] integrations: integration %q exited", p.Name)
+	return closeErr
+}
+
+// drain waits for all in-flight requests to complete, so the integration
+// has seen every notification before it's told to stop.
+func (p *IntegrationProcess) drain(ctx context.Context) error {
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		p.pendingMu.Lock()
+		inFlight := len(p.pending)
+		dead := p.closed
+		p.pendingMu.Unlock()
+		if inFlight == 0 || dead {
+			return nil
+		}
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for %d in-flight requests to integration %q", inFlight, p.Name)
+		}
+	}
+}
+
+// sendShutdown performs the "shutdown" request, giving the integration its
+// chance to flush buffered findings before its stdin closes. Unlike Call,
+// it bypasses the concurrency slots and the closing check, since it's part
+// of closing.
+func (p *IntegrationProcess) sendShutdown(ctx context.Context) error {
+	id := atomic.AddInt64(&p.nextID, 1)
+	req := rpcRequest{
+		Version: jsonrpcVersion,
+		ID:      id,
+		Method:  "shutdown",
+	}
+	buf, err := json.Marshal(&req)
+	if err != nil {
+		return fmt.Errorf("failed to encode shutdown request: %s", err)
+	}
+
+	respCh := make(chan *rpcResponse, 1)
+	p.pendingMu.Lock()
+	if p.closed {
+		p.pendingMu.Unlock()
+		return nil
+	}
+	p.pending[id] = respCh
+	p.pendingMu.Unlock()
+
+	if err := p.writeMessage(buf); err != nil {
+		p.pendingMu.Lock()
+		delete(p.pending, id)
+		p.pendingMu.Unlock()
+		return fmt.Errorf("failed to send shutdown request to integration %q: %s", p.Name, err)
+	}
+
+	select {
+	case resp, ok := <-respCh:
+		if !ok {
+			// The integration exited without answering, which is close
+			// enough to a shutdown acknowledgement.
+			return nil
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("integration %q failed to handle shutdown: %s (code %d)", p.Name, resp.Error.Message, resp.Error.Code)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("integration %q did not acknowledge the shutdown request within the drain timeout", p.Name)
+	}
+}
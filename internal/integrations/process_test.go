@@ -0,0 +1,144 @@
+package integrations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testIntegrationServer runs a fake integration on the given pipe ends,
+// answering every request with a result echoing the method name. It tracks
+// the maximum number of requests it has seen in flight at once.
+type testIntegrationServer struct {
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (s *testIntegrationServer) serve(t *testing.T, in io.Reader, out io.WriteCloser) {
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			t.Errorf("server: invalid request line: %s", err)
+			continue
+		}
+		if req.ID == 0 {
+			continue // notification, e.g. shutdown
+		}
+
+		n := atomic.AddInt32(&s.inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&s.maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&s.maxInFlight, max, n) {
+				break
+			}
+		}
+
+		wg.Add(1)
+		go func(id int64, method string) {
+			defer wg.Done()
+			body, _ := json.Marshal(map[string]string{"method": method})
+			resp := rpcResponse{
+				Version: jsonrpcVersion,
+				ID:      id,
+				Result:  body,
+			}
+			buf, _ := json.Marshal(&resp)
+			// Drop the in-flight count before responding: once the client
+			// sees the response it may immediately send another request,
+			// and that mustn't race our bookkeeping.
+			atomic.AddInt32(&s.inFlight, -1)
+			writeMu.Lock()
+			fmt.Fprintf(out, "%s\n", buf)
+			writeMu.Unlock()
+		}(req.ID, req.Method)
+	}
+	wg.Wait()
+	out.Close()
+}
+
+func testProcess(t *testing.T, concurrency int) (*IntegrationProcess, *testIntegrationServer) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+
+	server := &testIntegrationServer{}
+	go server.serve(t, toServerR, fromServerW)
+
+	p := &IntegrationProcess{Name: "test"}
+	p.initTransport(toServerW, fromServerR, concurrency)
+	return p, server
+}
+
+func TestIntegrationProcessCall(t *testing.T) {
+	p, _ := testProcess(t, 1)
+	defer p.Close()
+
+	var result map[string]string
+	if err := p.Call("post-plan-resource", nil, &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := result["method"], "post-plan-resource"; got != want {
+		t.Errorf("wrong result %q; want %q", got, want)
+	}
+}
+
+func TestIntegrationProcessCallConcurrent(t *testing.T) {
+	const concurrency = 3
+	p, server := testProcess(t, concurrency)
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var result map[string]string
+			method := fmt.Sprintf("hook-%d", i)
+			if err := p.Call(method, nil, &result); err != nil {
+				t.Errorf("call %d: unexpected error: %s", i, err)
+				return
+			}
+			if got := result["method"]; got != method {
+				t.Errorf("call %d: wrong result %q", i, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if max := atomic.LoadInt32(&server.maxInFlight); max > concurrency {
+		t.Errorf("server saw %d requests in flight; concurrency limit is %d", max, concurrency)
+	}
+}
+
+func TestIntegrationProcessClose(t *testing.T) {
+	p, _ := testProcess(t, 2)
+
+	if err := p.Call("post-plan-resource", nil, nil); err != nil {
+		t.Fatalf("unexpected call error: %s", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("unexpected close error: %s", err)
+	}
+
+	// Once shutdown has begun, new calls must be refused rather than
+	// racing the shutdown request.
+	if err := p.Call("post-plan-resource", nil, nil); err == nil {
+		t.Fatal("call succeeded after Close")
+	}
+}
+
+func TestIntegrationProcessCallAfterExit(t *testing.T) {
+	p, _ := testProcess(t, 1)
+	p.Close()
+
+	err := p.Call("post-plan-resource", nil, nil)
+	if err == nil {
+		t.Fatal("expected error after integration exit, got none")
+	}
+}
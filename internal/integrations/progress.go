@@ -0,0 +1,109 @@
+package integrations
+
+import (
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// defaultProgressInterval is how often an in-progress apply emits a
+// "resource-progress" event for a resource, matching the cadence of the
+// CLI's own "Still creating..." messages.
+const defaultProgressInterval = 10 * time.Second
+
+// SetProgressInterval changes how often "resource-progress" events are
+// emitted for long-running applies. It must be called before the apply
+// walk begins.
+func (h *IntegrationHook) SetProgressInterval(interval time.Duration) {
+	h.progressInterval = interval
+}
+
+// startProgress begins emitting periodic "resource-progress" events for
+// the given resource instance, until stopProgress is called for it. The
+// events are notification-only: an integration can't halt an apply that
+// is already underway, only observe it.
+func (h *IntegrationHook) startProgress(addr string, action string) {
+	if len(h.processes) == 0 {
+		return
+	}
+
+	interval := h.progressInterval
+	if interval == 0 {
+		interval = defaultProgressInterval
+	}
+	stop := make(chan struct{})
+
+	h.mu.Lock()
+	if h.progress == nil {
+		h.progress = make(map[string]chan struct{})
+	}
+	if _, exists := h.progress[addr]; exists {
+		// Shouldn't happen: the apply walk visits each instance once.
+		h.mu.Unlock()
+		close(stop)
+		return
+	}
+	h.progress[addr] = stop
+	h.mu.Unlock()
+
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				h.postProgress(resourceProgressParams{
+					Address:        addr,
+					Action:         action,
+					ElapsedSeconds: time.Since(start).Seconds(),
+					Run:            h.run,
+				})
+			}
+		}
+	}()
+}
+
+// stopProgress ends the periodic progress events for the given resource
+// instance, if any are running.
+func (h *IntegrationHook) stopProgress(addr string) {
+	h.mu.Lock()
+	stop, ok := h.progress[addr]
+	if ok {
+		delete(h.progress, addr)
+	}
+	h.mu.Unlock()
+	if ok {
+		close(stop)
+	}
+}
+
+// ProvisionOutput forwards provisioner output lines through the same
+// "resource-progress" hook, so an integration following a long-running
+// operation sees what the provisioner is printing too.
+func (h *IntegrationHook) ProvisionOutput(addr addrs.AbsResourceInstance, typeName string, line string) {
+	h.postProgress(resourceProgressParams{
+		Address:     addr.String(),
+		Provisioner: typeName,
+		Message:     line,
+		Run:         h.run,
+	})
+}
+
+// postProgress delivers one notification-only progress event to every
+// integration. Failures are logged and don't affect the operation.
+func (h *IntegrationHook) postProgress(params resourceProgressParams) {
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hookResourceProgress, params.Address)
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hookResourceProgress, &params, &result)
+		endHookSpan(span, &result, err)
+		if err != nil {
+			log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hookResourceProgress, p.Name, err)
+		}
+	}
+}
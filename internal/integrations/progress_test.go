@@ -0,0 +1,137 @@
+package integrations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// recordingProcess is like testProcess but remembers the method and
+// params of every request it serves.
+func recordingProcess(t *testing.T) (*IntegrationProcess, func() []rpcRequest) {
+	toServerR, toServerW := io.Pipe()
+	fromServerR, fromServerW := io.Pipe()
+
+	var mu sync.Mutex
+	var requests []rpcRequest
+
+	go func() {
+		scanner := bufio.NewScanner(toServerR)
+		for scanner.Scan() {
+			var req rpcRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				t.Errorf("server: invalid request line: %s", err)
+				continue
+			}
+			if req.ID == 0 {
+				continue
+			}
+			mu.Lock()
+			requests = append(requests, req)
+			mu.Unlock()
+
+			resp := rpcResponse{
+				Version: jsonrpcVersion,
+				ID:      req.ID,
+				Result:  json.RawMessage(`{}`),
+			}
+			buf, _ := json.Marshal(&resp)
+			fmt.Fprintf(fromServerW, "%s\n", buf)
+		}
+		fromServerW.Close()
+	}()
+
+	p := &IntegrationProcess{Name: "test"}
+	p.initTransport(toServerW, fromServerR, 1)
+	return p, func() []rpcRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]rpcRequest(nil), requests...)
+	}
+}
+
+func TestIntegrationHookResourceProgress(t *testing.T) {
+	p, recorded := recordingProcess(t)
+	defer p.Close()
+
+	h := NewIntegrationHook([]*IntegrationProcess{p}, nil)
+	h.SetProgressInterval(5 * time.Millisecond)
+
+	h.startProgress("aws_instance.web", "create")
+	deadline := time.After(2 * time.Second)
+	for {
+		n := 0
+		for _, req := range recorded() {
+			if req.Method == hookResourceProgress {
+				n++
+			}
+		}
+		if n >= 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("no periodic progress events after two seconds")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	h.stopProgress("aws_instance.web")
+
+	decodeParams := func(req rpcRequest) resourceProgressParams {
+		t.Helper()
+		buf, err := json.Marshal(req.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var params resourceProgressParams
+		if err := json.Unmarshal(buf, &params); err != nil {
+			t.Fatalf("invalid progress params: %s", err)
+		}
+		return params
+	}
+
+	var params resourceProgressParams
+	for _, req := range recorded() {
+		if req.Method == hookResourceProgress {
+			params = decodeParams(req)
+			break
+		}
+	}
+	if params.Address != "aws_instance.web" || params.Action != "create" {
+		t.Errorf("wrong progress params %+v", params)
+	}
+	if params.ElapsedSeconds <= 0 {
+		t.Errorf("progress event has no elapsed time")
+	}
+
+	// Stopping must stop the events.
+	before := len(recorded())
+	time.Sleep(25 * time.Millisecond)
+	if after := len(recorded()); after != before {
+		t.Errorf("progress events continued after stopProgress: %d new", after-before)
+	}
+
+	// Provisioner output lines go through the same hook.
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "aws_instance",
+		Name: "web",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	h.ProvisionOutput(addr, "remote-exec", "Installing foo...")
+
+	reqs := recorded()
+	last := reqs[len(reqs)-1]
+	if last.Method != hookResourceProgress {
+		t.Fatalf("provisioner output used method %q", last.Method)
+	}
+	params = decodeParams(last)
+	if params.Provisioner != "remote-exec" || params.Message != "Installing foo..." {
+		t.Errorf("wrong provisioner output params %+v", params)
+	}
+}
@@ -0,0 +1,610 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/plans"
+)
+
+// jsonrpcVersion is the protocol version we place in every message, per the
+// JSON-RPC 2.0 specification.
+const jsonrpcVersion = "2.0"
+
+// Names of the hook methods we can invoke on an integration. Each hook call
+// is a JSON-RPC request whose method is one of these names.
+const (
+	hookPrePlanResource     = "pre-plan-resource"
+	hookPostPlanResource    = "post-plan-resource"
+	hookPostPlanResources   = "post-plan-resources"
+	hookPreDestroyResource  = "pre-destroy-resource"
+	hookPreRefreshResource  = "pre-refresh-resource"
+	hookPostRefreshResource = "post-refresh-resource"
+	hookPreApplyResource    = "pre-apply-resource"
+	hookPostApplyResource   = "post-apply-resource"
+	hookPlanStageBegin      = "plan-stage-begin"
+	hookPostPlanDeferred    = "post-plan-deferred"
+	hookPreRunbookStep      = "pre-runbook-step"
+	hookPostRunbookStep     = "post-runbook-step"
+	hookPreInvokeAction     = "pre-invoke-action"
+	hookPostInvokeAction    = "post-invoke-action"
+	hookPostPlanAction      = "post-plan-action"
+	hookResourceProgress    = "resource-progress"
+	hookTestStepStarted     = "test-step-started"
+	hookTestStepFinished    = "test-step-finished"
+	hookTestAssertionFailed = "test-assertion-failed"
+	hookPreStateMutation    = "pre-state-mutation"
+	hookPostStateMutation   = "post-state-mutation"
+)
+
+// capabilityBatchedPlanResources is the capability an integration declares
+// during the initialize handshake to receive planned resource changes in
+// "post-plan-resources" batches, rather than one "post-plan-resource" call
+// per resource instance.
+const capabilityBatchedPlanResources = "post-plan-resources"
+
+// capabilityDestroyProtection is the capability an integration declares to
+// receive a "pre-destroy-resource" call for each planned destroy or
+// replacement, giving it the chance to block just that destruction.
+const capabilityDestroyProtection = "pre-destroy-resource"
+
+// capabilityPayloadFile is the capability an integration declares to
+// receive oversized hook payloads spilled to a temporary file rather than
+// truncated. Terraform announces its payload size limit in the initialize
+// params; an integration that declares this capability gets spilledParams
+// in place of any params whose encoding exceeds the limit, and must read
+// the file before responding, since Terraform deletes it afterwards.
+const capabilityPayloadFile = "payload-file"
+
+type rpcRequest struct {
+	Version string      `json:"jsonrpc"`
+	ID      int64       `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// RunContext describes the Terraform run that an integration is observing.
+// It is sent in the initialize params and attached to every hook call so
+// that integrations can correlate events with a particular run and apply
+// e.g. workspace-specific policy.
+type RunContext struct {
+	// TerraformVersion is the version of the Terraform CLI performing
+	// the run.
+	TerraformVersion string `json:"terraformVersion"`
+
+	// Command is the CLI operation being performed, e.g. "plan" or
+	// "apply".
+	Command string `json:"command,omitempty"`
+
+	// Workspace is the name of the active workspace.
+	Workspace string `json:"workspace,omitempty"`
+
+	// BackendType is the type of the backend the run uses for state
+	// storage and operations.
+	BackendType string `json:"backendType,omitempty"`
+
+	// ConfigRoot is the path to the root module directory of the
+	// configuration being run.
+	ConfigRoot string `json:"configRoot,omitempty"`
+
+	// TestSuite is the name of the test suite being run, for runs that are
+	// part of a "terraform test" execution.
+	TestSuite string `json:"testSuite,omitempty"`
+
+	// RunID is an opaque identifier that is stable for the duration of a
+	// single Terraform operation, shared by all events from that
+	// operation.
+	RunID string `json:"runId"`
+}
+
+type initializeParams struct {
+	*RunContext
+
+	// MaxPayloadBytes announces the size limit Terraform will apply to
+	// the encoded params of each subsequent call, so that the integration
+	// can decide whether to declare the "payload-file" capability.
+	MaxPayloadBytes int64 `json:"maxPayloadBytes"`
+}
+
+type initializeResult struct {
+	Capabilities []string `json:"capabilities"`
+
+	// Functions are the names of the evaluation functions the integration
+	// provides, each callable with a "call-function" request. See
+	// functions.go for how they are exposed to configuration expressions.
+	Functions []string `json:"functions,omitempty"`
+}
+
+// spilledParams replaces the params of a call whose encoding exceeds the
+// integration's payload size limit, for integrations that declared the
+// "payload-file" capability. The original params are in the named file,
+// which exists only until the call returns.
+type spilledParams struct {
+	PayloadFile   string `json:"payloadFile"`
+	OriginalBytes int64  `json:"originalBytes"`
+}
+
+// HookResult is the result payload an integration returns from a hook call.
+type HookResult struct {
+	// Status is either "ok", indicating that the integration has no
+	// objection to whatever the hook described, or "failed".
+	Status string `json:"status"`
+
+	// Action is either "continue" (the default, also implied by an empty
+	// string) or "halt", which asks Terraform to stop the operation.
+	Action string `json:"action"`
+
+	// Message is an optional human-readable elaboration on the status,
+	// which we include in the logs.
+	Message string `json:"message"`
+
+	// RequiresOverride is set by destroy-protection integrations to
+	// indicate that the described destruction may proceed only if the
+	// user has explicitly set the destroy override flag for this run.
+	RequiresOverride bool `json:"requiresOverride"`
+
+	// Annotations are remarks to show alongside the rendered plan, next
+	// to the resource diffs they are about.
+	Annotations []Annotation `json:"annotations,omitempty"`
+
+	// Findings are structured remarks, optionally pointing at specific
+	// attributes within a resource, which Terraform reports to the user
+	// as diagnostics.
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// resourceChange is the wire representation of a change to a single resource
+// instance, used in both planning and applying hook payloads.
+type resourceChange struct {
+	Address  string      `json:"address"`
+	Action   string      `json:"action"`
+	Provider string      `json:"provider,omitempty"`
+	Before   interface{} `json:"before"`
+	After    interface{} `json:"after"`
+
+	// BeforeSensitivePaths and AfterSensitivePaths list the locations of
+	// sensitive values that were redacted from Before and After, as JSON
+	// pointers relative to those values.
+	BeforeSensitivePaths []string `json:"beforeSensitivePaths,omitempty"`
+	AfterSensitivePaths  []string `json:"afterSensitivePaths,omitempty"`
+
+	// BeforeTruncated and AfterTruncated are set when the corresponding
+	// value was dropped to keep the call's params within the integration's
+	// payload size limit. Integrations that need the full values can
+	// declare the "payload-file" capability instead.
+	BeforeTruncated bool `json:"beforeTruncated,omitempty"`
+	AfterTruncated  bool `json:"afterTruncated,omitempty"`
+
+	// Config is the resource's evaluated configuration value, where
+	// available. Only the config-aware hook variants populate it.
+	Config interface{} `json:"config,omitempty"`
+
+	// Schema is a digest of the provider's schema for the resource type,
+	// where available, so that integrations don't need to guess attribute
+	// semantics from the raw values.
+	Schema *schemaDigest `json:"schema,omitempty"`
+
+	// Correlation is an opaque token shared by the pre- and post- halves
+	// of one resource operation, so integrations can pair the two events
+	// without re-deriving keys from addresses. Distinct phases (plan,
+	// apply, refresh) of the same resource get distinct tokens.
+	Correlation string `json:"correlation,omitempty"`
+
+	// Deposed identifies which deposed object of the instance an apply
+	// event concerns; empty means the current object. The destroy half of
+	// a create-before-destroy replacement acts on a deposed object.
+	Deposed string `json:"deposed,omitempty"`
+
+	// Replace marks an apply event that is part of a replacement, so an
+	// integration can connect the create and destroy halves instead of
+	// treating them as unrelated changes. CreateBeforeDestroy gives the
+	// ordering, and ReplaceRequiredBy lists the attribute paths whose
+	// changes required the replacement, as JSON pointers like the
+	// sensitive path lists.
+	Replace             bool     `json:"replace,omitempty"`
+	CreateBeforeDestroy bool     `json:"create_before_destroy,omitempty"`
+	ReplaceRequiredBy   []string `json:"replace_required_by,omitempty"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// resourceChangesParams is the params payload for the batched
+// "post-plan-resources" hook.
+type resourceChangesParams struct {
+	Changes []resourceChange `json:"changes"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// Annotation is a remark an integration attaches to a planned resource
+// change, for display alongside the rendered diff.
+type Annotation struct {
+	// Integration is the name of the integration that produced the
+	// annotation. It's filled in on our side rather than sent on the wire.
+	Integration string `json:"integration"`
+
+	// Address names the resource instance the annotation is about. It may
+	// be omitted in a response to a single-resource hook, where the
+	// subject is implied.
+	Address string `json:"address,omitempty"`
+
+	// Message is a short human-oriented remark.
+	Message string `json:"message"`
+
+	// Severity is "error", "warning", or "info". An unrecognized or empty
+	// severity is treated as "info".
+	Severity string `json:"severity,omitempty"`
+
+	// URL optionally links to documentation about the remark.
+	URL string `json:"url,omitempty"`
+}
+
+// schemaDigest is a compact summary of a provider's schema for one resource
+// type, included in hook payloads so that integrations can tell which
+// attributes are computed or sensitive without consulting the provider
+// themselves. Only top-level attributes are described; nested blocks are
+// omitted to keep payloads small.
+type schemaDigest struct {
+	// Provider is the fully-qualified provider address, e.g.
+	// "registry.terraform.io/hashicorp/aws".
+	Provider string `json:"provider"`
+
+	// Version is the provider's schema version for the resource type.
+	Version uint64 `json:"version"`
+
+	Attributes map[string]schemaAttribute `json:"attributes"`
+}
+
+type schemaAttribute struct {
+	Computed  bool `json:"computed,omitempty"`
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// Finding is a structured remark an integration returns from a hook call.
+// Unlike the single status/message pair, a response can carry any number of
+// findings, each with its own severity and optionally pointing at a
+// specific attribute within the resource. Terraform converts findings into
+// diagnostics and shows them with the rest of the operation's output.
+type Finding struct {
+	// Integration is the name of the integration that reported the
+	// finding. It's filled in on our side rather than sent on the wire.
+	Integration string `json:"integration,omitempty"`
+
+	// Address names the resource instance the finding is about. It may be
+	// omitted in a response to a single-resource hook, where the subject
+	// is implied.
+	Address string `json:"address,omitempty"`
+
+	// Path locates the attribute the finding concerns, as a sequence of
+	// attribute names and element indices or keys, e.g.
+	// ["ingress", 0, "cidr_blocks"]. An empty path means the finding is
+	// about the resource as a whole.
+	Path []interface{} `json:"path,omitempty"`
+
+	// Severity is "error" or "warning". An unrecognized or empty severity
+	// is treated as "warning".
+	Severity string `json:"severity,omitempty"`
+
+	// Summary is a short description of the problem, and Detail optionally
+	// elaborates on it.
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// ctyPath converts the finding's wire-format path into a cty.Path, treating
+// strings as attribute names and numbers as element indices. Steps of any
+// other type end the path early, since a partial path is still more useful
+// than none at all.
+func (f *Finding) ctyPath() cty.Path {
+	var path cty.Path
+	for _, raw := range f.Path {
+		switch step := raw.(type) {
+		case string:
+			path = append(path, cty.GetAttrStep{Name: step})
+		case float64:
+			path = append(path, cty.IndexStep{Key: cty.NumberIntVal(int64(step))})
+		default:
+			return path
+		}
+	}
+	return path
+}
+
+// planStageBeginParams is the payload for the "plan-stage-begin" hook,
+// sent once before the plan graph walk starts.
+type planStageBeginParams struct {
+	// Config is the configuration being planned, in the same JSON format
+	// that "terraform show -json" uses for its "configuration" property.
+	Config json.RawMessage `json:"config,omitempty"`
+
+	// Variables are the input variable values for the run, with sensitive
+	// variables redacted to null.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+
+	// Targets lists the resource targeting flags for the run, if any.
+	Targets []string `json:"targets,omitempty"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// deferredChangeParams is the payload for the "post-plan-deferred" hook,
+// describing a resource that Terraform could not fully plan.
+type deferredChangeParams struct {
+	Address string `json:"address"`
+
+	// Reason says why planning was deferred, e.g. that the resource's
+	// count depends on values not known until apply.
+	Reason string `json:"reason"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// resourceProgressParams is the payload for the "resource-progress"
+// hook: periodic status for a resource instance whose apply is still
+// underway, and the output lines of its provisioners.
+type resourceProgressParams struct {
+	Address string `json:"address"`
+
+	// Action is the change being applied, for the periodic elapsed-time
+	// events.
+	Action string `json:"action,omitempty"`
+
+	// ElapsedSeconds is how long the apply of this instance has been
+	// running, for the periodic events.
+	ElapsedSeconds float64 `json:"elapsed_seconds,omitempty"`
+
+	// Provisioner and Message carry one provisioner output line.
+	Provisioner string `json:"provisioner,omitempty"`
+	Message     string `json:"message,omitempty"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers when tracing is enabled.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// runbookStepParams is the payload for the runbook step and action
+// invocation hooks.
+type runbookStepParams struct {
+	Runbook string `json:"runbook"`
+	Step    string `json:"step"`
+
+	// Action is the action type the step invokes, for the invoke-action
+	// hooks and for steps that invoke an action.
+	Action string `json:"action,omitempty"`
+
+	// Config is the step's resolved configuration, encoded the same way
+	// as resource values.
+	Config interface{} `json:"config"`
+
+	// Result is the action's result, for "post-invoke-action" only.
+	Result interface{} `json:"result,omitempty"`
+
+	// Planned is the planned action data of a plan-only or dry-run step,
+	// for "post-plan-action" only. Such steps never invoke their action.
+	Planned interface{} `json:"planned,omitempty"`
+
+	// Error describes how the step or invocation failed, for the "post"
+	// hooks only.
+	Error string `json:"error,omitempty"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// testStepParams is the payload for the test step lifecycle hooks.
+type testStepParams struct {
+	Scenario string `json:"scenario"`
+	Step     string `json:"step"`
+
+	// Status is "passed", "failed", or "skipped", for the
+	// "test-step-finished" hook only.
+	Status string `json:"status,omitempty"`
+
+	// SkipReason explains a skipped step, when the scenario gave one.
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// DurationMS is how long the step ran, in milliseconds, across all
+	// attempts.
+	DurationMS int64 `json:"duration_ms,omitempty"`
+
+	// Attempts is how many times the step ran; more than 1 means a retry
+	// policy was exercised.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Error describes how the step or assertion failed.
+	Error string `json:"error,omitempty"`
+
+	// Message is the failed assertion's full message, for the
+	// "test-assertion-failed" hook only.
+	Message string `json:"message,omitempty"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// stateMutationParams is the payload for the state mutation hooks, sent
+// around the CLI commands that edit state directly rather than through a
+// plan and apply.
+type stateMutationParams struct {
+	// Operation is the CLI operation editing the state, e.g. "state mv"
+	// or "import".
+	Operation string `json:"operation"`
+
+	// Addresses are the resource and module addresses the operation
+	// affects.
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Error describes how the edit failed, for the "post-state-mutation"
+	// hook only; empty means it was persisted successfully.
+	Error string `json:"error,omitempty"`
+
+	// Run identifies the Terraform run this event belongs to.
+	Run *RunContext `json:"run,omitempty"`
+
+	// Trace carries W3C trace context headers ("traceparent" and friends)
+	// when tracing is enabled, so the integration can continue the trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// actionName returns the wire name for a plan action.
+func actionName(action plans.Action) string {
+	switch action {
+	case plans.NoOp:
+		return "no-op"
+	case plans.Create:
+		return "create"
+	case plans.Read:
+		return "read"
+	case plans.Update:
+		return "update"
+	case plans.Delete:
+		return "delete"
+	case plans.DeleteThenCreate, plans.CreateThenDelete:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// marshalCtyValue converts a cty value into plain Go values suitable for
+// encoding with encoding/json, for inclusion in hook payloads.
+//
+// Unknown values become null, because the protocol has no way to represent
+// them. Sensitive values are likewise redacted to null so that secrets never
+// leave the Terraform process.
+func marshalCtyValue(v cty.Value) interface{} {
+	return marshalCtyValueAt(v, "", nil)
+}
+
+// marshalCtyValueSensitive is like marshalCtyValue but additionally returns
+// the locations of the sensitive values it redacted, as JSON pointers
+// (RFC 6901) relative to the returned value, so that integrations can
+// reason about which attributes are sensitive without ever receiving the
+// secrets themselves.
+// pathPointer renders a cty path as a JSON pointer, the same encoding
+// the sensitive-path lists use.
+func pathPointer(path cty.Path) string {
+	var buf strings.Builder
+	for _, step := range path {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			buf.WriteString("/")
+			buf.WriteString(s.Name)
+		case cty.IndexStep:
+			switch s.Key.Type() {
+			case cty.Number:
+				i, _ := s.Key.AsBigFloat().Int64()
+				fmt.Fprintf(&buf, "/%d", i)
+			case cty.String:
+				buf.WriteString("/")
+				buf.WriteString(s.Key.AsString())
+			}
+		}
+	}
+	return buf.String()
+}
+
+func marshalCtyValueSensitive(v cty.Value) (interface{}, []string) {
+	var sensitive []string
+	ret := marshalCtyValueAt(v, "", &sensitive)
+	return ret, sensitive
+}
+
+func marshalCtyValueAt(v cty.Value, pointer string, sensitive *[]string) interface{} {
+	if v == cty.NilVal {
+		return nil
+	}
+	if v.IsMarked() {
+		if sensitive != nil {
+			*sensitive = append(*sensitive, pointer)
+		}
+		return nil
+	}
+	if !v.IsKnown() || v.IsNull() {
+		return nil
+	}
+
+	ty := v.Type()
+	switch {
+	case ty == cty.Bool:
+		return v.True()
+	case ty == cty.Number:
+		f, _ := v.AsBigFloat().Float64()
+		return f
+	case ty == cty.String:
+		return v.AsString()
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		ret := make([]interface{}, 0, v.LengthInt())
+		i := 0
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			ret = append(ret, marshalCtyValueAt(ev, fmt.Sprintf("%s/%d", pointer, i), sensitive))
+			i++
+		}
+		return ret
+	case ty.IsMapType() || ty.IsObjectType():
+		ret := make(map[string]interface{})
+		for it := v.ElementIterator(); it.Next(); {
+			kv, ev := it.Element()
+			k := kv.AsString()
+			ret[k] = marshalCtyValueAt(ev, pointer+"/"+escapePointerSegment(k), sensitive)
+		}
+		return ret
+	default:
+		// No reasonable JSON representation, e.g. for capsule types.
+		return nil
+	}
+}
+
+// escapePointerSegment escapes one JSON pointer reference token per
+// RFC 6901, section 3.
+func escapePointerSegment(s string) string {
+	s = strings.Replace(s, "~", "~0", -1)
+	s = strings.Replace(s, "/", "~1", -1)
+	return s
+}
@@ -0,0 +1,116 @@
+package integrations
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMarshalCtyValue(t *testing.T) {
+	tests := []struct {
+		Input cty.Value
+		Want  interface{}
+	}{
+		{
+			cty.StringVal("hello"),
+			"hello",
+		},
+		{
+			cty.NumberIntVal(5),
+			float64(5),
+		},
+		{
+			cty.True,
+			true,
+		},
+		{
+			cty.NullVal(cty.String),
+			nil,
+		},
+		{
+			cty.UnknownVal(cty.String),
+			nil,
+		},
+		{
+			cty.StringVal("secret").Mark("sensitive"),
+			nil,
+		},
+		{
+			cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			[]interface{}{"a", "b"},
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"name":  cty.StringVal("web"),
+				"count": cty.NumberIntVal(2),
+				"token": cty.StringVal("secret").Mark("sensitive"),
+			}),
+			map[string]interface{}{
+				"name":  "web",
+				"count": float64(2),
+				"token": nil,
+			},
+		},
+		{
+			cty.EmptyObjectVal,
+			map[string]interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Input.GoString(), func(t *testing.T) {
+			got := marshalCtyValue(test.Input)
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestMarshalCtyValueSensitive(t *testing.T) {
+	tests := []struct {
+		Input cty.Value
+		Want  []string
+	}{
+		{
+			cty.StringVal("hello"),
+			nil,
+		},
+		{
+			cty.StringVal("secret").Mark("sensitive"),
+			[]string{""},
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"name":  cty.StringVal("web"),
+				"token": cty.StringVal("secret").Mark("sensitive"),
+			}),
+			[]string{"/token"},
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"rules": cty.TupleVal([]cty.Value{
+					cty.StringVal("ok"),
+					cty.StringVal("secret").Mark("sensitive"),
+				}),
+			}),
+			[]string{"/rules/1"},
+		},
+		{
+			cty.MapVal(map[string]cty.Value{
+				"a/b": cty.StringVal("secret").Mark("sensitive"),
+			}),
+			[]string{"/a~1b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Input.GoString(), func(t *testing.T) {
+			_, got := marshalCtyValueSensitive(test.Input)
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong sensitive paths\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
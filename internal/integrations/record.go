@@ -0,0 +1,116 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// RecordEnvVar is the environment variable that, when set to a directory
+// path, causes every integration request and response to be written there
+// as JSON files, for later inspection or replay with
+// "terraform integration replay".
+const RecordEnvVar = "TF_INTEGRATION_RECORD"
+
+// RecordedCall is the on-disk representation of a single request/response
+// exchange with an integration.
+type RecordedCall struct {
+	Integration string          `json:"integration"`
+	Method      string          `json:"method"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// recorder writes one file per integration call into its directory. A
+// single recorder is shared by all of the integration processes in a run so
+// that the sequence numbers reflect the overall call order.
+type recorder struct {
+	dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// newRecorderFromEnv returns a recorder writing to the directory named by
+// TF_INTEGRATION_RECORD, or nil if recording isn't enabled. The directory
+// is created if necessary.
+func newRecorderFromEnv() (*recorder, error) {
+	dir := os.Getenv(RecordEnvVar)
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory %s: %s", dir, err)
+	}
+	return &recorder{dir: dir}, nil
+}
+
+// record writes a single call to disk. Recording failures are returned so
+// the caller can log them, but they don't fail the call itself.
+func (r *recorder) record(integration, method string, params interface{}, result json.RawMessage, callErr error) error {
+	rc := RecordedCall{
+		Integration: integration,
+		Method:      method,
+		Result:      result,
+	}
+	if params != nil {
+		buf, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		rc.Params = buf
+	}
+	if callErr != nil {
+		rc.Error = callErr.Error()
+	}
+
+	buf, err := json.MarshalIndent(&rc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+
+	fn := filepath.Join(r.dir, fmt.Sprintf("%06d-%s-%s.json", seq, integration, method))
+	return ioutil.WriteFile(fn, append(buf, '\n'), 0644)
+}
+
+// ReadRecordedCalls loads all of the calls previously recorded into the
+// given directory, in the order they were made.
+func ReadRecordedCalls(dir string) ([]*RecordedCall, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names) // the sequence number prefix gives call order
+
+	ret := make([]*RecordedCall, 0, len(names))
+	for _, name := range names {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var rc RecordedCall
+		if err := json.Unmarshal(buf, &rc); err != nil {
+			return nil, fmt.Errorf("invalid recorded call %s: %s", name, err)
+		}
+		ret = append(ret, &rc)
+	}
+	return ret, nil
+}
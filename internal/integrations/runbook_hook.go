@@ -0,0 +1,138 @@
+package integrations
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/runbooks"
+)
+
+// RunbookHook is a runbooks.Hook implementation that forwards runbook step
+// and action invocation events to a set of running integration processes.
+//
+// The "pre" events can veto: an integration that responds with a "halt"
+// action stops the step or invocation from running, which ends the runbook.
+type RunbookHook struct {
+	processes []*IntegrationProcess
+
+	// run identifies the Terraform run, and is attached to every hook
+	// payload.
+	run *RunContext
+}
+
+var _ runbooks.Hook = (*RunbookHook)(nil)
+
+// NewRunbookHook returns a hook that delivers runbook events to all of the
+// given integration processes, tagging each event with the given run
+// context.
+func NewRunbookHook(processes []*IntegrationProcess, run *RunContext) *RunbookHook {
+	return &RunbookHook{
+		processes: processes,
+		run:       run,
+	}
+}
+
+func (h *RunbookHook) PreRunbookStep(runbook string, step *runbooks.Step) error {
+	return h.preEvent(hookPreRunbookStep, runbookStepParams{
+		Runbook: runbook,
+		Step:    step.Name,
+		Action:  step.ActionType,
+		Config:  marshalCtyValue(step.Config),
+		Run:     h.run,
+	}, fmt.Sprintf("runbook step %q", step.Name))
+}
+
+func (h *RunbookHook) PostRunbookStep(runbook string, step *runbooks.Step, stepErr error) {
+	params := runbookStepParams{
+		Runbook: runbook,
+		Step:    step.Name,
+		Action:  step.ActionType,
+		Config:  marshalCtyValue(step.Config),
+		Run:     h.run,
+	}
+	if stepErr != nil {
+		params.Error = stepErr.Error()
+	}
+	h.postEvent(hookPostRunbookStep, params)
+}
+
+func (h *RunbookHook) PreInvokeAction(runbook string, step *runbooks.Step, actionType string, config cty.Value) error {
+	return h.preEvent(hookPreInvokeAction, runbookStepParams{
+		Runbook: runbook,
+		Step:    step.Name,
+		Action:  actionType,
+		Config:  marshalCtyValue(config),
+		Run:     h.run,
+	}, fmt.Sprintf("action %q in runbook step %q", actionType, step.Name))
+}
+
+func (h *RunbookHook) PostInvokeAction(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error) {
+	params := runbookStepParams{
+		Runbook: runbook,
+		Step:    step.Name,
+		Action:  actionType,
+		Config:  marshalCtyValue(step.Config),
+		Result:  marshalCtyValue(result),
+		Run:     h.run,
+	}
+	if invokeErr != nil {
+		params.Error = invokeErr.Error()
+	}
+	h.postEvent(hookPostInvokeAction, params)
+}
+
+func (h *RunbookHook) PostPlanAction(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error) {
+	params := runbookStepParams{
+		Runbook: runbook,
+		Step:    step.Name,
+		Action:  actionType,
+		Config:  marshalCtyValue(step.Config),
+		Planned: marshalCtyValue(planned),
+		Run:     h.run,
+	}
+	if planErr != nil {
+		params.Error = planErr.Error()
+	}
+	h.postEvent(hookPostPlanAction, params)
+}
+
+// preEvent delivers a vetoable event to every integration in order. A call
+// failure is logged and skipped, as for resource hooks, but a "halt"
+// response blocks whatever the event describes.
+func (h *RunbookHook) preEvent(hook string, params runbookStepParams, what string) error {
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hook, "")
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hook, &params, &result)
+		endHookSpan(span, &result, err)
+
+		if err != nil {
+			log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hook, p.Name, err)
+			continue
+		}
+		if result.Action == "halt" {
+			if result.Message != "" {
+				return fmt.Errorf("integration %q blocked %s: %s", p.Name, what, result.Message)
+			}
+			return fmt.Errorf("integration %q blocked %s", p.Name, what)
+		}
+	}
+	return nil
+}
+
+// postEvent delivers a notification-only event to every integration.
+func (h *RunbookHook) postEvent(hook string, params runbookStepParams) {
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hook, "")
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hook, &params, &result)
+		endHookSpan(span, &result, err)
+		if err != nil {
+			log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hook, p.Name, err)
+		}
+	}
+}
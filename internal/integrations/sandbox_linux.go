@@ -0,0 +1,87 @@
+// +build linux
+
+package integrations
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+// applySandbox configures the given not-yet-started command to honor the
+// given sandbox settings, for restrictions that must be established before
+// the process starts. An error means the settings can't be enforced here,
+// and the integration must not run at all.
+func applySandbox(cmd *exec.Cmd, sb *configs.IntegrationSandbox) error {
+	if sb == nil {
+		return nil
+	}
+
+	if sb.ReadOnlyFilesystem {
+		// Making the filesystem read-only needs a mount namespace set up
+		// from inside the child process, which we don't have a helper for
+		// yet. We refuse to run rather than silently running writable.
+		return fmt.Errorf("readonly_filesystem is not yet supported on this platform")
+	}
+
+	if sb.DisableNetwork {
+		// A fresh network namespace has no interfaces, so the process can't
+		// reach anything. Creating one without privileges requires a user
+		// namespace too, inside which the process maps to our own user.
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+			UidMappings: []syscall.SysProcIDMap{
+				{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+			},
+			GidMappings: []syscall.SysProcIDMap{
+				{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+			},
+		}
+	}
+
+	return nil
+}
+
+// applySandboxLimits enforces the resource limits from the given sandbox
+// settings on the already-started process with the given pid.
+func applySandboxLimits(pid int, sb *configs.IntegrationSandbox) error {
+	if sb == nil {
+		return nil
+	}
+
+	if sb.MemoryLimitMB > 0 {
+		limit := uint64(sb.MemoryLimitMB) << 20
+		if err := prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("failed to set memory limit: %s", err)
+		}
+	}
+
+	if sb.CPUTimeLimitSeconds > 0 {
+		limit := uint64(sb.CPUTimeLimitSeconds)
+		if err := prlimit(pid, unix.RLIMIT_CPU, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("failed to set CPU time limit: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// prlimit applies a resource limit to another process. The unix package
+// doesn't export a wrapper for prlimit64, so we make the syscall directly.
+func prlimit(pid int, resource int, rlim *unix.Rlimit) error {
+	_, _, errno := unix.RawSyscall6(
+		unix.SYS_PRLIMIT64,
+		uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(rlim)),
+		0, 0, 0,
+	)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// +build !linux
+
+package integrations
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+// applySandbox on platforms without sandboxing support refuses to start any
+// integration that asks for a sandbox, since silently running it
+// unrestricted would defeat the point of configuring one.
+func applySandbox(cmd *exec.Cmd, sb *configs.IntegrationSandbox) error {
+	if sb == nil {
+		return nil
+	}
+	return fmt.Errorf("integration sandboxing is not supported on this platform")
+}
+
+// applySandboxLimits is a no-op here because applySandbox already rejected
+// any sandbox settings.
+func applySandboxLimits(pid int, sb *configs.IntegrationSandbox) error {
+	return nil
+}
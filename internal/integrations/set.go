@@ -0,0 +1,154 @@
+package integrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans/planfile"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Describe returns a descriptor for this integration in the form the
+// planfile package records in a saved plan file.
+func (p *IntegrationProcess) Describe() planfile.IntegrationDescriptor {
+	desc := planfile.IntegrationDescriptor{
+		Name:           p.Name,
+		Source:         p.source,
+		Version:        p.version,
+		EnforceAtApply: p.enforceAtApply,
+	}
+	if p.cmd != nil {
+		desc.ExecutableHash = executableHash(p.cmd.Path)
+	}
+	return desc
+}
+
+// DescribeSet returns descriptors for the integrations the given
+// configuration would run, in name order, without starting any of them.
+// This is how an apply of a saved plan determines the current integration
+// set for comparison against the set recorded at plan time.
+//
+// An integration that can't be resolved on this machine — for example
+// because it isn't installed — still gets a descriptor, but with no
+// version or executable hash, so that the comparison reports it as
+// changed rather than the resolution failing outright.
+func DescribeSet(integrationConfigs map[string]*configs.Integration) []planfile.IntegrationDescriptor {
+	names := make([]string, 0, len(integrationConfigs))
+	for name := range integrationConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := make([]planfile.IntegrationDescriptor, 0, len(names))
+	for _, name := range names {
+		config := integrationConfigs[name]
+		desc := planfile.IntegrationDescriptor{
+			Name:           config.Name,
+			Source:         config.Source,
+			EnforceAtApply: config.EnforceAtApply,
+		}
+		if command, selectedVersion, err := resolveCommand(config); err == nil {
+			desc.Version = selectedVersion
+			exe := command[0]
+			if !strings.ContainsAny(exe, `/\`) {
+				if found, err := exec.LookPath(exe); err == nil {
+					exe = found
+				}
+			}
+			desc.ExecutableHash = executableHash(exe)
+		}
+		ret = append(ret, desc)
+	}
+	return ret
+}
+
+// executableHash returns the lowercase hex SHA-256 hash of the file at the
+// given path, or an empty string if the file can't be read.
+func executableHash(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CheckIntegrationSet compares the integration set recorded in a plan file
+// against the set the current configuration and machine would run,
+// returning a diagnostic for each difference.
+//
+// A difference in an integration whose block sets enforce_at_apply is an
+// error, so the apply stops; otherwise it's a warning. An integration that
+// is configured now but wasn't running at plan time is always just a
+// warning, since it had no chance to veto the plan.
+func CheckIntegrationSet(recorded, current []planfile.IntegrationDescriptor) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	currentByName := make(map[string]planfile.IntegrationDescriptor, len(current))
+	for _, desc := range current {
+		currentByName[desc.Name] = desc
+	}
+
+	for _, rec := range recorded {
+		severity := tfdiags.Warning
+		if rec.EnforceAtApply {
+			severity = tfdiags.Error
+		}
+
+		cur, ok := currentByName[rec.Name]
+		if !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				severity,
+				fmt.Sprintf("Integration %q is no longer configured", rec.Name),
+				fmt.Sprintf("The integration %q was running when this plan was created, but is not configured for this apply. Applying without it would bypass whatever policy it enforces at plan time.", rec.Name),
+			))
+			continue
+		}
+		delete(currentByName, rec.Name)
+
+		var changes []string
+		if cur.Source != rec.Source {
+			changes = append(changes, fmt.Sprintf("source changed from %q to %q", rec.Source, cur.Source))
+		}
+		if cur.Version != rec.Version {
+			changes = append(changes, fmt.Sprintf("version changed from %q to %q", rec.Version, cur.Version))
+		}
+		if cur.ExecutableHash != rec.ExecutableHash {
+			changes = append(changes, "the executable is different")
+		}
+		if len(changes) > 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				severity,
+				fmt.Sprintf("Integration %q has changed since the plan was created", rec.Name),
+				fmt.Sprintf("The integration %q is not the same as when this plan was created: %s. The integration that reviewed the plan is not the one that would observe the apply.", rec.Name, strings.Join(changes, ", ")),
+			))
+		}
+	}
+
+	// Anything left over is configured now but wasn't running at plan time.
+	leftover := make([]string, 0, len(currentByName))
+	for name := range currentByName {
+		leftover = append(leftover, name)
+	}
+	sort.Strings(leftover)
+	for _, name := range leftover {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			fmt.Sprintf("Integration %q was not running at plan time", name),
+			fmt.Sprintf("The integration %q is configured for this apply but was not running when the plan was created, so it had no opportunity to review the planned changes.", name),
+		))
+	}
+
+	return diags
+}
@@ -0,0 +1,165 @@
+package integrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans/planfile"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestDescribeSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-integrations-set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	exe := filepath.Join(dir, "policy")
+	content := []byte("#!/bin/sh\nexit 0\n")
+	if err := ioutil.WriteFile(exe, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wantHash := sha256.Sum256(content)
+
+	got := DescribeSet(map[string]*configs.Integration{
+		"policy": {
+			Name:           "policy",
+			Command:        []string{exe, "--strict"},
+			EnforceAtApply: true,
+		},
+		"missing": {
+			Name:   "missing",
+			Source: "example/missing",
+		},
+	})
+
+	if len(got) != 2 {
+		t.Fatalf("wrong number of descriptors %d; want 2", len(got))
+	}
+
+	// Descriptors come back in name order.
+	if got[0].Name != "missing" || got[1].Name != "policy" {
+		t.Fatalf("wrong descriptor order: %q, %q", got[0].Name, got[1].Name)
+	}
+
+	if got[0].ExecutableHash != "" {
+		t.Errorf("uninstalled integration has executable hash %q; want none", got[0].ExecutableHash)
+	}
+	if got[0].Source != "example/missing" {
+		t.Errorf("wrong source %q", got[0].Source)
+	}
+
+	if want := hex.EncodeToString(wantHash[:]); got[1].ExecutableHash != want {
+		t.Errorf("wrong executable hash %q; want %q", got[1].ExecutableHash, want)
+	}
+	if !got[1].EnforceAtApply {
+		t.Errorf("enforce_at_apply was not carried into the descriptor")
+	}
+}
+
+func TestCheckIntegrationSet(t *testing.T) {
+	recorded := []planfile.IntegrationDescriptor{
+		{
+			Name:           "policy",
+			Source:         "example/policy",
+			Version:        "1.2.0",
+			ExecutableHash: "aaaa",
+			EnforceAtApply: true,
+		},
+		{
+			Name:           "cost",
+			ExecutableHash: "bbbb",
+		},
+		{
+			Name: "legacy",
+		},
+	}
+	current := []planfile.IntegrationDescriptor{
+		{
+			Name:           "policy",
+			Source:         "example/policy",
+			Version:        "1.3.0",
+			ExecutableHash: "cccc",
+			EnforceAtApply: true,
+		},
+		{
+			Name:           "cost",
+			ExecutableHash: "bbbb",
+		},
+		{
+			Name: "audit",
+		},
+	}
+
+	diags := CheckIntegrationSet(recorded, current)
+	if len(diags) != 3 {
+		t.Fatalf("wrong number of diagnostics %d; want 3\n%s", len(diags), diags.Err())
+	}
+
+	// The changed enforced integration is an error naming what changed.
+	if diags[0].Severity() != tfdiags.Error {
+		t.Errorf("policy change has severity %s; want error", diags[0].Severity())
+	}
+	desc := diags[0].Description()
+	if !strings.Contains(desc.Summary, `"policy"`) {
+		t.Errorf("wrong summary %q", desc.Summary)
+	}
+	if !strings.Contains(desc.Detail, `version changed from "1.2.0" to "1.3.0"`) {
+		t.Errorf("detail doesn't mention the version change: %q", desc.Detail)
+	}
+	if !strings.Contains(desc.Detail, "the executable is different") {
+		t.Errorf("detail doesn't mention the executable change: %q", desc.Detail)
+	}
+
+	// The unenforced integration that disappeared is only a warning.
+	if diags[1].Severity() != tfdiags.Warning {
+		t.Errorf("missing legacy integration has severity %s; want warning", diags[1].Severity())
+	}
+	if !strings.Contains(diags[1].Description().Summary, `"legacy"`) {
+		t.Errorf("wrong summary %q", diags[1].Description().Summary)
+	}
+
+	// The integration added since plan time is a warning.
+	if diags[2].Severity() != tfdiags.Warning {
+		t.Errorf("added audit integration has severity %s; want warning", diags[2].Severity())
+	}
+	if !strings.Contains(diags[2].Description().Summary, `"audit"`) {
+		t.Errorf("wrong summary %q", diags[2].Description().Summary)
+	}
+
+	// An unchanged set produces no diagnostics at all.
+	if diags := CheckIntegrationSet(recorded, recorded); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics for identical sets: %s", diags.Err())
+	}
+}
+
+func TestCheckIntegrationSetMissingEnforced(t *testing.T) {
+	recorded := []planfile.IntegrationDescriptor{
+		{
+			Name:           "policy",
+			ExecutableHash: "aaaa",
+			EnforceAtApply: true,
+		},
+	}
+
+	diags := CheckIntegrationSet(recorded, nil)
+	if !diags.HasErrors() {
+		t.Fatalf("no error for missing enforced integration; got: %s", diags.ErrWithWarnings())
+	}
+}
@@ -0,0 +1,80 @@
+package integrations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+// installDir is where installed source-addressed integrations live,
+// relative to the configuration root: one directory per namespace, name,
+// and version, containing the integration's executable.
+const installDir = ".terraform/integrations"
+
+// resolveCommand determines the command line for an integration. For
+// integrations configured with "command" that's the command itself; for
+// source-addressed integrations it's the executable of the newest installed
+// version that satisfies the version constraint, and the second return
+// value gives the version that was selected.
+func resolveCommand(config *configs.Integration) ([]string, string, error) {
+	if len(config.Command) > 0 {
+		return config.Command, "", nil
+	}
+	if config.Source == "" {
+		// Config decoding rejects this, so we shouldn't get here.
+		return nil, "", fmt.Errorf("integration %q has no command to run", config.Name)
+	}
+
+	parts := strings.Split(config.Source, "/")
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("invalid integration source %q", config.Source)
+	}
+	namespace, name := parts[0], parts[1]
+
+	baseDir := filepath.Join(installDir, namespace, name)
+	entries, err := ioutil.ReadDir(baseDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("integration %s is not installed; run \"terraform init\" to install integrations", config.Source)
+	}
+
+	var available version.Collection
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		v, err := version.NewVersion(entry.Name())
+		if err != nil {
+			continue // not a version directory; ignore
+		}
+		available = append(available, v)
+	}
+	if len(available) == 0 {
+		return nil, "", fmt.Errorf("integration %s is not installed; run \"terraform init\" to install integrations", config.Source)
+	}
+
+	// Newest acceptable version wins, as for providers.
+	sort.Sort(sort.Reverse(available))
+	var selected *version.Version
+	for _, v := range available {
+		if config.Version.Required == nil || config.Version.Required.Check(v) {
+			selected = v
+			break
+		}
+	}
+	if selected == nil {
+		return nil, "", fmt.Errorf("no installed version of integration %s matches the constraint %q; run \"terraform init\" to install a suitable version", config.Source, config.Version.Required)
+	}
+
+	exe := name
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	return []string{filepath.Join(baseDir, selected.Original(), exe)}, selected.Original(), nil
+}
@@ -0,0 +1,90 @@
+package integrations
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+func TestResolveCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-integrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, ver := range []string{"1.0.0", "1.2.0", "2.0.0"} {
+		if err := os.MkdirAll(filepath.Join(installDir, "example", "policy", ver), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("explicit command wins", func(t *testing.T) {
+		got, _, err := resolveCommand(&configs.Integration{
+			Name:    "policy",
+			Command: []string{"./bin/policy", "--strict"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got[0] != "./bin/policy" {
+			t.Errorf("wrong command %#v", got)
+		}
+	})
+
+	t.Run("newest installed version", func(t *testing.T) {
+		got, _, err := resolveCommand(&configs.Integration{
+			Name:   "policy",
+			Source: "example/policy",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !strings.Contains(got[0], "2.0.0") {
+			t.Errorf("wrong command %#v; want the 2.0.0 executable", got)
+		}
+	})
+
+	t.Run("constrained version", func(t *testing.T) {
+		required, err := version.NewConstraint("~> 1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := resolveCommand(&configs.Integration{
+			Name:    "policy",
+			Source:  "example/policy",
+			Version: configs.VersionConstraint{Required: required},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !strings.Contains(got[0], "1.2.0") {
+			t.Errorf("wrong command %#v; want the 1.2.0 executable", got)
+		}
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		_, _, err := resolveCommand(&configs.Integration{
+			Name:   "policy",
+			Source: "example/missing",
+		})
+		if err == nil {
+			t.Fatal("expected an error for an uninstalled integration")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package integrations
+
+import (
+	"log"
+)
+
+// StateHook delivers pre/post events for CLI commands that edit state
+// directly — "state mv", "state rm", "state push", and "import" — to a
+// set of running integration processes, so governance integrations can
+// observe state surgery the same way they observe plan and apply. The
+// state mutation events are notification-only: an integration cannot
+// block the edit.
+//
+// All of the methods are safe to call on a nil hook, which is how the
+// commands run when no integrations are configured.
+type StateHook struct {
+	processes []*IntegrationProcess
+
+	// run identifies the Terraform run, and is attached to every hook
+	// payload. Its Command field names the mutating operation.
+	run *RunContext
+}
+
+// NewStateHook returns a hook that delivers state mutation events to all
+// of the given integration processes, tagging each event with the given
+// run context.
+func NewStateHook(processes []*IntegrationProcess, run *RunContext) *StateHook {
+	return &StateHook{
+		processes: processes,
+		run:       run,
+	}
+}
+
+// StateHook returns a hook that delivers state mutation events to this
+// manager's integrations.
+func (m *Manager) StateHook() *StateHook {
+	return NewStateHook(m.processes, m.run)
+}
+
+// PreMutation announces that the operation is about to modify the given
+// resource and module addresses in state.
+func (h *StateHook) PreMutation(addresses []string) {
+	if h == nil {
+		return
+	}
+	h.postEvent(hookPreStateMutation, stateMutationParams{
+		Operation: h.run.Command,
+		Addresses: addresses,
+		Run:       h.run,
+	})
+}
+
+// PostMutation announces that the operation has finished modifying the
+// given addresses. A non-nil error means the edit was not persisted.
+func (h *StateHook) PostMutation(addresses []string, opErr error) {
+	if h == nil {
+		return
+	}
+	params := stateMutationParams{
+		Operation: h.run.Command,
+		Addresses: addresses,
+		Run:       h.run,
+	}
+	if opErr != nil {
+		params.Error = opErr.Error()
+	}
+	h.postEvent(hookPostStateMutation, params)
+}
+
+// postEvent delivers a notification-only event to every integration.
+func (h *StateHook) postEvent(hook string, params stateMutationParams) {
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hook, "")
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hook, &params, &result)
+		endHookSpan(span, &result, err)
+		if err != nil {
+			log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hook, p.Name, err)
+		}
+	}
+}
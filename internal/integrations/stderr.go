@@ -0,0 +1,88 @@
+package integrations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Limits on how quickly an integration's stderr lines flow to the log or
+// the UI. An integration that logs pathologically fast gets its excess
+// lines dropped (with a note saying how many), rather than being allowed to
+// flood the user's terminal.
+const (
+	stderrBurstLines     = 100
+	stderrLinesPerSecond = 10
+)
+
+// forwardStderr consumes an integration's stderr stream according to the
+// integration's configured stderr mode, until the stream ends.
+func forwardStderr(config *configs.Integration, ui terraform.UIOutput, r io.Reader) {
+	mode := config.Stderr
+	if mode == "" {
+		mode = "log"
+	}
+	if mode == "ui" && ui == nil {
+		// No UI is available in this context, so fall back to logging
+		// rather than losing the output.
+		mode = "log"
+	}
+
+	if mode == "discard" {
+		io.Copy(ioutil.Discard, r)
+		return
+	}
+
+	limiter := &lineLimiter{tokens: stderrBurstLines, last: time.Now()}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		ok, dropped := limiter.allow(time.Now())
+		if dropped > 0 {
+			log.Printf("[WARN] integrations: dropped %d lines of stderr output from integration %q", dropped, config.Name)
+		}
+		if !ok {
+			continue
+		}
+
+		switch mode {
+		case "ui":
+			ui.Output(fmt.Sprintf("[%s] %s", config.Name, sc.Text()))
+		default:
+			log.Printf("[DEBUG] integrations: %s: %s", config.Name, sc.Text())
+		}
+	}
+}
+
+// lineLimiter is a token bucket over output lines. It is used from a single
+// goroutine, so it needs no locking.
+type lineLimiter struct {
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// allow reports whether one more line may flow now. Once the limiter starts
+// allowing lines again after having dropped some, it also reports how many
+// were dropped in between, so the caller can say so.
+func (l *lineLimiter) allow(now time.Time) (ok bool, dropped int) {
+	l.tokens += now.Sub(l.last).Seconds() * stderrLinesPerSecond
+	if l.tokens > stderrBurstLines {
+		l.tokens = stderrBurstLines
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		l.dropped++
+		return false, 0
+	}
+	l.tokens--
+	dropped = l.dropped
+	l.dropped = 0
+	return true, dropped
+}
@@ -0,0 +1,96 @@
+package integrations
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/internal/scenarios"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// TestHook is a scenarios.Hook implementation that forwards test step
+// lifecycle events to a set of running integration processes, so
+// external test-reporting services can follow a test run as it happens.
+// All of the test events are notification-only: an integration cannot
+// block or alter a test run.
+type TestHook struct {
+	processes []*IntegrationProcess
+
+	// run identifies the Terraform run, and is attached to every hook
+	// payload.
+	run *RunContext
+}
+
+var _ scenarios.Hook = (*TestHook)(nil)
+
+// NewTestHook returns a hook that delivers test events to all of the
+// given integration processes, tagging each event with the given run
+// context.
+func NewTestHook(processes []*IntegrationProcess, run *RunContext) *TestHook {
+	return &TestHook{
+		processes: processes,
+		run:       run,
+	}
+}
+
+func (h *TestHook) StepStarted(scenario *scenarios.Scenario, step *scenarios.Step) {
+	h.postEvent(hookTestStepStarted, testStepParams{
+		Scenario: scenario.Name,
+		Step:     step.Name,
+		Run:      h.run,
+	})
+}
+
+func (h *TestHook) StepFinished(scenario *scenarios.Scenario, status *scenarios.StepStatus) {
+	params := testStepParams{
+		Scenario:   scenario.Name,
+		Step:       status.Step.Name,
+		Status:     testStatusName(status.Status),
+		SkipReason: status.SkipReason,
+		DurationMS: status.Duration.Milliseconds(),
+		Attempts:   status.Attempts,
+		Run:        h.run,
+	}
+	if err := status.Diagnostics.Err(); err != nil {
+		params.Error = err.Error()
+	}
+	h.postEvent(hookTestStepFinished, params)
+}
+
+func (h *TestHook) AssertionFailed(scenario *scenarios.Scenario, step *scenarios.Step, diag tfdiags.Diagnostic) {
+	desc := diag.Description()
+	h.postEvent(hookTestAssertionFailed, testStepParams{
+		Scenario: scenario.Name,
+		Step:     step.Name,
+		Error:    desc.Summary,
+		Message:  desc.Detail,
+		Run:      h.run,
+	})
+}
+
+// postEvent delivers a notification-only event to every integration.
+func (h *TestHook) postEvent(hook string, params testStepParams) {
+	for _, p := range h.processes {
+		ctx, span := startHookSpan(p.Name, hook, "")
+		params.Trace = traceFields(ctx)
+		var result HookResult
+		err := p.Call(hook, &params, &result)
+		endHookSpan(span, &result, err)
+		if err != nil {
+			log.Printf("[WARN] integrations: %s call to integration %q failed: %s", hook, p.Name, err)
+		}
+	}
+}
+
+// testStatusName returns the wire name for a step status.
+func testStatusName(status scenarios.Status) string {
+	switch status {
+	case scenarios.StepPassed:
+		return "passed"
+	case scenarios.StepFailed:
+		return "failed"
+	case scenarios.StepSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,77 @@
+package integrations
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer produces a span for each hook RPC we make against an integration.
+// Spans are recorded only if the application has installed a real tracer
+// provider; otherwise they are no-ops.
+var tracer = otel.Tracer("github.com/hashicorp/terraform/internal/integrations")
+
+// startHookSpan begins a client span covering a single hook call against the
+// named integration. addr may be empty for hooks that aren't about one
+// specific resource.
+func startHookSpan(integration, hook, addr string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("terraform.integration", integration),
+		attribute.String("terraform.hook", hook),
+	}
+	if addr != "" {
+		attrs = append(attrs, attribute.String("terraform.resource", addr))
+	}
+	return tracer.Start(context.Background(), "integrations."+hook,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endHookSpan finishes a hook span, recording the call error and the status
+// the integration reported, if any.
+func endHookSpan(span trace.Span, result *HookResult, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if result != nil && result.Status != "" {
+		span.SetAttributes(attribute.String("terraform.hook_status", result.Status))
+	}
+	span.End()
+}
+
+// traceFields returns the W3C trace context headers for the span in the
+// given context, so that the integration on the other end of the call can
+// continue the trace across the process boundary. It returns nil when
+// there's no recording span, to keep the noise out of payloads when tracing
+// isn't configured.
+func traceFields(ctx context.Context) map[string]string {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return nil
+	}
+	carrier := make(mapCarrier)
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// mapCarrier adapts a plain map to the propagation carrier interface.
+type mapCarrier map[string]string
+
+func (c mapCarrier) Get(key string) string { return c[key] }
+
+func (c mapCarrier) Set(key, value string) { c[key] = value }
+
+func (c mapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
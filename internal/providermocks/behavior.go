@@ -0,0 +1,94 @@
+package providermocks
+
+import (
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SimulateResourceLatency blocks for the scripted latency of the given
+// managed resource type, if any. Serving code calls this before
+// returning each response, so that a step timeout or a module's retry
+// logic sees the mock behave like a slow remote API.
+func (p *MockProvider) SimulateResourceLatency(typeName string) {
+	if def, ok := p.Resources[typeName]; ok {
+		sleepLatency(def.Latency)
+	}
+}
+
+// SimulateDataSourceLatency is like SimulateResourceLatency but for data
+// source reads.
+func (p *MockProvider) SimulateDataSourceLatency(typeName string) {
+	if def, ok := p.DataSources[typeName]; ok {
+		sleepLatency(def.Latency)
+	}
+}
+
+func sleepLatency(d time.Duration) {
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// paginateResponse applies the type's pagination, if any, to a generated
+// response: the named list attribute is replaced with the page of
+// elements belonging to the given call number, and calls past the last
+// page get an empty list. Responses without the attribute, or whose
+// attribute is not a list-like value, pass through unchanged.
+func paginateResponse(def *MockResource, response cty.Value, call int) cty.Value {
+	pg := def.Pagination
+	if pg == nil || pg.PageSize < 1 || call < 1 {
+		return response
+	}
+	if response == cty.NilVal || response.IsNull() || !response.Type().IsObjectType() {
+		return response
+	}
+	if !response.Type().HasAttribute(pg.Attribute) {
+		return response
+	}
+
+	full := response.GetAttr(pg.Attribute)
+	ty := full.Type()
+	if !ty.IsListType() && !ty.IsTupleType() {
+		return response
+	}
+	if full.IsNull() || !full.IsKnown() {
+		return response
+	}
+
+	var elems []cty.Value
+	for it := full.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		elems = append(elems, v)
+	}
+
+	start := (call - 1) * pg.PageSize
+	end := start + pg.PageSize
+	if start > len(elems) {
+		start = len(elems)
+	}
+	if end > len(elems) {
+		end = len(elems)
+	}
+	page := elems[start:end]
+
+	var pageVal cty.Value
+	switch {
+	case ty.IsListType():
+		if len(page) == 0 {
+			pageVal = cty.ListValEmpty(ty.ElementType())
+		} else {
+			pageVal = cty.ListVal(page)
+		}
+	default: // tuple
+		if len(page) == 0 {
+			pageVal = cty.EmptyTupleVal
+		} else {
+			pageVal = cty.TupleVal(page)
+		}
+	}
+
+	attrs := response.AsValueMap()
+	attrs[pg.Attribute] = pageVal
+	return cty.ObjectVal(attrs)
+}
@@ -0,0 +1,153 @@
+package providermocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+func TestDataSourceTransitions(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"status": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"status": cty.UnknownVal(cty.String),
+	})
+
+	p := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		DataSources: map[string]*MockResource{
+			"aws_instance_status": {
+				Type: "aws_instance_status",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"status": cty.StringVal("pending"),
+				}),
+				Transitions: []*MockTransition{
+					{
+						OnCall: 3,
+						Values: cty.ObjectVal(map[string]cty.Value{
+							"status": cty.StringVal("running"),
+						}),
+					},
+				},
+			},
+		},
+	}
+
+	read := func() cty.Value {
+		if err := p.NextDataSourceError("aws_instance_status"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		response, err := p.DataSourceResponse("aws_instance_status", schema, config)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return response.GetAttr("status")
+	}
+
+	// The first two reads see the original defaults, and from the third
+	// onward the transition's values take over.
+	for i := 0; i < 2; i++ {
+		if got, want := read(), cty.StringVal("pending"); !got.RawEquals(want) {
+			t.Errorf("wrong status on read %d: %#v; want %#v", i+1, got, want)
+		}
+	}
+	for i := 2; i < 4; i++ {
+		if got, want := read(), cty.StringVal("running"); !got.RawEquals(want) {
+			t.Errorf("wrong status on read %d: %#v; want %#v", i+1, got, want)
+		}
+	}
+}
+
+func TestDataSourcePagination(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ids": {
+				Type:     cty.List(cty.String),
+				Computed: true,
+			},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"ids": cty.UnknownVal(cty.List(cty.String)),
+	})
+
+	p := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		DataSources: map[string]*MockResource{
+			"aws_subnets": {
+				Type: "aws_subnets",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"ids": cty.ListVal([]cty.Value{
+						cty.StringVal("subnet-1"),
+						cty.StringVal("subnet-2"),
+						cty.StringVal("subnet-3"),
+					}),
+				}),
+				Pagination: &MockPagination{
+					Attribute: "ids",
+					PageSize:  2,
+				},
+			},
+		},
+	}
+
+	read := func() cty.Value {
+		if err := p.NextDataSourceError("aws_subnets"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		response, err := p.DataSourceResponse("aws_subnets", schema, config)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return response.GetAttr("ids")
+	}
+
+	want := cty.ListVal([]cty.Value{cty.StringVal("subnet-1"), cty.StringVal("subnet-2")})
+	if got := read(); !got.RawEquals(want) {
+		t.Errorf("wrong first page %#v; want %#v", got, want)
+	}
+	want = cty.ListVal([]cty.Value{cty.StringVal("subnet-3")})
+	if got := read(); !got.RawEquals(want) {
+		t.Errorf("wrong second page %#v; want %#v", got, want)
+	}
+
+	// Past the last page a caller sees an empty list, which is how it
+	// knows it has everything.
+	want = cty.ListValEmpty(cty.String)
+	if got := read(); !got.RawEquals(want) {
+		t.Errorf("wrong page past the end %#v; want %#v", got, want)
+	}
+}
+
+func TestSimulateLatency(t *testing.T) {
+	p := &MockProvider{
+		Name: "aws",
+		Resources: map[string]*MockResource{
+			"aws_instance": {
+				Type:    "aws_instance",
+				Latency: 5 * time.Millisecond,
+			},
+		},
+	}
+
+	start := time.Now()
+	p.SimulateResourceLatency("aws_instance")
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("latency not simulated: only %s elapsed", elapsed)
+	}
+
+	// Types without scripted latency, and unknown types, don't sleep.
+	p.SimulateResourceLatency("aws_eip")
+	p.SimulateDataSourceLatency("aws_ami")
+}
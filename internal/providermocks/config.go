@@ -0,0 +1,145 @@
+package providermocks
+
+import (
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MockProvider describes one mocked provider within a test scenario.
+type MockProvider struct {
+	// Name is the provider's local name in the module under test, such
+	// as "aws".
+	Name string
+
+	// DefDir is the directory containing the provider's mock response
+	// definition files, if any. When DefDir is empty and a resource type
+	// has no entry in Resources or DataSources, responses are generated
+	// from the provider schema instead.
+	DefDir string
+
+	// Seed makes generated attribute values deterministic: two runs with
+	// the same seed produce the same values. An empty seed is used as-is,
+	// which still gives stable values from run to run.
+	Seed string
+
+	// Resources and DataSources give explicit response definitions for
+	// managed resource types and data source types respectively, keyed
+	// by type name.
+	Resources   map[string]*MockResource
+	DataSources map[string]*MockResource
+
+	// Functions gives stub implementations for the provider's functions,
+	// keyed by the function's name without its provider:: prefix.
+	Functions map[string]*MockFunction
+
+	// calls counts the calls made against each resource and data source
+	// type, for matching scripted errors keyed by call number.
+	calls map[string]int
+}
+
+// MockResource gives the mock responses for one resource type or data
+// source type.
+type MockResource struct {
+	Type string
+
+	// Defaults are attribute values to use for the type's computed
+	// attributes, overriding any generated values. Attributes absent
+	// here are still generated from the schema.
+	Defaults cty.Value
+
+	// Template is an expression producing additional values for the
+	// type's computed attributes, evaluated once per call with the
+	// request's configuration in scope as "config", so a response can
+	// derive values from the caller's inputs the way a real provider
+	// would (e.g. arn = "arn:aws:s3:::${config.bucket}"). Where the
+	// template and Defaults both define an attribute, the template wins.
+	Template hcl.Expression
+
+	// Errors are scripted failures for the type, so tests can exercise a
+	// module's handling of provider errors. An error with OnCall zero
+	// fails every call; otherwise it fails only the numbered call.
+	Errors []*MockError
+
+	// Latency delays every response for the type by the given duration,
+	// so tests can exercise timeout and retry logic that an
+	// instant-success mock would never trigger.
+	Latency time.Duration
+
+	// Transitions replace Defaults from a numbered call onward,
+	// simulating eventual consistency: a read returns different values
+	// once the simulated remote system has caught up. Transitions apply
+	// in declaration order, so a later transition supersedes an earlier
+	// one when both have taken effect.
+	Transitions []*MockTransition
+
+	// Pagination splits one list attribute of the type's responses into
+	// pages served by successive calls, for testing code that must walk
+	// a paginated API. Nil disables pagination.
+	Pagination *MockPagination
+}
+
+// MockTransition is one scripted change to a mocked type's defaults.
+type MockTransition struct {
+	// OnCall is the call number, counting from 1 across the life of the
+	// mock provider, from which Values take effect.
+	OnCall int
+
+	// Values are the attribute values replacing the type's Defaults from
+	// OnCall onward.
+	Values cty.Value
+}
+
+// MockPagination describes how one list attribute of a mocked type is
+// split into pages.
+type MockPagination struct {
+	// Attribute names the list attribute to paginate.
+	Attribute string
+
+	// PageSize is how many elements each call returns. Calls past the
+	// last page return an empty list, which is how a caller detects the
+	// end.
+	PageSize int
+}
+
+// MockError is one scripted failure of a mocked resource type.
+type MockError struct {
+	// Message is the error message reported to Terraform.
+	Message string
+
+	// OnCall selects which call against the type the error applies to,
+	// counting from 1 across the life of the mock provider. Zero applies
+	// the error to every call.
+	OnCall int
+}
+
+// resourceDefaults returns the defaults in effect for the given managed
+// resource type, or cty.NilVal if none were defined.
+func (p *MockProvider) resourceDefaults(typeName string) cty.Value {
+	return p.effectiveDefaults(p.Resources[typeName], "resource."+typeName)
+}
+
+// dataSourceDefaults returns the defaults in effect for the given data
+// source type, or cty.NilVal if none were defined.
+func (p *MockProvider) dataSourceDefaults(typeName string) cty.Value {
+	return p.effectiveDefaults(p.DataSources[typeName], "data."+typeName)
+}
+
+// effectiveDefaults resolves the defaults for the current call against
+// the given type, taking any transitions that have come due into
+// account. Call numbers are the ones recorded by nextError, so serving
+// code must record the call before generating its response.
+func (p *MockProvider) effectiveDefaults(def *MockResource, key string) cty.Value {
+	if def == nil {
+		return cty.NilVal
+	}
+	ret := def.Defaults
+	n := p.calls[key]
+	for _, transition := range def.Transitions {
+		if transition.OnCall <= n {
+			ret = transition.Values
+		}
+	}
+	return ret
+}
@@ -0,0 +1,9 @@
+// Package providermocks produces mock implementations of providers for
+// use in test scenarios, so that a module can be exercised without
+// reaching real infrastructure.
+//
+// A mock provider answers plan and apply calls from static response
+// definitions where they are given, and otherwise synthesizes plausible
+// values for computed attributes directly from the provider's schema, so
+// that most tests need no response definitions at all.
+package providermocks
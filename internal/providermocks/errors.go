@@ -0,0 +1,49 @@
+package providermocks
+
+import (
+	"fmt"
+)
+
+// NextResourceError records one more call against the given managed
+// resource type and returns the scripted error for that call, if any.
+// Call counting starts at 1 and spans the whole life of the mock
+// provider, so a test can script the second apply to fail while the
+// first succeeds.
+func (p *MockProvider) NextResourceError(typeName string) error {
+	return p.nextError(p.Resources[typeName], "resource."+typeName)
+}
+
+// NextDataSourceError is like NextResourceError but for data source
+// reads. Resource and data source calls are counted separately.
+func (p *MockProvider) NextDataSourceError(typeName string) error {
+	return p.nextError(p.DataSources[typeName], "data."+typeName)
+}
+
+// CallCount returns how many calls have been recorded against the mock
+// provider's resource and data source types so far, for per-step cost
+// reporting.
+func (p *MockProvider) CallCount() int {
+	n := 0
+	for _, calls := range p.calls {
+		n += calls
+	}
+	return n
+}
+
+func (p *MockProvider) nextError(def *MockResource, key string) error {
+	if p.calls == nil {
+		p.calls = make(map[string]int)
+	}
+	p.calls[key]++
+	if def == nil {
+		return nil
+	}
+
+	n := p.calls[key]
+	for _, mockErr := range def.Errors {
+		if mockErr.OnCall == 0 || mockErr.OnCall == n {
+			return fmt.Errorf("%s", mockErr.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package providermocks
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MockFunction gives the stub implementation of one provider-defined
+// function, so configurations calling provider::name::function() can run
+// against a mock without the real provider present.
+type MockFunction struct {
+	Name string
+
+	// Cases are exact argument-to-result mappings, checked in declaration
+	// order. The first case whose arguments equal the call's arguments
+	// supplies the result.
+	Cases []*FunctionCase
+
+	// Result is a fallback expression evaluated when no case matches,
+	// with the call's arguments in scope as the "args" tuple. Nil means
+	// an unmatched call is an error.
+	Result hcl.Expression
+}
+
+// FunctionCase is one argument-to-result mapping of a mocked function.
+type FunctionCase struct {
+	Args   []cty.Value
+	Result cty.Value
+}
+
+// CallFunction returns the mocked result of calling the named function
+// with the given arguments, consulting the function's cases first and
+// then its fallback result expression.
+func (p *MockProvider) CallFunction(name string, args []cty.Value) (cty.Value, error) {
+	fn, ok := p.Functions[name]
+	if !ok {
+		return cty.NilVal, fmt.Errorf("mock provider %q has no definition for function %q", p.Name, name)
+	}
+
+Cases:
+	for _, c := range fn.Cases {
+		if len(c.Args) != len(args) {
+			continue
+		}
+		for i, want := range c.Args {
+			if !args[i].RawEquals(want) {
+				continue Cases
+			}
+		}
+		return c.Result, nil
+	}
+
+	if fn.Result == nil {
+		return cty.NilVal, fmt.Errorf("no case of mocked function %q matches the given arguments", name)
+	}
+
+	argsVal := cty.EmptyTupleVal
+	if len(args) > 0 {
+		argsVal = cty.TupleVal(args)
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"args": argsVal,
+		},
+	}
+	ret, diags := fn.Result.Value(ctx)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("invalid result for mocked function %q: %s", name, diags.Error())
+	}
+	return ret, nil
+}
@@ -0,0 +1,152 @@
+package providermocks
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+// ResourceResponse returns the value a mocked managed resource of the
+// given type settles on after apply. Computed attributes that are still
+// unknown in the proposed value are filled first from the type's
+// template, evaluated against the request's configuration, then from its
+// explicit defaults, and otherwise with values generated from the
+// schema. The only possible error is a template that fails to evaluate.
+func (p *MockProvider) ResourceResponse(typeName string, schema *configschema.Block, config, proposed cty.Value) (cty.Value, error) {
+	defaults, err := templateDefaults(p.Resources[typeName], p.resourceDefaults(typeName), config)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return populateComputed(schema, proposed, defaults, p.generateSeed(typeName)), nil
+}
+
+// DataSourceResponse is like ResourceResponse but for a data source read,
+// using any template and defaults defined for the data source type. When
+// the type declares pagination, each call returns the next page of the
+// paginated attribute, so the call must already have been recorded via
+// NextDataSourceError.
+func (p *MockProvider) DataSourceResponse(typeName string, schema *configschema.Block, config cty.Value) (cty.Value, error) {
+	defaults, err := templateDefaults(p.DataSources[typeName], p.dataSourceDefaults(typeName), config)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ret := populateComputed(schema, config, defaults, p.generateSeed(typeName))
+	if def, ok := p.DataSources[typeName]; ok {
+		ret = paginateResponse(def, ret, p.calls["data."+typeName])
+	}
+	return ret, nil
+}
+
+// DataSourceValue is DataSourceResponse for callers without a provider
+// schema, such as runbook data reads: instead of populating computed
+// attributes from a schema, it overlays the type's template and defaults
+// over whatever the configuration left null or absent. As with
+// DataSourceResponse, the call must already have been recorded via
+// NextDataSourceError for pagination to advance.
+func (p *MockProvider) DataSourceValue(typeName string, config cty.Value) (cty.Value, error) {
+	defaults, err := templateDefaults(p.DataSources[typeName], p.dataSourceDefaults(typeName), config)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	vals := make(map[string]cty.Value)
+	if config != cty.NilVal && config.Type().IsObjectType() {
+		for name := range config.Type().AttributeTypes() {
+			vals[name] = config.GetAttr(name)
+		}
+	}
+	if defaults != cty.NilVal && defaults.Type().IsObjectType() {
+		for name := range defaults.Type().AttributeTypes() {
+			if existing, ok := vals[name]; !ok || existing.IsNull() {
+				vals[name] = defaults.GetAttr(name)
+			}
+		}
+	}
+
+	ret := cty.ObjectVal(vals)
+	if def, ok := p.DataSources[typeName]; ok {
+		ret = paginateResponse(def, ret, p.calls["data."+typeName])
+	}
+	return ret, nil
+}
+
+func (p *MockProvider) generateSeed(typeName string) string {
+	return p.Seed + "|" + typeName
+}
+
+// populateComputed returns a copy of base with every computed attribute
+// that is unknown or absent populated, preferring the corresponding
+// attribute from defaults and falling back to a value generated
+// deterministically from the seed and the attribute's path. Values
+// already decided in base, whether from configuration or a prior state,
+// are never replaced.
+func populateComputed(schema *configschema.Block, base cty.Value, defaults cty.Value, seed string) cty.Value {
+	return populateComputedBlock(schema, base, defaults, seed, "")
+}
+
+func populateComputedBlock(schema *configschema.Block, base cty.Value, defaults cty.Value, seed, path string) cty.Value {
+	if base == cty.NilVal || base.IsNull() || !base.IsKnown() {
+		base = schema.EmptyValue()
+	}
+
+	vals := make(map[string]cty.Value)
+	for name, attr := range schema.Attributes {
+		given := cty.NullVal(attr.Type)
+		if base.Type().HasAttribute(name) {
+			given = base.GetAttr(name)
+		}
+		if !attr.Computed || (given.IsKnown() && !given.IsNull()) {
+			vals[name] = given
+			continue
+		}
+		if defaults != cty.NilVal && defaults.Type().IsObjectType() && defaults.Type().HasAttribute(name) {
+			vals[name] = defaults.GetAttr(name)
+			continue
+		}
+		vals[name] = generateValue(attr.Type, seed, path+"."+name)
+	}
+
+	for name, blockS := range schema.BlockTypes {
+		given := cty.NullVal(blockS.Block.ImpliedType())
+		if base.Type().HasAttribute(name) {
+			given = base.GetAttr(name)
+		}
+		switch blockS.Nesting {
+		case configschema.NestingSingle, configschema.NestingGroup:
+			vals[name] = populateComputedBlock(&blockS.Block, given, cty.NilVal, seed, path+"."+name)
+		default:
+			// Collections of nested blocks come entirely from the
+			// configuration, so we pass them through unchanged.
+			vals[name] = given
+		}
+	}
+
+	return cty.ObjectVal(vals)
+}
+
+// generateValue produces a plausible placeholder value of the given type.
+// The result depends only on the seed and the attribute path, so repeated
+// runs of the same test see the same values.
+func generateValue(ty cty.Type, seed, path string) cty.Value {
+	h := sha256.Sum256([]byte(seed + "|" + path))
+	switch {
+	case ty == cty.String:
+		return cty.StringVal(fmt.Sprintf("mock-%x", h[:4]))
+	case ty == cty.Number:
+		return cty.NumberIntVal(int64(binary.BigEndian.Uint32(h[:4]) % 65536))
+	case ty == cty.Bool:
+		return cty.BoolVal(h[0]%2 == 0)
+	case ty.IsListType():
+		return cty.ListValEmpty(ty.ElementType())
+	case ty.IsSetType():
+		return cty.SetValEmpty(ty.ElementType())
+	case ty.IsMapType():
+		return cty.MapValEmpty(ty.ElementType())
+	default:
+		return cty.NullVal(ty)
+	}
+}
@@ -0,0 +1,133 @@
+package providermocks
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+func TestResourceResponse(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami": {
+				Type:     cty.String,
+				Required: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"private_ip": {
+				Type:     cty.String,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"ami":        cty.StringVal("ami-12345"),
+		"id":         cty.NullVal(cty.String),
+		"arn":        cty.NullVal(cty.String),
+		"private_ip": cty.StringVal("10.0.0.4"),
+	})
+	proposed := cty.ObjectVal(map[string]cty.Value{
+		"ami":        cty.StringVal("ami-12345"),
+		"id":         cty.UnknownVal(cty.String),
+		"arn":        cty.UnknownVal(cty.String),
+		"private_ip": cty.StringVal("10.0.0.4"),
+	})
+
+	p := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		Resources: map[string]*MockResource{
+			"aws_instance": {
+				Type: "aws_instance",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"arn": cty.StringVal("arn:aws:ec2::instance/i-1"),
+				}),
+			},
+		},
+	}
+
+	got, err := p.ResourceResponse("aws_instance", schema, config, proposed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Configured values always win.
+	if got, want := got.GetAttr("ami"), cty.StringVal("ami-12345"); !got.RawEquals(want) {
+		t.Errorf("wrong ami %#v; want %#v", got, want)
+	}
+	if got, want := got.GetAttr("private_ip"), cty.StringVal("10.0.0.4"); !got.RawEquals(want) {
+		t.Errorf("wrong private_ip %#v; want %#v", got, want)
+	}
+
+	// Explicit defaults override generation.
+	if got, want := got.GetAttr("arn"), cty.StringVal("arn:aws:ec2::instance/i-1"); !got.RawEquals(want) {
+		t.Errorf("wrong arn %#v; want %#v", got, want)
+	}
+
+	// Everything else is generated, known, and non-null.
+	id := got.GetAttr("id")
+	if !id.IsKnown() || id.IsNull() {
+		t.Fatalf("id was not generated: %#v", id)
+	}
+
+	// Generation is deterministic for a given seed...
+	again, err := p.ResourceResponse("aws_instance", schema, config, proposed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !again.RawEquals(got) {
+		t.Errorf("second response differs from first\nfirst:  %#v\nsecond: %#v", got, again)
+	}
+
+	// ...and varies when the seed changes.
+	other := &MockProvider{Name: "aws", Seed: "other"}
+	otherResponse, err := other.ResourceResponse("aws_instance", schema, config, proposed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	otherID := otherResponse.GetAttr("id")
+	if otherID.RawEquals(id) {
+		t.Errorf("same id %#v generated for different seeds", id)
+	}
+}
+
+func TestDataSourceResponse(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {
+				Type:     cty.String,
+				Required: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("web"),
+		"id":   cty.NullVal(cty.String),
+	})
+
+	p := &MockProvider{Name: "aws", Seed: "test"}
+	got, err := p.DataSourceResponse("aws_ami", schema, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := got.GetAttr("name"), cty.StringVal("web"); !got.RawEquals(want) {
+		t.Errorf("wrong name %#v; want %#v", got, want)
+	}
+	if id := got.GetAttr("id"); !id.IsKnown() || id.IsNull() {
+		t.Errorf("id was not generated: %#v", id)
+	}
+}
@@ -0,0 +1,249 @@
+package providermocks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+)
+
+// Provider is a providers.Interface that answers plan, apply, and read
+// requests from a MockProvider's definitions instead of calling a remote
+// API. Schema and validation requests are delegated to the real provider
+// plugin, which runs locally, so mocked steps still catch configuration
+// mistakes; everything that would reach out to a remote system is served
+// from the mock.
+type Provider struct {
+	mock *MockProvider
+	real providers.Interface
+
+	schema *providers.GetProviderSchemaResponse
+
+	// mu serializes access to the mock, whose call counting pairs each
+	// recorded call with the response served for it. Terraform applies
+	// independent resources concurrently, so without this two applies
+	// could interleave their call numbers.
+	mu sync.Mutex
+}
+
+var _ providers.Interface = (*Provider)(nil)
+
+// WrapFactory returns a provider factory whose instances serve mock
+// responses from the given mock provider, delegating schema and
+// validation requests to an instance from the real factory.
+func WrapFactory(mock *MockProvider, real providers.Factory) providers.Factory {
+	return func() (providers.Interface, error) {
+		inner, err := real()
+		if err != nil {
+			return nil, err
+		}
+		return &Provider{mock: mock, real: inner}, nil
+	}
+}
+
+func (p *Provider) GetProviderSchema() providers.GetProviderSchemaResponse {
+	if p.schema == nil {
+		resp := p.real.GetProviderSchema()
+		p.schema = &resp
+	}
+	return *p.schema
+}
+
+func (p *Provider) ValidateProviderConfig(req providers.ValidateProviderConfigRequest) providers.ValidateProviderConfigResponse {
+	return p.real.ValidateProviderConfig(req)
+}
+
+func (p *Provider) ValidateResourceConfig(req providers.ValidateResourceConfigRequest) providers.ValidateResourceConfigResponse {
+	return p.real.ValidateResourceConfig(req)
+}
+
+func (p *Provider) ValidateDataResourceConfig(req providers.ValidateDataResourceConfigRequest) providers.ValidateDataResourceConfigResponse {
+	return p.real.ValidateDataResourceConfig(req)
+}
+
+func (p *Provider) UpgradeResourceState(req providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+	return p.real.UpgradeResourceState(req)
+}
+
+// ConfigureProvider accepts any configuration without configuring the
+// real provider: a mocked step must work without credentials, since
+// needing them would defeat the point of mocking.
+func (p *Provider) ConfigureProvider(req providers.ConfigureProviderRequest) providers.ConfigureProviderResponse {
+	return providers.ConfigureProviderResponse{}
+}
+
+func (p *Provider) Stop() error {
+	return p.real.Stop()
+}
+
+// ReadResource returns the prior state unchanged: the simulated remote
+// system never drifts on its own.
+func (p *Provider) ReadResource(req providers.ReadResourceRequest) providers.ReadResourceResponse {
+	return providers.ReadResourceResponse{
+		NewState: req.PriorState,
+		Private:  req.Private,
+	}
+}
+
+// PlanResourceChange makes the decision a real provider makes during
+// plan: every computed attribute the configuration leaves unset becomes
+// unknown, to be decided by the mock at apply time.
+func (p *Provider) PlanResourceChange(req providers.PlanResourceChangeRequest) providers.PlanResourceChangeResponse {
+	var resp providers.PlanResourceChangeResponse
+
+	if req.ProposedNewState.IsNull() {
+		// Destroying, so there is nothing left to decide.
+		resp.PlannedState = req.ProposedNewState
+		resp.PlannedPrivate = req.PriorPrivate
+		return resp
+	}
+
+	schema := p.resourceSchema(req.TypeName)
+	if schema == nil {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("mocked provider %q has no resource type %q", p.mock.Name, req.TypeName))
+		return resp
+	}
+	resp.PlannedState = markComputedUnknown(schema, req.ProposedNewState)
+	resp.PlannedPrivate = req.PriorPrivate
+	return resp
+}
+
+// ApplyResourceChange settles the planned change against the mock: the
+// unknown computed attributes are filled from the mock's definitions for
+// the type, or generated from the schema.
+func (p *Provider) ApplyResourceChange(req providers.ApplyResourceChangeRequest) providers.ApplyResourceChangeResponse {
+	var resp providers.ApplyResourceChangeResponse
+
+	if req.PlannedState.IsNull() {
+		// Destroy: the mocked remote object simply ceases to exist.
+		resp.NewState = req.PlannedState
+		return resp
+	}
+
+	schema := p.resourceSchema(req.TypeName)
+	if schema == nil {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("mocked provider %q has no resource type %q", p.mock.Name, req.TypeName))
+		return resp
+	}
+
+	// Latency is simulated outside the lock, the way a slow remote API is
+	// slow for each caller rather than one caller at a time.
+	p.mock.SimulateResourceLatency(req.TypeName)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Each apply counts as one call against the type, which is what the
+	// scripted errors and transitions key off.
+	if err := p.mock.NextResourceError(req.TypeName); err != nil {
+		resp.NewState = req.PriorState
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	newState, err := p.mock.ResourceResponse(req.TypeName, schema, req.Config, req.PlannedState)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.NewState = newState
+	resp.Private = req.PlannedPrivate
+	return resp
+}
+
+func (p *Provider) ImportResourceState(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	var resp providers.ImportResourceStateResponse
+	resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("mocked provider %q cannot import resources", p.mock.Name))
+	return resp
+}
+
+// ReadDataSource serves the read from the mock's definitions for the
+// data source type, with generated values filling whatever the
+// definitions leave open.
+func (p *Provider) ReadDataSource(req providers.ReadDataSourceRequest) providers.ReadDataSourceResponse {
+	var resp providers.ReadDataSourceResponse
+
+	schema := p.dataSourceSchema(req.TypeName)
+	if schema == nil {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("mocked provider %q has no data source %q", p.mock.Name, req.TypeName))
+		return resp
+	}
+
+	p.mock.SimulateDataSourceLatency(req.TypeName)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.mock.NextDataSourceError(req.TypeName); err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	state, err := p.mock.DataSourceResponse(req.TypeName, schema, req.Config)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.State = state
+	return resp
+}
+
+func (p *Provider) Close() error {
+	return p.real.Close()
+}
+
+func (p *Provider) resourceSchema(typeName string) *configschema.Block {
+	if s, ok := p.GetProviderSchema().ResourceTypes[typeName]; ok {
+		return s.Block
+	}
+	return nil
+}
+
+func (p *Provider) dataSourceSchema(typeName string) *configschema.Block {
+	if s, ok := p.GetProviderSchema().DataSources[typeName]; ok {
+		return s.Block
+	}
+	return nil
+}
+
+// markComputedUnknown returns a copy of the proposed value with every
+// computed attribute the configuration leaves null marked unknown.
+// Attributes already decided, whether from configuration or prior state,
+// are never touched, so updates keep their existing computed values the
+// same way populateComputed keeps them at apply time.
+func markComputedUnknown(schema *configschema.Block, proposed cty.Value) cty.Value {
+	if proposed == cty.NilVal || proposed.IsNull() || !proposed.IsKnown() {
+		proposed = schema.EmptyValue()
+	}
+
+	vals := make(map[string]cty.Value)
+	for name, attr := range schema.Attributes {
+		given := cty.NullVal(attr.Type)
+		if proposed.Type().HasAttribute(name) {
+			given = proposed.GetAttr(name)
+		}
+		if attr.Computed && given.IsNull() {
+			vals[name] = cty.UnknownVal(attr.Type)
+			continue
+		}
+		vals[name] = given
+	}
+
+	for name, blockS := range schema.BlockTypes {
+		given := cty.NullVal(blockS.Block.ImpliedType())
+		if proposed.Type().HasAttribute(name) {
+			given = proposed.GetAttr(name)
+		}
+		switch blockS.Nesting {
+		case configschema.NestingSingle, configschema.NestingGroup:
+			vals[name] = markComputedUnknown(&blockS.Block, given)
+		default:
+			// Collections of nested blocks come entirely from the
+			// configuration, matching populateComputed.
+			vals[name] = given
+		}
+	}
+
+	return cty.ObjectVal(vals)
+}
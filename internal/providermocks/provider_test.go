@@ -0,0 +1,439 @@
+package providermocks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+)
+
+// fakeRealProvider is the stand-in for a real provider plugin behind the
+// shim: it can answer schema requests and records whether anything ever
+// tried to configure it, which for a mocked provider must never happen.
+type fakeRealProvider struct {
+	schema     providers.GetProviderSchemaResponse
+	configured bool
+	closed     bool
+}
+
+func (p *fakeRealProvider) GetProviderSchema() providers.GetProviderSchemaResponse {
+	return p.schema
+}
+
+func (p *fakeRealProvider) ValidateProviderConfig(req providers.ValidateProviderConfigRequest) providers.ValidateProviderConfigResponse {
+	return providers.ValidateProviderConfigResponse{PreparedConfig: req.Config}
+}
+
+func (p *fakeRealProvider) ValidateResourceConfig(providers.ValidateResourceConfigRequest) providers.ValidateResourceConfigResponse {
+	return providers.ValidateResourceConfigResponse{}
+}
+
+func (p *fakeRealProvider) ValidateDataResourceConfig(providers.ValidateDataResourceConfigRequest) providers.ValidateDataResourceConfigResponse {
+	return providers.ValidateDataResourceConfigResponse{}
+}
+
+func (p *fakeRealProvider) UpgradeResourceState(providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+	return providers.UpgradeResourceStateResponse{}
+}
+
+func (p *fakeRealProvider) ConfigureProvider(providers.ConfigureProviderRequest) providers.ConfigureProviderResponse {
+	p.configured = true
+	return providers.ConfigureProviderResponse{}
+}
+
+func (p *fakeRealProvider) Stop() error { return nil }
+
+func (p *fakeRealProvider) ReadResource(providers.ReadResourceRequest) providers.ReadResourceResponse {
+	return providers.ReadResourceResponse{}
+}
+
+func (p *fakeRealProvider) PlanResourceChange(providers.PlanResourceChangeRequest) providers.PlanResourceChangeResponse {
+	return providers.PlanResourceChangeResponse{}
+}
+
+func (p *fakeRealProvider) ApplyResourceChange(providers.ApplyResourceChangeRequest) providers.ApplyResourceChangeResponse {
+	return providers.ApplyResourceChangeResponse{}
+}
+
+func (p *fakeRealProvider) ImportResourceState(providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	return providers.ImportResourceStateResponse{}
+}
+
+func (p *fakeRealProvider) ReadDataSource(providers.ReadDataSourceRequest) providers.ReadDataSourceResponse {
+	return providers.ReadDataSourceResponse{}
+}
+
+func (p *fakeRealProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+func testShim(t *testing.T, mock *MockProvider) (*Provider, *fakeRealProvider) {
+	t.Helper()
+	real := &fakeRealProvider{
+		schema: providers.GetProviderSchemaResponse{
+			ResourceTypes: map[string]providers.Schema{
+				"aws_instance": {
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"ami": {Type: cty.String, Required: true},
+							"id":  {Type: cty.String, Computed: true},
+							"arn": {Type: cty.String, Computed: true},
+						},
+					},
+				},
+			},
+			DataSources: map[string]providers.Schema{
+				"aws_ami": {
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"name": {Type: cty.String, Required: true},
+							"id":   {Type: cty.String, Computed: true},
+						},
+					},
+				},
+				"aws_instances": {
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"ids": {Type: cty.List(cty.String), Computed: true},
+						},
+					},
+				},
+			},
+		},
+	}
+	factory := WrapFactory(mock, func() (providers.Interface, error) {
+		return real, nil
+	})
+	shim, err := factory()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return shim.(*Provider), real
+}
+
+func TestProviderLifecycle(t *testing.T) {
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		Resources: map[string]*MockResource{
+			"aws_instance": {
+				Type: "aws_instance",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"arn": cty.StringVal("arn:aws:ec2::instance/i-1"),
+				}),
+			},
+		},
+	}
+	shim, real := testShim(t, mock)
+
+	// Configuration is accepted without touching the real provider, so a
+	// mocked step needs no credentials.
+	if resp := shim.ConfigureProvider(providers.ConfigureProviderRequest{}); resp.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", resp.Diagnostics.Err())
+	}
+	if real.configured {
+		t.Fatal("the real provider was configured")
+	}
+
+	// Plan of a create: unset computed attributes become unknown, and
+	// configured values pass through.
+	proposed := cty.ObjectVal(map[string]cty.Value{
+		"ami": cty.StringVal("ami-12345"),
+		"id":  cty.NullVal(cty.String),
+		"arn": cty.NullVal(cty.String),
+	})
+	planResp := shim.PlanResourceChange(providers.PlanResourceChangeRequest{
+		TypeName:         "aws_instance",
+		PriorState:       cty.NullVal(proposed.Type()),
+		ProposedNewState: proposed,
+	})
+	if planResp.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", planResp.Diagnostics.Err())
+	}
+	planned := planResp.PlannedState
+	if got, want := planned.GetAttr("ami"), cty.StringVal("ami-12345"); !got.RawEquals(want) {
+		t.Errorf("wrong planned ami %#v; want %#v", got, want)
+	}
+	if got := planned.GetAttr("id"); got.IsKnown() {
+		t.Errorf("planned id is %#v; want unknown", got)
+	}
+
+	// Apply: the unknowns settle on the mock's defaults and generated
+	// values, and the result is consistent with the plan.
+	applyResp := shim.ApplyResourceChange(providers.ApplyResourceChangeRequest{
+		TypeName:     "aws_instance",
+		PriorState:   cty.NullVal(proposed.Type()),
+		PlannedState: planned,
+	})
+	if applyResp.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", applyResp.Diagnostics.Err())
+	}
+	state := applyResp.NewState
+	if got, want := state.GetAttr("arn"), cty.StringVal("arn:aws:ec2::instance/i-1"); !got.RawEquals(want) {
+		t.Errorf("wrong arn %#v; want %#v", got, want)
+	}
+	if id := state.GetAttr("id"); !id.IsKnown() || id.IsNull() {
+		t.Errorf("id was not generated: %#v", id)
+	}
+
+	// Reads see no drift.
+	readResp := shim.ReadResource(providers.ReadResourceRequest{
+		TypeName:   "aws_instance",
+		PriorState: state,
+	})
+	if !readResp.NewState.RawEquals(state) {
+		t.Errorf("read changed the state\nprior: %#v\nread:  %#v", state, readResp.NewState)
+	}
+
+	// Destroy: the object ceases to exist.
+	destroyResp := shim.ApplyResourceChange(providers.ApplyResourceChangeRequest{
+		TypeName:     "aws_instance",
+		PriorState:   state,
+		PlannedState: cty.NullVal(state.Type()),
+	})
+	if !destroyResp.NewState.IsNull() {
+		t.Errorf("state after destroy is %#v; want null", destroyResp.NewState)
+	}
+
+	if err := shim.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !real.closed {
+		t.Fatal("the real provider was not closed")
+	}
+}
+
+func TestProviderReadDataSource(t *testing.T) {
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		DataSources: map[string]*MockResource{
+			"aws_ami": {
+				Type: "aws_ami",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("ami-mocked"),
+				}),
+			},
+		},
+	}
+	shim, _ := testShim(t, mock)
+
+	resp := shim.ReadDataSource(providers.ReadDataSourceRequest{
+		TypeName: "aws_ami",
+		Config: cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal("web"),
+			"id":   cty.NullVal(cty.String),
+		}),
+	})
+	if resp.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", resp.Diagnostics.Err())
+	}
+	if got, want := resp.State.GetAttr("id"), cty.StringVal("ami-mocked"); !got.RawEquals(want) {
+		t.Errorf("wrong id %#v; want %#v", got, want)
+	}
+
+	// An undefined data source is an error rather than a guess.
+	resp = shim.ReadDataSource(providers.ReadDataSourceRequest{TypeName: "aws_vpc"})
+	if !resp.Diagnostics.HasErrors() {
+		t.Fatal("read of undefined data source succeeded; want error")
+	}
+}
+
+func TestProviderScriptedErrors(t *testing.T) {
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		Resources: map[string]*MockResource{
+			"aws_instance": {
+				Type:   "aws_instance",
+				Errors: []*MockError{{Message: "rate limited", OnCall: 2}},
+			},
+		},
+	}
+	shim, _ := testShim(t, mock)
+
+	req := providers.ApplyResourceChangeRequest{
+		TypeName: "aws_instance",
+		PlannedState: cty.ObjectVal(map[string]cty.Value{
+			"ami": cty.StringVal("ami-12345"),
+			"id":  cty.UnknownVal(cty.String),
+			"arn": cty.UnknownVal(cty.String),
+		}),
+	}
+	if resp := shim.ApplyResourceChange(req); resp.Diagnostics.HasErrors() {
+		t.Fatalf("first apply failed: %s", resp.Diagnostics.Err())
+	}
+	resp := shim.ApplyResourceChange(req)
+	if !resp.Diagnostics.HasErrors() {
+		t.Fatal("second apply succeeded; the script says it fails")
+	}
+	if got := resp.Diagnostics.Err().Error(); !strings.Contains(got, "rate limited") {
+		t.Errorf("wrong error %q; want the scripted message", got)
+	}
+	if resp := shim.ApplyResourceChange(req); resp.Diagnostics.HasErrors() {
+		t.Fatalf("third apply failed: %s", resp.Diagnostics.Err())
+	}
+}
+
+func TestProviderTransitions(t *testing.T) {
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		DataSources: map[string]*MockResource{
+			"aws_ami": {
+				Type: "aws_ami",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("ami-pending"),
+				}),
+				Transitions: []*MockTransition{
+					{
+						OnCall: 2,
+						Values: cty.ObjectVal(map[string]cty.Value{
+							"id": cty.StringVal("ami-available"),
+						}),
+					},
+				},
+			},
+		},
+	}
+	shim, _ := testShim(t, mock)
+
+	req := providers.ReadDataSourceRequest{
+		TypeName: "aws_ami",
+		Config: cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal("web"),
+			"id":   cty.NullVal(cty.String),
+		}),
+	}
+	first := shim.ReadDataSource(req)
+	if got, want := first.State.GetAttr("id"), cty.StringVal("ami-pending"); !got.RawEquals(want) {
+		t.Errorf("wrong id on first read %#v; want %#v", got, want)
+	}
+	second := shim.ReadDataSource(req)
+	if got, want := second.State.GetAttr("id"), cty.StringVal("ami-available"); !got.RawEquals(want) {
+		t.Errorf("wrong id on second read %#v; want %#v", got, want)
+	}
+}
+
+func TestProviderLatency(t *testing.T) {
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		DataSources: map[string]*MockResource{
+			"aws_ami": {
+				Type:    "aws_ami",
+				Latency: 20 * time.Millisecond,
+			},
+		},
+	}
+	shim, _ := testShim(t, mock)
+
+	start := time.Now()
+	resp := shim.ReadDataSource(providers.ReadDataSourceRequest{
+		TypeName: "aws_ami",
+		Config: cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal("web"),
+			"id":   cty.NullVal(cty.String),
+		}),
+	})
+	if resp.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", resp.Diagnostics.Err())
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("read returned after %s; the script asks for at least 20ms", elapsed)
+	}
+}
+
+func TestProviderPagination(t *testing.T) {
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		DataSources: map[string]*MockResource{
+			"aws_instances": {
+				Type: "aws_instances",
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"ids": cty.ListVal([]cty.Value{
+						cty.StringVal("i-1"),
+						cty.StringVal("i-2"),
+						cty.StringVal("i-3"),
+					}),
+				}),
+				Pagination: &MockPagination{Attribute: "ids", PageSize: 2},
+			},
+		},
+	}
+	shim, _ := testShim(t, mock)
+
+	req := providers.ReadDataSourceRequest{
+		TypeName: "aws_instances",
+		Config: cty.ObjectVal(map[string]cty.Value{
+			"ids": cty.NullVal(cty.List(cty.String)),
+		}),
+	}
+	wantPages := [][]string{
+		{"i-1", "i-2"},
+		{"i-3"},
+		{}, // past the last page, which is how a caller detects the end
+	}
+	for call, want := range wantPages {
+		resp := shim.ReadDataSource(req)
+		if resp.Diagnostics.HasErrors() {
+			t.Fatalf("call %d failed: %s", call+1, resp.Diagnostics.Err())
+		}
+		var got []string
+		for it := resp.State.GetAttr("ids").ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			got = append(got, v.AsString())
+		}
+		if len(got) != len(want) {
+			t.Fatalf("call %d returned %v; want %v", call+1, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("call %d returned %v; want %v", call+1, got, want)
+			}
+		}
+	}
+}
+
+func TestProviderTemplates(t *testing.T) {
+	// Responses served through the shim render the type's template, so a
+	// mocked value can derive from the request the way a real provider's
+	// would.
+	mock := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		Resources: map[string]*MockResource{
+			"aws_instance": {
+				Type:     "aws_instance",
+				Template: parseTemplate(t, `{ arn = "arn:aws:ec2::instance/${config.ami}" }`),
+			},
+		},
+	}
+	shim, _ := testShim(t, mock)
+
+	resp := shim.ApplyResourceChange(providers.ApplyResourceChangeRequest{
+		TypeName: "aws_instance",
+		Config: cty.ObjectVal(map[string]cty.Value{
+			"ami": cty.StringVal("ami-12345"),
+			"id":  cty.NullVal(cty.String),
+			"arn": cty.NullVal(cty.String),
+		}),
+		PlannedState: cty.ObjectVal(map[string]cty.Value{
+			"ami": cty.StringVal("ami-12345"),
+			"id":  cty.UnknownVal(cty.String),
+			"arn": cty.UnknownVal(cty.String),
+		}),
+	})
+	if resp.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", resp.Diagnostics.Err())
+	}
+	if got, want := resp.NewState.GetAttr("arn"), cty.StringVal("arn:aws:ec2::instance/ami-12345"); !got.RawEquals(want) {
+		t.Errorf("wrong arn %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package providermocks
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// templateDefaults evaluates the template of the given type definition
+// against the request's configuration and merges its attributes over the
+// given static defaults, so that templated values win where both define
+// the same attribute. Definitions without a template pass the defaults
+// through unchanged.
+func templateDefaults(def *MockResource, defaults, config cty.Value) (cty.Value, error) {
+	if def == nil || def.Template == nil {
+		return defaults, nil
+	}
+
+	if config == cty.NilVal {
+		config = cty.EmptyObjectVal
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"config": config,
+		},
+	}
+	val, diags := def.Template.Value(ctx)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("invalid mock template for %q: %s", def.Type, diags.Error())
+	}
+	if !val.Type().IsObjectType() {
+		return cty.NilVal, fmt.Errorf("invalid mock template for %q: must produce an object, not %s", def.Type, val.Type().FriendlyName())
+	}
+
+	if defaults == cty.NilVal || !defaults.Type().IsObjectType() {
+		return val, nil
+	}
+	merged := defaults.AsValueMap()
+	for name, v := range val.AsValueMap() {
+		merged[name] = v
+	}
+	return cty.ObjectVal(merged), nil
+}
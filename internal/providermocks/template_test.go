@@ -0,0 +1,167 @@
+package providermocks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+// parseTemplate turns HCL expression source into the expression a
+// template attribute would hold after decoding.
+func parseTemplate(t *testing.T, src string) hcl.Expression {
+	t.Helper()
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tftest", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("invalid test expression: %s", diags.Error())
+	}
+	return expr
+}
+
+func TestResourceResponseTemplate(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {
+				Type:     cty.String,
+				Required: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"region": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"bucket": cty.StringVal("logs"),
+		"arn":    cty.NullVal(cty.String),
+		"region": cty.NullVal(cty.String),
+	})
+	proposed := cty.ObjectVal(map[string]cty.Value{
+		"bucket": cty.StringVal("logs"),
+		"arn":    cty.UnknownVal(cty.String),
+		"region": cty.UnknownVal(cty.String),
+	})
+
+	p := &MockProvider{
+		Name: "aws",
+		Seed: "test",
+		Resources: map[string]*MockResource{
+			"aws_s3_bucket": {
+				Type:     "aws_s3_bucket",
+				Template: parseTemplate(t, `{ arn = "arn:aws:s3:::${config.bucket}" }`),
+				Defaults: cty.ObjectVal(map[string]cty.Value{
+					"arn":    cty.StringVal("arn:aws:s3:::static"),
+					"region": cty.StringVal("us-east-1"),
+				}),
+			},
+		},
+	}
+
+	got, err := p.ResourceResponse("aws_s3_bucket", schema, config, proposed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The template sees the request config, and wins over the static
+	// defaults where both define an attribute.
+	if got, want := got.GetAttr("arn"), cty.StringVal("arn:aws:s3:::logs"); !got.RawEquals(want) {
+		t.Errorf("wrong arn %#v; want %#v", got, want)
+	}
+
+	// Attributes the template does not define still come from Defaults.
+	if got, want := got.GetAttr("region"), cty.StringVal("us-east-1"); !got.RawEquals(want) {
+		t.Errorf("wrong region %#v; want %#v", got, want)
+	}
+}
+
+func TestResourceResponseTemplateErrors(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+	config := cty.EmptyObjectVal
+
+	t.Run("undefined reference", func(t *testing.T) {
+		p := &MockProvider{
+			Name: "aws",
+			Resources: map[string]*MockResource{
+				"aws_s3_bucket": {
+					Type:     "aws_s3_bucket",
+					Template: parseTemplate(t, `{ arn = config.nope }`),
+				},
+			},
+		}
+		_, err := p.ResourceResponse("aws_s3_bucket", schema, config, config)
+		if err == nil {
+			t.Fatal("no error for a template referencing a missing attribute")
+		}
+		if !strings.Contains(err.Error(), `"aws_s3_bucket"`) {
+			t.Errorf("error does not name the type: %s", err)
+		}
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		p := &MockProvider{
+			Name: "aws",
+			Resources: map[string]*MockResource{
+				"aws_s3_bucket": {
+					Type:     "aws_s3_bucket",
+					Template: parseTemplate(t, `"just a string"`),
+				},
+			},
+		}
+		_, err := p.ResourceResponse("aws_s3_bucket", schema, config, config)
+		if err == nil || !strings.Contains(err.Error(), "must produce an object") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestDataSourceResponseTemplate(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {
+				Type:     cty.String,
+				Required: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("web"),
+		"id":   cty.NullVal(cty.String),
+	})
+
+	p := &MockProvider{
+		Name: "aws",
+		DataSources: map[string]*MockResource{
+			"aws_ami": {
+				Type:     "aws_ami",
+				Template: parseTemplate(t, `{ id = "ami-${config.name}" }`),
+			},
+		},
+	}
+
+	got, err := p.DataSourceResponse("aws_ami", schema, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := got.GetAttr("id"), cty.StringVal("ami-web"); !got.RawEquals(want) {
+		t.Errorf("wrong id %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package runbooks
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Auth is one credential helper declaration from a runbook's auth
+// blocks. Helpers run before provider configuration, so a break-glass
+// runbook can mint short-lived scoped credentials instead of relying on
+// whatever ambient credentials the operator happens to have.
+type Auth struct {
+	// Name identifies the helper within its runbook. Provider
+	// configuration expressions refer to the minted credentials as
+	// auth.<name>.
+	Name string
+
+	// HelperType names the credential helper this block invokes, such as
+	// an assume-role helper.
+	HelperType string
+
+	// Config is the helper's fully-resolved configuration, with all
+	// expressions already evaluated.
+	Config cty.Value
+}
+
+// CredentialFunc mints credentials from a resolved auth configuration
+// and returns them as a value for provider configuration expressions.
+type CredentialFunc func(config cty.Value) (cty.Value, error)
+
+// Credentials runs the runbook's auth blocks in order and returns the
+// minted credentials keyed by helper name, for the caller to expose to
+// provider configuration evaluation as auth.<name>. The values come back
+// marked "sensitive", and they are ephemeral: valid for this run only,
+// never written to state or to a plan, so callers must not persist them
+// either. A helper failure aborts the run before any provider is
+// configured.
+func (e *Engine) Credentials(rb *Runbook) (map[string]cty.Value, error) {
+	if len(rb.Auth) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]cty.Value, len(rb.Auth))
+	for _, auth := range rb.Auth {
+		helper, ok := e.CredentialHelpers[auth.HelperType]
+		if !ok {
+			return nil, fmt.Errorf("auth %q: no credential helper for type %q", auth.Name, auth.HelperType)
+		}
+		creds, err := helper(auth.Config)
+		if err != nil {
+			return nil, fmt.Errorf("auth %q: %s", auth.Name, err)
+		}
+		if !creds.HasMark("sensitive") {
+			creds = creds.Mark("sensitive")
+		}
+		ret[auth.Name] = creds
+	}
+	return ret, nil
+}
@@ -0,0 +1,59 @@
+package runbooks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEngineCredentials(t *testing.T) {
+	engine := &Engine{
+		CredentialHelpers: map[string]CredentialFunc{
+			"aws_assume_role": func(config cty.Value) (cty.Value, error) {
+				return cty.ObjectVal(map[string]cty.Value{
+					"access_key_id": cty.StringVal("AKIA" + config.GetAttr("role_arn").AsString()),
+				}), nil
+			},
+		},
+	}
+
+	rb := &Runbook{
+		Name: "break_glass",
+		Auth: []*Auth{
+			{
+				Name:       "incident",
+				HelperType: "aws_assume_role",
+				Config:     cty.ObjectVal(map[string]cty.Value{"role_arn": cty.StringVal("arn:aws:iam::123:role/incident")}),
+			},
+		},
+	}
+
+	creds, err := engine.Credentials(rb)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got, ok := creds["incident"]
+	if !ok {
+		t.Fatal("no credentials under the auth block's name")
+	}
+	if !got.HasMark("sensitive") {
+		t.Error("minted credentials are not marked sensitive")
+	}
+
+	// A helper type the engine doesn't know fails before any provider
+	// would be configured.
+	rb.Auth[0].HelperType = "vault_token"
+	if _, err := engine.Credentials(rb); err == nil || !strings.Contains(err.Error(), `"vault_token"`) {
+		t.Errorf("wrong error for unknown helper type: %v", err)
+	}
+
+	// A helper failure aborts with the auth block's name in the error.
+	engine.CredentialHelpers["vault_token"] = func(config cty.Value) (cty.Value, error) {
+		return cty.NilVal, fmt.Errorf("permission denied")
+	}
+	if _, err := engine.Credentials(rb); err == nil || !strings.Contains(err.Error(), `auth "incident"`) {
+		t.Errorf("wrong error for failed helper: %v", err)
+	}
+}
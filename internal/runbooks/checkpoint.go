@@ -0,0 +1,96 @@
+package runbooks
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Checkpoint records which action invocations have completed, so that a
+// resumed runbook run can skip them instead of invoking side-effectful
+// actions a second time. Entries are appended to the backing file as
+// invocations complete, one JSON object per line, so a crash loses at
+// most the invocation that was in flight.
+type Checkpoint struct {
+	mu        sync.Mutex
+	file      *os.File
+	completed map[string]bool
+}
+
+// checkpointEntry is the wire form of one line of the checkpoint file.
+type checkpointEntry struct {
+	Key string `json:"key"`
+}
+
+// OpenCheckpoint opens the checkpoint file at the given path, creating
+// it if it doesn't exist, and loads the keys of the invocations already
+// recorded as completed.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry checkpointEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("invalid checkpoint file %s: %s", path, err)
+		}
+		completed[entry.Key] = true
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Checkpoint{
+		file:      file,
+		completed: completed,
+	}, nil
+}
+
+// Completed reports whether an invocation with the given idempotency key
+// has already been recorded as completed.
+func (c *Checkpoint) Completed(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.completed[key]
+}
+
+// Record marks the invocation with the given idempotency key as
+// completed, appending it to the backing file before returning.
+func (c *Checkpoint) Record(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.completed[key] {
+		return nil
+	}
+	buf, err := json.Marshal(checkpointEntry{Key: key})
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.file, "%s\n", buf); err != nil {
+		return err
+	}
+	if err := c.file.Sync(); err != nil {
+		return err
+	}
+	c.completed[key] = true
+	return nil
+}
+
+// Close closes the backing file. The checkpoint must not be used after
+// closing.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,83 @@
+package runbooks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEngineCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.jsonl")
+
+	var invocations []string
+	newEngine := func(cp *Checkpoint) *Engine {
+		return &Engine{
+			RunID:      "run-1",
+			Checkpoint: cp,
+			Actions: map[string]ActionFunc{
+				"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+					invocations = append(invocations, meta.IdempotencyKey)
+					return cty.True, nil
+				},
+			},
+		}
+	}
+	rb := &Runbook{
+		Name: "restart_fleet",
+		Steps: []*Step{
+			{Name: "restart", ActionType: "aws_restart", Config: cty.EmptyObjectVal, EachKey: "us-east-1"},
+		},
+	}
+
+	cp, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newEngine(cp).Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cp.Close()
+
+	if len(invocations) != 1 {
+		t.Fatalf("wrong number of invocations %d; want 1", len(invocations))
+	}
+	wantKey := "run-1/restart_fleet/restart/aws_restart/us-east-1"
+	if invocations[0] != wantKey {
+		t.Errorf("wrong idempotency key %q; want %q", invocations[0], wantKey)
+	}
+
+	// Resuming the same run with the same checkpoint must not invoke the
+	// action again.
+	cp, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+	if !cp.Completed(wantKey) {
+		t.Fatal("completed invocation missing after reopening the checkpoint")
+	}
+	if err := newEngine(cp).Run(rb); err != nil {
+		t.Fatalf("unexpected error on resume: %s", err)
+	}
+	if len(invocations) != 1 {
+		t.Errorf("resumed run double-invoked the action: %d invocations", len(invocations))
+	}
+
+	// A different run ID is a different logical invocation.
+	engine := newEngine(cp)
+	engine.RunID = "run-2"
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(invocations) != 2 {
+		t.Errorf("new run did not invoke the action: %d invocations", len(invocations))
+	}
+}
@@ -0,0 +1,63 @@
+package runbooks
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// decodeAttrRe extracts the attribute name from hcldec's type mismatch
+// details, which read `Inappropriate value for attribute "ami": ...`.
+var decodeAttrRe = regexp.MustCompile(`attribute "([^"]+)"`)
+
+// DecodeConfigDiagnostics converts the HCL diagnostics from decoding a
+// step's data, list, or action configuration against a provider schema
+// into tfdiags. Type mismatches get the type the schema expects printed
+// inline: hcldec alone reports "Incorrect attribute value type" with
+// just the conversion error, which rarely says what the provider
+// actually wants. The diagnostics keep their expression source ranges,
+// so the rendered output still points at the offending expression.
+func DecodeConfigDiagnostics(hclDiags hcl.Diagnostics, schema *configschema.Block) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for _, hclDiag := range hclDiags {
+		if schema != nil && hclDiag.Severity == hcl.DiagError && hclDiag.Summary == "Incorrect attribute value type" {
+			if m := decodeAttrRe.FindStringSubmatch(hclDiag.Detail); m != nil {
+				if attr, ok := schemaAttribute(schema, m[1]); ok {
+					enriched := *hclDiag
+					enriched.Detail = fmt.Sprintf("%s The provider requires %s for %q.", hclDiag.Detail, attr.Type.FriendlyName(), m[1])
+					diags = diags.Append(&enriched)
+					continue
+				}
+			}
+		}
+		diags = diags.Append(hclDiag)
+	}
+	return diags
+}
+
+// schemaAttribute finds the named attribute in the schema, looking
+// through nested block types when the top-level block doesn't declare
+// it. Where nested blocks reuse an attribute name the first match in
+// depth-first name order wins; providers rarely reuse names with
+// different types, so ambiguity is theoretical.
+func schemaAttribute(schema *configschema.Block, name string) (*configschema.Attribute, bool) {
+	if attr, ok := schema.Attributes[name]; ok {
+		return attr, true
+	}
+	blockNames := make([]string, 0, len(schema.BlockTypes))
+	for blockName := range schema.BlockTypes {
+		blockNames = append(blockNames, blockName)
+	}
+	sort.Strings(blockNames)
+	for _, blockName := range blockNames {
+		if attr, ok := schemaAttribute(&schema.BlockTypes[blockName].Block, name); ok {
+			return attr, true
+		}
+	}
+	return nil, false
+}
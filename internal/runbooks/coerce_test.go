@@ -0,0 +1,107 @@
+package runbooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestDecodeConfigDiagnostics(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami":  {Type: cty.String, Required: true},
+			"tags": {Type: cty.Map(cty.String), Optional: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"filter": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"values": {Type: cty.List(cty.String), Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	src := `
+ami = ["not", "a", "string"]
+filter {
+  values = true
+}
+`
+	file, parseDiags := hclsyntax.ParseConfig([]byte(src), "step.tfbook", hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		t.Fatalf("unexpected parse errors: %s", parseDiags)
+	}
+	_, hclDiags := hcldec.Decode(file.Body, schema.DecoderSpec(), nil)
+	if !hclDiags.HasErrors() {
+		t.Fatal("decode succeeded; the fixture should produce type mismatches")
+	}
+
+	diags := DecodeConfigDiagnostics(hclDiags, schema)
+	if !diags.HasErrors() {
+		t.Fatal("conversion lost the errors")
+	}
+
+	byAttr := make(map[string]tfdiags.Diagnostic)
+	for _, diag := range diags {
+		desc := diag.Description()
+		if m := decodeAttrRe.FindStringSubmatch(desc.Detail); m != nil {
+			byAttr[m[1]] = diag
+		}
+	}
+
+	amiDiag, ok := byAttr["ami"]
+	if !ok {
+		t.Fatal("no diagnostic for attribute \"ami\"")
+	}
+	if detail := amiDiag.Description().Detail; !strings.Contains(detail, `The provider requires string for "ami".`) {
+		t.Errorf("ami diagnostic does not name the expected type:\n%s", detail)
+	}
+	if source := amiDiag.Source(); source.Subject == nil || source.Subject.Filename != "step.tfbook" {
+		t.Errorf("ami diagnostic lost its source range: %#v", amiDiag.Source())
+	}
+
+	// Attributes of nested blocks resolve through the schema's block
+	// types.
+	valuesDiag, ok := byAttr["values"]
+	if !ok {
+		t.Fatal("no diagnostic for attribute \"values\"")
+	}
+	if detail := valuesDiag.Description().Detail; !strings.Contains(detail, `list of string`) {
+		t.Errorf("values diagnostic does not name the expected type:\n%s", detail)
+	}
+}
+
+func TestDecodeConfigDiagnosticsPassthrough(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami": {Type: cty.String, Required: true},
+		},
+	}
+
+	// Diagnostics other than type mismatches pass through unchanged.
+	file, _ := hclsyntax.ParseConfig([]byte(`tags = {}`), "step.tfbook", hcl.InitialPos)
+	_, hclDiags := hcldec.Decode(file.Body, schema.DecoderSpec(), nil)
+	if !hclDiags.HasErrors() {
+		t.Fatal("decode succeeded; the fixture should produce errors")
+	}
+
+	diags := DecodeConfigDiagnostics(hclDiags, schema)
+	if got, want := len(diags), len(hclDiags); got != want {
+		t.Fatalf("wrong diagnostic count %d; want %d", got, want)
+	}
+	for i, diag := range diags {
+		if got, want := diag.Description().Detail, hclDiags[i].Detail; got != want {
+			t.Errorf("diagnostic %d was altered:\ngot:  %s\nwant: %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Package runbooks contains the engine for executing runbooks: ordered
+// sequences of operational steps, such as reading data sources and invoking
+// provider actions, that teams run outside of the usual plan/apply cycle.
+//
+// This package is concerned with the runbook model and its execution. The
+// surrounding machinery — loading runbook configuration, rendering results,
+// and the "terraform runbook" CLI — lives elsewhere and builds on this.
+package runbooks
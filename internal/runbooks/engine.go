@@ -0,0 +1,282 @@
+package runbooks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/depsfile"
+)
+
+// ActionFunc executes a single action invocation with the given resolved
+// configuration and returns the action's result value.
+type ActionFunc func(config cty.Value, meta ActionMeta) (cty.Value, error)
+
+// ActionMeta carries cross-cutting metadata about one action invocation,
+// for the implementation to pass along to its backend — for example as
+// request headers or client capabilities.
+type ActionMeta struct {
+	// IdempotencyKey identifies this logical invocation: the same step
+	// invoking the same action in the same run always gets the same key,
+	// and no other invocation ever does. Backends that honor idempotency
+	// keys can use it to deduplicate retried requests.
+	IdempotencyKey string
+}
+
+// Engine executes runbooks. Action implementations are looked up by action
+// type in Actions, and every registered hook observes each step and action
+// invocation as it happens.
+type Engine struct {
+	// Actions maps action type names to their implementations.
+	Actions map[string]ActionFunc
+
+	// Readers maps data source type names to their implementations, for
+	// the data and list blocks of steps that gather facts.
+	Readers map[string]ReadFunc
+
+	// ReadParallelism bounds how many of a step's independent data reads
+	// run at once. Zero means a default chosen by the engine.
+	ReadParallelism int
+
+	// IdentitySchemas maps data source type names to the identity object
+	// type the provider declares for resources of that type, used to
+	// project each list read's results into typed identities. A list
+	// read of a type with no entry here uses the full resource objects
+	// as their own identities.
+	IdentitySchemas map[string]cty.Type
+
+	// Planners maps action type names to implementations that predict
+	// what the action would do without doing it, for plan-only steps and
+	// dry runs. An action type with no planner plans as its resolved
+	// configuration.
+	Planners map[string]ActionFunc
+
+	// DryRun plans every action instead of invoking it, as if each step
+	// had PlanOnly set.
+	DryRun bool
+
+	// StartProvider, when set, starts provider instances for a per-run
+	// ProviderPool built from the runbook's provider_pool settings. The
+	// engine holds an instance of the serving provider — matched against
+	// the runbook's required_providers by the usual type-name convention
+	// — for the duration of each read and action invocation, so the
+	// pool's MaxInstances bound and idle timeout govern the run's actual
+	// fan-out. Nil disables pooling.
+	StartProvider ProviderStartFunc
+
+	// CredentialHelpers maps auth helper type names to their
+	// implementations, for runbooks with auth blocks.
+	CredentialHelpers map[string]CredentialFunc
+
+	// RunID identifies the run, as the leading component of every
+	// invocation's idempotency key. Resuming an interrupted run must
+	// reuse its ID so the keys — and therefore the checkpoint entries —
+	// line up.
+	RunID string
+
+	// Checkpoint, when set, records completed action invocations and
+	// skips any already recorded, so a resumed run never double-invokes
+	// a side-effectful action.
+	Checkpoint *Checkpoint
+
+	// Hooks observe execution. Hooks run in order, and any hook may veto
+	// a step or an action invocation from its "pre" method.
+	Hooks []Hook
+
+	// ProviderVersions maps the local names from a runbook's
+	// required_providers to the version of the provider plugin actually
+	// instantiated for this run, for Run to check against the runbook's
+	// constraints and ProviderLocks before any step executes.
+	ProviderVersions map[string]string
+
+	// ProviderLocks, when set, is the dependency lock file of the
+	// configuration the runbook operates on. Instantiated providers must
+	// match their locked versions exactly.
+	ProviderLocks *depsfile.Locks
+
+	// BackendLock, when set, acquires named locks through the backend's
+	// locking mechanism, for runbooks and steps whose lock blocks set
+	// backend = true. A backend-routed lock excludes other operators
+	// org-wide, not just other runs in this process.
+	BackendLock LockFunc
+}
+
+// Run executes the given runbook's steps in order, stopping at the first
+// step that fails or is vetoed by a hook, and then checks the runbook's
+// output expectations. An unmet expectation fails the run just as a
+// failed step does.
+func (e *Engine) Run(rb *Runbook) (err error) {
+	if err := e.checkProviderVersions(rb); err != nil {
+		return err
+	}
+
+	// A runbook-level lock is held for the whole run, so a second
+	// operator starting the same runbook waits here rather than
+	// interleaving steps with ours.
+	release, lockErr := e.acquireLock(rb.Lock)
+	if lockErr != nil {
+		return fmt.Errorf("could not acquire lock %q: %s", rb.Lock.Name, lockErr)
+	}
+	defer func() {
+		if releaseErr := release(); releaseErr != nil && err == nil {
+			err = fmt.Errorf("could not release lock %q: %s", rb.Lock.Name, releaseErr)
+		}
+	}()
+
+	// The provider pool is per run: every step's reads and actions
+	// acquire from it, so the runbook's provider_pool settings bound the
+	// whole run rather than any one step.
+	var pool *ProviderPool
+	if e.StartProvider != nil {
+		settings := ProviderPoolSettings{}
+		if rb.ProviderPool != nil {
+			settings = *rb.ProviderPool
+		}
+		pool = NewProviderPool(e.StartProvider, settings)
+		defer pool.Close()
+	}
+
+	for _, step := range rb.Steps {
+		if err := e.runStep(rb, step, pool); err != nil {
+			return fmt.Errorf("step %q failed: %s", step.Name, err)
+		}
+	}
+	for _, output := range rb.Outputs {
+		if err := output.Check(); err != nil {
+			return fmt.Errorf("output %q: %s", output.Name, err)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) runStep(rb *Runbook, step *Step, pool *ProviderPool) (err error) {
+	release, lockErr := e.acquireLock(step.Lock)
+	if lockErr != nil {
+		return fmt.Errorf("could not acquire lock %q: %s", step.Lock.Name, lockErr)
+	}
+	defer func() {
+		if releaseErr := release(); releaseErr != nil && err == nil {
+			err = fmt.Errorf("could not release lock %q: %s", step.Lock.Name, releaseErr)
+		}
+	}()
+
+	for _, hook := range e.Hooks {
+		if err := hook.PreRunbookStep(rb.Name, step); err != nil {
+			return err
+		}
+	}
+
+	stepErr := e.runReads(rb, step, pool)
+	if stepErr == nil && step.ActionType != "" {
+		if step.PlanOnly || e.DryRun {
+			_, stepErr = e.planAction(rb, step)
+		} else {
+			_, stepErr = e.invokeAction(rb, step, pool)
+		}
+	}
+	if stepErr == nil {
+		for _, output := range step.Outputs {
+			if err := output.Check(); err != nil {
+				stepErr = fmt.Errorf("output %q: %s", output.Name, err)
+				break
+			}
+		}
+	}
+
+	for _, hook := range e.Hooks {
+		hook.PostRunbookStep(rb.Name, step, stepErr)
+	}
+	return stepErr
+}
+
+// planAction predicts a step's action without invoking it, for plan-only
+// steps and dry runs. The invoke hooks never fire for a planned action;
+// hooks observe the planned data through PostPlanAction instead.
+func (e *Engine) planAction(rb *Runbook, step *Step) (cty.Value, error) {
+	planned := step.Config
+	var planErr error
+	if planner, ok := e.Planners[step.ActionType]; ok {
+		planned, planErr = planner(step.Config, ActionMeta{
+			IdempotencyKey: e.invocationKey(rb, step),
+		})
+	}
+
+	for _, hook := range e.Hooks {
+		hook.PostPlanAction(rb.Name, step, step.ActionType, planned, planErr)
+	}
+	return planned, planErr
+}
+
+func (e *Engine) invokeAction(rb *Runbook, step *Step, pool *ProviderPool) (cty.Value, error) {
+	action, ok := e.Actions[step.ActionType]
+	if !ok {
+		return cty.NilVal, fmt.Errorf("no implementation for action type %q", step.ActionType)
+	}
+
+	key := e.invocationKey(rb, step)
+	if e.Checkpoint != nil && e.Checkpoint.Completed(key) {
+		// A previous attempt of this run already completed the invocation,
+		// so a resumed runbook must not perform it again. The invoke hooks
+		// don't fire either: nothing is being invoked.
+		return cty.NilVal, nil
+	}
+
+	for _, hook := range e.Hooks {
+		if err := hook.PreInvokeAction(rb.Name, step, step.ActionType, step.Config); err != nil {
+			return cty.NilVal, err
+		}
+	}
+
+	if pool != nil {
+		if provider := providerForType(rb, step.ActionType); provider != "" {
+			inst, err := pool.Acquire(provider)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			defer pool.Release(provider, inst)
+		}
+	}
+
+	result, invokeErr := action(step.Config, ActionMeta{IdempotencyKey: key})
+
+	if invokeErr == nil && e.Checkpoint != nil {
+		if err := e.Checkpoint.Record(key); err != nil {
+			// Failing loudly here is deliberate: without the record, a
+			// resume would invoke the action a second time.
+			invokeErr = fmt.Errorf("action succeeded but recording it in the checkpoint failed: %s", err)
+		}
+	}
+
+	for _, hook := range e.Hooks {
+		hook.PostInvokeAction(rb.Name, step, step.ActionType, result, invokeErr)
+	}
+	return result, invokeErr
+}
+
+// providerForType maps a data source or action type name to the local
+// name of the declared provider that serves it, by the usual type-name
+// convention: the type name is the provider's local name followed by an
+// underscore-separated suffix. The longest declared match wins, so for
+// a google_compute_instance read a declared "google_compute" beats a
+// declared "google". An empty result means no declared provider serves
+// the type, and the pool is not consulted for it.
+func providerForType(rb *Runbook, typeName string) string {
+	best := ""
+	for name := range rb.RequiredProviders {
+		if typeName != name && !strings.HasPrefix(typeName, name+"_") {
+			continue
+		}
+		if len(name) > len(best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// invocationKey derives the idempotency key for a step's action
+// invocation from the run ID, the runbook and step names, the action
+// type, and the step's each key.
+func (e *Engine) invocationKey(rb *Runbook, step *Step) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", e.RunID, rb.Name, step.Name, step.ActionType, step.EachKey)
+}
@@ -0,0 +1,254 @@
+package runbooks
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// recordingHook remembers the order of the hook events it receives.
+type recordingHook struct {
+	NilHook
+	events  []string
+	vetoRe  string
+	vetoErr error
+}
+
+func (h *recordingHook) PreRunbookStep(runbook string, step *Step) error {
+	h.events = append(h.events, "pre-step "+step.Name)
+	if h.vetoRe == step.Name {
+		return h.vetoErr
+	}
+	return nil
+}
+
+func (h *recordingHook) PostRunbookStep(runbook string, step *Step, stepErr error) {
+	h.events = append(h.events, "post-step "+step.Name)
+}
+
+func (h *recordingHook) PreInvokeAction(runbook string, step *Step, actionType string, config cty.Value) error {
+	h.events = append(h.events, "pre-invoke "+actionType)
+	return nil
+}
+
+func (h *recordingHook) PostInvokeAction(runbook string, step *Step, actionType string, result cty.Value, invokeErr error) {
+	h.events = append(h.events, "post-invoke "+actionType)
+}
+
+func (h *recordingHook) PostPlanAction(runbook string, step *Step, actionType string, planned cty.Value, planErr error) {
+	h.events = append(h.events, "post-plan "+actionType)
+}
+
+func TestEngineRun(t *testing.T) {
+	var invoked []string
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				invoked = append(invoked, config.GetAttr("id").AsString())
+				return cty.True, nil
+			},
+		},
+	}
+	hook := &recordingHook{}
+	engine.Hooks = []Hook{hook}
+
+	rb := &Runbook{
+		Name: "restart_fleet",
+		Steps: []*Step{
+			{Name: "gather", Config: cty.EmptyObjectVal},
+			{
+				Name:       "restart",
+				ActionType: "aws_restart",
+				Config:     cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("i-abc123")}),
+			},
+		},
+	}
+
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(invoked, []string{"i-abc123"}) {
+		t.Errorf("wrong action invocations %#v", invoked)
+	}
+
+	want := []string{
+		"pre-step gather",
+		"post-step gather",
+		"pre-step restart",
+		"pre-invoke aws_restart",
+		"post-invoke aws_restart",
+		"post-step restart",
+	}
+	if !reflect.DeepEqual(hook.events, want) {
+		t.Errorf("wrong hook events\ngot:  %#v\nwant: %#v", hook.events, want)
+	}
+}
+
+func TestEngineRunPlanOnly(t *testing.T) {
+	var planned []string
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				t.Error("plan-only action was invoked anyway")
+				return cty.NilVal, nil
+			},
+		},
+		Planners: map[string]ActionFunc{
+			"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				planned = append(planned, config.GetAttr("id").AsString())
+				return config, nil
+			},
+		},
+	}
+	hook := &recordingHook{}
+	engine.Hooks = []Hook{hook}
+
+	rb := &Runbook{
+		Name: "restart_fleet",
+		Steps: []*Step{
+			{
+				Name:       "restart",
+				ActionType: "aws_restart",
+				Config:     cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("i-abc123")}),
+				PlanOnly:   true,
+			},
+		},
+	}
+
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(planned, []string{"i-abc123"}) {
+		t.Errorf("wrong planner invocations %#v", planned)
+	}
+
+	want := []string{
+		"pre-step restart",
+		"post-plan aws_restart",
+		"post-step restart",
+	}
+	if !reflect.DeepEqual(hook.events, want) {
+		t.Errorf("wrong hook events\ngot:  %#v\nwant: %#v", hook.events, want)
+	}
+
+	// The global dry-run plans steps that don't ask for it themselves,
+	// even action types with no planner of their own.
+	engine = &Engine{DryRun: true}
+	hook = &recordingHook{}
+	engine.Hooks = []Hook{hook}
+	rb.Steps[0].PlanOnly = false
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(hook.events, []string{"pre-step restart", "post-plan aws_restart", "post-step restart"}) {
+		t.Errorf("wrong dry-run hook events %#v", hook.events)
+	}
+}
+
+func TestEngineRunVetoed(t *testing.T) {
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				t.Error("vetoed action was invoked anyway")
+				return cty.NilVal, nil
+			},
+		},
+	}
+	hook := &recordingHook{
+		vetoRe:  "restart",
+		vetoErr: fmt.Errorf("not during business hours"),
+	}
+	engine.Hooks = []Hook{hook}
+
+	rb := &Runbook{
+		Name: "restart_fleet",
+		Steps: []*Step{
+			{Name: "restart", ActionType: "aws_restart", Config: cty.EmptyObjectVal},
+			{Name: "after", Config: cty.EmptyObjectVal},
+		},
+	}
+
+	err := engine.Run(rb)
+	if err == nil {
+		t.Fatal("run succeeded despite veto")
+	}
+	for _, ev := range hook.events {
+		if ev == "pre-step after" {
+			t.Error("execution continued past the vetoed step")
+		}
+	}
+}
+
+func TestEngineRunProviderPool(t *testing.T) {
+	// With a StartProvider and a MaxInstances bound of 1, the fan-out of
+	// independent reads must serialize on the pooled provider instance
+	// even though the read parallelism would allow them to overlap.
+	var mu sync.Mutex
+	active, peak, closed := 0, 0, 0
+
+	engine := &Engine{
+		StartProvider: func(provider string) (ProviderInstance, error) {
+			if provider != "aws" {
+				return nil, fmt.Errorf("unexpected provider %q", provider)
+			}
+			return closeFunc(func() error {
+				mu.Lock()
+				closed++
+				mu.Unlock()
+				return nil
+			}), nil
+		},
+		Readers: map[string]ReadFunc{
+			"aws_instance": func(config cty.Value) (cty.Value, error) {
+				mu.Lock()
+				active++
+				if active > peak {
+					peak = active
+				}
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return cty.True, nil
+			},
+		},
+	}
+
+	rb := &Runbook{
+		Name: "pooled",
+		RequiredProviders: map[string]*ProviderRequirement{
+			"aws": {Source: "hashicorp/aws"},
+		},
+		ProviderPool: &ProviderPoolSettings{MaxInstances: 1},
+		Steps: []*Step{
+			{
+				Name: "gather",
+				Reads: []*Read{
+					{Name: "a", ReadType: "aws_instance"},
+					{Name: "b", ReadType: "aws_instance"},
+					{Name: "c", ReadType: "aws_instance"},
+				},
+			},
+		},
+	}
+
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if peak != 1 {
+		t.Errorf("%d reads held the provider at once; MaxInstances is 1", peak)
+	}
+	if closed == 0 {
+		t.Error("no provider instance was closed when the run finished")
+	}
+}
+
+// closeFunc adapts a function to ProviderInstance for tests.
+type closeFunc func() error
+
+func (f closeFunc) Close() error { return f() }
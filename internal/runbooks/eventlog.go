@@ -0,0 +1,156 @@
+package runbooks
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ActionEventLog is a Hook that appends one timestamped JSON object per
+// line to a writer for every action event of a run: an invocation
+// starting, an invocation completing, and a plan-only step's predicted
+// action. Callers point it at a per-run log file so that post-incident
+// review can reconstruct exactly what each action reported and when,
+// independently of whatever view rendered the run to the terminal.
+//
+// Each invocation is numbered in the order it began, so its started and
+// completed events can be paired even when the log is filtered. Writes
+// are serialized internally, so a single log can be shared by several
+// hooks or engines.
+type ActionEventLog struct {
+	NilHook
+
+	mu         sync.Mutex
+	w          io.Writer
+	runID      string
+	invocation int
+	err        error
+}
+
+var _ Hook = (*ActionEventLog)(nil)
+
+// NewActionEventLog returns an event log that writes to w, tagging every
+// event with the given run ID so that archived logs can be correlated
+// with their runs. The run ID may be empty, in which case it is omitted.
+func NewActionEventLog(w io.Writer, runID string) *ActionEventLog {
+	return &ActionEventLog{w: w, runID: runID}
+}
+
+// actionEvent is the wire shape of one log line.
+type actionEvent struct {
+	Time       string          `json:"time"`
+	RunID      string          `json:"run_id,omitempty"`
+	Invocation int             `json:"invocation"`
+	Event      string          `json:"event"`
+	Runbook    string          `json:"runbook"`
+	Step       string          `json:"step"`
+	EachKey    string          `json:"each_key,omitempty"`
+	Action     string          `json:"action"`
+	Config     json.RawMessage `json:"config,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Planned    json.RawMessage `json:"planned,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+func (l *ActionEventLog) PreInvokeAction(runbook string, step *Step, actionType string, config cty.Value) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.invocation++
+	l.writeEvent(actionEvent{
+		Invocation: l.invocation,
+		Event:      "invoke-started",
+		Runbook:    runbook,
+		Step:       step.Name,
+		EachKey:    step.EachKey,
+		Action:     actionType,
+		Config:     eventValue(config),
+	})
+	return nil
+}
+
+func (l *ActionEventLog) PostInvokeAction(runbook string, step *Step, actionType string, result cty.Value, invokeErr error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ev := actionEvent{
+		Invocation: l.invocation,
+		Event:      "invoke-completed",
+		Runbook:    runbook,
+		Step:       step.Name,
+		EachKey:    step.EachKey,
+		Action:     actionType,
+		Result:     eventValue(result),
+	}
+	if invokeErr != nil {
+		ev.Error = invokeErr.Error()
+	}
+	l.writeEvent(ev)
+}
+
+func (l *ActionEventLog) PostPlanAction(runbook string, step *Step, actionType string, planned cty.Value, planErr error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// A planned action is never invoked, so it gets an invocation number
+	// of its own with a single event.
+	l.invocation++
+	ev := actionEvent{
+		Invocation: l.invocation,
+		Event:      "plan-completed",
+		Runbook:    runbook,
+		Step:       step.Name,
+		EachKey:    step.EachKey,
+		Action:     actionType,
+		Planned:    eventValue(planned),
+	}
+	if planErr != nil {
+		ev.Error = planErr.Error()
+	}
+	l.writeEvent(ev)
+}
+
+// writeEvent stamps and writes one event. The caller must hold the
+// mutex. The log is append-only, so after the first write error further
+// events are discarded rather than risking an interleaved partial line;
+// the error is retained for Err.
+func (l *ActionEventLog) writeEvent(ev actionEvent) {
+	if l.err != nil {
+		return
+	}
+	ev.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	ev.RunID = l.runID
+	buf, err := json.Marshal(ev)
+	if err != nil {
+		l.err = err
+		return
+	}
+	if _, err := l.w.Write(append(buf, '\n')); err != nil {
+		l.err = err
+	}
+}
+
+// Err returns the first error encountered while writing the log, if any.
+// Callers that treat the log as an audit record should check it once the
+// run is over and surface the problem, since the hook methods themselves
+// have nowhere to report a failed write.
+func (l *ActionEventLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// eventValue encodes a cty value for a log line. Values that can't be
+// represented in JSON — unknown values, or none at all — are recorded as
+// null, the same convention as WriteOutputsAudit.
+func eventValue(val cty.Value) json.RawMessage {
+	if val == cty.NilVal || !val.IsWhollyKnown() {
+		return json.RawMessage("null")
+	}
+	buf, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(buf)
+}
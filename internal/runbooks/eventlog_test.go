@@ -0,0 +1,123 @@
+package runbooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestActionEventLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewActionEventLog(&buf, "run-123")
+
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				return cty.ObjectVal(map[string]cty.Value{"status": cty.StringVal("ok")}), nil
+			},
+			"pagerduty_page": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				return cty.NilVal, errors.New("pager provider unreachable")
+			},
+		},
+		Hooks: []Hook{log},
+	}
+
+	rb := &Runbook{
+		Name: "restart_fleet",
+		Steps: []*Step{
+			{
+				Name:       "restart",
+				ActionType: "aws_restart",
+				Config:     cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("i-abc123")}),
+			},
+			{
+				Name:       "drill",
+				ActionType: "aws_restart",
+				PlanOnly:   true,
+				Config:     cty.EmptyObjectVal,
+			},
+			{
+				Name:       "page",
+				ActionType: "pagerduty_page",
+				Config:     cty.EmptyObjectVal,
+			},
+		},
+	}
+
+	if err := engine.Run(rb); err == nil {
+		t.Fatal("run succeeded; the page step should have failed it")
+	}
+	if err := log.Err(); err != nil {
+		t.Fatalf("unexpected log error: %s", err)
+	}
+
+	var events []actionEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var ev actionEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("line is not valid JSON: %s\n%s", err, line)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, ev.Time); err != nil {
+			t.Errorf("unparseable timestamp %q: %s", ev.Time, err)
+		}
+		if ev.RunID != "run-123" {
+			t.Errorf("wrong run ID %q for event %q", ev.RunID, ev.Event)
+		}
+		events = append(events, ev)
+	}
+
+	if len(events) != 5 {
+		t.Fatalf("wrong event count %d; want 5", len(events))
+	}
+
+	if events[0].Event != "invoke-started" || events[0].Step != "restart" || events[0].Invocation != 1 {
+		t.Errorf("wrong first event %+v", events[0])
+	}
+	if string(events[0].Config) != `{"id":"i-abc123"}` {
+		t.Errorf("wrong config in started event: %s", events[0].Config)
+	}
+	if events[1].Event != "invoke-completed" || events[1].Invocation != 1 || events[1].Error != "" {
+		t.Errorf("wrong second event %+v", events[1])
+	}
+	if string(events[1].Result) != `{"status":"ok"}` {
+		t.Errorf("wrong result in completed event: %s", events[1].Result)
+	}
+	if events[2].Event != "plan-completed" || events[2].Step != "drill" || events[2].Invocation != 2 {
+		t.Errorf("wrong third event %+v", events[2])
+	}
+	if events[3].Event != "invoke-started" || events[3].Step != "page" || events[3].Invocation != 3 {
+		t.Errorf("wrong fourth event %+v", events[3])
+	}
+	if events[4].Event != "invoke-completed" || events[4].Invocation != 3 || events[4].Error != "pager provider unreachable" {
+		t.Errorf("wrong fifth event %+v", events[4])
+	}
+	if string(events[4].Result) != "null" {
+		t.Errorf("failed invocation should record a null result, got %s", events[4].Result)
+	}
+}
+
+// failingWriter fails every write, to exercise the log's error retention.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestActionEventLogWriteError(t *testing.T) {
+	log := NewActionEventLog(failingWriter{}, "")
+	step := &Step{Name: "restart", ActionType: "aws_restart", Config: cty.EmptyObjectVal}
+
+	if err := log.PreInvokeAction("restart_fleet", step, "aws_restart", step.Config); err != nil {
+		t.Fatalf("PreInvokeAction must not veto the invocation: %s", err)
+	}
+	log.PostInvokeAction("restart_fleet", step, "aws_restart", cty.True, nil)
+
+	if err := log.Err(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("wrong error %v; want the write error", err)
+	}
+}
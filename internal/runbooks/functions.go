@@ -0,0 +1,66 @@
+package runbooks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hashicorp/terraform/lang"
+)
+
+// Functions returns the functions available to expressions in runbook
+// files. The set is the main language's own function table — so helpers
+// like filebase64, textencodebase64, bcrypt, rsadecrypt, and uuidv5 behave
+// exactly as they do in a configuration, and new language functions become
+// available to runbooks without a hand-maintained copy of the table —
+// plus anything registered with RegisterFunction.
+//
+// baseDir is the directory that filesystem functions such as file and
+// filebase64 resolve relative paths against, normally the directory
+// containing the runbook.
+func Functions(baseDir string) map[string]function.Function {
+	scope := &lang.Scope{
+		BaseDir: baseDir,
+	}
+	core := scope.Functions()
+
+	extraMu.Lock()
+	defer extraMu.Unlock()
+
+	ret := make(map[string]function.Function, len(core)+len(extraFunctions))
+	for name, fn := range core {
+		ret[name] = fn
+	}
+	for name, fn := range extraFunctions {
+		ret[name] = fn
+	}
+	return ret
+}
+
+// extraFunctions are the functions registered beyond the core language
+// table, e.g. by runbook-aware plugins.
+var (
+	extraMu        sync.Mutex
+	extraFunctions = make(map[string]function.Function)
+)
+
+// RegisterFunction makes an additional function available to runbook
+// expressions under the given name. It's an error to register a name the
+// core language already defines, since shadowing a language function would
+// make the same expression mean different things in a runbook and a
+// configuration.
+func RegisterFunction(name string, fn function.Function) error {
+	core := (&lang.Scope{}).Functions()
+	if _, exists := core[name]; exists {
+		return fmt.Errorf("can't register runbook function %q: the language already defines a function of that name", name)
+	}
+
+	extraMu.Lock()
+	defer extraMu.Unlock()
+	if _, exists := extraFunctions[name]; exists {
+		return fmt.Errorf("can't register runbook function %q: it is already registered", name)
+	}
+	extraFunctions[name] = fn
+	return nil
+}
@@ -0,0 +1,111 @@
+package runbooks
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// evalTestExpr evaluates one expression string with the runbook function
+// set, failing the test on any diagnostic.
+func evalTestExpr(t *testing.T, baseDir, src string) cty.Value {
+	t.Helper()
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags.Error())
+	}
+	val, diags := expr.Value(&hcl.EvalContext{
+		Functions: Functions(baseDir),
+	})
+	if diags.HasErrors() {
+		t.Fatalf("eval error: %s", diags.Error())
+	}
+	return val
+}
+
+func TestFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "payload.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("filebase64", func(t *testing.T) {
+		got := evalTestExpr(t, dir, `filebase64("payload.txt")`)
+		want := base64.StdEncoding.EncodeToString([]byte("hello"))
+		if got != cty.StringVal(want) {
+			t.Errorf("wrong result %#v; want %q", got, want)
+		}
+	})
+
+	t.Run("textencodebase64", func(t *testing.T) {
+		got := evalTestExpr(t, dir, `textencodebase64("hello", "UTF-8")`)
+		want := base64.StdEncoding.EncodeToString([]byte("hello"))
+		if got != cty.StringVal(want) {
+			t.Errorf("wrong result %#v; want %q", got, want)
+		}
+	})
+
+	t.Run("uuidv5", func(t *testing.T) {
+		// A fixed name in the DNS namespace has a well-known UUID.
+		got := evalTestExpr(t, dir, `uuidv5("dns", "www.example.com")`)
+		if got != cty.StringVal("2ed6657d-e927-568b-95e1-2665a8aea6a2") {
+			t.Errorf("wrong result %#v", got)
+		}
+	})
+
+	t.Run("bcrypt", func(t *testing.T) {
+		got := evalTestExpr(t, dir, `bcrypt("secret")`)
+		if !strings.HasPrefix(got.AsString(), "$2a$") {
+			t.Errorf("result %q doesn't look like a bcrypt hash", got.AsString())
+		}
+	})
+}
+
+func TestRegisterFunction(t *testing.T) {
+	double := function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "s", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			s := args[0].AsString()
+			return cty.StringVal(s + s), nil
+		},
+	})
+
+	if err := RegisterFunction("test_double", double); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer func() {
+		extraMu.Lock()
+		delete(extraFunctions, "test_double")
+		extraMu.Unlock()
+	}()
+
+	got := evalTestExpr(t, ".", `test_double("ab")`)
+	if got != cty.StringVal("abab") {
+		t.Errorf("wrong result %#v", got)
+	}
+
+	// Registering the same name twice is an error.
+	if err := RegisterFunction("test_double", double); err == nil {
+		t.Error("no error for duplicate registration")
+	}
+
+	// Shadowing a core language function is an error.
+	if err := RegisterFunction("filebase64", double); err == nil {
+		t.Error("no error for shadowing a language function")
+	}
+}
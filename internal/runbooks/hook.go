@@ -0,0 +1,51 @@
+package runbooks
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Hook is the interface used to observe — and in some cases veto — runbook
+// execution. The engine calls each hook's methods in order; an error from
+// one of the "pre" methods prevents the step or action from running at all.
+type Hook interface {
+	// PreRunbookStep runs before a step. A non-nil error aborts the step
+	// and therefore the rest of the runbook.
+	PreRunbookStep(runbook string, step *Step) error
+
+	// PostRunbookStep runs after a step has finished, with the error that
+	// ended it, if any.
+	PostRunbookStep(runbook string, step *Step, stepErr error)
+
+	// PreInvokeAction runs before a step invokes an action. A non-nil
+	// error prevents the invocation.
+	PreInvokeAction(runbook string, step *Step, actionType string, config cty.Value) error
+
+	// PostInvokeAction runs after an action invocation has finished, with
+	// its result and the error that ended it, if any.
+	PostInvokeAction(runbook string, step *Step, actionType string, result cty.Value, invokeErr error)
+
+	// PostPlanAction runs after a plan-only or dry-run step has planned
+	// its action, with the planned action data. Such steps never reach
+	// PreInvokeAction or PostInvokeAction.
+	PostPlanAction(runbook string, step *Step, actionType string, planned cty.Value, planErr error)
+}
+
+// NilHook is a Hook implementation that does nothing, for embedding in
+// hooks that only care about some of the events.
+type NilHook struct{}
+
+var _ Hook = (*NilHook)(nil)
+
+func (*NilHook) PreRunbookStep(runbook string, step *Step) error { return nil }
+
+func (*NilHook) PostRunbookStep(runbook string, step *Step, stepErr error) {}
+
+func (*NilHook) PreInvokeAction(runbook string, step *Step, actionType string, config cty.Value) error {
+	return nil
+}
+
+func (*NilHook) PostInvokeAction(runbook string, step *Step, actionType string, result cty.Value, invokeErr error) {
+}
+
+func (*NilHook) PostPlanAction(runbook string, step *Step, actionType string, planned cty.Value, planErr error) {
+}
@@ -0,0 +1,163 @@
+package runbooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// LintFinding is one problem a lint rule found in a runbook. The JSON
+// form is the machine-readable output of "terraform runbook lint", for
+// CI gates that parse it.
+type LintFinding struct {
+	// Rule is the name of the rule that produced the finding.
+	Rule string `json:"rule"`
+
+	// Severity is either LintError or LintWarning.
+	Severity string `json:"severity"`
+
+	// Runbook and Step locate the finding. Step is empty for findings
+	// about the runbook as a whole.
+	Runbook string `json:"runbook"`
+	Step    string `json:"step,omitempty"`
+
+	// Message describes the problem and what to do about it.
+	Message string `json:"message"`
+}
+
+// The severities a lint finding can have. Errors are meant to fail a CI
+// gate; warnings are advisory.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// The built-in lint rule names, for enabling and disabling them in a
+// lint configuration.
+const (
+	RuleUnusedVariable     = "unused-variable"
+	RuleNoEffectStep       = "no-effect-step"
+	RuleUnguardedAction    = "unguarded-action"
+	RuleMissingDescription = "missing-description"
+	RuleUnpinnedProvider   = "unpinned-provider"
+)
+
+// lintRules names every built-in rule, for validating the rule names a
+// lint configuration disables.
+var lintRules = map[string]bool{
+	RuleUnusedVariable:     true,
+	RuleNoEffectStep:       true,
+	RuleUnguardedAction:    true,
+	RuleMissingDescription: true,
+	RuleUnpinnedProvider:   true,
+}
+
+// LintConfig adjusts which rules run, from a lint configuration block.
+// All rules are enabled by default.
+type LintConfig struct {
+	// Disabled names rules to skip.
+	Disabled []string
+}
+
+func (c *LintConfig) ruleEnabled(rule string) bool {
+	if c == nil {
+		return true
+	}
+	for _, name := range c.Disabled {
+		if name == rule {
+			return false
+		}
+	}
+	return true
+}
+
+// Lint checks the given runbook against the built-in rules, minus any
+// the configuration disables, and returns the findings in a stable
+// order. An empty result means the runbook is clean.
+func Lint(rb *Runbook, cfg *LintConfig) []*LintFinding {
+	var ret []*LintFinding
+	finding := func(rule, severity, step, message string) {
+		if !cfg.ruleEnabled(rule) {
+			return
+		}
+		ret = append(ret, &LintFinding{
+			Rule:     rule,
+			Severity: severity,
+			Runbook:  rb.Name,
+			Step:     step,
+			Message:  message,
+		})
+	}
+
+	if rb.Description == "" {
+		finding(RuleMissingDescription, LintWarning, "",
+			"The runbook has no description; operators browsing a runbook library can't tell what it does or when to run it.")
+	}
+
+	used := make(map[string]bool)
+	for _, step := range rb.Steps {
+		for _, name := range step.VariableRefs {
+			used[name] = true
+		}
+	}
+	varNames := make([]string, 0, len(rb.Variables))
+	for name := range rb.Variables {
+		varNames = append(varNames, name)
+	}
+	sort.Strings(varNames)
+	for _, name := range varNames {
+		if !used[name] {
+			finding(RuleUnusedVariable, LintWarning, "",
+				fmt.Sprintf("The variable %q is declared but no step refers to it.", name))
+		}
+	}
+
+	verified := false
+	for _, output := range rb.Outputs {
+		if output.Expect != nil {
+			verified = true
+			break
+		}
+	}
+	for _, step := range rb.Steps {
+		if step.Description == "" {
+			finding(RuleMissingDescription, LintWarning, step.Name,
+				"The step has no description.")
+		}
+		if step.ActionType == "" {
+			finding(RuleNoEffectStep, LintWarning, step.Name,
+				"The step invokes no action, so running it has no effect.")
+			continue
+		}
+		if !step.PlanOnly && !verified {
+			finding(RuleUnguardedAction, LintWarning, step.Name,
+				fmt.Sprintf("The step invokes action %q but nothing verifies its result; add an output with an expect block, or mark the step plan_only.", step.ActionType))
+		}
+	}
+
+	provNames := make([]string, 0, len(rb.RequiredProviders))
+	for name := range rb.RequiredProviders {
+		provNames = append(provNames, name)
+	}
+	sort.Strings(provNames)
+	for _, name := range provNames {
+		if rb.RequiredProviders[name].Version == "" {
+			finding(RuleUnpinnedProvider, LintError, "",
+				fmt.Sprintf("The provider requirement %q has no version constraint, so different operators may run the runbook against different provider versions.", name))
+		}
+	}
+
+	return ret
+}
+
+// WriteLintJSON writes the findings to w as a JSON array, the
+// machine-readable form of the lint output.
+func WriteLintJSON(w io.Writer, findings []*LintFinding) error {
+	if findings == nil {
+		findings = []*LintFinding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
@@ -0,0 +1,108 @@
+package runbooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLint(t *testing.T) {
+	rb := &Runbook{
+		Name: "restart_fleet",
+		Variables: map[string]*Variable{
+			"region": {Name: "region"},
+			"unused": {Name: "unused"},
+		},
+		RequiredProviders: map[string]*ProviderRequirement{
+			"aws":    {Source: "hashicorp/aws"},
+			"random": {Source: "hashicorp/random", Version: "~> 3.0"},
+		},
+		Steps: []*Step{
+			{
+				Name:         "restart",
+				ActionType:   "aws_restart",
+				Config:       cty.EmptyObjectVal,
+				VariableRefs: []string{"region"},
+			},
+			{Name: "noop", Config: cty.EmptyObjectVal},
+		},
+	}
+
+	findings := Lint(rb, nil)
+
+	wantRules := map[string]int{
+		RuleMissingDescription: 3, // the runbook and both steps
+		RuleUnusedVariable:     1, // "unused"
+		RuleUnguardedAction:    1, // "restart" with nothing verifying it
+		RuleNoEffectStep:       1, // "noop"
+		RuleUnpinnedProvider:   1, // "aws"
+	}
+	gotRules := make(map[string]int)
+	for _, f := range findings {
+		gotRules[f.Rule]++
+	}
+	for rule, want := range wantRules {
+		if gotRules[rule] != want {
+			t.Errorf("wrong number of %s findings %d; want %d", rule, gotRules[rule], want)
+		}
+	}
+	if len(findings) != 7 {
+		t.Errorf("wrong total number of findings %d; want 7", len(findings))
+	}
+
+	// Disabling rules suppresses their findings.
+	findings = Lint(rb, &LintConfig{
+		Disabled: []string{RuleMissingDescription, RuleNoEffectStep},
+	})
+	for _, f := range findings {
+		if f.Rule == RuleMissingDescription || f.Rule == RuleNoEffectStep {
+			t.Errorf("finding from disabled rule %s", f.Rule)
+		}
+	}
+
+	// A runbook with descriptions, expectations, and pins is clean.
+	clean := &Runbook{
+		Name:        "verified",
+		Description: "Restarts the fleet and verifies it came back.",
+		Steps: []*Step{
+			{Name: "restart", Description: "Restart everything.", ActionType: "aws_restart", Config: cty.EmptyObjectVal},
+		},
+		Outputs: []*Output{
+			{Name: "status", Value: cty.StringVal("ok"), Expect: &Expect{Equals: cty.StringVal("ok")}},
+		},
+	}
+	if findings := Lint(clean, nil); len(findings) != 0 {
+		t.Errorf("findings for clean runbook: %#v", findings)
+	}
+}
+
+func TestWriteLintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLintJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %s", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("non-empty array for no findings: %s", buf.String())
+	}
+
+	buf.Reset()
+	findings := []*LintFinding{
+		{Rule: RuleNoEffectStep, Severity: LintWarning, Runbook: "restart_fleet", Step: "noop", Message: "The step invokes no action, so running it has no effect."},
+	}
+	if err := WriteLintJSON(&buf, findings); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*LintFinding
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Rule != RuleNoEffectStep || decoded[0].Step != "noop" {
+		t.Errorf("wrong round-tripped findings: %s", buf.String())
+	}
+}
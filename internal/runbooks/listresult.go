@@ -0,0 +1,85 @@
+package runbooks
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// listResultValue normalizes the raw result of a list read into its
+// first-class shape: an object with an "identities" attribute projecting
+// each found resource through the provider's identity schema, an "objects"
+// attribute with the full resource objects, and a "data" attribute
+// preserving the shape older runbooks relied on.
+//
+// The raw result may be a sequence of resource objects, or the legacy
+// object-with-"data"-attribute shape some readers produce, in which case
+// the "data" attribute is taken as the sequence.
+func listResultValue(raw cty.Value, identityTy cty.Type) (cty.Value, error) {
+	if raw != cty.NilVal && raw.Type().IsObjectType() && raw.Type().HasAttribute("data") {
+		raw = raw.GetAttr("data")
+	}
+	if raw == cty.NilVal || raw.IsNull() {
+		raw = cty.EmptyTupleVal
+	}
+	if ty := raw.Type(); !ty.IsTupleType() && !ty.IsListType() && !ty.IsSetType() {
+		return cty.NilVal, fmt.Errorf("list result is %s; a list read must return a sequence of resource objects", ty.FriendlyName())
+	}
+
+	var objects, identities []cty.Value
+	for it := raw.ElementIterator(); it.Next(); {
+		_, obj := it.Element()
+		objects = append(objects, obj)
+
+		identity, err := identityValue(obj, identityTy)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("result %d: %s", len(objects)-1, err)
+		}
+		identities = append(identities, identity)
+	}
+
+	objectsVal := cty.EmptyTupleVal
+	if len(objects) > 0 {
+		objectsVal = cty.TupleVal(objects)
+	}
+	identitiesVal := cty.EmptyTupleVal
+	if len(identities) > 0 {
+		identitiesVal = cty.TupleVal(identities)
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"identities": identitiesVal,
+		"objects":    objectsVal,
+		"data":       objectsVal,
+	}), nil
+}
+
+// identityValue projects one resource object through the provider's
+// identity schema, keeping only the identity attributes and converting
+// each to its declared type. Without an identity schema the full object is
+// the best identity available.
+func identityValue(obj cty.Value, identityTy cty.Type) (cty.Value, error) {
+	if identityTy == cty.NilType {
+		return obj, nil
+	}
+	if !identityTy.IsObjectType() {
+		return cty.NilVal, fmt.Errorf("identity schema is %s, not an object type", identityTy.FriendlyName())
+	}
+	if !obj.Type().IsObjectType() {
+		return cty.NilVal, fmt.Errorf("resource object is %s, not an object", obj.Type().FriendlyName())
+	}
+
+	attrs := make(map[string]cty.Value, len(identityTy.AttributeTypes()))
+	for name, wantTy := range identityTy.AttributeTypes() {
+		if !obj.Type().HasAttribute(name) {
+			return cty.NilVal, fmt.Errorf("resource object has no attribute %q, required by the provider's identity schema", name)
+		}
+		val, err := convert.Convert(obj.GetAttr(name), wantTy)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("identity attribute %q: %s", name, err)
+		}
+		attrs[name] = val
+	}
+	return cty.ObjectVal(attrs), nil
+}
@@ -0,0 +1,151 @@
+package runbooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestListResultValue(t *testing.T) {
+	identityTy := cty.Object(map[string]cty.Type{
+		"id": cty.String,
+	})
+
+	instance := func(id int64, name string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"id":   cty.NumberIntVal(id),
+			"name": cty.StringVal(name),
+		})
+	}
+
+	t.Run("sequence of objects", func(t *testing.T) {
+		raw := cty.TupleVal([]cty.Value{instance(1, "web"), instance(2, "db")})
+		got, err := listResultValue(raw, identityTy)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		identities := got.GetAttr("identities")
+		if identities.LengthInt() != 2 {
+			t.Fatalf("wrong identity count %d", identities.LengthInt())
+		}
+		// The identity keeps only the schema's attributes, converted to
+		// their declared types: the numeric id becomes a string.
+		first := identities.Index(cty.NumberIntVal(0))
+		if !first.RawEquals(cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("1")})) {
+			t.Errorf("wrong first identity %#v", first)
+		}
+
+		objects := got.GetAttr("objects")
+		if !objects.Index(cty.NumberIntVal(1)).RawEquals(instance(2, "db")) {
+			t.Errorf("wrong second object %#v", objects.Index(cty.NumberIntVal(1)))
+		}
+		if !got.GetAttr("data").RawEquals(objects) {
+			t.Errorf("data attribute doesn't match objects")
+		}
+	})
+
+	t.Run("legacy data-attribute shape", func(t *testing.T) {
+		raw := cty.ObjectVal(map[string]cty.Value{
+			"data": cty.TupleVal([]cty.Value{instance(1, "web")}),
+		})
+		got, err := listResultValue(raw, identityTy)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.GetAttr("objects").LengthInt() != 1 {
+			t.Errorf("wrong object count %d", got.GetAttr("objects").LengthInt())
+		}
+	})
+
+	t.Run("no identity schema", func(t *testing.T) {
+		raw := cty.TupleVal([]cty.Value{instance(1, "web")})
+		got, err := listResultValue(raw, cty.NilType)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		// Without a schema, the full object is its own identity.
+		if !got.GetAttr("identities").RawEquals(got.GetAttr("objects")) {
+			t.Errorf("identities don't fall back to the full objects")
+		}
+	})
+
+	t.Run("empty result", func(t *testing.T) {
+		got, err := listResultValue(cty.NullVal(cty.DynamicPseudoType), identityTy)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.GetAttr("identities").LengthInt() != 0 || got.GetAttr("objects").LengthInt() != 0 {
+			t.Errorf("wrong value for empty result: %#v", got)
+		}
+	})
+
+	t.Run("not a sequence", func(t *testing.T) {
+		_, err := listResultValue(cty.StringVal("nope"), identityTy)
+		if err == nil || !strings.Contains(err.Error(), "must return a sequence") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("missing identity attribute", func(t *testing.T) {
+		raw := cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("web")}),
+		})
+		_, err := listResultValue(raw, identityTy)
+		if err == nil || !strings.Contains(err.Error(), `no attribute "id"`) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestEngineRunReadsList(t *testing.T) {
+	engine := &Engine{
+		Readers: map[string]ReadFunc{
+			"aws_instance": func(config cty.Value) (cty.Value, error) {
+				return cty.TupleVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.StringVal("i-1234"),
+						"name": cty.StringVal("web"),
+					}),
+				}), nil
+			},
+			"dns": func(config cty.Value) (cty.Value, error) {
+				return cty.StringVal("example.com"), nil
+			},
+		},
+		IdentitySchemas: map[string]cty.Type{
+			"aws_instance": cty.Object(map[string]cty.Type{"id": cty.String}),
+		},
+	}
+
+	step := &Step{
+		Name: "gather",
+		Reads: []*Read{
+			{Name: "all", ReadType: "aws_instance", List: true},
+			{Name: "zone", ReadType: "dns"},
+		},
+	}
+	if err := engine.runReads(&Runbook{}, step, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lists := step.ListResults()
+	identities := lists.GetAttr("aws_instance").GetAttr("all").GetAttr("identities")
+	want := cty.TupleVal([]cty.Value{
+		cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("i-1234")}),
+	})
+	if !identities.RawEquals(want) {
+		t.Errorf("wrong identities\ngot:  %#v\nwant: %#v", identities, want)
+	}
+
+	// Data reads and list reads are kept apart, so the "data" and "list"
+	// symbols in the eval context don't collide.
+	data := step.ReadResults()
+	if data.Type().HasAttribute("aws_instance") {
+		t.Errorf("list read leaked into ReadResults: %#v", data)
+	}
+	if !data.GetAttr("dns").GetAttr("zone").RawEquals(cty.StringVal("example.com")) {
+		t.Errorf("wrong data read results: %#v", data)
+	}
+}
@@ -0,0 +1,919 @@
+package runbooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// runbookSuffix is the filename suffix that marks a file as a runbook.
+const runbookSuffix = ".tfbook"
+
+// RunbookFiles returns the paths of the runbook files in the given
+// directory, sorted by name. A directory without any is not an error;
+// callers decide whether an empty result is a problem.
+func RunbookFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name := entry.Name(); strings.HasSuffix(name, runbookSuffix) && len(name) > len(runbookSuffix) {
+			ret = append(ret, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// LoadRunbookVariables parses the given runbook file just far enough to
+// return its variable declarations, so a caller can resolve values for
+// them — see VariableValues — before loading the runbook in full.
+func LoadRunbookVariables(path string) (map[string]*Variable, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	file, moreDiags := parseRunbookFile(path)
+	diags = diags.Append(moreDiags)
+	if file == nil {
+		return nil, diags
+	}
+
+	content, _, hclDiags := file.Body.PartialContent(runbookFileSchema)
+	diags = diags.Append(hclDiags)
+
+	decls := make(map[string]*Variable)
+	for _, block := range content.Blocks {
+		if block.Type != "variable" {
+			continue
+		}
+		decl, moreDiags := decodeVariableBlock(block)
+		diags = diags.Append(moreDiags)
+		if decl != nil {
+			decls[decl.Name] = decl
+		}
+	}
+	return decls, diags
+}
+
+// LoadRunbookFile loads a single runbook file. The runbook's name is the
+// filename without its .tfbook suffix.
+//
+// The schemas, when given, map data source type names to their provider
+// schemas: the data and list blocks of those types decode against them,
+// so a misspelled argument or a value of the wrong type fails at load
+// with a diagnostic naming what the provider expects — see
+// DecodeConfigDiagnostics — instead of surfacing from the provider mid
+// run. Types without a schema, and callers passing nil, decode free-form
+// as plain attribute sets.
+//
+// Step and output expressions are evaluated now, against the given
+// variable values and the runbook function set: the runbook model is
+// fully resolved, so that what the engine executes is exactly what hooks
+// and the checkpoint record. A declared variable the caller gives no
+// value for evaluates from its default, or as an unknown value when it
+// has none, which is enough for callers that only inspect the runbook —
+// lint, preflight — rather than execute it. References to the runbook's
+// secrets and auth helpers also evaluate as unknown, since those resolve
+// only at run time; see Engine.Secrets and Engine.Credentials.
+func LoadRunbookFile(path string, variables map[string]cty.Value, schemas map[string]*configschema.Block) (*Runbook, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	file, moreDiags := parseRunbookFile(path)
+	diags = diags.Append(moreDiags)
+	if file == nil {
+		return nil, diags
+	}
+
+	rb := &Runbook{
+		Name: strings.TrimSuffix(filepath.Base(path), runbookSuffix),
+	}
+
+	content, hclDiags := file.Body.Content(runbookFileSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["description"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &rb.Description)
+		diags = diags.Append(valDiags)
+	}
+
+	// Declarations decode first, so that the evaluation context for step
+	// and output expressions can know every variable, secret, and auth
+	// name regardless of where its block sits in the file.
+	var authNames []string
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "auth":
+			authNames = append(authNames, block.Labels[0])
+		case "variable":
+			decl, moreDiags := decodeVariableBlock(block)
+			diags = diags.Append(moreDiags)
+			if decl == nil {
+				continue
+			}
+			if _, exists := rb.Variables[decl.Name]; exists {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate variable declaration",
+					Detail:   fmt.Sprintf("A variable named %q was already declared in this runbook.", decl.Name),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			if rb.Variables == nil {
+				rb.Variables = make(map[string]*Variable)
+			}
+			rb.Variables[decl.Name] = decl
+		case "required_providers":
+			moreDiags := decodeRequiredProvidersBlock(block, rb)
+			diags = diags.Append(moreDiags)
+		case "provider_pool":
+			settings, moreDiags := decodeProviderPoolBlock(block)
+			diags = diags.Append(moreDiags)
+			if settings != nil {
+				rb.ProviderPool = settings
+			}
+		case "lock":
+			lock, moreDiags := decodeLockBlock(block)
+			diags = diags.Append(moreDiags)
+			if lock != nil {
+				rb.Lock = lock
+			}
+		case "secret":
+			secret, moreDiags := decodeSecretBlock(block)
+			diags = diags.Append(moreDiags)
+			if secret != nil {
+				rb.Secrets = append(rb.Secrets, secret)
+			}
+		case "lint":
+			cfg, moreDiags := decodeLintBlock(block)
+			diags = diags.Append(moreDiags)
+			if cfg != nil {
+				rb.Lint = cfg
+			}
+		}
+	}
+
+	ctx := runbookEvalContext(filepath.Dir(path), rb, authNames, variables)
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "auth":
+			auth, moreDiags := decodeAuthBlock(block, ctx)
+			diags = diags.Append(moreDiags)
+			if auth != nil {
+				rb.Auth = append(rb.Auth, auth)
+			}
+		case "step":
+			step, moreDiags := decodeRunbookStepBlock(block, ctx, schemas)
+			diags = diags.Append(moreDiags)
+			if step != nil {
+				rb.Steps = append(rb.Steps, step)
+			}
+		case "output":
+			output, moreDiags := decodeOutputBlock(block, ctx, nil)
+			diags = diags.Append(moreDiags)
+			if output != nil {
+				rb.Outputs = append(rb.Outputs, output)
+			}
+		}
+	}
+
+	return rb, diags
+}
+
+func parseRunbookFile(path string) (*hcl.File, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	parser := hclparse.NewParser()
+	file, hclDiags := parser.ParseHCLFile(path)
+	diags = diags.Append(hclDiags)
+	return file, diags
+}
+
+// runbookEvalContext builds the evaluation context for a runbook file's
+// expressions: the variable values under "var", the runbook function set
+// with relative paths resolved against the runbook's directory, and
+// unknown placeholders under "secret" and "auth" for the values that
+// exist only at run time.
+func runbookEvalContext(baseDir string, rb *Runbook, authNames []string, variables map[string]cty.Value) *hcl.EvalContext {
+	vars := make(map[string]cty.Value, len(rb.Variables))
+	for name, decl := range rb.Variables {
+		switch {
+		case variables[name] != cty.NilVal:
+			vars[name] = variables[name]
+		case decl.Default != cty.NilVal:
+			vars[name] = decl.Default
+		default:
+			vars[name] = cty.DynamicVal
+		}
+	}
+
+	secrets := make(map[string]cty.Value, len(rb.Secrets))
+	for _, secret := range rb.Secrets {
+		secrets[secret.Name] = cty.UnknownVal(cty.String)
+	}
+	auths := make(map[string]cty.Value, len(authNames))
+	for _, name := range authNames {
+		auths[name] = cty.DynamicVal
+	}
+
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":    cty.ObjectVal(vars),
+			"secret": cty.ObjectVal(secrets),
+			"auth":   cty.ObjectVal(auths),
+		},
+		Functions: Functions(baseDir),
+	}
+}
+
+func decodeVariableBlock(block *hcl.Block) (*Variable, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	decl := &Variable{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(runbookVariableBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["default"]; exists {
+		// Defaults are static data, with no other symbols in scope.
+		val, hclDiags := attr.Expr.Value(nil)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			decl.Default = val
+		}
+	}
+
+	return decl, diags
+}
+
+// decodeRequiredProvidersBlock decodes a required_providers block, whose
+// entries use the same object form as module requirements:
+//
+//	required_providers {
+//	  aws = { source = "hashicorp/aws", version = ">= 3.0" }
+//	}
+func decodeRequiredProvidersBlock(block *hcl.Block, rb *Runbook) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	attrs, hclDiags := block.Body.JustAttributes()
+	diags = diags.Append(hclDiags)
+
+	for name, attr := range attrs {
+		val, hclDiags := attr.Expr.Value(nil)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			continue
+		}
+		if !val.Type().IsObjectType() {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider requirement",
+				Detail:   fmt.Sprintf("The requirement for provider %q must be an object with source and version attributes.", name),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+		req := &ProviderRequirement{}
+		if val.Type().HasAttribute("source") {
+			if source := val.GetAttr("source"); source.Type() == cty.String && !source.IsNull() {
+				req.Source = source.AsString()
+			}
+		}
+		if val.Type().HasAttribute("version") {
+			if version := val.GetAttr("version"); version.Type() == cty.String && !version.IsNull() {
+				req.Version = version.AsString()
+			}
+		}
+		if rb.RequiredProviders == nil {
+			rb.RequiredProviders = make(map[string]*ProviderRequirement)
+		}
+		rb.RequiredProviders[name] = req
+	}
+
+	return diags
+}
+
+func decodeProviderPoolBlock(block *hcl.Block) (*ProviderPoolSettings, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	settings := &ProviderPoolSettings{}
+
+	content, hclDiags := block.Body.Content(providerPoolBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["max_instances"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &settings.MaxInstances)
+		diags = diags.Append(valDiags)
+		if settings.MaxInstances < 0 {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider pool limit",
+				Detail:   "The max_instances limit must be at least 1, or 0 for no bound.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			return nil, diags
+		}
+	}
+
+	if attr, exists := content.Attributes["idle_timeout"]; exists {
+		var raw string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &raw)
+		diags = diags.Append(valDiags)
+		if !valDiags.HasErrors() {
+			timeout, err := time.ParseDuration(raw)
+			if err != nil || timeout < 0 {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid idle timeout",
+					Detail:   fmt.Sprintf("The idle_timeout %q is not valid: must be a non-negative duration like \"1m\".", raw),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+				return nil, diags
+			}
+			settings.IdleTimeout = timeout
+		}
+	}
+
+	return settings, diags
+}
+
+func decodeLockBlock(block *hcl.Block) (*Lock, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	lock := &Lock{}
+
+	content, hclDiags := block.Body.Content(runbookLockBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["name"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &lock.Name)
+		diags = diags.Append(valDiags)
+	}
+	if lock.Name == "" {
+		// Content already reported the missing required attribute.
+		return nil, diags
+	}
+	if attr, exists := content.Attributes["backend"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &lock.Backend)
+		diags = diags.Append(valDiags)
+	}
+
+	return lock, diags
+}
+
+// decodeSecretBlock decodes a secret block, whose source follows from
+// which of the env, command, and file attributes is set; exactly one
+// must be.
+func decodeSecretBlock(block *hcl.Block) (*Secret, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	secret := &Secret{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(runbookSecretBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	sources := 0
+	if attr, exists := content.Attributes["env"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &secret.Var)
+		diags = diags.Append(valDiags)
+		secret.Source = SecretSourceEnv
+		sources++
+	}
+	if attr, exists := content.Attributes["command"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &secret.Command)
+		diags = diags.Append(valDiags)
+		secret.Source = SecretSourceCommand
+		sources++
+	}
+	if attr, exists := content.Attributes["file"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &secret.File)
+		diags = diags.Append(valDiags)
+		secret.Source = SecretSourceFile
+		sources++
+	}
+	if sources != 1 {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid secret source",
+			Detail:   fmt.Sprintf("The secret %q must set exactly one of env, command, or file.", secret.Name),
+			Subject:  block.DefRange.Ptr(),
+		})
+		return nil, diags
+	}
+
+	return secret, diags
+}
+
+// decodeLintBlock decodes a lint block, which names the lint rules to
+// skip for this runbook; see Lint.
+func decodeLintBlock(block *hcl.Block) (*LintConfig, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	cfg := &LintConfig{}
+
+	content, hclDiags := block.Body.Content(runbookLintBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["disabled"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &cfg.Disabled)
+		diags = diags.Append(valDiags)
+		for _, name := range cfg.Disabled {
+			if !lintRules[name] {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unknown lint rule",
+					Detail:   fmt.Sprintf("There is no lint rule named %q to disable.", name),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			}
+		}
+	}
+
+	return cfg, diags
+}
+
+func decodeAuthBlock(block *hcl.Block, ctx *hcl.EvalContext) (*Auth, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	auth := &Auth{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(runbookAuthBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["helper"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &auth.HelperType)
+		diags = diags.Append(valDiags)
+	}
+	if auth.HelperType == "" {
+		// Content already reported the missing required attribute.
+		return nil, diags
+	}
+
+	auth.Config = cty.EmptyObjectVal
+	for _, inner := range content.Blocks {
+		config, _, moreDiags := decodeConfigAttributes(inner.Body, ctx, nil)
+		diags = diags.Append(moreDiags)
+		auth.Config = config
+	}
+
+	return auth, diags
+}
+
+func decodeRunbookStepBlock(block *hcl.Block, ctx *hcl.EvalContext, schemas map[string]*configschema.Block) (*Step, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	step := &Step{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(runbookStepBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["description"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Description)
+		diags = diags.Append(valDiags)
+	}
+
+	refs := make(map[string]bool)
+	for _, inner := range content.Blocks {
+		switch inner.Type {
+		case "data", "list":
+			read, moreDiags := decodeReadBlock(inner, ctx, refs, schemas[inner.Labels[0]])
+			diags = diags.Append(moreDiags)
+			if read != nil {
+				step.Reads = append(step.Reads, read)
+			}
+		case "action":
+			if step.ActionType != "" {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate action block",
+					Detail:   fmt.Sprintf("The step %q already has an action block; a step invokes at most one action.", step.Name),
+					Subject:  inner.DefRange.Ptr(),
+				})
+				continue
+			}
+			step.ActionType = inner.Labels[0]
+			moreDiags := decodeActionBlock(inner, ctx, step, refs)
+			diags = diags.Append(moreDiags)
+		case "lock":
+			lock, moreDiags := decodeLockBlock(inner)
+			diags = diags.Append(moreDiags)
+			if lock != nil {
+				step.Lock = lock
+			}
+		case "output":
+			output, moreDiags := decodeOutputBlock(inner, ctx, refs)
+			diags = diags.Append(moreDiags)
+			if output != nil {
+				step.Outputs = append(step.Outputs, output)
+			}
+		}
+	}
+
+	for name := range refs {
+		step.VariableRefs = append(step.VariableRefs, name)
+	}
+	sort.Strings(step.VariableRefs)
+
+	return step, diags
+}
+
+// decodeReadBlock decodes a data or list block into a Read. The body's
+// attributes are the read's configuration, except for depends_on, which
+// names the other reads of the same step this one must wait for. With a
+// schema for the read's type, the configuration decodes against it; see
+// LoadRunbookFile.
+func decodeReadBlock(block *hcl.Block, ctx *hcl.EvalContext, refs map[string]bool, schema *configschema.Block) (*Read, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	read := &Read{
+		ReadType: block.Labels[0],
+		Name:     block.Labels[1],
+		List:     block.Type == "list",
+	}
+
+	if schema != nil {
+		// depends_on belongs to the runbook language rather than to the
+		// provider's schema, so it is split off before the rest of the
+		// body decodes.
+		content, remain, hclDiags := block.Body.PartialContent(readMetaArgsSchema)
+		diags = diags.Append(hclDiags)
+		if attr, exists := content.Attributes["depends_on"]; exists {
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &read.DependsOn)
+			diags = diags.Append(valDiags)
+		}
+
+		spec := schema.DecoderSpec()
+		recordTraversalRefs(hcldec.Variables(remain, spec), refs)
+		config, hclDiags := hcldec.Decode(remain, spec, ctx)
+		diags = diags.Append(DecodeConfigDiagnostics(hclDiags, schema))
+		read.Config = config
+		return read, diags
+	}
+
+	config, dependsOn, moreDiags := decodeConfigAttributes(block.Body, ctx, refs)
+	diags = diags.Append(moreDiags)
+	read.Config = config
+	read.DependsOn = dependsOn
+
+	return read, diags
+}
+
+// decodeActionBlock decodes a step's action block. The plan_only
+// attribute belongs to the step rather than to the action's
+// configuration, so it is pulled out before the rest evaluates.
+func decodeActionBlock(block *hcl.Block, ctx *hcl.EvalContext, step *Step, refs map[string]bool) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	attrs, hclDiags := block.Body.JustAttributes()
+	diags = diags.Append(hclDiags)
+
+	vals := make(map[string]cty.Value)
+	for name, attr := range attrs {
+		if name == "plan_only" {
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.PlanOnly)
+			diags = diags.Append(valDiags)
+			continue
+		}
+		recordVariableRefs(attr.Expr, refs)
+		val, hclDiags := attr.Expr.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			continue
+		}
+		vals[name] = val
+	}
+	step.Config = cty.ObjectVal(vals)
+
+	return diags
+}
+
+func decodeOutputBlock(block *hcl.Block, ctx *hcl.EvalContext, refs map[string]bool) (*Output, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	output := &Output{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(runbookOutputBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["description"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &output.Description)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["value"]; exists {
+		recordVariableRefs(attr.Expr, refs)
+		val, hclDiags := attr.Expr.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			output.Value = val
+		}
+	}
+
+	for _, inner := range content.Blocks {
+		expect, moreDiags := decodeExpectBlock(inner, ctx)
+		diags = diags.Append(moreDiags)
+		if expect != nil {
+			if output.Expect != nil {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate expect block",
+					Detail:   fmt.Sprintf("The output %q already has an expect block.", output.Name),
+					Subject:  inner.DefRange.Ptr(),
+				})
+				continue
+			}
+			output.Expect = expect
+		}
+	}
+
+	return output, diags
+}
+
+func decodeExpectBlock(block *hcl.Block, ctx *hcl.EvalContext) (*Expect, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	expect := &Expect{}
+
+	content, hclDiags := block.Body.Content(runbookExpectBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["equals"]; exists {
+		val, hclDiags := attr.Expr.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			expect.Equals = val
+		}
+	}
+	if attr, exists := content.Attributes["contains"]; exists {
+		val, hclDiags := attr.Expr.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			expect.Contains = val
+		}
+	}
+
+	if expect.Equals == cty.NilVal && expect.Contains == cty.NilVal {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Incomplete expect block",
+			Detail:   "An expect block must set equals, contains, or both.",
+			Subject:  block.DefRange.Ptr(),
+		})
+		return nil, diags
+	}
+
+	return expect, diags
+}
+
+// decodeConfigAttributes evaluates a body of free-form configuration
+// attributes, separating out depends_on when present. Variable
+// references are recorded into refs when the caller passes one, for the
+// step's VariableRefs.
+func decodeConfigAttributes(body hcl.Body, ctx *hcl.EvalContext, refs map[string]bool) (cty.Value, []string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	attrs, hclDiags := body.JustAttributes()
+	diags = diags.Append(hclDiags)
+
+	var dependsOn []string
+	vals := make(map[string]cty.Value)
+	for name, attr := range attrs {
+		if name == "depends_on" {
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &dependsOn)
+			diags = diags.Append(valDiags)
+			continue
+		}
+		recordVariableRefs(attr.Expr, refs)
+		val, hclDiags := attr.Expr.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			continue
+		}
+		vals[name] = val
+	}
+
+	return cty.ObjectVal(vals), dependsOn, diags
+}
+
+// recordVariableRefs records the names of the runbook variables the
+// expression references into refs, so a step knows its variable usage
+// after its expressions are gone.
+func recordVariableRefs(expr hcl.Expression, refs map[string]bool) {
+	recordTraversalRefs(expr.Variables(), refs)
+}
+
+func recordTraversalRefs(traversals []hcl.Traversal, refs map[string]bool) {
+	if refs == nil {
+		return
+	}
+	for _, traversal := range traversals {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+			refs[attr.Name] = true
+		}
+	}
+}
+
+var runbookFileSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "description",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "variable",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "required_providers",
+		},
+		{
+			Type: "provider_pool",
+		},
+		{
+			Type: "lock",
+		},
+		{
+			Type:       "secret",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "auth",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "lint",
+		},
+		{
+			Type:       "step",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "output",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var runbookVariableBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "default",
+		},
+	},
+}
+
+var providerPoolBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "max_instances",
+		},
+		{
+			Name: "idle_timeout",
+		},
+	},
+}
+
+var runbookLockBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "name",
+			Required: true,
+		},
+		{
+			Name: "backend",
+		},
+	},
+}
+
+var runbookSecretBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "env",
+		},
+		{
+			Name: "command",
+		},
+		{
+			Name: "file",
+		},
+	},
+}
+
+// readMetaArgsSchema splits the runbook language's own arguments out of
+// a data or list block before the rest decodes against the provider's
+// schema.
+var readMetaArgsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "depends_on",
+		},
+	},
+}
+
+var runbookLintBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "disabled",
+		},
+	},
+}
+
+var runbookAuthBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "helper",
+			Required: true,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "config",
+		},
+	},
+}
+
+var runbookStepBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "description",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "data",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type:       "list",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type:       "action",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type: "lock",
+		},
+		{
+			Type:       "output",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var runbookOutputBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "value",
+		},
+		{
+			Name: "description",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "expect",
+		},
+	},
+}
+
+var runbookExpectBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "equals",
+		},
+		{
+			Name: "contains",
+		},
+	},
+}
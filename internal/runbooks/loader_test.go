@@ -0,0 +1,340 @@
+package runbooks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+func TestLoadRunbookFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const src = `
+description = "Fail over the web tier"
+
+variable "environment" {
+  default = "staging"
+}
+
+variable "region" {}
+
+required_providers {
+  aws = { source = "hashicorp/aws", version = ">= 3.0" }
+}
+
+provider_pool {
+  max_instances = 2
+  idle_timeout  = "1m"
+}
+
+lock {
+  name    = "failover"
+  backend = true
+}
+
+secret "token" {
+  env = "PAGER_TOKEN"
+}
+
+lint {
+  disabled = ["missing-description"]
+}
+
+auth "ops" {
+  helper = "aws_assume_role"
+  config {
+    role_arn = "arn:aws:iam::123456789012:role/ops"
+  }
+}
+
+step "drain" {
+  description = "Drain the primary"
+
+  data "aws_instance" "primary" {
+    tags = { "Role" = "primary", "Env" = var.environment }
+  }
+
+  data "aws_instance" "standby" {
+    tags       = { "Role" = "standby" }
+    depends_on = ["primary"]
+  }
+
+  action "aws_elb_drain" {
+    name      = "web-${var.environment}"
+    plan_only = true
+  }
+
+  lock {
+    name = "drain"
+  }
+
+  output "drained" {
+    value = upper(var.environment)
+    expect {
+      equals = "STAGING"
+    }
+  }
+}
+
+output "summary" {
+  description = "What changed"
+  value       = "drained ${var.environment}"
+}
+`
+	path := filepath.Join(dir, "failover.tfbook")
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	decls, diags := LoadRunbookVariables(path)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if len(decls) != 2 {
+		t.Fatalf("wrong declarations %#v; want environment and region", decls)
+	}
+	if got, want := decls["environment"].Default, cty.StringVal("staging"); !got.RawEquals(want) {
+		t.Errorf("wrong environment default %#v; want %#v", got, want)
+	}
+
+	rb, diags := LoadRunbookFile(path, nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if got, want := rb.Name, "failover"; got != want {
+		t.Errorf("wrong name %q; want %q", got, want)
+	}
+	if got, want := rb.Description, "Fail over the web tier"; got != want {
+		t.Errorf("wrong description %q; want %q", got, want)
+	}
+	if req := rb.RequiredProviders["aws"]; req == nil || req.Source != "hashicorp/aws" || req.Version != ">= 3.0" {
+		t.Errorf("wrong aws requirement %#v", req)
+	}
+	if rb.ProviderPool == nil || rb.ProviderPool.MaxInstances != 2 || rb.ProviderPool.IdleTimeout != time.Minute {
+		t.Errorf("wrong provider pool settings %#v", rb.ProviderPool)
+	}
+	if rb.Lock == nil || rb.Lock.Name != "failover" || !rb.Lock.Backend {
+		t.Errorf("wrong runbook lock %#v", rb.Lock)
+	}
+	if len(rb.Secrets) != 1 || rb.Secrets[0].Source != SecretSourceEnv || rb.Secrets[0].Var != "PAGER_TOKEN" {
+		t.Errorf("wrong secrets %#v", rb.Secrets)
+	}
+	if rb.Lint == nil || len(rb.Lint.Disabled) != 1 || rb.Lint.Disabled[0] != RuleMissingDescription {
+		t.Errorf("wrong lint config %#v", rb.Lint)
+	}
+	if len(rb.Auth) != 1 || rb.Auth[0].HelperType != "aws_assume_role" {
+		t.Fatalf("wrong auth helpers %#v", rb.Auth)
+	}
+	if got, want := rb.Auth[0].Config.GetAttr("role_arn"), cty.StringVal("arn:aws:iam::123456789012:role/ops"); !got.RawEquals(want) {
+		t.Errorf("wrong auth config %#v; want role_arn %#v", rb.Auth[0].Config, want)
+	}
+
+	if len(rb.Steps) != 1 {
+		t.Fatalf("wrong steps %#v; want one", rb.Steps)
+	}
+	step := rb.Steps[0]
+	if got, want := step.ActionType, "aws_elb_drain"; got != want {
+		t.Errorf("wrong action type %q; want %q", got, want)
+	}
+	if !step.PlanOnly {
+		t.Error("step is not plan-only; the action block said plan_only = true")
+	}
+	if step.Config.Type().HasAttribute("plan_only") {
+		t.Errorf("plan_only leaked into the action config %#v", step.Config)
+	}
+	if got, want := step.Config.GetAttr("name"), cty.StringVal("web-staging"); !got.RawEquals(want) {
+		t.Errorf("wrong action name %#v; want %#v", got, want)
+	}
+	if len(step.Reads) != 2 {
+		t.Fatalf("wrong reads %#v; want two", step.Reads)
+	}
+	var standby *Read
+	for _, read := range step.Reads {
+		if read.Name == "standby" {
+			standby = read
+		}
+	}
+	if standby == nil {
+		t.Fatalf("no standby read in %#v", step.Reads)
+	}
+	if len(standby.DependsOn) != 1 || standby.DependsOn[0] != "primary" {
+		t.Errorf("wrong standby dependencies %#v; want [primary]", standby.DependsOn)
+	}
+	if standby.Config.Type().HasAttribute("depends_on") {
+		t.Errorf("depends_on leaked into the read config %#v", standby.Config)
+	}
+	if got, want := step.VariableRefs, []string{"environment"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("wrong variable refs %#v; want %#v", got, want)
+	}
+	if step.Lock == nil || step.Lock.Name != "drain" || step.Lock.Backend {
+		t.Errorf("wrong step lock %#v", step.Lock)
+	}
+	if len(step.Outputs) != 1 {
+		t.Fatalf("wrong step outputs %#v; want one", step.Outputs)
+	}
+	if err := step.Outputs[0].Check(); err != nil {
+		t.Errorf("step output expectation failed: %s", err)
+	}
+
+	if len(rb.Outputs) != 1 {
+		t.Fatalf("wrong runbook outputs %#v; want one", rb.Outputs)
+	}
+	if got, want := rb.Outputs[0].Value, cty.StringVal("drained staging"); !got.RawEquals(want) {
+		t.Errorf("wrong summary value %#v; want %#v", got, want)
+	}
+
+	// A variable without a default or a caller-given value evaluates as
+	// unknown, and a given value overrides the default.
+	rb, diags = LoadRunbookFile(path, map[string]cty.Value{
+		"environment": cty.StringVal("prod"),
+	}, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if got, want := rb.Outputs[0].Value, cty.StringVal("drained prod"); !got.RawEquals(want) {
+		t.Errorf("wrong summary value %#v; want %#v", got, want)
+	}
+
+	files, err := RunbookFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != path {
+		t.Errorf("wrong runbook files %#v; want just %q", files, path)
+	}
+}
+
+func TestLoadRunbookFileSchemas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-load-schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schemas := map[string]*configschema.Block{
+		"aws_instance": {
+			Attributes: map[string]*configschema.Attribute{
+				"instance_id": {Type: cty.String, Optional: true},
+				"count":       {Type: cty.Number, Optional: true},
+			},
+		},
+	}
+
+	const good = `
+variable "id" {}
+
+step "inspect" {
+  data "aws_instance" "primary" {
+    instance_id = var.id
+    depends_on  = []
+  }
+}
+`
+	path := filepath.Join(dir, "inspect.tfbook")
+	if err := ioutil.WriteFile(path, []byte(good), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rb, diags := LoadRunbookFile(path, nil, schemas)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	read := rb.Steps[0].Reads[0]
+	if !read.Config.Type().HasAttribute("count") {
+		t.Errorf("wrong read config %#v; a schema-decoded config has every schema attribute", read.Config)
+	}
+	if read.Config.Type().HasAttribute("depends_on") {
+		t.Errorf("depends_on leaked into the read config %#v", read.Config)
+	}
+	if got, want := rb.Steps[0].VariableRefs, []string{"id"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("wrong variable refs %#v; want %#v", got, want)
+	}
+
+	// An attribute of the wrong type fails the load, with the type the
+	// schema expects named in the detail.
+	const bad = `
+step "inspect" {
+  data "aws_instance" "primary" {
+    count = "not a number"
+  }
+}
+`
+	if err := ioutil.WriteFile(path, []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, diags = LoadRunbookFile(path, nil, schemas)
+	if !diags.HasErrors() {
+		t.Fatal("load succeeded; want a type error")
+	}
+	if got := diags.Err().Error(); !strings.Contains(got, "The provider requires number") {
+		t.Errorf("wrong error %q; want the schema's expected type named", got)
+	}
+}
+
+func TestLoadRunbookFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-load-errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"two sources": `
+secret "token" {
+  env  = "TOKEN"
+  file = "token.txt"
+}
+`,
+		"duplicate variable": `
+variable "a" {}
+variable "a" {}
+`,
+		"two actions": `
+step "s" {
+  action "one" {}
+  action "two" {}
+}
+`,
+		"empty expect": `
+step "s" {
+  output "o" {
+    value = 1
+    expect {}
+  }
+}
+`,
+		"negative pool limit": `
+provider_pool {
+  max_instances = -1
+}
+`,
+		"unknown lint rule": `
+lint {
+  disabled = ["no-such-rule"]
+}
+`,
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, "bad.tfbook")
+			if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, diags := LoadRunbookFile(path, nil, nil); !diags.HasErrors() {
+				t.Error("load succeeded; want an error")
+			}
+		})
+	}
+}
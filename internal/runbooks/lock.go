@@ -0,0 +1,78 @@
+package runbooks
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Lock is a concurrency guard from a runbook's or step's lock block.
+// Operational procedures are rarely safe to run twice at once — two
+// operators executing the same failover runbook concurrently is a real
+// hazard — so a runbook or step can name a lock that must be held for
+// the duration.
+type Lock struct {
+	// Name identifies the lock. Runbooks and steps naming the same lock
+	// exclude each other.
+	Name string
+
+	// Backend routes the lock through the backend's locking mechanism,
+	// the same one that guards state, making the exclusion effective
+	// across machines: only one operator org-wide can hold it. Without
+	// it the lock is process-local and guards only against concurrent
+	// runs within this process.
+	Backend bool
+}
+
+// LockFunc acquires the named lock, blocking until it is available, and
+// returns a function that releases it. The engine's BackendLock is one,
+// wired by the caller to the backend's locking mechanism.
+type LockFunc func(name string) (release func() error, err error)
+
+// LocalBackendLock is a LockFunc that satisfies backend = true locks
+// with the process-local mechanism instead of a real backend. It is for
+// harnesses that rehearse runbooks — dry runs and test scenarios — which
+// must exercise the locking behavior without excluding real operators
+// org-wide.
+func LocalBackendLock(name string) (func() error, error) {
+	return acquireLocalLock(name), nil
+}
+
+// localLocks serializes lock holders within this process, for locks
+// that don't use the backend.
+var localLocks = struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}{locks: make(map[string]*sync.Mutex)}
+
+// acquireLocalLock blocks until the named process-local lock is free,
+// takes it, and returns its release function.
+func acquireLocalLock(name string) func() error {
+	localLocks.mu.Lock()
+	l, ok := localLocks.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		localLocks.locks[name] = l
+	}
+	localLocks.mu.Unlock()
+
+	l.Lock()
+	return func() error {
+		l.Unlock()
+		return nil
+	}
+}
+
+// acquireLock acquires the given lock, returning the function that
+// releases it. A nil lock acquires nothing and releases nothing.
+func (e *Engine) acquireLock(lock *Lock) (func() error, error) {
+	if lock == nil {
+		return func() error { return nil }, nil
+	}
+	if lock.Backend {
+		if e.BackendLock == nil {
+			return nil, fmt.Errorf("lock %q requires backend locking, but none is available for this run", lock.Name)
+		}
+		return e.BackendLock(lock.Name)
+	}
+	return acquireLocalLock(lock.Name), nil
+}
@@ -0,0 +1,149 @@
+package runbooks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestRunBackendLock(t *testing.T) {
+	var events []string
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"db_failover": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				events = append(events, "invoke")
+				return cty.True, nil
+			},
+		},
+		BackendLock: func(name string) (func() error, error) {
+			events = append(events, "acquire "+name)
+			return func() error {
+				events = append(events, "release "+name)
+				return nil
+			}, nil
+		},
+	}
+
+	rb := &Runbook{
+		Name: "failover",
+		Lock: &Lock{Name: "db-failover", Backend: true},
+		Steps: []*Step{
+			{Name: "promote", ActionType: "db_failover", Config: cty.EmptyObjectVal},
+		},
+	}
+
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []string{"acquire db-failover", "invoke", "release db-failover"}
+	if strings.Join(events, ", ") != strings.Join(want, ", ") {
+		t.Errorf("wrong event order\ngot:  %v\nwant: %v", events, want)
+	}
+}
+
+func TestRunBackendLockReleasedOnFailure(t *testing.T) {
+	released := false
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"db_failover": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				return cty.NilVal, errors.New("primary unreachable")
+			},
+		},
+		BackendLock: func(name string) (func() error, error) {
+			return func() error {
+				released = true
+				return nil
+			}, nil
+		},
+	}
+
+	rb := &Runbook{
+		Name: "failover",
+		Lock: &Lock{Name: "db-failover", Backend: true},
+		Steps: []*Step{
+			{Name: "promote", ActionType: "db_failover", Config: cty.EmptyObjectVal},
+		},
+	}
+
+	if err := engine.Run(rb); err == nil {
+		t.Fatal("run succeeded; the step should have failed it")
+	}
+	if !released {
+		t.Error("lock was not released after the failed run")
+	}
+}
+
+func TestRunBackendLockUnavailable(t *testing.T) {
+	engine := &Engine{}
+	rb := &Runbook{
+		Name: "failover",
+		Lock: &Lock{Name: "db-failover", Backend: true},
+	}
+
+	err := engine.Run(rb)
+	if err == nil || !strings.Contains(err.Error(), "backend locking") {
+		t.Fatalf("wrong error %v; want one about missing backend locking", err)
+	}
+}
+
+func TestStepLock(t *testing.T) {
+	var events []string
+	engine := &Engine{
+		BackendLock: func(name string) (func() error, error) {
+			events = append(events, "acquire "+name)
+			return func() error {
+				events = append(events, "release "+name)
+				return nil
+			}, nil
+		},
+	}
+
+	rb := &Runbook{
+		Name: "failover",
+		Steps: []*Step{
+			{Name: "gather", Config: cty.EmptyObjectVal},
+			{
+				Name:   "promote",
+				Config: cty.EmptyObjectVal,
+				Lock:   &Lock{Name: "db-failover", Backend: true},
+			},
+		},
+	}
+
+	if err := engine.Run(rb); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []string{"acquire db-failover", "release db-failover"}
+	if strings.Join(events, ", ") != strings.Join(want, ", ") {
+		t.Errorf("wrong lock events\ngot:  %v\nwant: %v", events, want)
+	}
+}
+
+func TestLocalLock(t *testing.T) {
+	release := acquireLocalLock("test-local-lock")
+
+	acquired := make(chan struct{})
+	go func() {
+		second := acquireLocalLock("test-local-lock")
+		close(acquired)
+		second()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second holder acquired the lock while it was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := release(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second holder never acquired the lock after release")
+	}
+}
@@ -0,0 +1,187 @@
+package runbooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Output is a named result value of a runbook or of one of its steps,
+// shown to the operator after the steps have run.
+type Output struct {
+	// Name identifies the output within its runbook or step.
+	Name string
+
+	// Step is the name of the step that produced the output, and empty
+	// for runbook-level outputs. It's filled in when outputs are
+	// collected; see Runbook.AllOutputs.
+	Step string
+
+	// Description documents what the output means, for the end-of-run
+	// summary.
+	Description string
+
+	// Value is the output's fully-resolved value.
+	Value cty.Value
+
+	// Expect optionally turns the output into an assertion: when set, a
+	// value that doesn't satisfy it fails the run. Outputs without an
+	// Expect are informational only.
+	Expect *Expect
+}
+
+// Expect is the expectation from an output's expect block. At least one
+// of its fields is set; when both are, both must hold.
+type Expect struct {
+	// Equals requires the output value to equal this value exactly.
+	// cty.NilVal means no equality requirement.
+	Equals cty.Value
+
+	// Contains requires the output value to contain this value: an
+	// element of a list, set, or tuple, a substring of a string, or for
+	// a map or object every key/value pair given here. cty.NilVal means
+	// no containment requirement.
+	Contains cty.Value
+}
+
+// Check verifies the output's expectation against its value, returning
+// an error describing the mismatch. Outputs without an expectation
+// always pass.
+func (o *Output) Check() error {
+	if o.Expect == nil {
+		return nil
+	}
+
+	if want := o.Expect.Equals; want != cty.NilVal {
+		eq := o.Value.Equals(want)
+		if !eq.IsKnown() || eq.False() {
+			return fmt.Errorf("expected value %s, but the output value is %s", valueStr(want), valueStr(o.Value))
+		}
+	}
+
+	if want := o.Expect.Contains; want != cty.NilVal {
+		ok, err := valueContains(o.Value, want)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("expected the output value %s to contain %s, but it does not", valueStr(o.Value), valueStr(want))
+		}
+	}
+
+	return nil
+}
+
+// valueContains decides whether got contains want, with the meaning of
+// "contains" depending on got's type: element membership for lists,
+// sets, and tuples, substring matching for strings, and subset of
+// key/value pairs for maps and objects.
+func valueContains(got, want cty.Value) (bool, error) {
+	if !got.IsKnown() || got.IsNull() {
+		return false, nil
+	}
+	ty := got.Type()
+
+	switch {
+	case ty == cty.String:
+		if want.Type() != cty.String || want.IsNull() {
+			return false, fmt.Errorf("can only check a string output for containment of another string")
+		}
+		return strings.Contains(got.AsString(), want.AsString()), nil
+
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		for it := got.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if eq := elem.Equals(want); eq.IsKnown() && eq.True() {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case ty.IsMapType() || ty.IsObjectType():
+		if wantTy := want.Type(); !wantTy.IsMapType() && !wantTy.IsObjectType() {
+			return false, fmt.Errorf("can only check a %s output for containment of key/value pairs from another %s", ty.FriendlyName(), ty.FriendlyName())
+		}
+		if want.IsNull() {
+			return false, nil
+		}
+		gotPairs := got.AsValueMap()
+		for key, wantVal := range want.AsValueMap() {
+			gotVal, ok := gotPairs[key]
+			if !ok {
+				return false, nil
+			}
+			if eq := gotVal.Equals(wantVal); !eq.IsKnown() || eq.False() {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("cannot check a %s output for containment", ty.FriendlyName())
+	}
+}
+
+// valueStr renders a value for an expectation failure message.
+func valueStr(val cty.Value) string {
+	if val == cty.NilVal {
+		return "(not set)"
+	}
+	if !val.IsKnown() {
+		return "(not yet known)"
+	}
+	if val.IsNull() {
+		return "null"
+	}
+	switch val.Type() {
+	case cty.String:
+		return fmt.Sprintf("%q", val.AsString())
+	case cty.Number:
+		return val.AsBigFloat().Text('f', -1)
+	case cty.Bool:
+		if val.True() {
+			return "true"
+		}
+		return "false"
+	default:
+		return val.GoString()
+	}
+}
+
+// WriteOutputsAudit appends the given outputs to w as one JSON object per
+// line, for callers that keep an audit log of what their runs reported.
+// Values that can't be represented in JSON — unknown values, or none at
+// all — are recorded as null.
+func WriteOutputsAudit(w io.Writer, runbook string, outputs []*Output) error {
+	for _, output := range outputs {
+		value := json.RawMessage("null")
+		if output.Value != cty.NilVal && output.Value.IsWhollyKnown() {
+			if buf, err := ctyjson.Marshal(output.Value, output.Value.Type()); err == nil {
+				value = json.RawMessage(buf)
+			}
+		}
+		entry := map[string]interface{}{
+			"runbook": runbook,
+			"output":  output.Name,
+			"value":   value,
+		}
+		if output.Step != "" {
+			entry["step"] = output.Step
+		}
+		if output.Description != "" {
+			entry["description"] = output.Description
+		}
+		buf, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
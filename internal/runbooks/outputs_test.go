@@ -0,0 +1,239 @@
+package runbooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestOutputCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   cty.Value
+		expect  *Expect
+		wantErr string
+	}{
+		{
+			"no expectation",
+			cty.StringVal("anything"),
+			nil,
+			"",
+		},
+		{
+			"equals number ok",
+			cty.NumberIntVal(3),
+			&Expect{Equals: cty.NumberIntVal(3)},
+			"",
+		},
+		{
+			"equals number mismatch",
+			cty.NumberIntVal(3),
+			&Expect{Equals: cty.NumberIntVal(5)},
+			"expected value 5",
+		},
+		{
+			"contains string",
+			cty.StringVal("service healthy"),
+			&Expect{Contains: cty.StringVal("healthy")},
+			"",
+		},
+		{
+			"contains list element",
+			cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			&Expect{Contains: cty.StringVal("b")},
+			"",
+		},
+		{
+			"contains list element missing",
+			cty.ListVal([]cty.Value{cty.StringVal("a")}),
+			&Expect{Contains: cty.StringVal("b")},
+			"to contain",
+		},
+		{
+			"contains map pairs",
+			cty.ObjectVal(map[string]cty.Value{
+				"status": cty.StringVal("ok"),
+				"count":  cty.NumberIntVal(2),
+			}),
+			&Expect{Contains: cty.ObjectVal(map[string]cty.Value{"status": cty.StringVal("ok")})},
+			"",
+		},
+		{
+			"contains map pair mismatch",
+			cty.ObjectVal(map[string]cty.Value{"status": cty.StringVal("degraded")}),
+			&Expect{Contains: cty.ObjectVal(map[string]cty.Value{"status": cty.StringVal("ok")})},
+			"to contain",
+		},
+		{
+			"both requirements",
+			cty.ListVal([]cty.Value{cty.StringVal("a")}),
+			&Expect{
+				Equals:   cty.ListVal([]cty.Value{cty.StringVal("a")}),
+				Contains: cty.StringVal("a"),
+			},
+			"",
+		},
+		{
+			"contains on number",
+			cty.NumberIntVal(3),
+			&Expect{Contains: cty.NumberIntVal(3)},
+			"cannot check",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &Output{Name: "result", Value: test.value, Expect: test.expect}
+			err := output.Check()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("no error; want one mentioning %q", test.wantErr)
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("wrong error %q; want one mentioning %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngineRunOutputExpectations(t *testing.T) {
+	engine := &Engine{}
+	rb := &Runbook{
+		Name: "verify_service",
+		Outputs: []*Output{
+			{Name: "status", Value: cty.StringVal("degraded"), Expect: &Expect{Equals: cty.StringVal("ok")}},
+		},
+	}
+
+	err := engine.Run(rb)
+	if err == nil {
+		t.Fatal("run succeeded despite unmet output expectation")
+	}
+	if !strings.Contains(err.Error(), `"status"`) {
+		t.Errorf("error does not name the output: %s", err)
+	}
+}
+
+func TestEngineRunStepOutputExpectations(t *testing.T) {
+	rb := &Runbook{
+		Name: "restart",
+		Steps: []*Step{
+			{
+				Name: "check_health",
+				Outputs: []*Output{
+					{
+						Name:   "healthy",
+						Value:  cty.False,
+						Expect: &Expect{Equals: cty.True},
+					},
+				},
+			},
+			{Name: "never_reached"},
+		},
+	}
+
+	var ran []string
+	engine := &Engine{
+		Hooks: []Hook{&recordingHook{}},
+	}
+	engine.Hooks = append(engine.Hooks, &stepRecorder{ran: &ran})
+
+	err := engine.Run(rb)
+	if err == nil || !strings.Contains(err.Error(), `output "healthy"`) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	for _, name := range ran {
+		if name == "never_reached" {
+			t.Error("a later step ran despite the failed step output expectation")
+		}
+	}
+}
+
+// stepRecorder remembers which steps started.
+type stepRecorder struct {
+	NilHook
+	ran *[]string
+}
+
+func (h *stepRecorder) PreRunbookStep(runbook string, step *Step) error {
+	*h.ran = append(*h.ran, step.Name)
+	return nil
+}
+
+func TestRunbookAllOutputs(t *testing.T) {
+	rb := &Runbook{
+		Name: "restart",
+		Steps: []*Step{
+			{
+				Name:    "check_health",
+				EachKey: "eu",
+				Outputs: []*Output{
+					{Name: "healthy", Value: cty.True, Description: "Service health after restart"},
+				},
+			},
+		},
+		Outputs: []*Output{
+			{Name: "duration", Value: cty.NumberIntVal(42)},
+		},
+	}
+
+	got := rb.AllOutputs()
+	if len(got) != 2 {
+		t.Fatalf("wrong output count %d", len(got))
+	}
+	if got[0].Step != "check_health[eu]" || got[0].Name != "healthy" {
+		t.Errorf("wrong first output %#v", got[0])
+	}
+	if got[1].Step != "" || got[1].Name != "duration" {
+		t.Errorf("wrong second output %#v", got[1])
+	}
+	// The originals must not be mutated by the attribution.
+	if rb.Steps[0].Outputs[0].Step != "" {
+		t.Errorf("collection mutated the step's own output")
+	}
+}
+
+func TestWriteOutputsAudit(t *testing.T) {
+	outputs := []*Output{
+		{Step: "check_health[eu]", Name: "healthy", Value: cty.True, Description: "Service health"},
+		{Name: "duration", Value: cty.NumberIntVal(42)},
+		{Name: "pending", Value: cty.UnknownVal(cty.String)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOutputsAudit(&buf, "restart", outputs); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("wrong line count %d:\n%s", len(lines), buf.String())
+	}
+
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("first line is not valid JSON: %s", err)
+	}
+	if first["runbook"] != "restart" || first["step"] != "check_health[eu]" || first["value"] != true {
+		t.Errorf("wrong first entry %#v", first)
+	}
+
+	var last map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatalf("last line is not valid JSON: %s", err)
+	}
+	if last["value"] != nil {
+		t.Errorf("unknown value should be recorded as null, got %#v", last["value"])
+	}
+	if _, exists := last["step"]; exists {
+		t.Errorf("runbook-level output should have no step field: %#v", last)
+	}
+}
@@ -0,0 +1,232 @@
+package runbooks
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProviderPoolSettings are the limits from a runbook's provider_pool
+// block. They let an author decide deliberately how provider processes
+// are shared or scaled when a for_each fan-out would otherwise start one
+// process per expanded step.
+type ProviderPoolSettings struct {
+	// MaxInstances bounds how many instances of any one provider run at
+	// once. An acquisition beyond the bound waits for an instance to be
+	// released. Zero means no bound.
+	MaxInstances int
+
+	// IdleTimeout is how long a released instance may sit idle before
+	// the pool closes it instead of handing it out again, so a brief
+	// burst of fan-out doesn't pin provider processes for the rest of
+	// the run. Zero keeps idle instances for the life of the pool.
+	IdleTimeout time.Duration
+}
+
+// ProviderInstance is one running provider process, or whatever else the
+// caller's start function produces. The pool closes instances it evicts
+// or that remain when the pool itself is closed.
+type ProviderInstance interface {
+	Close() error
+}
+
+// ProviderStartFunc starts a new instance of the named provider.
+type ProviderStartFunc func(provider string) (ProviderInstance, error)
+
+// ProviderPool lends provider instances to action invocations, reusing
+// released instances and starting new ones on demand within the
+// runbook's provider_pool settings. It is safe for concurrent use, which
+// is the point: a for_each fan-out acquires from one shared pool instead
+// of each expanded step starting its own process.
+type ProviderPool struct {
+	start    ProviderStartFunc
+	settings ProviderPoolSettings
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	idle    map[string][]idleInstance
+	running map[string]int
+	closed  bool
+	stats   PoolStats
+
+	// now stands in for time.Now so tests can control idle expiry.
+	now func() time.Time
+}
+
+// idleInstance is a released instance together with when it was
+// released, for idle timeout accounting.
+type idleInstance struct {
+	inst  ProviderInstance
+	since time.Time
+}
+
+// PoolStats counts what a pool did over its lifetime, for run reports
+// and for tuning provider_pool settings against real fan-out behavior.
+type PoolStats struct {
+	// Started counts instances the pool started.
+	Started int
+
+	// Reused counts acquisitions satisfied by an idle instance instead
+	// of starting a new one.
+	Reused int
+
+	// Evicted counts idle instances closed for exceeding the idle
+	// timeout.
+	Evicted int
+
+	// Waited counts acquisitions that had to wait because the provider
+	// was at its instance bound.
+	Waited int
+
+	// PeakActive is the largest number of instances of any one provider
+	// active at the same time.
+	PeakActive int
+}
+
+// NewProviderPool returns a pool that starts instances with start and
+// enforces the given settings.
+func NewProviderPool(start ProviderStartFunc, settings ProviderPoolSettings) *ProviderPool {
+	p := &ProviderPool{
+		start:    start,
+		settings: settings,
+		idle:     make(map[string][]idleInstance),
+		running:  make(map[string]int),
+		now:      time.Now,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Acquire returns an instance of the named provider, reusing a released
+// one when it has an idle instance that hasn't outlived the idle
+// timeout, and otherwise starting a new one. When the provider is at its
+// MaxInstances bound, Acquire blocks until another goroutine releases an
+// instance. The caller must pass the instance back to Release when its
+// invocation is done.
+func (p *ProviderPool) Acquire(provider string) (ProviderInstance, error) {
+	p.mu.Lock()
+
+	waited := false
+	for {
+		if p.closed {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("provider pool is closed")
+		}
+
+		stale := p.evictStaleLocked(provider)
+
+		if n := len(p.idle[provider]); n > 0 {
+			entry := p.idle[provider][n-1]
+			p.idle[provider] = p.idle[provider][:n-1]
+			p.stats.Reused++
+			p.mu.Unlock()
+			closeInstances(stale)
+			return entry.inst, nil
+		}
+
+		if p.settings.MaxInstances == 0 || p.running[provider] < p.settings.MaxInstances {
+			p.running[provider]++
+			p.stats.Started++
+			if p.running[provider] > p.stats.PeakActive {
+				p.stats.PeakActive = p.running[provider]
+			}
+			p.mu.Unlock()
+			closeInstances(stale)
+
+			inst, err := p.start(provider)
+			if err != nil {
+				p.mu.Lock()
+				p.running[provider]--
+				p.cond.Broadcast()
+				p.mu.Unlock()
+				return nil, fmt.Errorf("failed to start provider %q: %s", provider, err)
+			}
+			return inst, nil
+		}
+
+		if !waited {
+			p.stats.Waited++
+			waited = true
+		}
+		p.cond.Wait()
+	}
+}
+
+// Release returns an instance acquired from Acquire to the pool, making
+// it available for reuse. Releasing into a closed pool just closes the
+// instance.
+func (p *ProviderPool) Release(provider string, inst ProviderInstance) {
+	p.mu.Lock()
+	if p.closed {
+		p.running[provider]--
+		p.mu.Unlock()
+		inst.Close()
+		return
+	}
+	p.idle[provider] = append(p.idle[provider], idleInstance{inst: inst, since: p.now()})
+	p.cond.Broadcast()
+	p.mu.Unlock()
+}
+
+// Close closes every idle instance and refuses further acquisitions.
+// Instances still out with callers are closed as they come back through
+// Release. The returned error is the first close error, if any.
+func (p *ProviderPool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	var all []ProviderInstance
+	for provider, entries := range p.idle {
+		for _, entry := range entries {
+			all = append(all, entry.inst)
+		}
+		p.running[provider] -= len(entries)
+		delete(p.idle, provider)
+	}
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	var err error
+	for _, inst := range all {
+		if closeErr := inst.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Stats returns a snapshot of the pool's usage counters.
+func (p *ProviderPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// evictStaleLocked removes the named provider's idle instances that have
+// outlived the idle timeout, returning them for the caller to close once
+// the lock is released. The caller must hold the mutex.
+func (p *ProviderPool) evictStaleLocked(provider string) []ProviderInstance {
+	if p.settings.IdleTimeout == 0 {
+		return nil
+	}
+	cutoff := p.now().Add(-p.settings.IdleTimeout)
+
+	var stale []ProviderInstance
+	fresh := p.idle[provider][:0]
+	for _, entry := range p.idle[provider] {
+		if entry.since.Before(cutoff) {
+			stale = append(stale, entry.inst)
+			p.running[provider]--
+			p.stats.Evicted++
+		} else {
+			fresh = append(fresh, entry)
+		}
+	}
+	p.idle[provider] = fresh
+	return stale
+}
+
+func closeInstances(insts []ProviderInstance) {
+	for _, inst := range insts {
+		inst.Close()
+	}
+}
@@ -0,0 +1,229 @@
+package runbooks
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeInstance is a ProviderInstance that remembers whether it was
+// closed.
+type fakeInstance struct {
+	provider string
+	closed   bool
+}
+
+func (i *fakeInstance) Close() error {
+	i.closed = true
+	return nil
+}
+
+func TestProviderPoolReuse(t *testing.T) {
+	started := 0
+	pool := NewProviderPool(func(provider string) (ProviderInstance, error) {
+		started++
+		return &fakeInstance{provider: provider}, nil
+	}, ProviderPoolSettings{})
+
+	first, err := pool.Acquire("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pool.Release("aws", first)
+
+	second, err := pool.Acquire("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if second != first {
+		t.Error("second acquisition should reuse the released instance")
+	}
+
+	// A different provider never shares instances.
+	other, err := pool.Acquire("pagerduty")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if other == first {
+		t.Error("providers must not share instances")
+	}
+
+	if started != 2 {
+		t.Errorf("wrong start count %d; want 2", started)
+	}
+	stats := pool.Stats()
+	if stats.Started != 2 || stats.Reused != 1 {
+		t.Errorf("wrong stats %+v", stats)
+	}
+}
+
+func TestProviderPoolMaxInstances(t *testing.T) {
+	pool := NewProviderPool(func(provider string) (ProviderInstance, error) {
+		return &fakeInstance{provider: provider}, nil
+	}, ProviderPoolSettings{MaxInstances: 2})
+
+	first, err := pool.Acquire("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := pool.Acquire("aws"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The third acquisition must wait until an instance comes back.
+	acquired := make(chan ProviderInstance)
+	go func() {
+		inst, err := pool.Acquire("aws")
+		if err != nil {
+			panic(err)
+		}
+		acquired <- inst
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("third acquisition did not wait for the instance bound")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Release("aws", first)
+	select {
+	case inst := <-acquired:
+		if inst != first {
+			t.Error("waiting acquisition should get the released instance")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("third acquisition never completed after a release")
+	}
+
+	stats := pool.Stats()
+	if stats.Started != 2 || stats.Waited != 1 || stats.PeakActive != 2 {
+		t.Errorf("wrong stats %+v", stats)
+	}
+}
+
+func TestProviderPoolIdleTimeout(t *testing.T) {
+	now := time.Now()
+	pool := NewProviderPool(func(provider string) (ProviderInstance, error) {
+		return &fakeInstance{provider: provider}, nil
+	}, ProviderPoolSettings{IdleTimeout: time.Minute})
+	pool.now = func() time.Time { return now }
+
+	first, err := pool.Acquire("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pool.Release("aws", first)
+
+	// Once the instance has sat idle past the timeout, the next
+	// acquisition evicts it and starts fresh.
+	now = now.Add(2 * time.Minute)
+	second, err := pool.Acquire("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if second == first {
+		t.Error("expired instance should not be handed out again")
+	}
+	if !first.(*fakeInstance).closed {
+		t.Error("evicted instance was not closed")
+	}
+
+	stats := pool.Stats()
+	if stats.Evicted != 1 || stats.Started != 2 {
+		t.Errorf("wrong stats %+v", stats)
+	}
+}
+
+func TestProviderPoolClose(t *testing.T) {
+	pool := NewProviderPool(func(provider string) (ProviderInstance, error) {
+		return &fakeInstance{provider: provider}, nil
+	}, ProviderPoolSettings{})
+
+	idle, err := pool.Acquire("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pool.Release("aws", idle)
+	out, err := pool.Acquire("pagerduty")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !idle.(*fakeInstance).closed {
+		t.Error("idle instance was not closed")
+	}
+
+	if _, err := pool.Acquire("aws"); err == nil {
+		t.Error("acquiring from a closed pool should fail")
+	}
+
+	// An instance still out with a caller is closed as it comes back.
+	pool.Release("pagerduty", out)
+	if !out.(*fakeInstance).closed {
+		t.Error("instance released into a closed pool was not closed")
+	}
+}
+
+func TestProviderPoolStartError(t *testing.T) {
+	pool := NewProviderPool(func(provider string) (ProviderInstance, error) {
+		return nil, fmt.Errorf("plugin binary missing")
+	}, ProviderPoolSettings{MaxInstances: 1})
+
+	if _, err := pool.Acquire("aws"); err == nil {
+		t.Fatal("expected a start error")
+	}
+
+	// The failed start must release its slot, or the next acquisition
+	// would wait forever on a bound-1 pool.
+	done := make(chan struct{})
+	go func() {
+		pool.Acquire("aws")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("slot from failed start was never released")
+	}
+}
+
+func TestProviderPoolConcurrentFanOut(t *testing.T) {
+	var mu sync.Mutex
+	live, peak := 0, 0
+	pool := NewProviderPool(func(provider string) (ProviderInstance, error) {
+		mu.Lock()
+		live++
+		if live > peak {
+			peak = live
+		}
+		mu.Unlock()
+		return &fakeInstance{provider: provider}, nil
+	}, ProviderPoolSettings{MaxInstances: 3})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inst, err := pool.Acquire("aws")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			pool.Release("aws", inst)
+		}()
+	}
+	wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("started %d concurrent instances; the bound is 3", peak)
+	}
+	if stats := pool.Stats(); stats.PeakActive > 3 {
+		t.Errorf("wrong peak %d; the bound is 3", stats.PeakActive)
+	}
+}
@@ -0,0 +1,47 @@
+package runbooks
+
+import (
+	"fmt"
+)
+
+// PreflightTypes lists the type names the installed provider schemas
+// actually declare, for CheckPreflight: data source types serve both data
+// and list reads, and action types serve the steps' action blocks.
+type PreflightTypes struct {
+	DataSources map[string]bool
+	Actions     map[string]bool
+}
+
+// CheckPreflight verifies, without executing anything, that every data,
+// list, and action type the given runbook references exists in the
+// installed provider schemas. The runbook CLI uses this during
+// "terraform runbook init -check", so CI can tell that a runbook is
+// executable on a runner before the run actually starts.
+//
+// The returned errors are in step order, one per missing reference. A
+// nil Actions map means action types cannot be checked at all — today's
+// plugin protocol gives providers no way to declare them — so action
+// references pass unverified rather than all failing; an empty non-nil
+// map instead means no action types exist.
+func CheckPreflight(rb *Runbook, types PreflightTypes) []error {
+	var errs []error
+
+	for _, step := range rb.Steps {
+		for _, read := range step.Reads {
+			if types.DataSources[read.ReadType] {
+				continue
+			}
+			kind := "data"
+			if read.List {
+				kind = "list"
+			}
+			errs = append(errs, fmt.Errorf("step %q: %s block %q reads data source type %q, which no installed provider declares", step.Name, kind, read.Name, read.ReadType))
+		}
+
+		if step.ActionType != "" && types.Actions != nil && !types.Actions[step.ActionType] {
+			errs = append(errs, fmt.Errorf("step %q: action type %q is not declared by any installed provider", step.Name, step.ActionType))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,59 @@
+package runbooks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPreflight(t *testing.T) {
+	rb := &Runbook{
+		Name: "restart",
+		Steps: []*Step{
+			{
+				Name: "gather",
+				Reads: []*Read{
+					{Name: "all", ReadType: "aws_instance", List: true},
+					{Name: "zone", ReadType: "aws_route53_zone"},
+				},
+			},
+			{Name: "restart", ActionType: "aws_restart"},
+		},
+	}
+
+	t.Run("all types known", func(t *testing.T) {
+		errs := CheckPreflight(rb, PreflightTypes{
+			DataSources: map[string]bool{"aws_instance": true, "aws_route53_zone": true},
+			Actions:     map[string]bool{"aws_restart": true},
+		})
+		if len(errs) != 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("missing types", func(t *testing.T) {
+		errs := CheckPreflight(rb, PreflightTypes{
+			DataSources: map[string]bool{"aws_route53_zone": true},
+			Actions:     map[string]bool{},
+		})
+		if len(errs) != 2 {
+			t.Fatalf("wrong error count %d: %v", len(errs), errs)
+		}
+		if !strings.Contains(errs[0].Error(), `list block "all" reads data source type "aws_instance"`) {
+			t.Errorf("wrong first error: %s", errs[0])
+		}
+		if !strings.Contains(errs[1].Error(), `action type "aws_restart"`) {
+			t.Errorf("wrong second error: %s", errs[1])
+		}
+	})
+
+	t.Run("actions unverifiable", func(t *testing.T) {
+		// A nil Actions map means the caller has no way to know which
+		// action types exist, so only the data reads are checked.
+		errs := CheckPreflight(rb, PreflightTypes{
+			DataSources: map[string]bool{"aws_instance": true, "aws_route53_zone": true},
+		})
+		if len(errs) != 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+	})
+}
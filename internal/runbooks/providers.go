@@ -0,0 +1,65 @@
+package runbooks
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/getproviders"
+)
+
+// checkProviderVersions verifies that the provider versions actually
+// instantiated for this run satisfy the runbook's required_providers
+// constraints and, when a dependency lock file is available, match the
+// locked selections. It runs before any step, so a stale cached provider
+// fails the run up front instead of quietly executing actions with a
+// version the author never tested.
+func (e *Engine) checkProviderVersions(rb *Runbook) error {
+	if len(rb.RequiredProviders) == 0 || len(e.ProviderVersions) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(rb.RequiredProviders))
+	for name := range rb.RequiredProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		req := rb.RequiredProviders[name]
+
+		verStr, instantiated := e.ProviderVersions[name]
+		if !instantiated {
+			// A required provider that no action ended up needing isn't
+			// instantiated at all, and then there's no version to check.
+			continue
+		}
+		version, err := getproviders.ParseVersion(verStr)
+		if err != nil {
+			return fmt.Errorf("provider %q has unparseable version %q: %s", name, verStr, err)
+		}
+
+		if req.Version != "" {
+			constraints, err := getproviders.ParseVersionConstraints(req.Version)
+			if err != nil {
+				return fmt.Errorf("provider %q has invalid version constraint %q: %s", name, req.Version, err)
+			}
+			if !getproviders.MeetingConstraints(constraints).Has(version) {
+				return fmt.Errorf("provider %q (%s) is version %s, which does not satisfy the runbook's version constraint %q", name, req.Source, version, req.Version)
+			}
+		}
+
+		if e.ProviderLocks != nil && req.Source != "" {
+			addr, diags := addrs.ParseProviderSourceString(req.Source)
+			if diags.HasErrors() {
+				return fmt.Errorf("provider %q has invalid source address %q: %s", name, req.Source, diags.Err())
+			}
+			lock := e.ProviderLocks.Provider(addr)
+			if lock != nil && lock.Version() != version {
+				return fmt.Errorf("provider %q (%s) is version %s, but the dependency lock file requires version %s; run \"terraform init\" to reinstall the locked version, or update the lock file", name, req.Source, version, lock.Version())
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package runbooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/depsfile"
+	"github.com/hashicorp/terraform/internal/getproviders"
+)
+
+func TestEngineCheckProviderVersions(t *testing.T) {
+	rb := &Runbook{
+		Name: "restart",
+		RequiredProviders: map[string]*ProviderRequirement{
+			"aws": {
+				Source:  "hashicorp/aws",
+				Version: ">= 3.0.0, < 4.0.0",
+			},
+		},
+	}
+
+	locks := depsfile.NewLocks()
+	locks.SetProvider(
+		addrs.MustParseProviderSourceString("hashicorp/aws"),
+		getproviders.MustParseVersion("3.42.0"),
+		getproviders.MustParseVersionConstraints(">= 3.0.0"),
+		nil,
+	)
+
+	t.Run("satisfied", func(t *testing.T) {
+		engine := &Engine{
+			ProviderVersions: map[string]string{"aws": "3.42.0"},
+			ProviderLocks:    locks,
+		}
+		if err := engine.checkProviderVersions(rb); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("constraint not satisfied", func(t *testing.T) {
+		engine := &Engine{
+			ProviderVersions: map[string]string{"aws": "4.1.0"},
+		}
+		err := engine.checkProviderVersions(rb)
+		if err == nil || !strings.Contains(err.Error(), `does not satisfy the runbook's version constraint ">= 3.0.0, < 4.0.0"`) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("lock file mismatch", func(t *testing.T) {
+		// 3.41.0 satisfies the runbook's constraint, but the lock file
+		// selected 3.42.0, so a stale cached plugin must not run.
+		engine := &Engine{
+			ProviderVersions: map[string]string{"aws": "3.41.0"},
+			ProviderLocks:    locks,
+		}
+		err := engine.checkProviderVersions(rb)
+		if err == nil || !strings.Contains(err.Error(), "the dependency lock file requires version 3.42.0") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("not instantiated", func(t *testing.T) {
+		engine := &Engine{
+			ProviderVersions: map[string]string{"unrelated": "1.0.0"},
+			ProviderLocks:    locks,
+		}
+		if err := engine.checkProviderVersions(rb); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("unparseable version", func(t *testing.T) {
+		engine := &Engine{
+			ProviderVersions: map[string]string{"aws": "not-a-version"},
+		}
+		err := engine.checkProviderVersions(rb)
+		if err == nil || !strings.Contains(err.Error(), "unparseable version") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestEngineRunChecksProviderVersions(t *testing.T) {
+	rb := &Runbook{
+		Name: "restart",
+		RequiredProviders: map[string]*ProviderRequirement{
+			"aws": {Source: "hashicorp/aws", Version: ">= 3.0.0"},
+		},
+		Steps: []*Step{{Name: "restart", ActionType: "aws_restart"}},
+	}
+
+	invoked := false
+	engine := &Engine{
+		Actions: map[string]ActionFunc{
+			"aws_restart": func(config cty.Value, meta ActionMeta) (cty.Value, error) {
+				invoked = true
+				return cty.True, nil
+			},
+		},
+		ProviderVersions: map[string]string{"aws": "2.0.0"},
+	}
+
+	err := engine.Run(rb)
+	if err == nil || !strings.Contains(err.Error(), "does not satisfy") {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if invoked {
+		t.Error("action ran despite the failed provider version check")
+	}
+}
@@ -0,0 +1,212 @@
+package runbooks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ReadFunc reads one data source with the given resolved configuration and
+// returns the data found. Reads must be side-effect free: the engine runs
+// them concurrently and never records them in the checkpoint.
+type ReadFunc func(config cty.Value) (cty.Value, error)
+
+// Read is a single data read within a step, from one of the step's data or
+// list blocks. Reads run before the step's action, so a step can gather
+// facts and then act on them.
+type Read struct {
+	// Name identifies the read within its step.
+	Name string
+
+	// ReadType names the data source this read consults, used to look up
+	// the implementation in the engine's Readers.
+	ReadType string
+
+	// Config is the read's fully-resolved configuration.
+	Config cty.Value
+
+	// List marks a read as coming from a list block rather than a data
+	// block: its result enumerates resources of ReadType, and the engine
+	// normalizes it into an object with "identities", "objects", and
+	// "data" attributes. See listResultValue.
+	List bool
+
+	// DependsOn names other reads in the same step whose results this
+	// read needs. Reads with no dependencies between them run
+	// concurrently, up to the engine's read parallelism.
+	DependsOn []string
+
+	// Result is the data returned by the read, set by the engine once
+	// the read has run.
+	Result cty.Value
+}
+
+// defaultReadParallelism bounds how many of a step's independent reads run
+// at once when the engine doesn't say otherwise. It matches the default
+// parallelism of the main graph walk.
+const defaultReadParallelism = 10
+
+// runReads executes the given step's data reads. Reads whose dependencies
+// are all satisfied run concurrently, bounded by the engine's read
+// parallelism, and the next batch starts only once the current one has
+// finished, so a read always sees its dependencies' results. When several
+// reads fail, the error reported is the first in name order, to keep runs
+// deterministic regardless of goroutine scheduling. A non-nil pool
+// additionally bounds the reads by provider: each read holds an instance
+// of its serving provider for its duration, so a fan-out of reads cannot
+// exceed the runbook's MaxInstances for any one provider.
+func (e *Engine) runReads(rb *Runbook, step *Step, pool *ProviderPool) error {
+	if len(step.Reads) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]*Read, len(step.Reads))
+	for _, read := range step.Reads {
+		if _, exists := byName[read.Name]; exists {
+			return fmt.Errorf("duplicate read %q", read.Name)
+		}
+		byName[read.Name] = read
+	}
+	for _, read := range step.Reads {
+		for _, dep := range read.DependsOn {
+			if _, exists := byName[dep]; !exists {
+				return fmt.Errorf("read %q depends on undeclared read %q", read.Name, dep)
+			}
+		}
+	}
+
+	parallelism := e.ReadParallelism
+	if parallelism < 1 {
+		parallelism = defaultReadParallelism
+	}
+	sem := make(chan struct{}, parallelism)
+
+	done := make(map[string]bool, len(step.Reads))
+	pending := len(step.Reads)
+
+	for pending > 0 {
+		var batch []*Read
+		for _, read := range step.Reads {
+			if done[read.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range read.DependsOn {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				batch = append(batch, read)
+			}
+		}
+		if len(batch) == 0 {
+			return fmt.Errorf("dependency cycle among the step's reads")
+		}
+		sort.Slice(batch, func(i, j int) bool { return batch[i].Name < batch[j].Name })
+
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i, read := range batch {
+			wg.Add(1)
+			go func(i int, read *Read) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				reader, ok := e.Readers[read.ReadType]
+				if !ok {
+					errs[i] = fmt.Errorf("read %q: no implementation for data source type %q", read.Name, read.ReadType)
+					return
+				}
+				if pool != nil {
+					if provider := providerForType(rb, read.ReadType); provider != "" {
+						inst, err := pool.Acquire(provider)
+						if err != nil {
+							errs[i] = fmt.Errorf("read %q: %s", read.Name, err)
+							return
+						}
+						defer pool.Release(provider, inst)
+					}
+				}
+				result, err := reader(read.Config)
+				if err != nil {
+					errs[i] = fmt.Errorf("read %q failed: %s", read.Name, err)
+					return
+				}
+				if read.List {
+					result, err = listResultValue(result, e.IdentitySchemas[read.ReadType])
+					if err != nil {
+						errs[i] = fmt.Errorf("read %q: %s", read.Name, err)
+						return
+					}
+				}
+				read.Result = result
+			}(i, read)
+		}
+		wg.Wait()
+
+		// The batch is in name order, so reporting the first error keeps
+		// the outcome deterministic even though the reads themselves
+		// finished in whatever order scheduling produced.
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, read := range batch {
+			done[read.Name] = true
+		}
+		pending -= len(batch)
+	}
+
+	return nil
+}
+
+// ReadResults returns the results of the step's data reads as a single
+// object keyed by data source type and then read name, mirroring how data
+// sources appear in configuration expressions under the "data" symbol.
+// List reads are excluded; they appear under ListResults instead. It's
+// meaningful only once the step has run.
+func (s *Step) ReadResults() cty.Value {
+	return s.groupedReadResults(false)
+}
+
+// ListResults returns the results of the step's list reads as a single
+// object keyed by data source type and then read name, for exposure under
+// the "list" symbol. Each result is in the normalized shape produced by
+// listResultValue, so expressions can use list.<type>.<name>.identities
+// and .objects directly. It's meaningful only once the step has run.
+func (s *Step) ListResults() cty.Value {
+	return s.groupedReadResults(true)
+}
+
+func (s *Step) groupedReadResults(list bool) cty.Value {
+	byType := make(map[string]map[string]cty.Value)
+	for _, read := range s.Reads {
+		if read.List != list {
+			continue
+		}
+		if byType[read.ReadType] == nil {
+			byType[read.ReadType] = make(map[string]cty.Value)
+		}
+		result := read.Result
+		if result == cty.NilVal {
+			result = cty.NullVal(cty.DynamicPseudoType)
+		}
+		byType[read.ReadType][read.Name] = result
+	}
+	if len(byType) == 0 {
+		return cty.EmptyObjectVal
+	}
+
+	types := make(map[string]cty.Value, len(byType))
+	for readType, results := range byType {
+		types[readType] = cty.ObjectVal(results)
+	}
+	return cty.ObjectVal(types)
+}
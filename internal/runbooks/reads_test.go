@@ -0,0 +1,224 @@
+package runbooks
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEngineRunReadsConcurrency(t *testing.T) {
+	// Each independent read blocks until all of the others in its batch
+	// have started, so the step can finish only if the engine really does
+	// run them concurrently.
+	const readCount = 4
+
+	var started sync.WaitGroup
+	started.Add(readCount)
+	engine := &Engine{
+		ReadParallelism: readCount,
+		Readers: map[string]ReadFunc{
+			"probe": func(config cty.Value) (cty.Value, error) {
+				started.Done()
+				started.Wait()
+				return config, nil
+			},
+		},
+	}
+
+	step := &Step{Name: "gather"}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		step.Reads = append(step.Reads, &Read{
+			Name:     name,
+			ReadType: "probe",
+			Config:   cty.StringVal(name),
+		})
+	}
+
+	if err := engine.runReads(&Runbook{}, step, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, read := range step.Reads {
+		if read.Result != cty.StringVal(read.Name) {
+			t.Errorf("read %q has wrong result %#v", read.Name, read.Result)
+		}
+	}
+}
+
+func TestEngineRunReadsParallelismBound(t *testing.T) {
+	var running, peak int32
+	engine := &Engine{
+		ReadParallelism: 2,
+		Readers: map[string]ReadFunc{
+			"probe": func(config cty.Value) (cty.Value, error) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+						break
+					}
+				}
+				defer atomic.AddInt32(&running, -1)
+				return cty.True, nil
+			},
+		},
+	}
+
+	step := &Step{Name: "gather"}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		step.Reads = append(step.Reads, &Read{Name: name, ReadType: "probe"})
+	}
+
+	if err := engine.runReads(&Runbook{}, step, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Errorf("%d reads ran at once; parallelism is bounded at 2", got)
+	}
+}
+
+func TestEngineRunReadsDependencies(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	engine := &Engine{
+		Readers: map[string]ReadFunc{
+			"probe": func(config cty.Value) (cty.Value, error) {
+				mu.Lock()
+				order = append(order, config.AsString())
+				mu.Unlock()
+				return config, nil
+			},
+		},
+	}
+
+	step := &Step{
+		Name: "gather",
+		Reads: []*Read{
+			{Name: "summary", ReadType: "probe", Config: cty.StringVal("summary"), DependsOn: []string{"cpu", "mem"}},
+			{Name: "cpu", ReadType: "probe", Config: cty.StringVal("cpu")},
+			{Name: "mem", ReadType: "probe", Config: cty.StringVal("mem")},
+		},
+	}
+
+	if err := engine.runReads(&Runbook{}, step, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if order[len(order)-1] != "summary" {
+		t.Errorf("dependent read ran before its dependencies: %v", order)
+	}
+}
+
+func TestEngineRunReadsDeterministicError(t *testing.T) {
+	engine := &Engine{
+		Readers: map[string]ReadFunc{
+			"probe": func(config cty.Value) (cty.Value, error) {
+				return cty.NilVal, errors.New("unreachable")
+			},
+		},
+	}
+
+	step := &Step{
+		Name: "gather",
+		Reads: []*Read{
+			{Name: "zebra", ReadType: "probe"},
+			{Name: "aardvark", ReadType: "probe"},
+		},
+	}
+
+	// Both reads fail, so the reported error must be the first in name
+	// order no matter which goroutine finished first.
+	for i := 0; i < 10; i++ {
+		err := engine.runReads(&Runbook{}, step, nil)
+		if err == nil || !strings.Contains(err.Error(), `read "aardvark" failed`) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	}
+}
+
+func TestEngineRunReadsInvalid(t *testing.T) {
+	engine := &Engine{Readers: map[string]ReadFunc{}}
+
+	t.Run("dependency cycle", func(t *testing.T) {
+		step := &Step{
+			Name: "gather",
+			Reads: []*Read{
+				{Name: "a", ReadType: "probe", DependsOn: []string{"b"}},
+				{Name: "b", ReadType: "probe", DependsOn: []string{"a"}},
+			},
+		}
+		err := engine.runReads(&Runbook{}, step, nil)
+		if err == nil || !strings.Contains(err.Error(), "cycle") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("undeclared dependency", func(t *testing.T) {
+		step := &Step{
+			Name: "gather",
+			Reads: []*Read{
+				{Name: "a", ReadType: "probe", DependsOn: []string{"nope"}},
+			},
+		}
+		err := engine.runReads(&Runbook{}, step, nil)
+		if err == nil || !strings.Contains(err.Error(), `undeclared read "nope"`) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		step := &Step{
+			Name: "gather",
+			Reads: []*Read{
+				{Name: "a", ReadType: "probe"},
+				{Name: "a", ReadType: "probe"},
+			},
+		}
+		err := engine.runReads(&Runbook{}, step, nil)
+		if err == nil || !strings.Contains(err.Error(), `duplicate read "a"`) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("unknown data source type", func(t *testing.T) {
+		step := &Step{
+			Name:  "gather",
+			Reads: []*Read{{Name: "a", ReadType: "nonesuch"}},
+		}
+		err := engine.runReads(&Runbook{}, step, nil)
+		if err == nil || !strings.Contains(err.Error(), `no implementation for data source type "nonesuch"`) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestStepReadResults(t *testing.T) {
+	step := &Step{
+		Name: "gather",
+		Reads: []*Read{
+			{Name: "web", ReadType: "instances", Result: cty.StringVal("i-1234")},
+			{Name: "db", ReadType: "instances", Result: cty.StringVal("i-5678")},
+			{Name: "zone", ReadType: "dns", Result: cty.StringVal("example.com")},
+		},
+	}
+
+	got := step.ReadResults()
+	want := cty.ObjectVal(map[string]cty.Value{
+		"instances": cty.ObjectVal(map[string]cty.Value{
+			"web": cty.StringVal("i-1234"),
+			"db":  cty.StringVal("i-5678"),
+		}),
+		"dns": cty.ObjectVal(map[string]cty.Value{
+			"zone": cty.StringVal("example.com"),
+		}),
+	})
+	if !got.RawEquals(want) {
+		t.Errorf("wrong results\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if got := (&Step{Name: "empty"}).ReadResults(); !got.RawEquals(cty.EmptyObjectVal) {
+		t.Errorf("wrong results for step with no reads: %#v", got)
+	}
+}
@@ -0,0 +1,148 @@
+package runbooks
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// RemoteRunner is implemented by backends that can execute runbooks
+// remotely, the way remote plan and apply work: the local CLI uploads a
+// bundle of the runbook's files, the backend's runner executes it where
+// production credentials are available, and the logs stream back.
+type RemoteRunner interface {
+	// RunRunbook uploads the given bundle, executes the named runbook
+	// from it with the given variable values, and copies the remote logs
+	// to logs as they arrive. It returns once the remote run has
+	// finished, with its error if it failed.
+	RunRunbook(ctx context.Context, bundle io.Reader, name string, variables map[string]cty.Value, logs io.Writer) error
+}
+
+// RunRemote packs the runbook directory into a bundle and executes the
+// named runbook on the given remote runner, streaming the remote logs to
+// logs.
+func RunRemote(ctx context.Context, runner RemoteRunner, dir, name string, variables map[string]cty.Value, logs io.Writer) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(PackBundle(dir, pw))
+	}()
+
+	if err := runner.RunRunbook(ctx, pr, name, variables, logs); err != nil {
+		pr.Close()
+		return err
+	}
+	return nil
+}
+
+// PackBundle writes the contents of the given runbook directory to w as
+// a gzip-compressed tar archive, with paths relative to the directory.
+// VCS and working directories like .git and .terraform stay out of the
+// bundle, as do irregular files such as sockets and symlinks, so the
+// bundle contains exactly the files a remote runner needs and nothing it
+// can't use.
+func PackBundle(dir string, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); name == ".git" || name == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pack runbook bundle: %s", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// UnpackBundle extracts a bundle produced by PackBundle into the given
+// directory, for the runner side. Entries that would escape the
+// directory are rejected.
+func UnpackBundle(r io.Reader, dir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid runbook bundle: %s", err)
+	}
+	tr := tar.NewReader(gr)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid runbook bundle: %s", err)
+		}
+		name := filepath.FromSlash(header.Name)
+		if strings.HasPrefix(name, "..") || filepath.IsAbs(name) {
+			return fmt.Errorf("invalid runbook bundle: entry %q escapes the target directory", header.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid runbook bundle: entry %q has unsupported type", header.Name)
+		}
+	}
+	return gr.Close()
+}
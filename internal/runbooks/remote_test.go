@@ -0,0 +1,125 @@
+package runbooks
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// fakeRemoteRunner unpacks the uploaded bundle and writes a couple of
+// log lines, standing in for a backend's remote runner.
+type fakeRemoteRunner struct {
+	dir       string
+	name      string
+	variables map[string]cty.Value
+}
+
+func (r *fakeRemoteRunner) RunRunbook(ctx context.Context, bundle io.Reader, name string, variables map[string]cty.Value, logs io.Writer) error {
+	r.name = name
+	r.variables = variables
+	if err := UnpackBundle(bundle, r.dir); err != nil {
+		return err
+	}
+	io.WriteString(logs, "remote: step \"restart\" complete\n")
+	return nil
+}
+
+func TestRunRemote(t *testing.T) {
+	src, err := ioutil.TempDir("", "runbook-remote-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "runbook-remote-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("restart.runbook.hcl", "# runbook\n")
+	writeFile("terraform.runbook.tfvars", "region = \"us-east-1\"\n")
+	writeFile("modules/net/main.tf", "# module\n")
+	writeFile(".terraform/providers/cache", "should not upload")
+	writeFile(".git/HEAD", "should not upload")
+
+	runner := &fakeRemoteRunner{dir: dst}
+	var logs bytes.Buffer
+	err = RunRemote(context.Background(), runner, src, "restart_fleet",
+		map[string]cty.Value{"region": cty.StringVal("us-east-1")}, &logs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if runner.name != "restart_fleet" {
+		t.Errorf("wrong runbook name %q", runner.name)
+	}
+	if got := runner.variables["region"]; got != cty.StringVal("us-east-1") {
+		t.Errorf("wrong variables %#v", runner.variables)
+	}
+	for _, name := range []string{"restart.runbook.hcl", "terraform.runbook.tfvars", "modules/net/main.tf"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("bundle is missing %s: %s", name, err)
+		}
+	}
+	for _, name := range []string{".terraform/providers/cache", ".git/HEAD"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Errorf("bundle should not contain %s", name)
+		}
+	}
+	if got := logs.String(); got != "remote: step \"restart\" complete\n" {
+		t.Errorf("wrong streamed logs %q", got)
+	}
+}
+
+func TestUnpackBundleEscape(t *testing.T) {
+	var buf bytes.Buffer
+	// A hand-built bundle with a path traversal entry.
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	content := []byte("nope")
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "../escape.txt",
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(content)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "runbook-bundle-escape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := UnpackBundle(&buf, dir); err == nil {
+		t.Fatal("no error for path traversal entry")
+	}
+}
@@ -0,0 +1,137 @@
+package runbooks
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Runbook is an ordered sequence of operational steps to execute.
+type Runbook struct {
+	// Name identifies the runbook, for the UI and for hook events.
+	Name string
+
+	// Description documents what the runbook does and when to run it,
+	// for the UI and for operators browsing a runbook library.
+	Description string
+
+	// Variables are the runbook's input variable declarations, keyed by
+	// name. Values for them are resolved by VariableValues.
+	Variables map[string]*Variable
+
+	// RequiredProviders are the runbook's provider requirements, keyed
+	// by local name. An empty version constraint means the requirement
+	// is unpinned.
+	RequiredProviders map[string]*ProviderRequirement
+
+	// ProviderPool holds the limits from the runbook's provider_pool
+	// block, when it has one, for the caller to enforce with a
+	// ProviderPool. Nil means the runbook leaves pooling to the caller's
+	// defaults.
+	ProviderPool *ProviderPoolSettings
+
+	// Steps are executed in order. A step that fails, or that a hook
+	// vetoes, stops execution of the remaining steps.
+	Steps []*Step
+
+	// Auth are the runbook's credential helper declarations, run before
+	// provider configuration; see Engine.Credentials.
+	Auth []*Auth
+
+	// Secrets are the runbook's secret declarations, resolved before any
+	// step runs; see Engine.Secrets.
+	Secrets []*Secret
+
+	// Lock, when set, is a concurrency guard held for the whole run, so
+	// only one holder at a time can execute the runbook; see Lock.
+	Lock *Lock
+
+	// Outputs are the runbook's result values, checked after every step
+	// has run. Outputs with expectations make a runbook double as a
+	// verification procedure; see Output.Check.
+	Outputs []*Output
+
+	// Lint adjusts which lint rules run for this runbook, from its lint
+	// block. Nil leaves every rule enabled.
+	Lint *LintConfig
+}
+
+// AllOutputs collects every output of a run for the end-of-run summary:
+// each step's outputs in step order, then the runbook-level outputs. The
+// returned outputs are copies with their Step field filled in, so a
+// step's output is attributable after the steps' own progress output has
+// scrolled away.
+func (rb *Runbook) AllOutputs() []*Output {
+	var ret []*Output
+	for _, step := range rb.Steps {
+		for _, output := range step.Outputs {
+			o := *output
+			o.Step = step.Name
+			if step.EachKey != "" {
+				o.Step = step.Name + "[" + step.EachKey + "]"
+			}
+			ret = append(ret, &o)
+		}
+	}
+	return append(ret, rb.Outputs...)
+}
+
+// ProviderRequirement is one entry of a runbook's required_providers
+// block.
+type ProviderRequirement struct {
+	// Source is the provider's source address in the usual
+	// registry/namespace/type form.
+	Source string
+
+	// Version is the version constraint, empty when none was given.
+	Version string
+}
+
+// Step is a single step within a runbook.
+type Step struct {
+	// Name identifies the step within its runbook.
+	Name string
+
+	// Description documents what the step does, for the UI and for
+	// operators reading the runbook.
+	Description string
+
+	// Reads are the step's data reads, run before its action (if any) so
+	// a step can gather facts and then act on them. Reads with no
+	// dependencies between them run concurrently.
+	Reads []*Read
+
+	// ActionType names the action this step invokes, if any. Steps with
+	// an empty ActionType don't invoke an action, e.g. because they only
+	// gather data.
+	ActionType string
+
+	// Config is the step's fully-resolved configuration, with all
+	// expressions already evaluated.
+	Config cty.Value
+
+	// EachKey is the instance key of a step expanded from a for_each
+	// expression, and empty for ordinary steps. It distinguishes the
+	// expanded instances in hook events and idempotency keys.
+	EachKey string
+
+	// VariableRefs names the runbook variables the step's configuration
+	// expressions referenced, recorded when the configuration was
+	// resolved, so tooling like lint can reason about variable usage
+	// after the expressions themselves are gone.
+	VariableRefs []string
+
+	// Lock, when set, is a concurrency guard held while this step runs,
+	// for steps that must not run concurrently with their counterparts
+	// in other runs; see Lock.
+	Lock *Lock
+
+	// PlanOnly makes the step plan its action and render the planned
+	// action data without ever invoking it, from the plan_only attribute
+	// of the step's action block. It lets one step rehearse in an
+	// otherwise live run.
+	PlanOnly bool
+
+	// Outputs are the step's result values, checked as soon as the step
+	// has run so an unmet expectation stops the runbook right away. They
+	// also appear in the end-of-run summary; see Runbook.AllOutputs.
+	Outputs []*Output
+}
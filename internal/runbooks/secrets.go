@@ -0,0 +1,104 @@
+package runbooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Secret is one secret declaration from a runbook's secret blocks. A
+// secret names where a sensitive input value comes from, so a runbook
+// can pull a token from the environment, a command like "vault kv get",
+// or a file without hiding the fact inside a wrapper script where the
+// audit trail can't see it.
+type Secret struct {
+	// Name identifies the secret within its runbook. Expressions refer
+	// to the resolved value as secret.<name>.
+	Name string
+
+	// Source says where the value comes from: SecretSourceEnv,
+	// SecretSourceCommand, or SecretSourceFile.
+	Source string
+
+	// Var is the environment variable read when Source is "env".
+	Var string
+
+	// Command is the command run when Source is "command". Its standard
+	// output, with trailing whitespace trimmed, becomes the value.
+	Command []string
+
+	// File is the path read when Source is "file", resolved relative to
+	// the process working directory. Trailing whitespace is trimmed, so
+	// a file ending in a newline yields the same value as one without.
+	File string
+}
+
+// The allowed values for Secret.Source.
+const (
+	SecretSourceEnv     = "env"
+	SecretSourceCommand = "command"
+	SecretSourceFile    = "file"
+)
+
+// Secrets resolves the runbook's secret blocks in order and returns the
+// values keyed by name, for the caller to expose to expression
+// evaluation as secret.<name>. The values come back marked "sensitive"
+// and are ephemeral: they are never recorded in the checkpoint, the
+// action event log, or the outputs audit, all of which redact marked
+// values, and callers must not persist them either. A secret that can't
+// be resolved aborts the run before any step executes, since a silently
+// empty token would just fail later and more confusingly.
+func (e *Engine) Secrets(rb *Runbook) (map[string]cty.Value, error) {
+	if len(rb.Secrets) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]cty.Value, len(rb.Secrets))
+	for _, secret := range rb.Secrets {
+		raw, err := resolveSecret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %s", secret.Name, err)
+		}
+		ret[secret.Name] = cty.StringVal(raw).Mark("sensitive")
+	}
+	return ret, nil
+}
+
+// resolveSecret fetches one secret's raw value from its source.
+func resolveSecret(secret *Secret) (string, error) {
+	switch secret.Source {
+	case SecretSourceEnv:
+		value, set := os.LookupEnv(secret.Var)
+		if !set {
+			return "", fmt.Errorf("environment variable %q is not set", secret.Var)
+		}
+		return value, nil
+
+	case SecretSourceCommand:
+		if len(secret.Command) == 0 {
+			return "", fmt.Errorf("no command given")
+		}
+		out, err := exec.Command(secret.Command[0], secret.Command[1:]...).Output()
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+				return "", fmt.Errorf("command failed: %s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("command failed: %s", err)
+		}
+		return strings.TrimRight(string(out), "\r\n \t"), nil
+
+	case SecretSourceFile:
+		content, err := ioutil.ReadFile(secret.File)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n \t"), nil
+
+	default:
+		return "", fmt.Errorf("invalid source %q: must be %q, %q, or %q", secret.Source, SecretSourceEnv, SecretSourceCommand, SecretSourceFile)
+	}
+}
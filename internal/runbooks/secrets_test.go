@@ -0,0 +1,101 @@
+package runbooks
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSecrets(t *testing.T) {
+	os.Setenv("RUNBOOK_TEST_TOKEN", "from-env")
+	defer os.Unsetenv("RUNBOOK_TEST_TOKEN")
+
+	f, err := ioutil.TempFile("", "runbook-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("from-file\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	engine := &Engine{}
+	rb := &Runbook{
+		Name: "rotate_keys",
+		Secrets: []*Secret{
+			{Name: "api_token", Source: SecretSourceEnv, Var: "RUNBOOK_TEST_TOKEN"},
+			{Name: "signing_key", Source: SecretSourceFile, File: f.Name()},
+			{Name: "otp", Source: SecretSourceCommand, Command: []string{"echo", "from-command"}},
+		},
+	}
+
+	secrets, err := engine.Secrets(rb)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string]string{
+		"api_token":   "from-env",
+		"signing_key": "from-file",
+		"otp":         "from-command",
+	}
+	for name, wantRaw := range want {
+		val, ok := secrets[name]
+		if !ok {
+			t.Errorf("no value for secret %q", name)
+			continue
+		}
+		if !val.HasMark("sensitive") {
+			t.Errorf("secret %q is not marked sensitive", name)
+		}
+		raw, _ := val.Unmark()
+		if got := raw.AsString(); got != wantRaw {
+			t.Errorf("wrong value for secret %q: %q, want %q", name, got, wantRaw)
+		}
+	}
+}
+
+func TestSecretsErrors(t *testing.T) {
+	os.Unsetenv("RUNBOOK_TEST_UNSET")
+	engine := &Engine{}
+
+	tests := []struct {
+		secret *Secret
+		want   string
+	}{
+		{
+			&Secret{Name: "token", Source: SecretSourceEnv, Var: "RUNBOOK_TEST_UNSET"},
+			`environment variable "RUNBOOK_TEST_UNSET" is not set`,
+		},
+		{
+			&Secret{Name: "token", Source: SecretSourceCommand},
+			"no command given",
+		},
+		{
+			&Secret{Name: "token", Source: "vault"},
+			`invalid source "vault"`,
+		},
+	}
+	for _, test := range tests {
+		rb := &Runbook{Name: "rotate_keys", Secrets: []*Secret{test.secret}}
+		_, err := engine.Secrets(rb)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("wrong error %v; want one containing %q", err, test.want)
+		}
+	}
+}
+
+func TestSecretsRedactedInEventLog(t *testing.T) {
+	// A config carrying a secret-derived value must never reach the
+	// action event log in the clear: marked values encode as null.
+	config := cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal("hunter2").Mark("sensitive"),
+	})
+	if got := string(eventValue(config)); strings.Contains(got, "hunter2") {
+		t.Errorf("event log would leak the secret: %s", got)
+	}
+}
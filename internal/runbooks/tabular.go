@@ -0,0 +1,198 @@
+package runbooks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// The formats WriteTabular accepts, from the -output-format option of
+// commands that run runbooks.
+const (
+	OutputFormatTable = "table"
+	OutputFormatCSV   = "csv"
+	OutputFormatJSON  = "json"
+)
+
+// TabularRows projects a collection of objects — the shape of list read
+// results, and of fleet inventories generally — into column names and
+// rows of rendered cells. The columns are the union of the elements'
+// attribute names, sorted, so ragged objects still line up; an element
+// without a column's attribute gets an empty cell. The third result is
+// false when the value has no tabular shape: anything but a known,
+// non-null list, set, or tuple whose elements are all objects or maps.
+func TabularRows(val cty.Value) ([]string, [][]string, bool) {
+	if val == cty.NilVal || val.IsMarked() || !val.IsWhollyKnown() || val.IsNull() {
+		return nil, nil, false
+	}
+	ty := val.Type()
+	if !ty.IsListType() && !ty.IsSetType() && !ty.IsTupleType() {
+		return nil, nil, false
+	}
+
+	colSet := make(map[string]bool)
+	var elems []map[string]cty.Value
+	for it := val.ElementIterator(); it.Next(); {
+		_, elem := it.Element()
+		ety := elem.Type()
+		if elem.IsNull() || (!ety.IsObjectType() && !ety.IsMapType()) {
+			return nil, nil, false
+		}
+		m := elem.AsValueMap()
+		for name := range m {
+			colSet[name] = true
+		}
+		elems = append(elems, m)
+	}
+
+	if len(elems) == 0 {
+		// An empty list or set of objects still has columns, from its
+		// element type; an empty tuple tells us nothing.
+		if ty.IsTupleType() || !ty.ElementType().IsObjectType() {
+			return nil, nil, false
+		}
+		for name := range ty.ElementType().AttributeTypes() {
+			colSet[name] = true
+		}
+	}
+
+	columns := make([]string, 0, len(colSet))
+	for name := range colSet {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	rows := make([][]string, len(elems))
+	for i, elem := range elems {
+		row := make([]string, len(columns))
+		for j, name := range columns {
+			row[j] = cellStr(elem[name])
+		}
+		rows[i] = row
+	}
+	return columns, rows, true
+}
+
+// cellStr renders one cell of a tabular value. Strings come through
+// unquoted — the point of the table and CSV formats is values an
+// operator or spreadsheet can use directly — and nested collections fall
+// back to their JSON encoding.
+func cellStr(val cty.Value) string {
+	if val == cty.NilVal || val.IsNull() {
+		return ""
+	}
+	if val.IsMarked() {
+		return "(sensitive)"
+	}
+	if !val.IsWhollyKnown() {
+		return "(known after apply)"
+	}
+	switch val.Type() {
+	case cty.String:
+		return val.AsString()
+	case cty.Number:
+		return val.AsBigFloat().Text('f', -1)
+	case cty.Bool:
+		if val.True() {
+			return "true"
+		}
+		return "false"
+	default:
+		if buf, err := ctyjson.Marshal(val, val.Type()); err == nil {
+			return string(buf)
+		}
+		return val.GoString()
+	}
+}
+
+// WriteTabular renders a tabular value to w in the given format: "table"
+// for aligned columns, "csv" for spreadsheets, or "json" for further
+// processing. The table and csv formats require a value TabularRows
+// accepts; json takes any known value.
+func WriteTabular(w io.Writer, val cty.Value, format string) error {
+	switch format {
+	case OutputFormatJSON:
+		if val == cty.NilVal || val.IsMarked() || !val.IsWhollyKnown() {
+			return fmt.Errorf("value is not fully known")
+		}
+		buf, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf, "", "  "); err != nil {
+			return err
+		}
+		out.WriteByte('\n')
+		_, err = w.Write(out.Bytes())
+		return err
+
+	case OutputFormatCSV:
+		columns, rows, ok := TabularRows(val)
+		if !ok {
+			return fmt.Errorf("value is not tabular: must be a list of objects")
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case OutputFormatTable:
+		columns, rows, ok := TabularRows(val)
+		if !ok {
+			return fmt.Errorf("value is not tabular: must be a list of objects")
+		}
+		widths := make([]int, len(columns))
+		for i, name := range columns {
+			widths[i] = len(strings.ToUpper(name))
+		}
+		for _, row := range rows {
+			for i, cell := range row {
+				if len(cell) > widths[i] {
+					widths[i] = len(cell)
+				}
+			}
+		}
+		writeRow := func(cells []string) error {
+			var line strings.Builder
+			for i, cell := range cells {
+				if i > 0 {
+					line.WriteString("  ")
+				}
+				fmt.Fprintf(&line, "%-*s", widths[i], cell)
+			}
+			_, err := fmt.Fprintf(w, "%s\n", strings.TrimRight(line.String(), " "))
+			return err
+		}
+		header := make([]string, len(columns))
+		for i, name := range columns {
+			header[i] = strings.ToUpper(name)
+		}
+		if err := writeRow(header); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writeRow(row); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid output format %q: must be %q, %q, or %q", format, OutputFormatTable, OutputFormatCSV, OutputFormatJSON)
+	}
+}
@@ -0,0 +1,116 @@
+package runbooks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func inventoryVal() cty.Value {
+	return cty.TupleVal([]cty.Value{
+		cty.ObjectVal(map[string]cty.Value{
+			"id":   cty.StringVal("i-abc123"),
+			"zone": cty.StringVal("us-east-1a"),
+			"size": cty.NumberIntVal(4),
+		}),
+		cty.ObjectVal(map[string]cty.Value{
+			"id":      cty.StringVal("i-def456"),
+			"zone":    cty.StringVal("us-east-1b"),
+			"standby": cty.True,
+		}),
+	})
+}
+
+func TestTabularRows(t *testing.T) {
+	columns, rows, ok := TabularRows(inventoryVal())
+	if !ok {
+		t.Fatal("inventory value should be tabular")
+	}
+	if want := []string{"id", "size", "standby", "zone"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("wrong columns %#v; want %#v", columns, want)
+	}
+	wantRows := [][]string{
+		{"i-abc123", "4", "", "us-east-1a"},
+		{"i-def456", "", "true", "us-east-1b"},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("wrong rows\ngot:  %#v\nwant: %#v", rows, wantRows)
+	}
+}
+
+func TestTabularRowsNotTabular(t *testing.T) {
+	for _, val := range []cty.Value{
+		cty.NilVal,
+		cty.StringVal("just a string"),
+		cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		cty.NullVal(cty.List(cty.EmptyObject)),
+		cty.UnknownVal(cty.List(cty.EmptyObject)),
+		cty.EmptyTupleVal,
+	} {
+		if _, _, ok := TabularRows(val); ok {
+			t.Errorf("%#v should not be tabular", val)
+		}
+	}
+
+	// An empty list of objects still has columns from its element type.
+	empty := cty.ListValEmpty(cty.Object(map[string]cty.Type{"id": cty.String}))
+	columns, rows, ok := TabularRows(empty)
+	if !ok || len(rows) != 0 || !reflect.DeepEqual(columns, []string{"id"}) {
+		t.Errorf("wrong result for empty list: %#v, %#v, %v", columns, rows, ok)
+	}
+}
+
+func TestWriteTabularTable(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteTabular(&buf, inventoryVal(), OutputFormatTable); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := strings.Join([]string{
+		"ID        SIZE  STANDBY  ZONE",
+		"i-abc123  4              us-east-1a",
+		"i-def456        true     us-east-1b",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTabularCSV(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteTabular(&buf, inventoryVal(), OutputFormatCSV); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := strings.Join([]string{
+		"id,size,standby,zone",
+		"i-abc123,4,,us-east-1a",
+		"i-def456,,true,us-east-1b",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTabularJSON(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteTabular(&buf, inventoryVal(), OutputFormatJSON); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, `"id": "i-abc123"`) || !strings.HasSuffix(got, "\n") {
+		t.Errorf("wrong output:\n%s", got)
+	}
+}
+
+func TestWriteTabularErrors(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteTabular(&buf, cty.StringVal("nope"), OutputFormatCSV); err == nil || !strings.Contains(err.Error(), "not tabular") {
+		t.Errorf("wrong error %v for non-tabular value", err)
+	}
+	if err := WriteTabular(&buf, inventoryVal(), "yaml"); err == nil || !strings.Contains(err.Error(), `invalid output format "yaml"`) {
+		t.Errorf("wrong error %v for invalid format", err)
+	}
+}
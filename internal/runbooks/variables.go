@@ -0,0 +1,227 @@
+package runbooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Variable is a runbook input variable declaration.
+type Variable struct {
+	// Name identifies the variable within its runbook.
+	Name string
+
+	// Default is the value used when no other source provides one.
+	// cty.NilVal means the variable is required.
+	Default cty.Value
+}
+
+// The variables files loaded automatically for a runbook, from the
+// directory containing it. The names deliberately differ from the module
+// tfvars filenames so that runbook inputs committed next to a
+// configuration are never picked up by plan and apply, or vice versa.
+const (
+	defaultVarsFilename = "terraform.runbook.tfvars"
+	autoVarsSuffix      = ".auto.runbook.tfvars"
+)
+
+// ParseCLIVariables turns the raw NAME=VALUE arguments of -var options
+// into a value map for VariableValues' cliValues argument. Each value is
+// interpreted the way the variables files are: as an HCL expression when
+// it parses and evaluates as one, and as a literal string otherwise.
+func ParseCLIVariables(raw []string) (map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	ret := make(map[string]cty.Value, len(raw))
+	for _, entry := range raw {
+		eq := strings.Index(entry, "=")
+		if eq == -1 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -var option",
+				fmt.Sprintf("The value %q is not valid: must be in NAME=VALUE format.", entry),
+			))
+			continue
+		}
+		ret[entry[:eq]] = parseFlexibleValue(entry[eq+1:], "<var argument>")
+	}
+	return ret, diags
+}
+
+// VariableValues resolves values for the given variable declarations,
+// combining the usual sources in the usual order: declaration defaults
+// first, then terraform.runbook.tfvars and any *.auto.runbook.tfvars
+// files from dir in lexical order, then TF_VAR_ environment variables
+// from environ, and finally the explicit values from -var options. Later
+// sources override earlier ones name by name, so environment-specific
+// inputs can live in committed files and still be overridden for one
+// run. A declared variable left without a value by every source is an
+// error.
+func VariableValues(dir string, decls map[string]*Variable, environ []string, cliValues map[string]cty.Value) (map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	ret := make(map[string]cty.Value, len(decls))
+
+	for name, decl := range decls {
+		if decl.Default != cty.NilVal {
+			ret[name] = decl.Default
+		}
+	}
+
+	for _, filename := range autoVarsFiles(dir) {
+		moreDiags := applyVarsFile(filename, decls, ret)
+		diags = diags.Append(moreDiags)
+	}
+
+	for _, entry := range environ {
+		if !strings.HasPrefix(entry, "TF_VAR_") {
+			continue
+		}
+		eq := strings.Index(entry, "=")
+		if eq == -1 {
+			continue
+		}
+		name := entry[len("TF_VAR_"):eq]
+		if _, declared := decls[name]; !declared {
+			// Undeclared TF_VAR_ variables are likely intended for some
+			// other configuration sharing the environment, so they are
+			// ignored rather than rejected.
+			continue
+		}
+		ret[name] = parseFlexibleValue(entry[eq+1:], "<environment variable TF_VAR_"+name+">")
+	}
+
+	for name, val := range cliValues {
+		if _, declared := decls[name]; !declared {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Value for undeclared runbook variable",
+				fmt.Sprintf("A value was given for variable %q, but the runbook declares no variable of that name.", name),
+			))
+			continue
+		}
+		ret[name] = val
+	}
+
+	names := make([]string, 0, len(decls))
+	for name := range decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := ret[name]; !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"No value for runbook variable",
+				fmt.Sprintf("The runbook variable %q has no default and no value was provided. Set it in %s, with a TF_VAR_ environment variable, or with the -var option.", name, defaultVarsFilename),
+			))
+		}
+	}
+
+	return ret, diags
+}
+
+// autoVarsFiles returns the runbook variables files to load from dir, in
+// the order they apply: the default file first, then the auto files
+// sorted by name.
+func autoVarsFiles(dir string) []string {
+	var ret []string
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		// A missing or unreadable directory just means no files; any
+		// real problem will surface when the runbook itself is loaded.
+		return nil
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if entry.Name() == defaultVarsFilename {
+			ret = append(ret, filepath.Join(dir, entry.Name()))
+		}
+	}
+	var auto []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name := entry.Name(); strings.HasSuffix(name, autoVarsSuffix) {
+			auto = append(auto, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(auto)
+	return append(ret, auto...)
+}
+
+// applyVarsFile parses one runbook variables file and merges its values
+// into the given map, rejecting names the runbook does not declare.
+func applyVarsFile(filename string, decls map[string]*Variable, into map[string]cty.Value) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read runbook variables file",
+			fmt.Sprintf("Could not read %s: %s.", filename, err),
+		))
+		return diags
+	}
+
+	file, hclDiags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	diags = diags.Append(hclDiags)
+	if hclDiags.HasErrors() {
+		return diags
+	}
+
+	// Expressions in variables files may use the runbook function set,
+	// with relative paths resolved against the file's own directory.
+	evalCtx := &hcl.EvalContext{
+		Functions: Functions(filepath.Dir(filename)),
+	}
+
+	attrs, hclDiags := file.Body.JustAttributes()
+	diags = diags.Append(hclDiags)
+	for name, attr := range attrs {
+		if _, declared := decls[name]; !declared {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Value for undeclared runbook variable",
+				Detail:   fmt.Sprintf("The runbook declares no variable named %q. To use this value, declare the variable in the runbook.", name),
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+		val, hclDiags := attr.Expr.Value(evalCtx)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			into[name] = val
+		}
+	}
+
+	return diags
+}
+
+// parseFlexibleValue interprets a raw string value the way the CLI
+// options do: as an HCL expression when it parses and evaluates as one,
+// and as a literal string otherwise.
+func parseFlexibleValue(raw, filename string) cty.Value {
+	expr, hclDiags := hclsyntax.ParseExpression([]byte(raw), filename, hcl.InitialPos)
+	if !hclDiags.HasErrors() {
+		evalCtx := &hcl.EvalContext{
+			Functions: Functions("."),
+		}
+		if val, valDiags := expr.Value(evalCtx); !valDiags.HasErrors() {
+			return val
+		}
+	}
+	return cty.StringVal(raw)
+}
@@ -0,0 +1,100 @@
+package runbooks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestVariableValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("terraform.runbook.tfvars", "region = \"us-east-1\"\nretries = 3\n")
+	writeFile("a.auto.runbook.tfvars", "region = \"us-west-2\"\n")
+	writeFile("b.auto.runbook.tfvars", "region = \"eu-west-1\"\n")
+
+	decls := map[string]*Variable{
+		"region":  {Name: "region"},
+		"retries": {Name: "retries", Default: cty.NumberIntVal(1)},
+		"dry_run": {Name: "dry_run", Default: cty.False},
+	}
+
+	// The later sources win: defaults, then the tfvars files in order,
+	// then the environment, then -var values.
+	got, diags := VariableValues(dir, decls,
+		[]string{"TF_VAR_dry_run=true", "TF_VAR_unrelated=ignored"},
+		map[string]cty.Value{"retries": cty.NumberIntVal(5)},
+	)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	want := map[string]cty.Value{
+		"region":  cty.StringVal("eu-west-1"),
+		"retries": cty.NumberIntVal(5),
+		"dry_run": cty.True,
+	}
+	for name, wantVal := range want {
+		if gotVal, ok := got[name]; !ok || !gotVal.RawEquals(wantVal) {
+			t.Errorf("wrong value for %q\ngot:  %#v\nwant: %#v", name, gotVal, wantVal)
+		}
+	}
+
+	// A required variable no source covers is an error naming it.
+	_, diags = VariableValues(dir, map[string]*Variable{
+		"region":  {Name: "region"},
+		"retries": {Name: "retries"},
+		"subnet":  {Name: "subnet"},
+	}, nil, nil)
+	if !diags.HasErrors() {
+		t.Fatal("no error for missing required variable")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, `"subnet"`) {
+		t.Errorf("error does not name the missing variable:\n%s", detail)
+	}
+
+	// Values for undeclared variables in a file are rejected, so a typo
+	// in a committed tfvars file doesn't silently do nothing.
+	writeFile("c.auto.runbook.tfvars", "regoin = \"typo\"\n")
+	_, diags = VariableValues(dir, decls, nil, nil)
+	if !diags.HasErrors() {
+		t.Fatal("no error for undeclared variable in tfvars file")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, `"regoin"`) {
+		t.Errorf("error does not name the undeclared variable:\n%s", detail)
+	}
+}
+
+func TestParseCLIVariables(t *testing.T) {
+	got, diags := ParseCLIVariables([]string{
+		"region=us-west-2",
+		"ports=[80, 443]",
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if want := cty.StringVal("us-west-2"); !got["region"].RawEquals(want) {
+		t.Errorf("wrong region %#v; want %#v", got["region"], want)
+	}
+	if want := cty.TupleVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}); !got["ports"].RawEquals(want) {
+		t.Errorf("wrong ports %#v; want %#v", got["ports"], want)
+	}
+
+	// An option without an equals sign is an error, not a silent skip.
+	if _, diags := ParseCLIVariables([]string{"region"}); !diags.HasErrors() {
+		t.Fatal("no error for option without a value")
+	}
+}
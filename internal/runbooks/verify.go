@@ -0,0 +1,88 @@
+package runbooks
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// VerificationConfig is the runbook signing policy from the CLI
+// configuration's runbook_verification block. Runbooks invoke
+// destructive actions, so teams can require that every runbook carries a
+// detached signature from a trusted key before it executes.
+type VerificationConfig struct {
+	// Enforce refuses to execute runbooks that are unsigned or whose
+	// signature doesn't verify. When false, signatures are still checked
+	// when present, but unsigned runbooks run with only a log message.
+	Enforce bool
+
+	// TrustedKeys are ASCII-armored PGP public keys. A signature from
+	// any one of them is accepted.
+	TrustedKeys []string
+}
+
+// signatureSuffix is appended to a runbook file or bundle name to find
+// its detached signature.
+const signatureSuffix = ".sig"
+
+// VerifyRunbookFile checks the detached signature of the runbook file or
+// bundle at the given path, read from the adjacent file with the ".sig"
+// suffix, against the trusted keys. A missing signature is an error only
+// when enforcement is on; a signature that doesn't verify — whether from
+// an untrusted key or because the file was modified after signing — is
+// always an error.
+func VerifyRunbookFile(path string, cfg *VerificationConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sig, err := ioutil.ReadFile(path + signatureSuffix)
+	if os.IsNotExist(err) {
+		if cfg.Enforce {
+			return fmt.Errorf("runbook %s is not signed: expected a detached signature at %s%s", path, path, signatureSuffix)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := VerifyDetached(data, sig, cfg); err != nil {
+		return fmt.Errorf("runbook %s failed signature verification: %s", path, err)
+	}
+	return nil
+}
+
+// VerifyDetached checks a detached signature over the given data against
+// the trusted keys, accepting both armored and binary signatures.
+func VerifyDetached(data, sig []byte, cfg *VerificationConfig) error {
+	if len(cfg.TrustedKeys) == 0 {
+		return fmt.Errorf("no trusted keys are configured")
+	}
+
+	var keyring openpgp.EntityList
+	for _, armored := range cfg.TrustedKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+		if err != nil {
+			return fmt.Errorf("invalid trusted key in runbook_verification configuration: %s", err)
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN PGP")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	if _, err := check(keyring, bytes.NewReader(data), bytes.NewReader(sig)); err != nil {
+		return fmt.Errorf("signature is not from a trusted key, or the runbook was modified after signing: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package runbooks
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// testSigningKey generates a throwaway PGP key and returns the entity
+// together with its ASCII-armored public key.
+func testSigningKey(t *testing.T) (*openpgp.Entity, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return entity, pub.String()
+}
+
+func TestVerifyRunbookFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runbook-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entity, trustedKey := testSigningKey(t)
+	cfg := &VerificationConfig{
+		Enforce:     true,
+		TrustedKeys: []string{trustedKey},
+	}
+
+	path := filepath.Join(dir, "restart.runbook.hcl")
+	content := []byte("# restart fleet\n")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unsigned with enforcement on is refused.
+	err = VerifyRunbookFile(path, cfg)
+	if err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Fatalf("wrong error for unsigned runbook: %v", err)
+	}
+
+	// Unsigned without enforcement passes.
+	if err := VerifyRunbookFile(path, &VerificationConfig{TrustedKeys: cfg.TrustedKeys}); err != nil {
+		t.Fatalf("unexpected error without enforcement: %s", err)
+	}
+
+	// A valid signature from the trusted key passes.
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(&sig, entity, bytes.NewReader(content), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path+signatureSuffix, sig.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyRunbookFile(path, cfg); err != nil {
+		t.Fatalf("unexpected error for signed runbook: %s", err)
+	}
+
+	// Modifying the runbook after signing is refused.
+	if err := ioutil.WriteFile(path, append(content, []byte("# tampered\n")...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyRunbookFile(path, cfg)
+	if err == nil || !strings.Contains(err.Error(), "failed signature verification") {
+		t.Fatalf("wrong error for tampered runbook: %v", err)
+	}
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A signature from some other key is refused, even with enforcement
+	// off: a bad signature is worse than none.
+	other, _ := testSigningKey(t)
+	sig.Reset()
+	if err := openpgp.DetachSign(&sig, other, bytes.NewReader(content), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path+signatureSuffix, sig.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyRunbookFile(path, &VerificationConfig{TrustedKeys: cfg.TrustedKeys})
+	if err == nil {
+		t.Fatal("no error for signature from untrusted key")
+	}
+}
@@ -0,0 +1,82 @@
+package scenarios
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// CollectArtifacts copies the files matching the given step's artifact
+// patterns into the run's artifacts directory, under a subdirectory per
+// scenario and step so that steps with identically-named outputs don't
+// overwrite each other. The patterns are resolved relative to baseDir,
+// the directory containing the scenario file. A pattern that matches
+// nothing is not an error: the files a step produces can legitimately
+// depend on its inputs.
+//
+// The returned paths name the copies, relative to the process working
+// directory, in a stable order for the run reports.
+func (s *Scenario) CollectArtifacts(step *Step, baseDir, artifactsDir string) ([]string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if len(step.Artifacts) == 0 {
+		return nil, diags
+	}
+
+	destDir := filepath.Join(artifactsDir, s.Name, step.Name)
+	var collected []string
+	for _, pattern := range step.Artifacts {
+		matches, err := filepath.Glob(filepath.Join(baseDir, pattern))
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid artifact pattern",
+				fmt.Sprintf("The artifact pattern %q of step %q is not a valid glob pattern: %s.", pattern, step.Name, err),
+			))
+			continue
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || info.IsDir() {
+				continue
+			}
+			dest := filepath.Join(destDir, filepath.Base(match))
+			if err := copyArtifact(match, dest); err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failed to collect step artifact",
+					fmt.Sprintf("Could not copy %s to the artifacts directory: %s.", match, err),
+				))
+				continue
+			}
+			collected = append(collected, dest)
+		}
+	}
+
+	sort.Strings(collected)
+	return collected, diags
+}
+
+// copyArtifact copies one artifact file, creating the destination
+// directory as needed.
+func copyArtifact(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,91 @@
+package scenarios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "scenario-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	artifactsDir, err := ioutil.TempDir("", "artifacts-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(artifactsDir)
+
+	if err := os.MkdirAll(filepath.Join(baseDir, "rendered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"rendered/alpha.json": `{"a":1}`,
+		"rendered/beta.json":  `{"b":2}`,
+		"rendered/notes.txt":  "not matched",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(baseDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenario := &Scenario{Name: "render"}
+	step := &Step{
+		Name:      "generate",
+		Artifacts: []string{"rendered/*.json", "missing/*.tmp"},
+	}
+
+	collected, diags := scenario.CollectArtifacts(step, baseDir, artifactsDir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := []string{
+		filepath.Join(artifactsDir, "render", "generate", "alpha.json"),
+		filepath.Join(artifactsDir, "render", "generate", "beta.json"),
+	}
+	if !reflect.DeepEqual(collected, want) {
+		t.Fatalf("wrong collected paths\ngot:  %#v\nwant: %#v", collected, want)
+	}
+
+	content, err := ioutil.ReadFile(collected[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"a":1}` {
+		t.Errorf("wrong copied content %q", content)
+	}
+}
+
+func TestCollectArtifactsInvalidPattern(t *testing.T) {
+	artifactsDir, err := ioutil.TempDir("", "artifacts-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(artifactsDir)
+
+	scenario := &Scenario{Name: "render"}
+	step := &Step{
+		Name:      "generate",
+		Artifacts: []string{"["},
+	}
+
+	_, diags := scenario.CollectArtifacts(step, ".", artifactsDir)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for the invalid pattern")
+	}
+}
+
+func TestCollectArtifactsNoPatterns(t *testing.T) {
+	scenario := &Scenario{Name: "render"}
+	step := &Step{Name: "generate"}
+
+	collected, diags := scenario.CollectArtifacts(step, ".", "somewhere")
+	if len(collected) != 0 || len(diags) != 0 {
+		t.Fatalf("a step without artifact patterns should collect nothing: %#v, %s", collected, diags.Err())
+	}
+}
@@ -0,0 +1,117 @@
+package scenarios
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Assert is a single assertion within a step, checked after the step has
+// run.
+type Assert struct {
+	// Condition is an expression that must produce true for the assertion
+	// to pass.
+	Condition hcl.Expression
+
+	// ErrorMessage is an expression producing the message to report when
+	// the condition is false.
+	ErrorMessage hcl.Expression
+
+	DeclRange hcl.Range
+}
+
+// CheckAsserts evaluates the assertions of the given step against its
+// result. Each failed assertion produces its own diagnostic, so a single
+// run reports every assertion that did not hold rather than only the
+// first.
+//
+// The step's own outputs are available to the condition as
+// output.<name>, the outcomes of earlier steps as
+// step.<name>.outputs.<output>, and the step's plan, when it produced
+// one, as plan (see planSymbol for its shape). The state query helpers
+// from stateFunctions are in scope as well.
+func (s *Scenario) CheckAsserts(step *Step, result *StepResult, results map[string]*StepResult, run *Run) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if len(step.Asserts) == 0 {
+		return diags
+	}
+
+	ctx := stepResultsEvalContext(results, run)
+	outputs := make(map[string]cty.Value)
+	if result != nil {
+		for name, val := range result.Outputs {
+			outputs[name] = val
+		}
+	}
+	ctx.Variables["output"] = cty.ObjectVal(outputs)
+
+	// The state query helpers — state_resources, output, resource_attr —
+	// give precise errors when an address or name does not exist, where
+	// a raw reference would only report a missing attribute.
+	if ctx.Functions == nil {
+		ctx.Functions = make(map[string]function.Function)
+	}
+	for name, fn := range stateFunctions(step, result) {
+		ctx.Functions[name] = fn
+	}
+	for name, fn := range s.MockFunctions() {
+		ctx.Functions[name] = fn
+	}
+
+	if result != nil && len(result.PlanJSON) > 0 {
+		planVal, err := planSymbol(result.PlanJSON)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Invalid plan for step %q", step.Name),
+				fmt.Sprintf("Cannot prepare the plan for assertions: %s.", err),
+			))
+		} else {
+			ctx.Variables["plan"] = planVal
+		}
+	}
+
+	for _, assert := range step.Asserts {
+		result, hclDiags := assert.Condition.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			continue
+		}
+
+		result, err := convert.Convert(result, cty.Bool)
+		if err != nil || result.IsNull() || !result.IsKnown() {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid assertion condition",
+				Detail:   "The condition expression must produce either true or false.",
+				Subject:  assert.Condition.Range().Ptr(),
+			})
+			continue
+		}
+		if result.True() {
+			continue
+		}
+
+		message := "This assertion failed."
+		msgVal, hclDiags := assert.ErrorMessage.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			if msgVal, err := convert.Convert(msgVal, cty.String); err == nil && !msgVal.IsNull() && msgVal.IsKnown() {
+				message = msgVal.AsString()
+			}
+		}
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Assertion failed in step %q", step.Name),
+			Detail:   message,
+			Subject:  assert.Condition.Range().Ptr(),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,49 @@
+package scenarios
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCheckAsserts(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/asserts")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+	step := scenario.Steps[0]
+	if len(step.Asserts) != 2 {
+		t.Fatalf("wrong number of assertions %d; want 2", len(step.Asserts))
+	}
+
+	// Both assertions hold.
+	pass := &StepResult{
+		Outputs: map[string]cty.Value{
+			"instance_count": cty.NumberIntVal(2),
+			"region":         cty.StringVal("us-west-2"),
+		},
+	}
+	if diags := scenario.CheckAsserts(step, pass, nil, nil); diags.HasErrors() {
+		t.Errorf("unexpected errors: %s", diags.Err())
+	}
+
+	// Both assertions fail, and both must be reported.
+	fail := &StepResult{
+		Outputs: map[string]cty.Value{
+			"instance_count": cty.NumberIntVal(1),
+			"region":         cty.StringVal("us-east-1"),
+		},
+	}
+	diags = scenario.CheckAsserts(step, fail, nil, nil)
+	if got, want := len(diags), 2; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d", got, want)
+	}
+	if got, want := diags[0].Description().Detail, "Expected exactly two instances."; got != want {
+		t.Errorf("wrong detail for first diagnostic %q; want %q", got, want)
+	}
+	if got := diags[1].Description().Summary; !strings.Contains(got, "apply") {
+		t.Errorf("second diagnostic summary %q does not name the step", got)
+	}
+}
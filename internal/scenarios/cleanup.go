@@ -0,0 +1,65 @@
+package scenarios
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// CheckCleanup verifies that the scenario's cleanup steps really
+// destroyed everything, by inspecting the states the steps left behind:
+// any resource instance still recorded is a leak, reported by address.
+// The states given are the final ones per state key, as recorded after
+// the cleanup phase — the caller should refresh them first when it wants
+// verification against the remote system rather than against what the
+// last apply believed.
+func (s *Scenario) CheckCleanup(finalStates map[string]*states.State) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	keys := make([]string, 0, len(finalStates))
+	for key := range finalStates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		leaked := leakedAddrs(finalStates[key])
+		if len(leaked) == 0 {
+			continue
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Test resources leaked after cleanup",
+			fmt.Sprintf(
+				"Scenario %q finished its cleanup steps with %d resource(s) still in state %q:\n  - %s\n\nThese objects may still exist remotely and accrue cost until destroyed by hand.",
+				s.Name, len(leaked), key, strings.Join(leaked, "\n  - "),
+			),
+		))
+	}
+
+	return diags
+}
+
+// leakedAddrs returns the addresses of every resource instance still
+// present in the given state, sorted.
+func leakedAddrs(state *states.State) []string {
+	if state == nil {
+		return nil
+	}
+	var ret []string
+	for _, module := range state.Modules {
+		for _, rs := range module.Resources {
+			for key, inst := range rs.Instances {
+				if inst.Current == nil {
+					continue
+				}
+				ret = append(ret, rs.Addr.Instance(key).String())
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
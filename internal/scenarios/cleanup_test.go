@@ -0,0 +1,57 @@
+package scenarios
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestCheckCleanup(t *testing.T) {
+	scenario := &Scenario{Name: "teardown", VerifyCleanup: true}
+
+	// Empty or destroyed states are a clean finish.
+	diags := scenario.CheckCleanup(map[string]*states.State{
+		"default": states.NewState(),
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors for empty state: %s", diags.Err())
+	}
+
+	// Anything still recorded is a leak, reported by address.
+	leakedState := states.NewState()
+	root := leakedState.EnsureModule(addrs.RootModuleInstance)
+	root.SetResourceInstanceCurrent(
+		addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: "web",
+		}.Instance(addrs.IntKey(1)),
+		&states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"id":"i-123"}`),
+		},
+		addrs.AbsProviderConfig{
+			Module:   addrs.RootModule,
+			Provider: addrs.NewDefaultProvider("aws"),
+		},
+	)
+
+	diags = scenario.CheckCleanup(map[string]*states.State{
+		"default":       states.NewState(),
+		"named:staging": leakedState,
+	})
+	if !diags.HasErrors() {
+		t.Fatalf("no error for leaked resources")
+	}
+	if got, want := len(diags), 1; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d", got, want)
+	}
+	detail := diags[0].Description().Detail
+	for _, want := range []string{"aws_instance.web[1]", `"named:staging"`, `"teardown"`} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("detail does not mention %s:\n%s", want, detail)
+		}
+	}
+}
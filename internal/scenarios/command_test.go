@@ -0,0 +1,78 @@
+package scenarios
+
+import (
+	"testing"
+)
+
+func TestStepCommands(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/commands")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+	if len(scenario.Steps) != 3 {
+		t.Fatalf("wrong number of steps %d; want 3", len(scenario.Steps))
+	}
+
+	checkPlan := scenario.Steps[0]
+	if got, want := checkPlan.Command, CommandPlan; got != want {
+		t.Errorf("wrong command for %q %q; want %q", checkPlan.Name, got, want)
+	}
+	if checkPlan.PlanOptions == nil {
+		t.Fatalf("no plan options for %q", checkPlan.Name)
+	}
+	if got, want := checkPlan.PlanOptions.Mode, ModeNormal; got != want {
+		t.Errorf("wrong mode for %q %q; want %q", checkPlan.Name, got, want)
+	}
+	if checkPlan.PlanOptions.Refresh {
+		t.Errorf("refresh enabled for %q; want disabled", checkPlan.Name)
+	}
+	if got, want := len(checkPlan.PlanOptions.Targets), 2; got != want {
+		t.Fatalf("wrong number of targets %d; want %d", got, want)
+	}
+	if got, want := checkPlan.PlanOptions.Targets[0].String(), "aws_instance.web"; got != want {
+		t.Errorf("wrong first target %q; want %q", got, want)
+	}
+	if got, want := checkPlan.PlanOptions.Targets[1].String(), "module.network"; got != want {
+		t.Errorf("wrong second target %q; want %q", got, want)
+	}
+
+	drift := scenario.Steps[1]
+	if got, want := drift.Command, CommandPlan; got != want {
+		t.Errorf("wrong command for %q %q; want %q", drift.Name, got, want)
+	}
+	if got, want := drift.PlanOptions.Mode, ModeRefreshOnly; got != want {
+		t.Errorf("wrong mode for %q %q; want %q", drift.Name, got, want)
+	}
+	if !drift.PlanOptions.Refresh {
+		t.Errorf("refresh disabled for %q; want enabled", drift.Name)
+	}
+
+	deploy := scenario.Steps[2]
+	if got, want := deploy.Command, CommandApply; got != want {
+		t.Errorf("wrong command for %q %q; want %q", deploy.Name, got, want)
+	}
+	if deploy.PlanOptions != nil {
+		t.Errorf("unexpected plan options for %q", deploy.Name)
+	}
+}
+
+func TestStepStateKeys(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/commands")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+
+	// The first step inherits the scenario's default state, the second
+	// gets a fresh state of its own, and the third uses a named state.
+	if got, want := scenario.Steps[0].StateKey(), "default"; got != want {
+		t.Errorf("wrong state key for %q %q; want %q", scenario.Steps[0].Name, got, want)
+	}
+	if got, want := scenario.Steps[1].StateKey(), "fresh:drift"; got != want {
+		t.Errorf("wrong state key for %q %q; want %q", scenario.Steps[1].Name, got, want)
+	}
+	if got, want := scenario.Steps[2].StateKey(), "named:blue"; got != want {
+		t.Errorf("wrong state key for %q %q; want %q", scenario.Steps[2].Name, got, want)
+	}
+}
@@ -0,0 +1,206 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+// Coverage tracks which declarations of the module under test a suite of
+// scenarios actually exercised: resources and data sources that were
+// planned, module calls whose resources were planned, variables that were
+// set, and outputs that were read. It gives teams an objective measure of
+// how complete their suite is, and in particular which resources no
+// scenario ever planned.
+type Coverage struct {
+	// Each map has one entry per declaration in the module under test,
+	// true once any scenario exercised it.
+	Resources   map[string]bool
+	ModuleCalls map[string]bool
+	Variables   map[string]bool
+	Outputs     map[string]bool
+}
+
+// NewCoverage returns a Coverage with one unexercised entry per
+// declaration of the given module.
+func NewCoverage(mod *configs.Module) *Coverage {
+	cov := &Coverage{
+		Resources:   make(map[string]bool),
+		ModuleCalls: make(map[string]bool),
+		Variables:   make(map[string]bool),
+		Outputs:     make(map[string]bool),
+	}
+	for key := range mod.ManagedResources {
+		cov.Resources[key] = false
+	}
+	for key := range mod.DataResources {
+		cov.Resources[key] = false
+	}
+	for name := range mod.ModuleCalls {
+		cov.ModuleCalls[name] = false
+	}
+	for name := range mod.Variables {
+		cov.Variables[name] = false
+	}
+	for name := range mod.Outputs {
+		cov.Outputs[name] = false
+	}
+	return cov
+}
+
+// instanceKeyPattern strips instance keys like [0] or ["eu"] so that a
+// planned instance counts for its declared resource.
+var instanceKeyPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// RecordPlan marks the declarations exercised by a plan in the external
+// JSON plan format: every resource in resource_changes, the module calls
+// their addresses pass through, and every changed output.
+func (c *Coverage) RecordPlan(planJSON []byte) error {
+	var plan struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+		} `json:"resource_changes"`
+		OutputChanges map[string]json.RawMessage `json:"output_changes"`
+	}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return err
+	}
+
+	for _, rc := range plan.ResourceChanges {
+		addr := instanceKeyPattern.ReplaceAllString(rc.Address, "")
+		if strings.HasPrefix(addr, "module.") {
+			// module.<name>.<resource...> exercises the module call; the
+			// resource itself belongs to the child module, which this
+			// coverage does not track.
+			parts := strings.SplitN(addr, ".", 3)
+			if len(parts) >= 2 {
+				if _, tracked := c.ModuleCalls[parts[1]]; tracked {
+					c.ModuleCalls[parts[1]] = true
+				}
+			}
+			continue
+		}
+		if _, tracked := c.Resources[addr]; tracked {
+			c.Resources[addr] = true
+		}
+	}
+	for name := range plan.OutputChanges {
+		if _, tracked := c.Outputs[name]; tracked {
+			c.Outputs[name] = true
+		}
+	}
+	return nil
+}
+
+// RecordVariables marks the given input variables as set by a scenario.
+func (c *Coverage) RecordVariables(names ...string) {
+	for _, name := range names {
+		if _, tracked := c.Variables[name]; tracked {
+			c.Variables[name] = true
+		}
+	}
+}
+
+// RecordOutput marks the given output as read, e.g. by an assertion or a
+// later step's expression.
+func (c *Coverage) RecordOutput(name string) {
+	if _, tracked := c.Outputs[name]; tracked {
+		c.Outputs[name] = true
+	}
+}
+
+// WriteText writes a human-readable coverage summary, with a line per
+// category and an explicit list of the resources no scenario planned.
+func (c *Coverage) WriteText(w io.Writer) error {
+	categories := []struct {
+		name    string
+		entries map[string]bool
+	}{
+		{"resources", c.Resources},
+		{"module calls", c.ModuleCalls},
+		{"variables", c.Variables},
+		{"outputs", c.Outputs},
+	}
+	for _, category := range categories {
+		covered, total := count(category.entries)
+		if total == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d of %d covered (%.0f%%)\n", category.name, covered, total, 100*float64(covered)/float64(total)); err != nil {
+			return err
+		}
+	}
+	if missed := uncovered(c.Resources); len(missed) > 0 {
+		if _, err := fmt.Fprintf(w, "\nNever planned by any scenario:\n"); err != nil {
+			return err
+		}
+		for _, addr := range missed {
+			if _, err := fmt.Fprintf(w, "  - %s\n", addr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the coverage as JSON, with per-category percentages
+// and the uncovered names listed so CI can gate on them.
+func (c *Coverage) WriteJSON(w io.Writer) error {
+	report := jsonCoverage{
+		FormatVersion: "1.0",
+		Resources:     jsonCoverageCategory{Percent: percent(c.Resources), Uncovered: uncovered(c.Resources)},
+		ModuleCalls:   jsonCoverageCategory{Percent: percent(c.ModuleCalls), Uncovered: uncovered(c.ModuleCalls)},
+		Variables:     jsonCoverageCategory{Percent: percent(c.Variables), Uncovered: uncovered(c.Variables)},
+		Outputs:       jsonCoverageCategory{Percent: percent(c.Outputs), Uncovered: uncovered(c.Outputs)},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+type jsonCoverage struct {
+	FormatVersion string               `json:"format_version"`
+	Resources     jsonCoverageCategory `json:"resources"`
+	ModuleCalls   jsonCoverageCategory `json:"module_calls"`
+	Variables     jsonCoverageCategory `json:"variables"`
+	Outputs       jsonCoverageCategory `json:"outputs"`
+}
+
+type jsonCoverageCategory struct {
+	Percent   float64  `json:"percent"`
+	Uncovered []string `json:"uncovered,omitempty"`
+}
+
+func count(entries map[string]bool) (covered, total int) {
+	for _, hit := range entries {
+		total++
+		if hit {
+			covered++
+		}
+	}
+	return covered, total
+}
+
+func percent(entries map[string]bool) float64 {
+	covered, total := count(entries)
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+func uncovered(entries map[string]bool) []string {
+	var ret []string
+	for name, hit := range entries {
+		if !hit {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,73 @@
+package scenarios
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+func TestCoverage(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, hclDiags := parser.LoadConfigDir("testdata/coverage")
+	if hclDiags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", hclDiags.Error())
+	}
+
+	cov := NewCoverage(mod)
+	if err := cov.RecordPlan([]byte(`{
+		"resource_changes": [
+			{"address": "aws_instance.web[0]"},
+			{"address": "module.network.aws_vpc.main"},
+			{"address": "aws_instance.untracked_elsewhere"}
+		],
+		"output_changes": {"endpoint": {}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	cov.RecordVariables("region")
+
+	if !cov.Resources["aws_instance.web"] {
+		t.Errorf("aws_instance.web not covered despite instance key")
+	}
+	if cov.Resources["aws_eip.lb"] {
+		t.Errorf("aws_eip.lb covered despite never being planned")
+	}
+	if !cov.ModuleCalls["network"] {
+		t.Errorf("module call network not covered")
+	}
+	if !cov.Variables["region"] || cov.Variables["instance_type"] {
+		t.Errorf("wrong variable coverage: %#v", cov.Variables)
+	}
+	if !cov.Outputs["endpoint"] {
+		t.Errorf("output endpoint not covered")
+	}
+
+	var text bytes.Buffer
+	if err := cov.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	out := text.String()
+	if !strings.Contains(out, "Never planned by any scenario:") || !strings.Contains(out, "aws_eip.lb") {
+		t.Errorf("text summary does not flag the unplanned resource:\n%s", out)
+	}
+
+	var buf bytes.Buffer
+	if err := cov.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Resources struct {
+			Percent   float64  `json:"percent"`
+			Uncovered []string `json:"uncovered"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Resources.Uncovered) != 2 {
+		t.Errorf("wrong uncovered resources %#v; want aws_eip.lb and data.aws_ami.base", report.Resources.Uncovered)
+	}
+}
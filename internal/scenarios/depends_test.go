@@ -0,0 +1,117 @@
+package scenarios
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestRunnerDependencies(t *testing.T) {
+	base := &Scenario{
+		Name:  "base_network",
+		Steps: []*Step{{Name: "apply"}},
+	}
+	app := &Scenario{
+		Name:      "app",
+		DependsOn: []string{"base_network"},
+		Steps:     []*Step{{Name: "apply"}},
+	}
+
+	var mu sync.Mutex
+	var order []string
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			mu.Lock()
+			order = append(order, s.Name)
+			mu.Unlock()
+			if s.Name == "app" {
+				// The fixture's outputs are visible under its name.
+				fixture := results["base_network"]
+				if fixture == nil || !fixture.Outputs["vpc_id"].RawEquals(cty.StringVal("vpc-123")) {
+					t.Errorf("fixture outputs not visible to dependent: %#v", fixture)
+				}
+			}
+			return &StepResult{Outputs: map[string]cty.Value{
+				"vpc_id": cty.StringVal("vpc-123"),
+			}}, nil
+		},
+	}
+
+	// The dependent never starts before its fixture finishes, regardless
+	// of scheduling.
+	results := runner.Run([]*Scenario{app, base})
+	if len(order) != 2 || order[0] != "base_network" || order[1] != "app" {
+		t.Fatalf("wrong execution order %#v", order)
+	}
+	for _, result := range results {
+		if result.Diagnostics.HasErrors() {
+			t.Errorf("unexpected errors in %q: %s", result.Scenario.Name, result.Diagnostics.Err())
+		}
+	}
+}
+
+func TestRunnerDependencyFailure(t *testing.T) {
+	base := &Scenario{Name: "base_network", Steps: []*Step{{Name: "apply"}}}
+	app := &Scenario{
+		Name:      "app",
+		DependsOn: []string{"base_network"},
+		Steps:     []*Step{{Name: "apply"}},
+	}
+
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			var diags tfdiags.Diagnostics
+			if s.Name == "base_network" {
+				diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "quota exceeded", ""))
+				return nil, diags
+			}
+			t.Errorf("dependent ran despite failed dependency")
+			return &StepResult{}, diags
+		},
+	}
+
+	results := runner.Run([]*Scenario{base, app})
+	appResult := results[1]
+	if !appResult.Skipped {
+		t.Fatalf("dependent not skipped: %#v", appResult)
+	}
+	if len(appResult.Steps) != 1 || appResult.Steps[0].Status != StepSkipped {
+		t.Errorf("dependent steps not reported as skipped: %#v", appResult.Steps)
+	}
+}
+
+func TestRunnerDependencyValidation(t *testing.T) {
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			t.Errorf("scenario %q ran despite invalid dependencies", s.Name)
+			return &StepResult{}, nil
+		},
+	}
+
+	// Unknown dependency.
+	results := runner.Run([]*Scenario{
+		{Name: "app", DependsOn: []string{"nope"}, Steps: []*Step{{Name: "apply"}}},
+	})
+	if !results[0].Diagnostics.HasErrors() {
+		t.Errorf("no error for unknown dependency")
+	}
+
+	// Dependency cycle.
+	results = runner.Run([]*Scenario{
+		{Name: "a", DependsOn: []string{"b"}, Steps: []*Step{{Name: "apply"}}},
+		{Name: "b", DependsOn: []string{"a"}, Steps: []*Step{{Name: "apply"}}},
+	})
+	errs := 0
+	for _, result := range results {
+		if result.Diagnostics.HasErrors() || result.Skipped {
+			errs++
+		}
+	}
+	if errs != 2 {
+		t.Errorf("cycle not reported for both scenarios: %#v", results)
+	}
+}
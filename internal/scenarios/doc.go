@@ -0,0 +1,9 @@
+// Package scenarios contains the models and loader for test scenario
+// files.
+//
+// A test scenario describes an ordered sequence of steps to run against a
+// Terraform configuration, for exercising a module the way its callers
+// would use it. Scenarios live in files with a ".tftest" suffix alongside
+// the configuration they test, and are run by the "terraform test"
+// command.
+package scenarios
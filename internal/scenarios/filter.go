@@ -0,0 +1,138 @@
+package scenarios
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Filter selects which scenarios, and which steps within them, should
+// run. Its fields correspond to the -run, -skip, -tags, and
+// -exclude-tags command line options.
+type Filter struct {
+	// Run keeps only scenarios whose name, or one of whose qualified
+	// step names ("scenario/step"), matches. Nil keeps everything.
+	Run *regexp.Regexp
+
+	// Skip drops scenarios whose name matches, and individual steps
+	// whose qualified name matches. Nil drops nothing.
+	Skip *regexp.Regexp
+
+	// Tags keeps only scenarios carrying at least one of the given
+	// tags. Empty keeps everything.
+	Tags []string
+
+	// ExcludeTags drops scenarios carrying any of the given tags.
+	ExcludeTags []string
+}
+
+// ParseFilter builds a Filter from raw command line option values,
+// compiling the run and skip patterns. Empty strings and nil slices
+// leave the corresponding dimension unfiltered.
+func ParseFilter(run, skip string, tags, excludeTags []string) (*Filter, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	filter := &Filter{
+		Tags:        tags,
+		ExcludeTags: excludeTags,
+	}
+
+	if run != "" {
+		re, err := regexp.Compile(run)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -run pattern",
+				fmt.Sprintf("The pattern %q is not a valid regular expression: %s.", run, err),
+			))
+		}
+		filter.Run = re
+	}
+	if skip != "" {
+		re, err := regexp.Compile(skip)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -skip pattern",
+				fmt.Sprintf("The pattern %q is not a valid regular expression: %s.", skip, err),
+			))
+		}
+		filter.Skip = re
+	}
+
+	return filter, diags
+}
+
+// Select returns the scenarios that should run, in their original order.
+// A scenario selected only because some of its step names match the run
+// pattern is reduced to just those steps; a reduced scenario's remaining
+// steps cannot refer to the outputs of steps that were filtered away.
+func (f *Filter) Select(scenarios []*Scenario) []*Scenario {
+	var ret []*Scenario
+	for _, scenario := range scenarios {
+		if !f.selectByTags(scenario) {
+			continue
+		}
+		if f.Skip != nil && f.Skip.MatchString(scenario.Name) {
+			continue
+		}
+
+		// The whole scenario is in if its own name matches the run
+		// pattern; otherwise we keep only the steps whose qualified
+		// names do.
+		byStep := false
+		if f.Run != nil && !f.Run.MatchString(scenario.Name) {
+			byStep = true
+		}
+
+		var steps []*Step
+		for _, step := range scenario.Steps {
+			qualified := scenario.Name + "/" + step.Name
+			if byStep && !f.Run.MatchString(qualified) {
+				continue
+			}
+			if f.Skip != nil && f.Skip.MatchString(qualified) {
+				continue
+			}
+			steps = append(steps, step)
+		}
+		if len(steps) == 0 {
+			continue
+		}
+		if len(steps) == len(scenario.Steps) {
+			ret = append(ret, scenario)
+			continue
+		}
+
+		reduced := *scenario
+		reduced.Steps = steps
+		ret = append(ret, &reduced)
+	}
+	return ret
+}
+
+func (f *Filter) selectByTags(scenario *Scenario) bool {
+	for _, tag := range f.ExcludeTags {
+		if scenarioHasTag(scenario, tag) {
+			return false
+		}
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if scenarioHasTag(scenario, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func scenarioHasTag(scenario *Scenario, tag string) bool {
+	for _, have := range scenario.Tags {
+		if have == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package scenarios
+
+import (
+	"testing"
+)
+
+func filterTestScenarios() []*Scenario {
+	return []*Scenario{
+		{
+			Name: "defaults",
+			Tags: []string{"fast"},
+			Steps: []*Step{
+				{Name: "plan"},
+				{Name: "apply"},
+			},
+		},
+		{
+			Name: "upgrade",
+			Tags: []string{"slow", "destructive"},
+			Steps: []*Step{
+				{Name: "deploy_old"},
+				{Name: "upgrade"},
+			},
+		},
+		{
+			Name:  "untagged",
+			Steps: []*Step{{Name: "plan"}},
+		},
+	}
+}
+
+func TestFilterSelect(t *testing.T) {
+	names := func(scenarios []*Scenario) []string {
+		var ret []string
+		for _, s := range scenarios {
+			ret = append(ret, s.Name)
+		}
+		return ret
+	}
+
+	tests := []struct {
+		Name        string
+		Run, Skip   string
+		Tags        []string
+		ExcludeTags []string
+		Want        []string
+	}{
+		{
+			Name: "everything",
+			Want: []string{"defaults", "upgrade", "untagged"},
+		},
+		{
+			Name: "run by scenario name",
+			Run:  "^defaults$",
+			Want: []string{"defaults"},
+		},
+		{
+			Name: "skip by scenario name",
+			Skip: "^upgrade$",
+			Want: []string{"defaults", "untagged"},
+		},
+		{
+			Name: "tags",
+			Tags: []string{"fast"},
+			Want: []string{"defaults"},
+		},
+		{
+			Name:        "exclude tags",
+			ExcludeTags: []string{"destructive"},
+			Want:        []string{"defaults", "untagged"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			filter, diags := ParseFilter(test.Run, test.Skip, test.Tags, test.ExcludeTags)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+			got := names(filter.Select(filterTestScenarios()))
+			if len(got) != len(test.Want) {
+				t.Fatalf("wrong scenarios %v; want %v", got, test.Want)
+			}
+			for i := range got {
+				if got[i] != test.Want[i] {
+					t.Fatalf("wrong scenarios %v; want %v", got, test.Want)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterSelectSteps(t *testing.T) {
+	// A run pattern matching a step's qualified name reduces the
+	// scenario to the matching steps.
+	filter, diags := ParseFilter("^upgrade/upgrade$", "", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	got := filter.Select(filterTestScenarios())
+	if len(got) != 1 {
+		t.Fatalf("wrong number of scenarios %d; want 1", len(got))
+	}
+	if len(got[0].Steps) != 1 || got[0].Steps[0].Name != "upgrade" {
+		t.Fatalf("wrong steps selected: %#v", got[0].Steps)
+	}
+
+	// A skip pattern can drop individual steps too.
+	filter, diags = ParseFilter("", "^defaults/plan$", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	got = filter.Select(filterTestScenarios())
+	if len(got[0].Steps) != 1 || got[0].Steps[0].Name != "apply" {
+		t.Fatalf("wrong steps after skip: %#v", got[0].Steps)
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	if _, diags := ParseFilter("(", "", nil, nil); !diags.HasErrors() {
+		t.Errorf("unexpected success with invalid -run pattern")
+	}
+	if _, diags := ParseFilter("", "[", nil, nil); !diags.HasErrors() {
+		t.Errorf("unexpected success with invalid -skip pattern")
+	}
+}
@@ -0,0 +1,67 @@
+package scenarios
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Hook receives notifications as a Runner works through its scenarios,
+// so that external systems — test-reporting services subscribed through
+// the integrations subsystem, progress UIs — can follow a run as it
+// happens instead of only seeing the final results.
+//
+// Hooks are notification-only: they cannot veto or alter anything.
+// StepFinished fires for every step the runner considered, including
+// steps skipped by their own skip condition, while StepStarted precedes
+// only steps that actually run; steps abandoned because an earlier step
+// failed produce no events. Hooks are called from whichever goroutine is
+// running the step, so implementations must be safe for concurrent use.
+type Hook interface {
+	// StepStarted is called immediately before a step's first attempt.
+	StepStarted(scenario *Scenario, step *Step)
+
+	// StepFinished is called once a step's status is final, after all
+	// retry attempts.
+	StepFinished(scenario *Scenario, status *StepStatus)
+
+	// AssertionFailed is called once per failed assertion of a step,
+	// before the step's StepFinished.
+	AssertionFailed(scenario *Scenario, step *Step, diag tfdiags.Diagnostic)
+}
+
+// NilHook is a Hook that does nothing, for embedding in implementations
+// that only care about some of the events.
+type NilHook struct{}
+
+var _ Hook = NilHook{}
+
+func (NilHook) StepStarted(scenario *Scenario, step *Step)                           {}
+func (NilHook) StepFinished(scenario *Scenario, status *StepStatus)                  {}
+func (NilHook) AssertionFailed(scenario *Scenario, step *Step, d tfdiags.Diagnostic) {}
+
+func (r *Runner) stepStarted(scenario *Scenario, step *Step) {
+	for _, hook := range r.Hooks {
+		hook.StepStarted(scenario, step)
+	}
+}
+
+func (r *Runner) stepFinished(scenario *Scenario, status *StepStatus) {
+	// Failed assertions are reported individually before the step's
+	// overall outcome. They are recognizable by the summary CheckAsserts
+	// gives them.
+	for _, diag := range status.Diagnostics {
+		if diag.Severity() != tfdiags.Error {
+			continue
+		}
+		if !strings.HasPrefix(diag.Description().Summary, "Assertion failed") {
+			continue
+		}
+		for _, hook := range r.Hooks {
+			hook.AssertionFailed(scenario, status.Step, diag)
+		}
+	}
+	for _, hook := range r.Hooks {
+		hook.StepFinished(scenario, status)
+	}
+}
@@ -0,0 +1,81 @@
+package scenarios
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// recordingHook records every event it receives, in order.
+type recordingHook struct {
+	NilHook
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHook) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+func (h *recordingHook) StepStarted(scenario *Scenario, step *Step) {
+	h.record(fmt.Sprintf("started %s/%s", scenario.Name, step.Name))
+}
+
+func (h *recordingHook) StepFinished(scenario *Scenario, status *StepStatus) {
+	h.record(fmt.Sprintf("finished %s/%s %s", scenario.Name, status.Step.Name, status.Status))
+}
+
+func (h *recordingHook) AssertionFailed(scenario *Scenario, step *Step, diag tfdiags.Diagnostic) {
+	h.record(fmt.Sprintf("assertion %s/%s", scenario.Name, step.Name))
+}
+
+func TestRunnerHooks(t *testing.T) {
+	scenario := &Scenario{
+		Name: "hooked",
+		Steps: []*Step{
+			{Name: "pass"},
+			{Name: "assert"},
+			{Name: "abandoned"},
+		},
+	}
+
+	hook := &recordingHook{}
+	runner := &Runner{
+		Hooks: []Hook{hook},
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			var diags tfdiags.Diagnostics
+			if step.Name == "assert" {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Assertion failed in step %q", step.Name),
+					Detail:   "The condition was false.",
+				})
+				return nil, diags
+			}
+			return &StepResult{}, nil
+		},
+	}
+
+	runner.Run([]*Scenario{scenario})
+
+	// The abandoned step never started, so it produces no events; the
+	// failed assertion is reported before its step's finish.
+	want := []string{
+		"started hooked/pass",
+		"finished hooked/pass StepPassed",
+		"started hooked/assert",
+		"assertion hooked/assert",
+		"finished hooked/assert StepFailed",
+	}
+	if got := fmt.Sprintf("%v", hook.events); got != fmt.Sprintf("%v", want) {
+		t.Errorf("wrong events\ngot:  %s\nwant: %v", got, want)
+	}
+}
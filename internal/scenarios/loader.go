@@ -0,0 +1,1310 @@
+package scenarios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/providermocks"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// scenarioSuffixes are the filename suffixes that mark a file as a test
+// scenario, tried in order. A bare ".tftest" file always uses HCL native
+// syntax, while the longer suffixes make the syntax explicit in the same
+// way as ".tf" vs ".tf.json". A directory may freely mix all three.
+var scenarioSuffixes = []string{".tftest.hcl", ".tftest.json", ".tftest"}
+
+// LoadScenariosDir loads all of the scenario files in the given directory,
+// returning the scenarios sorted by name.
+func LoadScenariosDir(dir string) ([]*Scenario, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read test scenarios directory",
+			fmt.Sprintf("Terraform could not read the directory %s: %s.", dir, err),
+		))
+		return nil, diags
+	}
+
+	var ret []*Scenario
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name, ok := scenarioName(entry.Name())
+		if !ok {
+			continue
+		}
+		scenario, moreDiags := loadScenarioFile(filepath.Join(dir, entry.Name()))
+		diags = diags.Append(moreDiags)
+		if scenario == nil {
+			continue
+		}
+		scenario.Name = name
+		ret = append(ret, scenario)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, diags
+}
+
+// scenarioName returns the scenario name for the given filename, and
+// whether the filename marks a scenario file at all.
+func scenarioName(filename string) (string, bool) {
+	for _, suffix := range scenarioSuffixes {
+		if strings.HasSuffix(filename, suffix) && len(filename) > len(suffix) {
+			return filename[:len(filename)-len(suffix)], true
+		}
+	}
+	return "", false
+}
+
+// loadScenarioFile loads a single scenario file, which may use either HCL
+// native syntax or, for files with a ".tftest.json" suffix, JSON syntax.
+// The returned scenario has no Name; the caller derives that from the
+// filename.
+func loadScenarioFile(path string) (*Scenario, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var hclDiags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, hclDiags = parser.ParseJSONFile(path)
+	} else {
+		file, hclDiags = parser.ParseHCLFile(path)
+	}
+	diags = diags.Append(hclDiags)
+	if file == nil {
+		return nil, diags
+	}
+
+	scenario := &Scenario{
+		SourceFile: path,
+	}
+
+	content, hclDiags := file.Body.Content(scenarioFileSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["tags"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &scenario.Tags)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["preserve_on_failure"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &scenario.PreserveOnFailure)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["verify_cleanup"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &scenario.VerifyCleanup)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["env"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &scenario.Env)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["required_version"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &scenario.RequiredVersion)
+		diags = diags.Append(valDiags)
+		scenario.RequiredVersionRange = attr.Expr.Range()
+	}
+	if attr, exists := content.Attributes["required_features"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &scenario.RequiredFeatures)
+		diags = diags.Append(valDiags)
+		scenario.RequiredFeaturesRange = attr.Expr.Range()
+	}
+
+	if attr, exists := content.Attributes["depends_on"]; exists {
+		exprs, hclDiags := hcl.ExprList(attr.Expr)
+		diags = diags.Append(hclDiags)
+		for _, expr := range exprs {
+			traversal, hclDiags := hcl.AbsTraversalForExpr(expr)
+			diags = diags.Append(hclDiags)
+			if hclDiags.HasErrors() {
+				continue
+			}
+			name, ok := scenarioRef(traversal)
+			if !ok {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid scenario dependency",
+					Detail:   "Each depends_on entry must be a reference of the form scenario.<name>.",
+					Subject:  expr.Range().Ptr(),
+				})
+				continue
+			}
+			scenario.DependsOn = append(scenario.DependsOn, name)
+		}
+	}
+
+	// Skip conditions are evaluated at run time, so they stay as
+	// expressions here.
+	if attr, exists := content.Attributes["skip"]; exists {
+		scenario.Skip = attr.Expr
+	}
+	if attr, exists := content.Attributes["skip_reason"]; exists {
+		scenario.SkipReason = attr.Expr
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "step":
+			step, moreDiags := decodeStepBlock(block)
+			diags = diags.Append(moreDiags)
+			if step != nil {
+				scenario.Steps = append(scenario.Steps, step)
+			}
+		case "mock_provider":
+			mock, moreDiags := decodeMockProviderBlock(block)
+			diags = diags.Append(moreDiags)
+			if mock == nil {
+				continue
+			}
+			if _, exists := scenario.MockProviders[mock.Name]; exists {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate mock_provider block",
+					Detail:   fmt.Sprintf("A mock provider named %q was already declared in this scenario.", mock.Name),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			if scenario.MockProviders == nil {
+				scenario.MockProviders = make(map[string]*providermocks.MockProvider)
+			}
+			scenario.MockProviders[mock.Name] = mock
+		case "override_resource", "override_module":
+			override, moreDiags := decodeOverrideBlock(block)
+			diags = diags.Append(moreDiags)
+			if override != nil {
+				scenario.Overrides = append(scenario.Overrides, override)
+			}
+		case "variables":
+			vars, moreDiags := decodeVariablesBlock(block)
+			diags = diags.Append(moreDiags)
+			if scenario.Variables != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Duplicate variables block",
+					fmt.Sprintf("A scenario file may have only one top-level variables block; a second one was found at %s.", block.DefRange),
+				))
+				continue
+			}
+			scenario.Variables = vars
+		}
+	}
+
+	return scenario, diags
+}
+
+func decodeStepBlock(block *hcl.Block) (*Step, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	step := &Step{
+		Name:      block.Labels[0],
+		Command:   CommandApply,
+		Phase:     PhaseMain,
+		DeclRange: block.DefRange,
+	}
+
+	content, hclDiags := block.Body.Content(stepBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["module"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Module)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["runbook"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Runbook)
+		diags = diags.Append(valDiags)
+		if moduleAttr, exists := content.Attributes["module"]; exists {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Conflicting step arguments",
+				Detail:   fmt.Sprintf("A runbook step does not operate on a module, so the runbook attribute conflicts with the module attribute at %s.", moduleAttr.Range),
+				Subject:  attr.Range.Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["state"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.State)
+		diags = diags.Append(valDiags)
+		if step.State != StateInherit && step.State != StateFresh && !hclsyntax.ValidIdentifier(step.State) {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid step state",
+				Detail:   fmt.Sprintf("The state %q is not valid: must be %q, %q, or an identifier naming a shared state.", step.State, StateInherit, StateFresh),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			step.State = StateInherit
+		}
+	}
+
+	if attr, exists := content.Attributes["phase"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Phase)
+		diags = diags.Append(valDiags)
+		switch step.Phase {
+		case PhaseSetup, PhaseMain, PhaseCleanup:
+			// ok
+		default:
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid step phase",
+				Detail:   fmt.Sprintf("The phase %q is not valid: must be %q, %q, or %q.", step.Phase, PhaseSetup, PhaseMain, PhaseCleanup),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			step.Phase = PhaseMain
+		}
+	}
+
+	if attr, exists := content.Attributes["env"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Env)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["snapshot"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Snapshot)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["artifacts"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Artifacts)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["skip"]; exists {
+		step.Skip = attr.Expr
+	}
+	if attr, exists := content.Attributes["skip_reason"]; exists {
+		step.SkipReason = attr.Expr
+	}
+
+	if attr, exists := content.Attributes["timeout"]; exists {
+		var raw string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &raw)
+		diags = diags.Append(valDiags)
+		if !valDiags.HasErrors() {
+			timeout, err := time.ParseDuration(raw)
+			if err != nil || timeout <= 0 {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid step timeout",
+					Detail:   fmt.Sprintf("The timeout %q is not valid: must be a positive duration like \"20m\".", raw),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			} else {
+				step.Timeout = timeout
+			}
+		}
+	}
+
+	if attr, exists := content.Attributes["parallel"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Parallel)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["command"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Command)
+		diags = diags.Append(valDiags)
+		switch step.Command {
+		case CommandPlan, CommandApply:
+			// ok
+		default:
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid step command",
+				Detail:   fmt.Sprintf("The command %q is not valid: must be either %q or %q.", step.Command, CommandPlan, CommandApply),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			step.Command = CommandApply
+		}
+	}
+
+	for _, inner := range content.Blocks {
+		switch inner.Type {
+		case "assert":
+			assert, moreDiags := decodeAssertBlock(inner)
+			diags = diags.Append(moreDiags)
+			if assert != nil {
+				step.Asserts = append(step.Asserts, assert)
+			}
+		case "module":
+			if attr, exists := content.Attributes["module"]; exists {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Conflicting module arguments",
+					Detail:   fmt.Sprintf("This step already set its module with the attribute at %s; use either the module attribute or a module block, not both.", attr.Range),
+					Subject:  inner.DefRange.Ptr(),
+				})
+				continue
+			}
+			moreDiags := decodeModuleBlock(inner, step)
+			diags = diags.Append(moreDiags)
+		case "retry":
+			retry, moreDiags := decodeRetryBlock(inner)
+			diags = diags.Append(moreDiags)
+			if step.Retry != nil {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate retry block",
+					Detail:   "A step may have only one retry block.",
+					Subject:  inner.DefRange.Ptr(),
+				})
+				continue
+			}
+			step.Retry = retry
+		case "plan_options":
+			opts, moreDiags := decodePlanOptionsBlock(inner)
+			diags = diags.Append(moreDiags)
+			if step.PlanOptions != nil {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate plan_options block",
+					Detail:   "A step may have only one plan_options block.",
+					Subject:  inner.DefRange.Ptr(),
+				})
+				continue
+			}
+			step.PlanOptions = opts
+		case "variables":
+			vars, moreDiags := decodeVariablesBlock(inner)
+			diags = diags.Append(moreDiags)
+			if step.Variables != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Duplicate variables block",
+					fmt.Sprintf("A step may have only one variables block; a second one was found at %s.", inner.DefRange),
+				))
+				continue
+			}
+			step.Variables = vars
+		}
+	}
+
+	return step, diags
+}
+
+// decodeOverrideBlock decodes an override_resource or override_module
+// block. The two share a schema and differ only in which kind of address
+// the target attribute must give.
+func decodeOverrideBlock(block *hcl.Block) (*Override, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	override := &Override{
+		DeclRange: block.DefRange,
+	}
+
+	content, hclDiags := block.Body.Content(overrideBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["target"]; exists {
+		traversal, hclDiags := hcl.AbsTraversalForExpr(attr.Expr)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			target, targetDiags := addrs.ParseTarget(traversal)
+			diags = diags.Append(targetDiags)
+			if !targetDiags.HasErrors() {
+				override.Target = target.Subject
+			}
+		}
+		if override.Target != nil {
+			_, isModule := override.Target.(addrs.ModuleInstance)
+			if wantModule := block.Type == "override_module"; isModule != wantModule {
+				noun := "a resource"
+				if wantModule {
+					noun = "a module call"
+				}
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid override target",
+					Detail:   fmt.Sprintf("The target of %s must be %s address; %s is not one.", block.Type, noun, override.Target.String()),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+				return nil, diags
+			}
+		}
+	}
+
+	if attr, exists := content.Attributes["values"]; exists {
+		// Override values are static data, like mock defaults.
+		val, hclDiags := attr.Expr.Value(nil)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			override.Values = val
+		}
+	}
+
+	if override.Target == nil {
+		return nil, diags
+	}
+	return override, diags
+}
+
+// decodeMockProviderBlock decodes a mock_provider block, including any
+// inline mock_resource and mock_data blocks. Inline definitions are an
+// alternative to a def_dir of response files: small tests can keep their
+// mock data in the scenario file itself.
+func decodeMockProviderBlock(block *hcl.Block) (*providermocks.MockProvider, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	mock := &providermocks.MockProvider{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(mockProviderBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["def_dir"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &mock.DefDir)
+		diags = diags.Append(valDiags)
+	}
+	if attr, exists := content.Attributes["seed"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &mock.Seed)
+		diags = diags.Append(valDiags)
+	}
+
+	for _, inner := range content.Blocks {
+		if inner.Type == "mock_function" {
+			fn, moreDiags := decodeMockFunctionBlock(inner)
+			diags = diags.Append(moreDiags)
+			if fn == nil {
+				continue
+			}
+			if _, exists := mock.Functions[fn.Name]; exists {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate mock_function block",
+					Detail:   fmt.Sprintf("The function %q was already defined for mock provider %q.", fn.Name, mock.Name),
+					Subject:  inner.DefRange.Ptr(),
+				})
+				continue
+			}
+			if mock.Functions == nil {
+				mock.Functions = make(map[string]*providermocks.MockFunction)
+			}
+			mock.Functions[fn.Name] = fn
+			continue
+		}
+
+		def, moreDiags := decodeMockResourceBlock(inner)
+		diags = diags.Append(moreDiags)
+		if def == nil {
+			continue
+		}
+		into := &mock.Resources
+		if inner.Type == "mock_data" {
+			into = &mock.DataSources
+		}
+		if _, exists := (*into)[def.Type]; exists {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Duplicate %s block", inner.Type),
+				Detail:   fmt.Sprintf("The type %q was already defined for mock provider %q.", def.Type, mock.Name),
+				Subject:  inner.DefRange.Ptr(),
+			})
+			continue
+		}
+		if *into == nil {
+			*into = make(map[string]*providermocks.MockResource)
+		}
+		(*into)[def.Type] = def
+	}
+
+	return mock, diags
+}
+
+func decodeMockResourceBlock(block *hcl.Block) (*providermocks.MockResource, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	def := &providermocks.MockResource{
+		Type: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(mockResourceBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["defaults"]; exists {
+		// Mock defaults are static data, so they evaluate with no
+		// variables or functions in scope.
+		val, hclDiags := attr.Expr.Value(nil)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			def.Defaults = val
+		}
+	}
+
+	if attr, exists := content.Attributes["template"]; exists {
+		// Templates reference the request's configuration, so they are
+		// kept as expressions and evaluated once per call.
+		def.Template = attr.Expr
+	}
+
+	if attr, exists := content.Attributes["latency"]; exists {
+		var raw string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &raw)
+		diags = diags.Append(valDiags)
+		if !valDiags.HasErrors() {
+			latency, err := time.ParseDuration(raw)
+			if err != nil || latency < 0 {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid mock latency",
+					Detail:   fmt.Sprintf("The latency %q is not valid: must be a non-negative duration like \"500ms\".", raw),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			} else {
+				def.Latency = latency
+			}
+		}
+	}
+
+	for _, inner := range content.Blocks {
+		switch inner.Type {
+		case "error":
+			mockErr, moreDiags := decodeMockErrorBlock(inner)
+			diags = diags.Append(moreDiags)
+			if mockErr != nil {
+				def.Errors = append(def.Errors, mockErr)
+			}
+		case "transition":
+			transition, moreDiags := decodeMockTransitionBlock(inner)
+			diags = diags.Append(moreDiags)
+			if transition != nil {
+				def.Transitions = append(def.Transitions, transition)
+			}
+		case "paginate":
+			pagination, moreDiags := decodeMockPaginateBlock(inner)
+			diags = diags.Append(moreDiags)
+			if pagination != nil {
+				if def.Pagination != nil {
+					diags = diags.Append(&hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Duplicate paginate block",
+						Detail:   fmt.Sprintf("The mocked type %q already has a paginate block; each type can paginate only one attribute.", def.Type),
+						Subject:  inner.DefRange.Ptr(),
+					})
+					continue
+				}
+				def.Pagination = pagination
+			}
+		}
+	}
+
+	return def, diags
+}
+
+// decodeMockTransitionBlock decodes a transition block, which scripts an
+// eventual-consistency change: from the numbered call onward the mocked
+// type's defaults are replaced with the block's values.
+func decodeMockTransitionBlock(block *hcl.Block) (*providermocks.MockTransition, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	transition := &providermocks.MockTransition{}
+
+	content, hclDiags := block.Body.Content(mockTransitionBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["on_call"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &transition.OnCall)
+		diags = diags.Append(valDiags)
+		if !valDiags.HasErrors() && transition.OnCall < 1 {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid call number",
+				Detail:   "The on_call number of a transition must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			return nil, diags
+		}
+	}
+
+	if attr, exists := content.Attributes["values"]; exists {
+		// Like mock defaults, transition values are static data.
+		val, hclDiags := attr.Expr.Value(nil)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			return nil, diags
+		}
+		transition.Values = val
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return transition, diags
+}
+
+// decodeMockPaginateBlock decodes a paginate block, which splits one
+// list attribute of the mocked type's responses into pages served by
+// successive calls.
+func decodeMockPaginateBlock(block *hcl.Block) (*providermocks.MockPagination, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	pagination := &providermocks.MockPagination{}
+
+	content, hclDiags := block.Body.Content(mockPaginateBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["attribute"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &pagination.Attribute)
+		diags = diags.Append(valDiags)
+	}
+	if attr, exists := content.Attributes["page_size"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &pagination.PageSize)
+		diags = diags.Append(valDiags)
+		if !valDiags.HasErrors() && pagination.PageSize < 1 {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid page size",
+				Detail:   "The page_size must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			return nil, diags
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return pagination, diags
+}
+
+func decodeMockErrorBlock(block *hcl.Block) (*providermocks.MockError, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	mockErr := &providermocks.MockError{}
+
+	content, hclDiags := block.Body.Content(mockErrorBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["message"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &mockErr.Message)
+		diags = diags.Append(valDiags)
+	}
+	if mockErr.Message == "" {
+		// Content already reported the missing required attribute.
+		return nil, diags
+	}
+
+	if attr, exists := content.Attributes["on_call"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &mockErr.OnCall)
+		diags = diags.Append(valDiags)
+		if mockErr.OnCall < 0 {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid call number",
+				Detail:   "The on_call number must be at least 1, or omitted to fail every call.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			return nil, diags
+		}
+	}
+
+	return mockErr, diags
+}
+
+// decodeMockFunctionBlock decodes a mock_function block, which stubs one
+// provider-defined function so that configurations calling it can run
+// offline. The block maps argument tuples to results through its case
+// blocks, with an optional result attribute as a fallback expression for
+// calls no case matches.
+func decodeMockFunctionBlock(block *hcl.Block) (*providermocks.MockFunction, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	fn := &providermocks.MockFunction{
+		Name: block.Labels[0],
+	}
+
+	content, hclDiags := block.Body.Content(mockFunctionBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["result"]; exists {
+		// The fallback result is kept unevaluated so that it can refer to
+		// the call's arguments through the "args" symbol.
+		fn.Result = attr.Expr
+	}
+
+	for _, inner := range content.Blocks {
+		funcCase, moreDiags := decodeFunctionCaseBlock(inner)
+		diags = diags.Append(moreDiags)
+		if funcCase != nil {
+			fn.Cases = append(fn.Cases, funcCase)
+		}
+	}
+
+	if len(fn.Cases) == 0 && fn.Result == nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Incomplete mock_function block",
+			Detail:   fmt.Sprintf("The mocked function %q needs at least one case block or a result attribute to produce results from.", fn.Name),
+			Subject:  block.DefRange.Ptr(),
+		})
+		return nil, diags
+	}
+
+	return fn, diags
+}
+
+func decodeFunctionCaseBlock(block *hcl.Block) (*providermocks.FunctionCase, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	funcCase := &providermocks.FunctionCase{}
+
+	content, hclDiags := block.Body.Content(functionCaseBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["args"]; exists {
+		exprs, hclDiags := hcl.ExprList(attr.Expr)
+		diags = diags.Append(hclDiags)
+		for _, expr := range exprs {
+			// Case arguments are static data, like mock defaults.
+			val, hclDiags := expr.Value(nil)
+			diags = diags.Append(hclDiags)
+			if hclDiags.HasErrors() {
+				return nil, diags
+			}
+			funcCase.Args = append(funcCase.Args, val)
+		}
+	}
+
+	if attr, exists := content.Attributes["result"]; exists {
+		val, hclDiags := attr.Expr.Value(nil)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			return nil, diags
+		}
+		funcCase.Result = val
+	}
+
+	if funcCase.Result == cty.NilVal {
+		// Content already reported the missing required attribute.
+		return nil, diags
+	}
+
+	return funcCase, diags
+}
+
+// decodeModuleBlock decodes a step's module block, which names a fixture
+// or helper module for the step to operate on instead of the
+// configuration under test. The suite's provider configurations pass
+// through to the module, renamed per the providers argument if one is
+// given.
+func decodeModuleBlock(block *hcl.Block, step *Step) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	content, hclDiags := block.Body.Content(moduleBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["source"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &step.Module)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["providers"]; exists {
+		pairs, hclDiags := hcl.ExprMap(attr.Expr)
+		diags = diags.Append(hclDiags)
+		for _, pair := range pairs {
+			name, hclDiags := pair.Key.Value(nil)
+			diags = diags.Append(hclDiags)
+			if hclDiags.HasErrors() {
+				continue
+			}
+			traversal, hclDiags := hcl.AbsTraversalForExpr(pair.Value)
+			diags = diags.Append(hclDiags)
+			if hclDiags.HasErrors() {
+				continue
+			}
+			if step.Providers == nil {
+				step.Providers = make(map[string]string)
+			}
+			step.Providers[name.AsString()] = traversalStr(traversal)
+		}
+	}
+
+	return diags
+}
+
+// scenarioRef extracts the scenario name from a scenario.<name>
+// traversal, reporting false for any other shape of reference.
+func scenarioRef(traversal hcl.Traversal) (string, bool) {
+	if len(traversal) != 2 {
+		return "", false
+	}
+	root, ok := traversal[0].(hcl.TraverseRoot)
+	if !ok || root.Name != "scenario" {
+		return "", false
+	}
+	attr, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+	return attr.Name, true
+}
+
+// traversalStr renders a simple absolute traversal like aws.west back to
+// its source form, for provider configuration references.
+func traversalStr(traversal hcl.Traversal) string {
+	var buf strings.Builder
+	for i, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			buf.WriteString(step.Name)
+		case hcl.TraverseAttr:
+			if i > 0 {
+				buf.WriteString(".")
+			}
+			buf.WriteString(step.Name)
+		}
+	}
+	return buf.String()
+}
+
+func decodePlanOptionsBlock(block *hcl.Block) (*PlanOptions, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	opts := &PlanOptions{
+		Mode:    ModeNormal,
+		Refresh: true,
+	}
+
+	content, hclDiags := block.Body.Content(planOptionsBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["mode"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &opts.Mode)
+		diags = diags.Append(valDiags)
+		switch opts.Mode {
+		case ModeNormal, ModeRefreshOnly:
+			// ok
+		default:
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid planning mode",
+				Detail:   fmt.Sprintf("The planning mode %q is not valid: must be either %q or %q.", opts.Mode, ModeNormal, ModeRefreshOnly),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			opts.Mode = ModeNormal
+		}
+	}
+
+	if attr, exists := content.Attributes["refresh"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &opts.Refresh)
+		diags = diags.Append(valDiags)
+	}
+
+	if attr, exists := content.Attributes["target"]; exists {
+		exprs, hclDiags := hcl.ExprList(attr.Expr)
+		diags = diags.Append(hclDiags)
+		for _, expr := range exprs {
+			traversal, hclDiags := hcl.AbsTraversalForExpr(expr)
+			diags = diags.Append(hclDiags)
+			if hclDiags.HasErrors() {
+				continue
+			}
+			target, targetDiags := addrs.ParseTarget(traversal)
+			diags = diags.Append(targetDiags)
+			if targetDiags.HasErrors() {
+				continue
+			}
+			opts.Targets = append(opts.Targets, target.Subject)
+		}
+	}
+
+	return opts, diags
+}
+
+func decodeRetryBlock(block *hcl.Block) (*Retry, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	retry := &Retry{}
+
+	content, hclDiags := block.Body.Content(retryBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["attempts"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &retry.Attempts)
+		diags = diags.Append(valDiags)
+		if retry.Attempts < 1 {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid retry attempts",
+				Detail:   "The number of retry attempts must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			return nil, diags
+		}
+	}
+
+	if attr, exists := content.Attributes["on"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &retry.On)
+		diags = diags.Append(valDiags)
+		for _, kind := range retry.On {
+			switch kind {
+			case "plan_error", "apply_error", "assert_error", "timeout":
+				// ok
+			default:
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid retry condition",
+					Detail:   fmt.Sprintf("The failure kind %q is not valid: must be one of \"plan_error\", \"apply_error\", \"assert_error\", or \"timeout\".", kind),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+				return nil, diags
+			}
+		}
+	}
+
+	return retry, diags
+}
+
+func decodeAssertBlock(block *hcl.Block) (*Assert, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	assert := &Assert{
+		DeclRange: block.DefRange,
+	}
+
+	content, hclDiags := block.Body.Content(assertBlockSchema)
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["condition"]; exists {
+		assert.Condition = attr.Expr
+	}
+	if attr, exists := content.Attributes["error_message"]; exists {
+		assert.ErrorMessage = attr.Expr
+	}
+	if assert.Condition == nil || assert.ErrorMessage == nil {
+		// Content already produced diagnostics for the missing required
+		// attributes, so we just discard the incomplete assertion.
+		return nil, diags
+	}
+
+	return assert, diags
+}
+
+// decodeVariablesBlock decodes a "variables" block into a map of input
+// variable names to the expressions that will produce their values. The
+// expressions are retained unevaluated so that step-level blocks can refer
+// to the outputs of prior steps.
+func decodeVariablesBlock(block *hcl.Block) (map[string]hcl.Expression, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	attrs, hclDiags := block.Body.JustAttributes()
+	diags = diags.Append(hclDiags)
+	if len(attrs) == 0 {
+		return nil, diags
+	}
+
+	ret := make(map[string]hcl.Expression, len(attrs))
+	for name, attr := range attrs {
+		ret[name] = attr.Expr
+	}
+	return ret, diags
+}
+
+var scenarioFileSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "tags",
+		},
+		{
+			Name: "preserve_on_failure",
+		},
+		{
+			Name: "verify_cleanup",
+		},
+		{
+			Name: "env",
+		},
+		{
+			Name: "skip",
+		},
+		{
+			Name: "skip_reason",
+		},
+		{
+			Name: "depends_on",
+		},
+		{
+			Name: "required_version",
+		},
+		{
+			Name: "required_features",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "step",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "mock_provider",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "override_resource",
+		},
+		{
+			Type: "override_module",
+		},
+		{
+			Type: "variables",
+		},
+	},
+}
+
+var overrideBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "target",
+			Required: true,
+		},
+		{
+			Name: "values",
+		},
+	},
+}
+
+var mockProviderBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "def_dir",
+		},
+		{
+			Name: "seed",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "mock_resource",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type:       "mock_data",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type:       "mock_function",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var mockFunctionBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "result",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "case",
+		},
+	},
+}
+
+var functionCaseBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "args",
+			Required: true,
+		},
+		{
+			Name:     "result",
+			Required: true,
+		},
+	},
+}
+
+var mockResourceBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "defaults",
+		},
+		{
+			Name: "template",
+		},
+		{
+			Name: "latency",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "error",
+		},
+		{
+			Type: "transition",
+		},
+		{
+			Type: "paginate",
+		},
+	},
+}
+
+var mockTransitionBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "on_call",
+			Required: true,
+		},
+		{
+			Name:     "values",
+			Required: true,
+		},
+	},
+}
+
+var mockPaginateBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "attribute",
+			Required: true,
+		},
+		{
+			Name:     "page_size",
+			Required: true,
+		},
+	},
+}
+
+var mockErrorBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "message",
+			Required: true,
+		},
+		{
+			Name: "on_call",
+		},
+	},
+}
+
+var stepBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "module",
+		},
+		{
+			Name: "runbook",
+		},
+		{
+			Name: "command",
+		},
+		{
+			Name: "phase",
+		},
+		{
+			Name: "parallel",
+		},
+		{
+			Name: "state",
+		},
+		{
+			Name: "timeout",
+		},
+		{
+			Name: "env",
+		},
+		{
+			Name: "snapshot",
+		},
+		{
+			Name: "artifacts",
+		},
+		{
+			Name: "skip",
+		},
+		{
+			Name: "skip_reason",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "assert",
+		},
+		{
+			Type: "module",
+		},
+		{
+			Type: "plan_options",
+		},
+		{
+			Type: "retry",
+		},
+		{
+			Type: "variables",
+		},
+	},
+}
+
+var retryBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "attempts",
+			Required: true,
+		},
+		{
+			Name: "on",
+		},
+	},
+}
+
+var moduleBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "source",
+			Required: true,
+		},
+		{
+			Name: "providers",
+		},
+	},
+}
+
+var planOptionsBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "mode",
+		},
+		{
+			Name: "refresh",
+		},
+		{
+			Name: "target",
+		},
+	},
+}
+
+var assertBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "condition",
+			Required: true,
+		},
+		{
+			Name:     "error_message",
+			Required: true,
+		},
+	},
+}
@@ -0,0 +1,72 @@
+package scenarios
+
+import (
+	"testing"
+)
+
+func TestLoadScenariosDir(t *testing.T) {
+	got, diags := LoadScenariosDir("testdata/simple")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("wrong number of scenarios %d; want 3", len(got))
+	}
+
+	// LoadScenariosDir sorts by name, so the order here is predictable.
+	if got, want := got[0].Name, "defaults"; got != want {
+		t.Errorf("wrong name for first scenario %q; want %q", got, want)
+	}
+	if got, want := got[1].Name, "generated"; got != want {
+		t.Errorf("wrong name for second scenario %q; want %q", got, want)
+	}
+	if got, want := got[2].Name, "named"; got != want {
+		t.Errorf("wrong name for third scenario %q; want %q", got, want)
+	}
+
+	defaults := got[0]
+	if len(defaults.Steps) != 2 {
+		t.Fatalf("wrong number of steps in %q %d; want 2", defaults.Name, len(defaults.Steps))
+	}
+	if got, want := defaults.Steps[0].Name, "plan"; got != want {
+		t.Errorf("wrong name for first step %q; want %q", got, want)
+	}
+	if got, want := defaults.Steps[1].Module, "./fixtures/full"; got != want {
+		t.Errorf("wrong module for second step %q; want %q", got, want)
+	}
+
+	generated := got[1]
+	if len(generated.Steps) != 1 {
+		t.Fatalf("wrong number of steps in %q %d; want 1", generated.Name, len(generated.Steps))
+	}
+	if got, want := generated.Steps[0].Module, "./fixtures/minimal"; got != want {
+		t.Errorf("wrong module for JSON-syntax step %q; want %q", got, want)
+	}
+}
+
+func TestScenarioName(t *testing.T) {
+	tests := []struct {
+		Filename string
+		Want     string
+		WantOK   bool
+	}{
+		{"defaults.tftest", "defaults", true},
+		{"defaults.tftest.hcl", "defaults", true},
+		{"defaults.tftest.json", "defaults", true},
+		{"defaults.tf", "", false},
+		{"defaults.tftest.yaml", "", false},
+		{".tftest", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := scenarioName(test.Filename)
+		if ok != test.WantOK {
+			t.Errorf("scenarioName(%q) ok %v; want %v", test.Filename, ok, test.WantOK)
+			continue
+		}
+		if got != test.Want {
+			t.Errorf("scenarioName(%q) = %q; want %q", test.Filename, got, test.Want)
+		}
+	}
+}
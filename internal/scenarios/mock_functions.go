@@ -0,0 +1,48 @@
+package scenarios
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hashicorp/terraform/internal/providermocks"
+)
+
+// MockFunctions returns the provider-defined functions stubbed by the
+// scenario's mock providers as cty functions for expression evaluation,
+// keyed by the name scenario expressions call them by:
+// provider_<localname>_<function>, so e.g. the arn_for function of the
+// mocked "aws" provider is called as provider_aws_arn_for(...). Returns
+// nil if no mock provider stubs any functions.
+func (s *Scenario) MockFunctions() map[string]function.Function {
+	var ret map[string]function.Function
+	for localName, mock := range s.MockProviders {
+		for name := range mock.Functions {
+			if ret == nil {
+				ret = make(map[string]function.Function)
+			}
+			ret["provider_"+localName+"_"+name] = mockFunction(mock, name)
+		}
+	}
+	return ret
+}
+
+// mockFunction wraps one stubbed function of a mock provider as a cty
+// function. The wrapper accepts any number of arguments of any type,
+// since the stub's cases decide what matches; an unknown argument gives
+// an unknown result the usual way.
+func mockFunction(mock *providermocks.MockProvider, name string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{
+			Name:      "args",
+			Type:      cty.DynamicPseudoType,
+			AllowNull: true,
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			// The result's type is known only once a case matches.
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return mock.CallFunction(name, args)
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/providermocks"
+)
+
+func TestMockFunctions(t *testing.T) {
+	scenario := &Scenario{
+		Name: "mocked",
+		MockProviders: map[string]*providermocks.MockProvider{
+			"aws": {
+				Name: "aws",
+				Functions: map[string]*providermocks.MockFunction{
+					"arn_for": {
+						Name: "arn_for",
+						Cases: []*providermocks.FunctionCase{
+							{
+								Args:   []cty.Value{cty.StringVal("logs")},
+								Result: cty.StringVal("arn:aws:s3:::logs"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fns := scenario.MockFunctions()
+	if _, ok := fns["provider_aws_arn_for"]; !ok {
+		t.Fatalf("no provider_aws_arn_for function; got %#v", fns)
+	}
+
+	// The functions are reachable from step variable expressions.
+	expr, diags := hclsyntax.ParseExpression([]byte(`provider_aws_arn_for("logs")`), "test", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid test expression: %s", diags.Error())
+	}
+	scenario.Variables = map[string]hcl.Expression{"bucket_arn": expr}
+	step := &Step{Name: "plan"}
+	vals, varDiags := scenario.StepVariables(step, nil, nil)
+	if varDiags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", varDiags.Err())
+	}
+	if got, want := vals["bucket_arn"], cty.StringVal("arn:aws:s3:::logs"); !got.RawEquals(want) {
+		t.Errorf("wrong bucket_arn %#v; want %#v", got, want)
+	}
+
+	// An unmatched call surfaces the mock's error.
+	badExpr, diags := hclsyntax.ParseExpression([]byte(`provider_aws_arn_for("other")`), "test", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid test expression: %s", diags.Error())
+	}
+	scenario.Variables = map[string]hcl.Expression{"bucket_arn": badExpr}
+	_, varDiags = scenario.StepVariables(step, nil, nil)
+	if !varDiags.HasErrors() {
+		t.Fatal("unmatched call succeeded; want error")
+	}
+
+	// A scenario without mocked functions exposes none.
+	if fns := (&Scenario{}).MockFunctions(); fns != nil {
+		t.Errorf("unexpected functions %#v from empty scenario", fns)
+	}
+}
@@ -0,0 +1,172 @@
+package scenarios
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+func TestMockProviderBlocks(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/mocks")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+	if got, want := len(scenario.MockProviders), 2; got != want {
+		t.Fatalf("wrong number of mock providers %d; want %d", got, want)
+	}
+
+	aws := scenario.MockProviders["aws"]
+	if aws == nil {
+		t.Fatalf("no mock provider named \"aws\"")
+	}
+	if got, want := aws.Seed, "stable"; got != want {
+		t.Errorf("wrong seed %q; want %q", got, want)
+	}
+	inst := aws.Resources["aws_instance"]
+	if inst == nil {
+		t.Fatalf("no mock_resource for aws_instance")
+	}
+	gotARN := inst.Defaults.GetAttr("arn")
+	if want := cty.StringVal("arn:aws:ec2::instance/i-1"); !gotARN.RawEquals(want) {
+		t.Errorf("wrong arn default %#v; want %#v", gotARN, want)
+	}
+	ami := aws.DataSources["aws_ami"]
+	if ami == nil {
+		t.Fatalf("no mock_data for aws_ami")
+	}
+	gotID := ami.Defaults.GetAttr("id")
+	if want := cty.StringVal("ami-12345"); !gotID.RawEquals(want) {
+		t.Errorf("wrong id default %#v; want %#v", gotID, want)
+	}
+
+	status := aws.DataSources["aws_instance_status"]
+	if status == nil {
+		t.Fatalf("no mock_data for aws_instance_status")
+	}
+	if got, want := status.Latency, 10*time.Millisecond; got != want {
+		t.Errorf("wrong latency %s; want %s", got, want)
+	}
+	if got, want := len(status.Transitions), 1; got != want {
+		t.Fatalf("wrong number of transitions %d; want %d", got, want)
+	}
+	if got, want := status.Transitions[0].OnCall, 3; got != want {
+		t.Errorf("wrong transition on_call %d; want %d", got, want)
+	}
+	gotStatus := status.Transitions[0].Values.GetAttr("status")
+	if want := cty.StringVal("running"); !gotStatus.RawEquals(want) {
+		t.Errorf("wrong transition status %#v; want %#v", gotStatus, want)
+	}
+
+	subnets := aws.DataSources["aws_subnets"]
+	if subnets == nil {
+		t.Fatalf("no mock_data for aws_subnets")
+	}
+	if subnets.Pagination == nil {
+		t.Fatalf("no pagination for aws_subnets")
+	}
+	if got, want := subnets.Pagination.Attribute, "ids"; got != want {
+		t.Errorf("wrong paginated attribute %q; want %q", got, want)
+	}
+	if got, want := subnets.Pagination.PageSize, 2; got != want {
+		t.Errorf("wrong page_size %d; want %d", got, want)
+	}
+
+	// Templated responses derive computed attributes from the request's
+	// configuration, evaluated per call.
+	bucket := aws.Resources["aws_s3_bucket"]
+	if bucket == nil {
+		t.Fatalf("no mock_resource for aws_s3_bucket")
+	}
+	if bucket.Template == nil {
+		t.Fatalf("no template for aws_s3_bucket")
+	}
+	bucketSchema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+			"arn":    {Type: cty.String, Computed: true},
+		},
+	}
+	config := cty.ObjectVal(map[string]cty.Value{
+		"bucket": cty.StringVal("logs"),
+		"arn":    cty.NullVal(cty.String),
+	})
+	proposed := cty.ObjectVal(map[string]cty.Value{
+		"bucket": cty.StringVal("logs"),
+		"arn":    cty.UnknownVal(cty.String),
+	})
+	response, err := aws.ResourceResponse("aws_s3_bucket", bucketSchema, config, proposed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := response.GetAttr("arn"), cty.StringVal("arn:aws:s3:::logs"); !got.RawEquals(want) {
+		t.Errorf("wrong templated arn %#v; want %#v", got, want)
+	}
+
+	eip := aws.Resources["aws_eip"]
+	if eip == nil {
+		t.Fatalf("no mock_resource for aws_eip")
+	}
+	if got, want := len(eip.Errors), 1; got != want {
+		t.Fatalf("wrong number of errors %d; want %d", got, want)
+	}
+	if got, want := eip.Errors[0].OnCall, 2; got != want {
+		t.Errorf("wrong on_call %d; want %d", got, want)
+	}
+
+	// Only the second call against aws_eip fails.
+	if err := aws.NextResourceError("aws_eip"); err != nil {
+		t.Errorf("unexpected error on first call: %s", err)
+	}
+	err = aws.NextResourceError("aws_eip")
+	if err == nil {
+		t.Fatalf("no error on second call")
+	}
+	if got, want := err.Error(), "AddressLimitExceeded: too many addresses"; got != want {
+		t.Errorf("wrong error %q; want %q", got, want)
+	}
+	if err := aws.NextResourceError("aws_eip"); err != nil {
+		t.Errorf("unexpected error on third call: %s", err)
+	}
+
+	// A matching case supplies the result; anything else falls back to
+	// the result expression, with the arguments in scope as "args".
+	if got, want := len(aws.Functions), 2; got != want {
+		t.Fatalf("wrong number of mock functions %d; want %d", got, want)
+	}
+	got, err := aws.CallFunction("arn_parse", []cty.Value{cty.StringVal("arn:aws:ec2::instance/i-1")})
+	if err != nil {
+		t.Fatalf("unexpected error calling arn_parse: %s", err)
+	}
+	if want := cty.StringVal("ec2"); !got.GetAttr("service").RawEquals(want) {
+		t.Errorf("wrong result for cased call %#v; want %#v", got.GetAttr("service"), want)
+	}
+	got, err = aws.CallFunction("arn_parse", []cty.Value{cty.StringVal("arn:aws:iam::role/r")})
+	if err != nil {
+		t.Fatalf("unexpected error calling arn_parse: %s", err)
+	}
+	if want := cty.StringVal("unknown"); !got.GetAttr("service").RawEquals(want) {
+		t.Errorf("wrong result for fallback call %#v; want %#v", got.GetAttr("service"), want)
+	}
+	got, err = aws.CallFunction("trim_prefix", []cty.Value{cty.StringVal("boop")})
+	if err != nil {
+		t.Fatalf("unexpected error calling trim_prefix: %s", err)
+	}
+	if want := cty.StringVal("boop"); !got.RawEquals(want) {
+		t.Errorf("wrong result for args reference %#v; want %#v", got, want)
+	}
+	if _, err := aws.CallFunction("undefined", nil); err == nil {
+		t.Errorf("no error calling an undefined function")
+	}
+
+	null := scenario.MockProviders["null"]
+	if null == nil {
+		t.Fatalf("no mock provider named \"null\"")
+	}
+	if got, want := null.DefDir, "./mocks/null"; got != want {
+		t.Errorf("wrong def_dir %q; want %q", got, want)
+	}
+}
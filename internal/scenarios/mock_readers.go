@@ -0,0 +1,35 @@
+package scenarios
+
+import (
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/runbooks"
+)
+
+// MockReaders returns the data sources defined by the scenario's mock
+// providers as runbook read implementations, keyed by data source type
+// name, so a runbook step can answer its data and list blocks from the
+// mocks instead of real infrastructure. Each read records its call
+// against the owning mock, so scripted errors, transitions, latency, and
+// pagination behave the same as reads from the module under test.
+// Returns nil if no mock provider defines any data sources.
+func (s *Scenario) MockReaders() map[string]runbooks.ReadFunc {
+	var ret map[string]runbooks.ReadFunc
+	for _, mock := range s.MockProviders {
+		mock := mock
+		for typeName := range mock.DataSources {
+			typeName := typeName
+			if ret == nil {
+				ret = make(map[string]runbooks.ReadFunc)
+			}
+			ret[typeName] = func(config cty.Value) (cty.Value, error) {
+				mock.SimulateDataSourceLatency(typeName)
+				if err := mock.NextDataSourceError(typeName); err != nil {
+					return cty.NilVal, err
+				}
+				return mock.DataSourceValue(typeName, config)
+			}
+		}
+	}
+	return ret
+}
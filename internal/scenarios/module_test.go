@@ -0,0 +1,55 @@
+package scenarios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStepModuleBlock(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/modules")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+
+	seed := scenario.Steps[0]
+	if got, want := seed.Module, "./tests/fixtures/networking"; got != want {
+		t.Errorf("wrong module source %q; want %q", got, want)
+	}
+	if got, want := seed.Providers["aws"], "aws.test_account"; got != want {
+		t.Errorf("wrong provider mapping %q; want %q", got, want)
+	}
+
+	apply := scenario.Steps[1]
+	if apply.Module != "" {
+		t.Errorf("unexpected module source %q for step with no module block", apply.Module)
+	}
+}
+
+func TestStepModuleBlockConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+step "both" {
+  module = "./a"
+  module {
+    source = "./b"
+  }
+}
+`
+	err = ioutil.WriteFile(filepath.Join(dir, "bad.tftest"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, diags := LoadScenariosDir(dir)
+	if !diags.HasErrors() {
+		t.Fatalf("unexpected success with both module attribute and block")
+	}
+}
@@ -0,0 +1,63 @@
+package scenarios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestOverrideBlocks(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/overrides")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+	if got, want := len(scenario.Overrides), 2; got != want {
+		t.Fatalf("wrong number of overrides %d; want %d", got, want)
+	}
+
+	mod := scenario.Overrides[0]
+	if got, want := mod.Target.String(), "module.k8s_cluster"; got != want {
+		t.Errorf("wrong module target %q; want %q", got, want)
+	}
+	gotEndpoint := mod.Values.GetAttr("endpoint")
+	if want := cty.StringVal("https://127.0.0.1:6443"); !gotEndpoint.RawEquals(want) {
+		t.Errorf("wrong endpoint %#v; want %#v", gotEndpoint, want)
+	}
+
+	res := scenario.Overrides[1]
+	if got, want := res.Target.String(), "aws_instance.bastion"; got != want {
+		t.Errorf("wrong resource target %q; want %q", got, want)
+	}
+	gotID := res.Values.GetAttr("id")
+	if want := cty.StringVal("i-00000001"); !gotID.RawEquals(want) {
+		t.Errorf("wrong id %#v; want %#v", gotID, want)
+	}
+}
+
+func TestOverrideBlocksInvalidTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+override_module {
+  target = aws_instance.web
+  values = {}
+}
+`
+	err = ioutil.WriteFile(filepath.Join(dir, "bad.tftest"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, diags := LoadScenariosDir(dir)
+	if !diags.HasErrors() {
+		t.Fatalf("unexpected success with resource address in override_module")
+	}
+}
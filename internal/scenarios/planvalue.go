@@ -0,0 +1,89 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// planSymbol builds the value bound to "plan" in assertion expressions
+// from a step's JSON plan representation. The result reshapes the
+// jsonplan structures for convenient asserting:
+//
+//	plan.resource_changes["aws_instance.web"].actions
+//	plan.action_counts.update
+//	plan.output_changes["endpoint"].actions
+//
+// resource_changes is keyed by resource address rather than being a
+// list, and action_counts counts how many resource changes include each
+// action, so "this change only updates in place" becomes a one-line
+// condition.
+func planSymbol(planJSON []byte) (cty.Value, error) {
+	var plan struct {
+		ResourceChanges []json.RawMessage          `json:"resource_changes"`
+		OutputChanges   map[string]json.RawMessage `json:"output_changes"`
+	}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return cty.NilVal, fmt.Errorf("invalid JSON plan: %s", err)
+	}
+
+	resourceChanges := make(map[string]interface{})
+	actionCounts := map[string]int{
+		"create": 0,
+		"read":   0,
+		"update": 0,
+		"delete": 0,
+		"no-op":  0,
+	}
+	for _, raw := range plan.ResourceChanges {
+		var rc struct {
+			Address string `json:"address"`
+			Change  struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		}
+		if err := json.Unmarshal(raw, &rc); err != nil {
+			return cty.NilVal, fmt.Errorf("invalid resource change in JSON plan: %s", err)
+		}
+
+		// Each entry keeps the full jsonplan resource change object,
+		// with the actions hoisted to the top for the common case.
+		var entry map[string]interface{}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return cty.NilVal, fmt.Errorf("invalid resource change in JSON plan: %s", err)
+		}
+		entry["actions"] = rc.Change.Actions
+		resourceChanges[rc.Address] = entry
+
+		for _, action := range rc.Change.Actions {
+			actionCounts[action]++
+		}
+	}
+
+	outputChanges := make(map[string]interface{})
+	for name, raw := range plan.OutputChanges {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return cty.NilVal, fmt.Errorf("invalid output change in JSON plan: %s", err)
+		}
+		outputChanges[name] = entry
+	}
+
+	// Round-tripping through JSON gives us the cty value with an object
+	// type matching the actual shape of this particular plan.
+	reshaped, err := json.Marshal(map[string]interface{}{
+		"resource_changes": resourceChanges,
+		"action_counts":    actionCounts,
+		"output_changes":   outputChanges,
+	})
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(reshaped)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(reshaped, ty)
+}
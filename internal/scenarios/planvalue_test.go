@@ -0,0 +1,101 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const planValueTestJSON = `{
+  "format_version": "0.1",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {
+        "actions": ["update"]
+      }
+    },
+    {
+      "address": "aws_eip.lb",
+      "mode": "managed",
+      "type": "aws_eip",
+      "name": "lb",
+      "change": {
+        "actions": ["delete", "create"]
+      }
+    }
+  ],
+  "output_changes": {
+    "endpoint": {
+      "actions": ["update"]
+    }
+  }
+}`
+
+func TestPlanSymbol(t *testing.T) {
+	val, err := planSymbol([]byte(planValueTestJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web := val.GetAttr("resource_changes").GetAttr("aws_instance.web")
+	actions := web.GetAttr("actions")
+	if got, want := actions.LengthInt(), 1; got != want {
+		t.Fatalf("wrong number of actions %d; want %d", got, want)
+	}
+	if got := actions.Index(cty.NumberIntVal(0)); got.AsString() != "update" {
+		t.Errorf("wrong action %#v; want update", got)
+	}
+
+	counts := val.GetAttr("action_counts")
+	if got := counts.GetAttr("create"); got.AsBigFloat().String() != "1" {
+		t.Errorf("wrong create count %#v; want 1", got)
+	}
+	if got := counts.GetAttr("delete"); got.AsBigFloat().String() != "1" {
+		t.Errorf("wrong delete count %#v; want 1", got)
+	}
+	if got := counts.GetAttr("update"); got.AsBigFloat().String() != "1" {
+		t.Errorf("wrong update count %#v; want 1", got)
+	}
+}
+
+func TestCheckAssertsPlan(t *testing.T) {
+	parse := func(src string) hcl.Expression {
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "test", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			t.Fatalf("invalid test expression %q: %s", src, diags.Error())
+		}
+		return expr
+	}
+
+	scenario := &Scenario{Name: "plancheck"}
+	step := &Step{
+		Name: "plan",
+		Asserts: []*Assert{
+			{
+				Condition:    parse(`plan.resource_changes["aws_instance.web"].actions == ["update"]`),
+				ErrorMessage: parse(`"web instance should only update in place"`),
+			},
+			{
+				Condition:    parse(`plan.action_counts.delete == 0`),
+				ErrorMessage: parse(`"nothing should be destroyed"`),
+			},
+		},
+	}
+	result := &StepResult{PlanJSON: []byte(planValueTestJSON)}
+
+	diags := scenario.CheckAsserts(step, result, nil, nil)
+	// The first assertion holds; the second fails because of the EIP
+	// replacement.
+	if got, want := len(diags), 1; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d\n%s", got, want, diags.Err())
+	}
+	if got, want := diags[0].Description().Detail, "nothing should be destroyed"; got != want {
+		t.Errorf("wrong detail %q; want %q", got, want)
+	}
+}
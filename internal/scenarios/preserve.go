@@ -0,0 +1,86 @@
+package scenarios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statefile"
+)
+
+// PreserveStates writes the final states of a preserved scenario to a
+// debug directory under baseDir, one state file per state key, along
+// with a README explaining how to destroy the leftover resources. It
+// returns the directory it wrote so the caller can point the user at
+// it.
+func PreserveStates(baseDir string, scenario *Scenario, finalStates map[string]*states.State) (string, error) {
+	dir := filepath.Join(baseDir, scenario.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create debug directory: %s", err)
+	}
+
+	keys := make([]string, 0, len(finalStates))
+	for key := range finalStates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		state := finalStates[key]
+		if state == nil || state.Empty() {
+			continue
+		}
+		path := filepath.Join(dir, stateFilename(key))
+		f, err := os.Create(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to create state file %s: %s", path, err)
+		}
+		err = statefile.Write(statefile.New(state, "", 0), f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to write state file %s: %s", path, err)
+		}
+	}
+
+	readme := fmt.Sprintf(`Terraform preserved the resources of the failed test scenario %q
+so that the failure can be diagnosed.
+
+Each .tfstate file in this directory is the final state of one of the
+scenario's states. Once you are done investigating, destroy the
+leftover resources by running, for each state file:
+
+    terraform destroy -state=<file>
+
+using the same configuration and variables the scenario ran with, and
+then delete this directory.
+`, scenario.Name)
+	err := ioutil.WriteFile(filepath.Join(dir, "README.txt"), []byte(readme), 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write README: %s", err)
+	}
+
+	return dir, nil
+}
+
+// stateFilename maps a state key from Step.StateKey to the name of the
+// file its state is preserved in.
+func stateFilename(key string) string {
+	switch key {
+	case "default":
+		return "terraform.tfstate"
+	default:
+		// Keys look like "fresh:stepname" or "named:statename"; both
+		// halves are identifiers, so joining them with a dash gives a
+		// safe filename.
+		name := key
+		for i := 0; i < len(name); i++ {
+			if name[i] == ':' {
+				name = name[:i] + "-" + name[i+1:]
+			}
+		}
+		return name + ".tfstate"
+	}
+}
@@ -0,0 +1,106 @@
+package scenarios
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestRunnerPreserveOnFailure(t *testing.T) {
+	scenario := &Scenario{
+		Name:              "failing",
+		PreserveOnFailure: true,
+		Steps: []*Step{
+			{Name: "boom"},
+			{Name: "teardown", Phase: PhaseCleanup},
+		},
+	}
+
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			var diags tfdiags.Diagnostics
+			if step.Name == "boom" {
+				diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "step failed", ""))
+				return nil, diags
+			}
+			t.Errorf("cleanup step ran despite preserve_on_failure")
+			return &StepResult{}, diags
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	if !results[0].Preserved {
+		t.Errorf("result not marked as preserved")
+	}
+	last := results[0].Steps[len(results[0].Steps)-1]
+	if last.Step.Name != "teardown" || last.Status != StepSkipped {
+		t.Errorf("cleanup step not recorded as skipped: %#v", last)
+	}
+
+	// Cleanup still runs when the scenario passes.
+	ranCleanup := false
+	runner.RunStep = func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+		if step.Name == "teardown" {
+			ranCleanup = true
+		}
+		return &StepResult{}, nil
+	}
+	results = runner.Run([]*Scenario{scenario})
+	if results[0].Preserved {
+		t.Errorf("passing scenario marked as preserved")
+	}
+	if !ranCleanup {
+		t.Errorf("cleanup step did not run for passing scenario")
+	}
+}
+
+func TestPreserveStates(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "tf-scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetOutputValue(
+			addrs.OutputValue{Name: "id"}.Absolute(addrs.RootModuleInstance),
+			cty.StringVal("i-123"), false,
+		)
+	})
+	scenario := &Scenario{Name: "failing"}
+
+	dir, err := PreserveStates(baseDir, scenario, map[string]*states.State{
+		"default":     state,
+		"named:blue":  state,
+		"fresh:drift": states.NewState(), // empty states are not written
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"terraform.tfstate", "named-blue.tfstate", "README.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, want)); err != nil {
+			t.Errorf("missing %s: %s", want, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "fresh-drift.tfstate")); !os.IsNotExist(err) {
+		t.Errorf("state file written for empty state")
+	}
+
+	readme, err := ioutil.ReadFile(filepath.Join(dir, "README.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(readme), "terraform destroy") {
+		t.Errorf("README does not tell the user how to destroy:\n%s", readme)
+	}
+}
@@ -0,0 +1,139 @@
+package scenarios
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/providers"
+)
+
+// ProviderPool shares provider instances across the steps and scenarios
+// of a test run. Each provider is launched at most once, on first use,
+// and then handed out to every step that needs it; the instance outlives
+// the steps using it and is only really closed when the pool itself is
+// closed at the end of the run. Launching a fresh provider subprocess
+// for every step makes large suites dramatically slower, and the pool
+// removes that cost without the steps having to coordinate.
+type ProviderPool struct {
+	factories map[addrs.Provider]providers.Factory
+
+	mu      sync.Mutex
+	entries map[addrs.Provider]*pooledEntry
+}
+
+type pooledEntry struct {
+	instance providers.Interface
+	refs     int
+}
+
+// NewProviderPool returns a pool that launches providers using the given
+// factories. The caller must Close the pool when the run is over to shut
+// the launched providers down.
+func NewProviderPool(factories map[addrs.Provider]providers.Factory) *ProviderPool {
+	return &ProviderPool{
+		factories: factories,
+		entries:   make(map[addrs.Provider]*pooledEntry),
+	}
+}
+
+// Factories returns a factory map equivalent to the one the pool was
+// built from, except that the factories share instances through the
+// pool. The instances they return intercept Close to release the
+// caller's reference rather than terminating the provider, so existing
+// code that conscientiously closes its providers works unchanged.
+func (p *ProviderPool) Factories() map[addrs.Provider]providers.Factory {
+	ret := make(map[addrs.Provider]providers.Factory, len(p.factories))
+	for addr := range p.factories {
+		addr := addr // separate symbol per iteration, for the closure
+		ret[addr] = func() (providers.Interface, error) {
+			return p.acquire(addr)
+		}
+	}
+	return ret
+}
+
+// acquire returns the shared instance for the given provider, launching
+// it if this is the first use, and takes a reference that the returned
+// instance's Close releases.
+func (p *ProviderPool) acquire(addr addrs.Provider) (providers.Interface, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[addr]
+	if !ok {
+		factory, ok := p.factories[addr]
+		if !ok {
+			return nil, fmt.Errorf("provider %s is not in the pool", addr)
+		}
+		instance, err := factory()
+		if err != nil {
+			return nil, err
+		}
+		entry = &pooledEntry{instance: instance}
+		p.entries[addr] = entry
+	}
+	entry.refs++
+	return &pooledInstance{Interface: entry.instance, pool: p, addr: addr}, nil
+}
+
+// release drops one reference to the given provider. The instance stays
+// alive even with no references, so the next step to need it does not
+// pay the launch cost again.
+func (p *ProviderPool) release(addr addrs.Provider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.entries[addr]; ok && entry.refs > 0 {
+		entry.refs--
+	}
+}
+
+// Launched returns how many distinct provider instances the pool has
+// launched so far, for reporting and for tests of the pooling itself.
+func (p *ProviderPool) Launched() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Close terminates every provider the pool launched. It is safe to call
+// while references are still outstanding — at the end of a run anything
+// still referenced is done anyway — and returns the first error any
+// provider reported while shutting down.
+func (p *ProviderPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for addr, entry := range p.entries {
+		if closeErr := entry.instance.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to shut down provider %s: %s", addr, closeErr)
+		}
+		delete(p.entries, addr)
+	}
+	return err
+}
+
+// pooledInstance is the view of a shared provider handed to one caller.
+// Close releases the caller's reference instead of terminating the
+// provider; everything else passes through.
+type pooledInstance struct {
+	providers.Interface
+
+	pool *ProviderPool
+	addr addrs.Provider
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (i *pooledInstance) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	i.pool.release(i.addr)
+	return nil
+}
@@ -0,0 +1,77 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestProviderPool(t *testing.T) {
+	awsAddr := addrs.NewDefaultProvider("aws")
+	awsInstance := &terraform.MockProvider{}
+	launches := 0
+
+	pool := NewProviderPool(map[addrs.Provider]providers.Factory{
+		awsAddr: func() (providers.Interface, error) {
+			launches++
+			return awsInstance, nil
+		},
+	})
+
+	factories := pool.Factories()
+	factory, ok := factories[awsAddr]
+	if !ok {
+		t.Fatalf("pool has no factory for %s", awsAddr)
+	}
+
+	// Two acquisitions share one launched instance.
+	first, err := factory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := factory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launches != 1 {
+		t.Fatalf("launched %d instances; want 1", launches)
+	}
+	if got := pool.Launched(); got != 1 {
+		t.Fatalf("pool reports %d launched; want 1", got)
+	}
+
+	// Closing a caller's handle releases its reference without shutting
+	// the provider down, even when closed twice.
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if awsInstance.CloseCalled {
+		t.Fatalf("provider closed while the pool is still open")
+	}
+
+	// A later acquisition reuses the instance rather than relaunching.
+	third, err := factory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	third.Close()
+	if launches != 1 {
+		t.Fatalf("launched %d instances after reuse; want 1", launches)
+	}
+
+	// Closing the pool is what finally terminates the provider.
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !awsInstance.CloseCalled {
+		t.Fatalf("provider not closed with the pool")
+	}
+}
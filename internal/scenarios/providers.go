@@ -0,0 +1,143 @@
+package scenarios
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// CheckStepProviders validates the given step's providers mapping against
+// the module the step operates on. Every name on the left-hand side must
+// be a provider configuration the module declares — its default
+// configurations and any aliases from configuration_aliases — and every
+// alias the module requires must be supplied, since aliased
+// configurations never pass through implicitly. The right-hand sides may
+// freely mix the scenario's mock providers with real configurations, so
+// a multi-region module can run one region mocked and the other real.
+func (s *Scenario) CheckStepProviders(step *Step, mod *configs.Module) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	// The configuration names the module can accept, and the aliased
+	// subset of them that the step must supply.
+	accepted := make(map[string]bool)
+	required := make(map[string]hcl.Range)
+	if mod.ProviderRequirements != nil {
+		for name, req := range mod.ProviderRequirements.RequiredProviders {
+			accepted[name] = true
+			for _, alias := range req.Aliases {
+				full := alias.LocalName + "." + alias.Alias
+				accepted[full] = true
+				required[full] = req.DeclRange
+			}
+		}
+	}
+
+	for inside, outside := range step.Providers {
+		if !accepted[inside] {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unexpected provider configuration",
+				Detail:   fmt.Sprintf("The module for step %q does not declare a provider configuration named %q, so there is nothing to map %q to. Declare it in the module's required_providers block, using configuration_aliases for aliased configurations.", step.Name, inside, outside),
+				Subject:  &step.DeclRange,
+			})
+			continue
+		}
+		if _, _, ok := providerConfigRef(outside); !ok {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider configuration reference",
+				Detail:   fmt.Sprintf("The reference %q for provider configuration %q must be a provider name, optionally followed by one alias, like \"aws\" or \"aws.east\".", outside, inside),
+				Subject:  &step.DeclRange,
+			})
+		}
+	}
+
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, supplied := step.Providers[name]; supplied {
+			continue
+		}
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing provider configuration for alias",
+			Detail:   fmt.Sprintf("The module for step %q requires a provider configuration named %q through configuration_aliases, and aliased configurations do not pass through implicitly. Map it to one of the scenario's provider configurations in the step's providers argument.", step.Name, name),
+			Subject:  &step.DeclRange,
+		})
+	}
+
+	return diags
+}
+
+// providerConfigRef splits a provider configuration reference of the form
+// "name" or "name.alias" into its parts, reporting false for anything
+// else.
+func providerConfigRef(ref string) (local, alias string, ok bool) {
+	parts := strings.SplitN(ref, ".", 3)
+	switch len(parts) {
+	case 1:
+		local = parts[0]
+	case 2:
+		local, alias = parts[0], parts[1]
+		if !hclsyntax.ValidIdentifier(alias) {
+			return "", "", false
+		}
+	default:
+		return "", "", false
+	}
+	if !hclsyntax.ValidIdentifier(local) {
+		return "", "", false
+	}
+	return local, alias, true
+}
+
+// CheckInlineProviderConfigs inspects the configuration under test for
+// provider blocks declared inside the modules themselves. A module that
+// configures its own providers always talks to the real services those
+// providers implement, so a scenario's mock providers and overrides can
+// never be injected into it: the inline configuration shadows whatever
+// the scenario would pass in. Detecting that up front lets us explain
+// the problem at its source, instead of letting a step fail later with
+// a confusing credentials or connection error from the real provider.
+func CheckInlineProviderConfigs(cfg *configs.Config) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	cfg.DeepEach(func(c *configs.Config) {
+		names := make([]string, 0, len(c.Module.ProviderConfigs))
+		for name := range c.Module.ProviderConfigs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			pc := c.Module.ProviderConfigs[name]
+			var detail string
+			if c.Path.IsRoot() {
+				detail = fmt.Sprintf(
+					"The module under test configures provider %q itself, so test scenarios cannot substitute a mock for it and every step would use the real provider.\n\nTo make this module testable, remove the provider block and let the calling configuration pass a provider configuration in, as described in the provider requirements documentation.",
+					name,
+				)
+			} else {
+				detail = fmt.Sprintf(
+					"The module %s configures provider %q itself, so test scenarios cannot substitute a mock for it and every step would use the real provider.\n\nProvider blocks inside nested modules are a legacy pattern. To make this configuration testable, remove the provider block and pass a provider configuration in from the caller using the \"providers\" argument of the module block.",
+					c.Path, name,
+				)
+			}
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Module contains its own provider configuration",
+				Detail:   detail,
+				Subject:  &pc.DeclRange,
+			})
+		}
+	})
+	return diags
+}
@@ -0,0 +1,88 @@
+package scenarios
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+func TestCheckInlineProviderConfigs(t *testing.T) {
+	loadConfig := func(dir string) *configs.Config {
+		parser := configs.NewParser(nil)
+		mod, hclDiags := parser.LoadConfigDir(dir)
+		if hclDiags.HasErrors() {
+			t.Fatalf("unexpected errors loading %s: %s", dir, hclDiags.Error())
+		}
+		cfg, hclDiags := configs.BuildConfig(mod, configs.DisabledModuleWalker)
+		if hclDiags.HasErrors() {
+			t.Fatalf("unexpected errors building %s: %s", dir, hclDiags.Error())
+		}
+		return cfg
+	}
+
+	// A module declaring only provider requirements is fine.
+	if diags := CheckInlineProviderConfigs(loadConfig("testdata/providers/clean")); diags.HasErrors() {
+		t.Errorf("unexpected errors for clean module: %s", diags.Err())
+	}
+
+	// A module with inline provider blocks gets one diagnostic per block.
+	diags := CheckInlineProviderConfigs(loadConfig("testdata/providers/inline"))
+	if got, want := len(diags), 2; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d\n%s", got, want, diags.Err())
+	}
+	for _, diag := range diags {
+		if got, want := diag.Description().Summary, "Module contains its own provider configuration"; got != want {
+			t.Errorf("wrong summary %q; want %q", got, want)
+		}
+	}
+	if detail := diags[1].Description().Detail; !strings.Contains(detail, `"aws.east"`) {
+		t.Errorf("detail does not name the aliased provider:\n%s", detail)
+	}
+}
+
+func TestCheckStepProviders(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, hclDiags := parser.LoadConfigDir("testdata/providers/aliases")
+	if hclDiags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", hclDiags.Error())
+	}
+
+	scenario := &Scenario{Name: "regions"}
+
+	// Supplying the required alias passes, mixing mock and real freely.
+	step := &Step{
+		Name: "apply",
+		Providers: map[string]string{
+			"aws":      "aws",
+			"aws.east": "aws.secondary",
+		},
+	}
+	if diags := scenario.CheckStepProviders(step, mod); diags.HasErrors() {
+		t.Errorf("unexpected errors for complete mapping: %s", diags.Err())
+	}
+
+	// Leaving the alias unmapped is an error that names it.
+	step = &Step{Name: "apply"}
+	diags := scenario.CheckStepProviders(step, mod)
+	if !diags.HasErrors() {
+		t.Fatalf("no error for missing alias")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, `"aws.east"`) {
+		t.Errorf("detail does not name the missing alias:\n%s", detail)
+	}
+
+	// Mapping a configuration the module doesn't declare is an error, as
+	// is a malformed reference.
+	step = &Step{
+		Name: "apply",
+		Providers: map[string]string{
+			"google":   "google",
+			"aws.east": "aws.east.extra",
+		},
+	}
+	diags = scenario.CheckStepProviders(step, mod)
+	if got, want := len(diags), 2; got != want {
+		t.Errorf("wrong number of diagnostics %d; want %d\n%s", got, want, diags.Err())
+	}
+}
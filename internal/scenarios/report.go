@@ -0,0 +1,196 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// WriteJUnitXML writes the given results as a JUnit-style XML report,
+// with one test suite per scenario and one test case per step. Failed
+// steps carry their diagnostics as failure elements and skipped steps
+// are marked as such, so CI systems can annotate pull requests from the
+// report. Pass the path given to the -junit-xml option.
+func WriteJUnitXML(w io.Writer, results []*ScenarioResult) error {
+	doc := junitDocument{}
+	for _, result := range results {
+		suite := junitSuite{
+			Name: result.Scenario.Name,
+			Time: result.Duration.Seconds(),
+		}
+		for _, status := range result.Steps {
+			testCase := junitCase{
+				Classname: result.Scenario.Name,
+				Name:      status.Step.Name,
+				Time:      status.Duration.Seconds(),
+			}
+			if stats := status.Stats; stats != nil {
+				testCase.SystemOut = fmt.Sprintf("provider_calls=%d resources_created=%d resources_destroyed=%d",
+					stats.ProviderCalls, stats.ResourcesCreated, stats.ResourcesDestroyed)
+			}
+			suite.Tests++
+			switch status.Status {
+			case StepFailed:
+				suite.Failures++
+				for _, diag := range status.Diagnostics {
+					if diag.Severity() != tfdiags.Error {
+						continue
+					}
+					desc := diag.Description()
+					testCase.Failures = append(testCase.Failures, junitFailure{
+						Message: desc.Summary,
+						Body:    desc.Detail,
+					})
+				}
+			case StepSkipped:
+				suite.Skipped++
+				testCase.Skipped = &junitSkipped{Message: status.SkipReason}
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type junitDocument struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Classname string         `xml:"classname,attr"`
+	Name      string         `xml:"name,attr"`
+	Time      float64        `xml:"time,attr"`
+	Failures  []junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped  `xml:"skipped,omitempty"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// WriteJSONReport writes the given results as a JSON report carrying the
+// same information as the JUnit one, for consumers that prefer a
+// structured format over XML.
+func WriteJSONReport(w io.Writer, results []*ScenarioResult) error {
+	report := jsonReport{
+		FormatVersion: "1.0",
+	}
+	for _, result := range results {
+		scenario := jsonScenario{
+			Name:     result.Scenario.Name,
+			Duration: result.Duration.Seconds(),
+		}
+		for _, status := range result.Steps {
+			step := jsonStep{
+				Name:     status.Step.Name,
+				Duration: status.Duration.Seconds(),
+			}
+			if stats := status.Stats; stats != nil {
+				step.ProviderCalls = &stats.ProviderCalls
+				step.ResourcesCreated = &stats.ResourcesCreated
+				step.ResourcesDestroyed = &stats.ResourcesDestroyed
+			}
+			step.Artifacts = status.Artifacts
+			switch status.Status {
+			case StepPassed:
+				step.Status = "pass"
+			case StepFailed:
+				step.Status = "fail"
+			case StepSkipped:
+				step.Status = "skip"
+				step.SkipReason = status.SkipReason
+			}
+			for _, diag := range status.Diagnostics {
+				severity := "warning"
+				if diag.Severity() == tfdiags.Error {
+					severity = "error"
+				}
+				desc := diag.Description()
+				step.Diagnostics = append(step.Diagnostics, jsonDiagnostic{
+					Severity: severity,
+					Summary:  desc.Summary,
+					Detail:   desc.Detail,
+				})
+			}
+			scenario.Steps = append(scenario.Steps, step)
+		}
+		report.Scenarios = append(report.Scenarios, scenario)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to serialize report: %s", err)
+	}
+	return nil
+}
+
+type jsonReport struct {
+	FormatVersion string         `json:"format_version"`
+	Scenarios     []jsonScenario `json:"scenarios"`
+}
+
+type jsonScenario struct {
+	Name     string     `json:"name"`
+	Duration float64    `json:"duration"`
+	Steps    []jsonStep `json:"steps"`
+}
+
+type jsonStep struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Duration   float64 `json:"duration"`
+	SkipReason string  `json:"skip_reason,omitempty"`
+
+	// The stats fields are pointers so that steps with no recorded stats
+	// omit them entirely rather than reporting misleading zeroes.
+	ProviderCalls      *int `json:"provider_calls,omitempty"`
+	ResourcesCreated   *int `json:"resources_created,omitempty"`
+	ResourcesDestroyed *int `json:"resources_destroyed,omitempty"`
+
+	// Artifacts are the paths of the files collected for the step's
+	// artifact patterns, for report consumers to pick up after the run.
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	Diagnostics []jsonDiagnostic `json:"diagnostics,omitempty"`
+}
+
+type jsonDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+}
@@ -0,0 +1,98 @@
+package scenarios
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func reportTestResults() []*ScenarioResult {
+	var failDiags tfdiags.Diagnostics
+	failDiags = failDiags.Append(tfdiags.Sourceless(
+		tfdiags.Error,
+		"Assertion failed in step \"apply\"",
+		"Expected exactly two instances.",
+	))
+
+	return []*ScenarioResult{
+		{
+			Scenario: &Scenario{Name: "defaults"},
+			Duration: 3 * time.Second,
+			Steps: []*StepStatus{
+				{
+					Step:     &Step{Name: "plan"},
+					Status:   StepPassed,
+					Duration: time.Second,
+				},
+				{
+					Step:        &Step{Name: "apply"},
+					Status:      StepFailed,
+					Duration:    2 * time.Second,
+					Diagnostics: failDiags,
+				},
+				{
+					Step:   &Step{Name: "verify"},
+					Status: StepSkipped,
+				},
+			},
+		},
+	}
+}
+
+func TestWriteJUnitXML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(&buf, reportTestResults()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		`<testsuites tests="3" failures="1">`,
+		`<testsuite name="defaults" tests="3" failures="1" skipped="1" time="3">`,
+		`<testcase classname="defaults" name="plan" time="1">`,
+		`<failure message="Assertion failed in step &#34;apply&#34;">`,
+		`<skipped></skipped>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report does not contain %s\nreport:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, reportTestResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %s", err)
+	}
+	if len(report.Scenarios) != 1 {
+		t.Fatalf("wrong number of scenarios %d; want 1", len(report.Scenarios))
+	}
+	steps := report.Scenarios[0].Steps
+	if len(steps) != 3 {
+		t.Fatalf("wrong number of steps %d; want 3", len(steps))
+	}
+	if got, want := steps[0].Status, "pass"; got != want {
+		t.Errorf("wrong status for plan %q; want %q", got, want)
+	}
+	if got, want := steps[1].Status, "fail"; got != want {
+		t.Errorf("wrong status for apply %q; want %q", got, want)
+	}
+	if got, want := steps[2].Status, "skip"; got != want {
+		t.Errorf("wrong status for verify %q; want %q", got, want)
+	}
+	if len(steps[1].Diagnostics) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1", len(steps[1].Diagnostics))
+	}
+	if got, want := steps[1].Diagnostics[0].Detail, "Expected exactly two instances."; got != want {
+		t.Errorf("wrong diagnostic detail %q; want %q", got, want)
+	}
+}
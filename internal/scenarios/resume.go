@@ -0,0 +1,205 @@
+package scenarios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/states/statefile"
+)
+
+// RunRecordFormatVersion identifies the on-disk layout of a run record.
+// ReadRunRecord rejects records written by an incompatible Terraform
+// version rather than guessing at their meaning.
+const RunRecordFormatVersion = "1.0"
+
+// A RunRecord remembers how the scenarios of a test run went, including
+// the step results and state snapshots of the scenarios that passed, in
+// a form that survives between runs. "terraform test -rerun-failed"
+// reads the record of the previous run to re-execute only the scenarios
+// that failed, standing in the recorded results for the ones that
+// passed so their dependents still see their outputs.
+type RunRecord struct {
+	FormatVersion string `json:"format_version"`
+
+	// Scenarios holds one record per scenario, keyed by scenario name.
+	Scenarios map[string]*ScenarioRecord `json:"scenarios"`
+}
+
+// ScenarioRecord is the recorded outcome of one scenario.
+type ScenarioRecord struct {
+	// Status is "pass", "fail", or "skip", matching the vocabulary of
+	// the JSON report.
+	Status string `json:"status"`
+
+	// Steps records the scenario's own steps, keyed by step name. It is
+	// populated only for passed scenarios, since only their results are
+	// ever reused.
+	Steps map[string]*StepRecord `json:"steps,omitempty"`
+}
+
+// StepRecord is the recorded outcome of one step of a passed scenario.
+type StepRecord struct {
+	// Status is "pass" for steps that ran or "skip" for steps whose own
+	// skip condition held.
+	Status string `json:"status"`
+
+	// Outputs are the step's root module output values, each serialized
+	// with its type so it round-trips exactly.
+	Outputs map[string]json.RawMessage `json:"outputs,omitempty"`
+
+	// State is the state snapshot the step left behind, in the usual
+	// state file format, if the step produced one.
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// NewRunRecord returns an empty record, ready for Record.
+func NewRunRecord() *RunRecord {
+	return &RunRecord{
+		FormatVersion: RunRecordFormatVersion,
+		Scenarios:     make(map[string]*ScenarioRecord),
+	}
+}
+
+// Record stores the outcomes of the given results, replacing any earlier
+// entries for the same scenarios and leaving entries for other scenarios
+// alone, so a filtered or resumed run does not forget the scenarios it
+// did not select.
+func (r *RunRecord) Record(results []*ScenarioResult) {
+	for _, result := range results {
+		rec := &ScenarioRecord{}
+		switch {
+		case result.Skipped:
+			rec.Status = "skip"
+		case result.Diagnostics.HasErrors():
+			rec.Status = "fail"
+		default:
+			rec.Status = "pass"
+			rec.Steps = make(map[string]*StepRecord, len(result.Steps))
+			for _, status := range result.Steps {
+				rec.Steps[status.Step.Name] = newStepRecord(status, result.StepResults[status.Step.Name])
+			}
+		}
+		r.Scenarios[result.Scenario.Name] = rec
+	}
+}
+
+// newStepRecord captures one step of a passed scenario. Outputs that
+// cannot be serialized — unknown values, mainly — are left out, since
+// they could not be reused anyway.
+func newStepRecord(status *StepStatus, result *StepResult) *StepRecord {
+	rec := &StepRecord{Status: "pass"}
+	if status.Status == StepSkipped {
+		rec.Status = "skip"
+		return rec
+	}
+	if result == nil {
+		return rec
+	}
+
+	if len(result.Outputs) != 0 {
+		rec.Outputs = make(map[string]json.RawMessage, len(result.Outputs))
+		for name, val := range result.Outputs {
+			raw, err := ctyjson.Marshal(val, cty.DynamicPseudoType)
+			if err != nil {
+				continue
+			}
+			rec.Outputs[name] = raw
+		}
+	}
+	if result.State != nil && !result.State.Empty() {
+		var buf bytes.Buffer
+		if err := statefile.Write(statefile.New(result.State, "", 0), &buf); err == nil {
+			rec.State = buf.Bytes()
+		}
+	}
+	return rec
+}
+
+// Result rebuilds the recorded result of the given scenario, for use as
+// a Runner's Recorded entry, or returns nil if the scenario must run:
+// because it failed or was skipped, because it has no record at all, or
+// because its steps no longer match the record. A rebuilt result has
+// Reused set so reporting can tell it apart from a fresh pass.
+func (r *RunRecord) Result(scenario *Scenario) *ScenarioResult {
+	rec, exists := r.Scenarios[scenario.Name]
+	if !exists || rec.Status != "pass" {
+		return nil
+	}
+	if len(rec.Steps) != len(scenario.Steps) {
+		return nil
+	}
+
+	result := &ScenarioResult{
+		Scenario:    scenario,
+		Reused:      true,
+		StepResults: make(map[string]*StepResult, len(rec.Steps)),
+	}
+	for _, step := range scenario.Steps {
+		stepRec, exists := rec.Steps[step.Name]
+		if !exists {
+			return nil
+		}
+		if stepRec.Status == "skip" {
+			result.Steps = append(result.Steps, &StepStatus{
+				Step:   step,
+				Status: StepSkipped,
+			})
+			continue
+		}
+
+		stepResult := &StepResult{
+			Outputs: make(map[string]cty.Value, len(stepRec.Outputs)),
+		}
+		for name, raw := range stepRec.Outputs {
+			val, err := ctyjson.Unmarshal(raw, cty.DynamicPseudoType)
+			if err != nil {
+				return nil
+			}
+			stepResult.Outputs[name] = val
+		}
+		if len(stepRec.State) != 0 {
+			file, err := statefile.Read(bytes.NewReader(stepRec.State))
+			if err != nil {
+				return nil
+			}
+			stepResult.State = file.State
+		}
+		result.StepResults[step.Name] = stepResult
+		result.Steps = append(result.Steps, &StepStatus{
+			Step:   step,
+			Status: StepPassed,
+		})
+	}
+	return result
+}
+
+// WriteRunRecord writes the record in its JSON form.
+func WriteRunRecord(w io.Writer, record *RunRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to serialize run record: %s", err)
+	}
+	return nil
+}
+
+// ReadRunRecord reads a record previously written by WriteRunRecord.
+func ReadRunRecord(r io.Reader) (*RunRecord, error) {
+	var record RunRecord
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(&record); err != nil {
+		return nil, fmt.Errorf("invalid run record: %s", err)
+	}
+	if record.FormatVersion != RunRecordFormatVersion {
+		return nil, fmt.Errorf("run record has unsupported format version %q", record.FormatVersion)
+	}
+	if record.Scenarios == nil {
+		record.Scenarios = make(map[string]*ScenarioRecord)
+	}
+	return &record, nil
+}
@@ -0,0 +1,201 @@
+package scenarios
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestRunRecordRoundTrip(t *testing.T) {
+	scenario := &Scenario{
+		Name: "base_network",
+		Steps: []*Step{
+			{Name: "apply"},
+			{Name: "optional"},
+		},
+	}
+	failed := &Scenario{Name: "app", Steps: []*Step{{Name: "apply"}}}
+
+	var failedDiags tfdiags.Diagnostics
+	failedDiags = failedDiags.Append(tfdiags.Sourceless(tfdiags.Error, "quota exceeded", ""))
+
+	record := NewRunRecord()
+	record.Record([]*ScenarioResult{
+		{
+			Scenario: scenario,
+			Steps: []*StepStatus{
+				{Step: scenario.Steps[0], Status: StepPassed},
+				{Step: scenario.Steps[1], Status: StepSkipped, SkipReason: "not today"},
+			},
+			StepResults: map[string]*StepResult{
+				"apply": {
+					Outputs: map[string]cty.Value{
+						"vpc_id": cty.StringVal("vpc-123"),
+						"count":  cty.NumberIntVal(2),
+					},
+				},
+			},
+		},
+		{Scenario: failed, Diagnostics: failedDiags},
+	})
+
+	var buf bytes.Buffer
+	if err := WriteRunRecord(&buf, record); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+	read, err := ReadRunRecord(&buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %s", err)
+	}
+
+	result := read.Result(scenario)
+	if result == nil {
+		t.Fatal("no reusable result for the passed scenario")
+	}
+	if !result.Reused {
+		t.Error("rebuilt result not marked as reused")
+	}
+	if len(result.Steps) != 2 || result.Steps[0].Status != StepPassed || result.Steps[1].Status != StepSkipped {
+		t.Errorf("wrong step statuses %#v", result.Steps)
+	}
+	outputs := result.StepResults["apply"].Outputs
+	if !outputs["vpc_id"].RawEquals(cty.StringVal("vpc-123")) {
+		t.Errorf("wrong vpc_id output %#v", outputs["vpc_id"])
+	}
+	if !outputs["count"].RawEquals(cty.NumberIntVal(2)) {
+		t.Errorf("wrong count output %#v", outputs["count"])
+	}
+
+	if read.Result(failed) != nil {
+		t.Error("failed scenario produced a reusable result")
+	}
+}
+
+func TestRunRecordStateSnapshot(t *testing.T) {
+	state := states.NewState()
+	state.RootModule().SetOutputValue("vpc_id", cty.StringVal("vpc-123"), false)
+
+	scenario := &Scenario{Name: "base_network", Steps: []*Step{{Name: "apply"}}}
+	record := NewRunRecord()
+	record.Record([]*ScenarioResult{
+		{
+			Scenario: scenario,
+			Steps:    []*StepStatus{{Step: scenario.Steps[0], Status: StepPassed}},
+			StepResults: map[string]*StepResult{
+				"apply": {State: state},
+			},
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := WriteRunRecord(&buf, record); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+	read, err := ReadRunRecord(&buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %s", err)
+	}
+
+	result := read.Result(scenario)
+	if result == nil {
+		t.Fatal("no reusable result")
+	}
+	got := result.StepResults["apply"].State
+	if got == nil {
+		t.Fatal("state snapshot not restored")
+	}
+	ov := got.RootModule().OutputValues["vpc_id"]
+	if ov == nil || !ov.Value.RawEquals(cty.StringVal("vpc-123")) {
+		t.Errorf("wrong restored state output %#v", ov)
+	}
+}
+
+func TestRunRecordResultChangedScenario(t *testing.T) {
+	scenario := &Scenario{Name: "base_network", Steps: []*Step{{Name: "apply"}}}
+	record := NewRunRecord()
+	record.Record([]*ScenarioResult{
+		{
+			Scenario:    scenario,
+			Steps:       []*StepStatus{{Step: scenario.Steps[0], Status: StepPassed}},
+			StepResults: map[string]*StepResult{"apply": {}},
+		},
+	})
+
+	// A scenario whose steps no longer match its record must run again.
+	changed := &Scenario{
+		Name:  "base_network",
+		Steps: []*Step{{Name: "apply"}, {Name: "verify"}},
+	}
+	if record.Result(changed) != nil {
+		t.Error("changed scenario produced a reusable result")
+	}
+
+	renamed := &Scenario{Name: "base_network", Steps: []*Step{{Name: "deploy"}}}
+	if record.Result(renamed) != nil {
+		t.Error("scenario with renamed step produced a reusable result")
+	}
+}
+
+func TestRunRecordReadInvalid(t *testing.T) {
+	if _, err := ReadRunRecord(bytes.NewReader([]byte("not json"))); err == nil {
+		t.Error("no error for malformed record")
+	}
+	if _, err := ReadRunRecord(bytes.NewReader([]byte(`{"format_version":"99.0"}`))); err == nil {
+		t.Error("no error for unsupported format version")
+	}
+}
+
+func TestRunnerRecorded(t *testing.T) {
+	base := &Scenario{
+		Name:  "base_network",
+		Steps: []*Step{{Name: "apply"}},
+	}
+	app := &Scenario{
+		Name:      "app",
+		DependsOn: []string{"base_network"},
+		Steps:     []*Step{{Name: "apply"}},
+	}
+
+	record := NewRunRecord()
+	record.Record([]*ScenarioResult{
+		{
+			Scenario: base,
+			Steps:    []*StepStatus{{Step: base.Steps[0], Status: StepPassed}},
+			StepResults: map[string]*StepResult{
+				"apply": {Outputs: map[string]cty.Value{
+					"vpc_id": cty.StringVal("vpc-123"),
+				}},
+			},
+		},
+	})
+
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			if s.Name == "base_network" {
+				t.Errorf("recorded scenario ran anyway")
+			}
+			// The reused fixture's outputs are visible as usual.
+			fixture := results["base_network"]
+			if fixture == nil || !fixture.Outputs["vpc_id"].RawEquals(cty.StringVal("vpc-123")) {
+				t.Errorf("recorded fixture outputs not visible to dependent: %#v", fixture)
+			}
+			return &StepResult{}, nil
+		},
+		Recorded: map[string]*ScenarioResult{
+			"base_network": record.Result(base),
+		},
+	}
+
+	results := runner.Run([]*Scenario{base, app})
+	if !results[0].Reused {
+		t.Errorf("recorded scenario's result not marked as reused: %#v", results[0])
+	}
+	if results[1].Skipped || results[1].Diagnostics.HasErrors() {
+		t.Errorf("dependent did not run cleanly: %#v", results[1])
+	}
+}
@@ -0,0 +1,133 @@
+package scenarios
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// Run is the identity of one execution of a test suite. Expressions in
+// scenario files see it as the "run" symbol, and its seed drives the
+// deterministic random helpers, so resource names can be unique per
+// execution yet reproducible when a failing run is replayed with -seed.
+type Run struct {
+	// Seed is the value all of the run's randomness derives from. Two
+	// runs with the same seed see the same run.id and the same values
+	// from the random helpers.
+	Seed string
+
+	// ID is a short identifier derived from the seed, for embedding in
+	// resource names so concurrent runs do not collide.
+	ID string
+
+	// ExtraFunctions are additional functions the run's environment
+	// supplies to scenario expressions, such as the evaluation functions
+	// declared by running integrations. Like the random helpers they must
+	// be pure, or replaying a run with -seed stops being reproducible.
+	ExtraFunctions map[string]function.Function
+}
+
+// NewRun returns the run identity for the given seed. An empty seed —
+// no -seed option — draws a fresh random one, so distinct executions
+// get distinct IDs.
+func NewRun(seed string) *Run {
+	if seed == "" {
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			// Falling back to a fixed seed keeps the run working; it
+			// only weakens collision avoidance.
+			buf = [8]byte{}
+		}
+		seed = fmt.Sprintf("%x", buf)
+	}
+	h := sha256.Sum256([]byte("run-id|" + seed))
+	return &Run{
+		Seed: seed,
+		ID:   fmt.Sprintf("%x", h[:4]),
+	}
+}
+
+// addToContext installs the run's symbols into an evaluation context:
+// the "run" object with its id and seed, the random_suffix and
+// random_pet functions, and any extra functions the run carries. The
+// functions are pure — their results depend only on the seed and their
+// arguments — which is what makes a run reproducible.
+func (r *Run) addToContext(ctx *hcl.EvalContext) {
+	ctx.Variables["run"] = cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.StringVal(r.ID),
+		"seed": cty.StringVal(r.Seed),
+	})
+	if ctx.Functions == nil {
+		ctx.Functions = make(map[string]function.Function)
+	}
+	ctx.Functions["random_suffix"] = r.randomSuffixFunc()
+	ctx.Functions["random_pet"] = r.randomPetFunc()
+	for name, fn := range r.ExtraFunctions {
+		ctx.Functions[name] = fn
+	}
+}
+
+// randomSuffixFunc returns a function producing a lowercase alphanumeric
+// string of the requested length, derived from the run's seed.
+func (r *Run) randomSuffixFunc() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "length",
+				Type: cty.Number,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			var length int
+			if err := gocty.FromCtyValue(args[0], &length); err != nil {
+				return cty.NilVal, err
+			}
+			if length < 1 || length > 64 {
+				return cty.NilVal, fmt.Errorf("length must be between 1 and 64")
+			}
+
+			const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+			buf := make([]byte, length)
+			digest := sha256.Sum256([]byte("suffix|" + r.Seed))
+			for i := range buf {
+				if i > 0 && i%len(digest) == 0 {
+					digest = sha256.Sum256(digest[:])
+				}
+				buf[i] = alphabet[int(digest[i%len(digest)])%len(alphabet)]
+			}
+			return cty.StringVal(string(buf)), nil
+		},
+	})
+}
+
+// randomPetFunc returns a function producing a friendly "adjective-noun"
+// name, in the style of the random_pet resource, derived from the run's
+// seed.
+func (r *Run) randomPetFunc() function.Function {
+	adjectives := []string{
+		"brave", "calm", "eager", "fast", "gentle", "happy",
+		"keen", "lucky", "mellow", "nimble", "proud", "quiet",
+		"rapid", "sturdy", "tidy", "witty",
+	}
+	nouns := []string{
+		"badger", "crane", "dolphin", "falcon", "gopher", "heron",
+		"ibex", "jackal", "koala", "lynx", "marmot", "otter",
+		"panda", "quail", "raven", "tapir",
+	}
+
+	return function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			digest := sha256.Sum256([]byte("pet|" + r.Seed))
+			adjective := adjectives[int(digest[0])%len(adjectives)]
+			noun := nouns[int(digest[1])%len(nouns)]
+			return cty.StringVal(adjective + "-" + noun), nil
+		},
+	})
+}
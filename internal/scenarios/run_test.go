@@ -0,0 +1,105 @@
+package scenarios
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestNewRun(t *testing.T) {
+	// The same seed always yields the same run identity.
+	a := NewRun("ci-1234")
+	b := NewRun("ci-1234")
+	if a.ID != b.ID {
+		t.Errorf("same seed gave different ids %q and %q", a.ID, b.ID)
+	}
+
+	// Different seeds yield different identities.
+	c := NewRun("ci-5678")
+	if c.ID == a.ID {
+		t.Errorf("different seeds gave the same id %q", a.ID)
+	}
+
+	// With no seed, each run draws its own.
+	d := NewRun("")
+	e := NewRun("")
+	if d.Seed == e.Seed {
+		t.Errorf("unseeded runs share the seed %q", d.Seed)
+	}
+}
+
+func TestRunSymbols(t *testing.T) {
+	parse := func(src string) hcl.Expression {
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "test", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			t.Fatalf("invalid test expression %q: %s", src, diags.Error())
+		}
+		return expr
+	}
+
+	run := NewRun("ci-1234")
+	ctx := stepResultsEvalContext(nil, run)
+
+	id, diags := parse(`run.id`).Value(ctx)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	if id.AsString() != run.ID {
+		t.Errorf("wrong run.id %q; want %q", id.AsString(), run.ID)
+	}
+
+	suffix, diags := parse(`random_suffix(8)`).Value(ctx)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	if !regexp.MustCompile(`^[a-z0-9]{8}$`).MatchString(suffix.AsString()) {
+		t.Errorf("malformed suffix %q", suffix.AsString())
+	}
+	again, _ := parse(`random_suffix(8)`).Value(ctx)
+	if suffix.AsString() != again.AsString() {
+		t.Errorf("suffix not stable within a run: %q then %q", suffix.AsString(), again.AsString())
+	}
+	other, _ := parse(`random_suffix(8)`).Value(stepResultsEvalContext(nil, NewRun("ci-5678")))
+	if suffix.AsString() == other.AsString() {
+		t.Errorf("different seeds gave the same suffix %q", suffix.AsString())
+	}
+
+	pet, diags := parse(`random_pet()`).Value(ctx)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	if !regexp.MustCompile(`^[a-z]+-[a-z]+$`).MatchString(pet.AsString()) {
+		t.Errorf("malformed pet name %q", pet.AsString())
+	}
+}
+
+func TestRunExtraFunctions(t *testing.T) {
+	run := NewRun("ci-1234")
+	run.ExtraFunctions = map[string]function.Function{
+		"integration_costcheck_budget_for": function.New(&function.Spec{
+			Params: []function.Parameter{
+				{Name: "team", Type: cty.String},
+			},
+			Type: function.StaticReturnType(cty.Number),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				return cty.NumberIntVal(int64(len(args[0].AsString())) * 100), nil
+			},
+		}),
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(`integration_costcheck_budget_for("platform")`), "test", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid test expression: %s", diags.Error())
+	}
+	val, diags := expr.Value(stepResultsEvalContext(nil, run))
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	if got, want := val, cty.NumberIntVal(800); !got.RawEquals(want) {
+		t.Errorf("wrong result %#v; want %#v", got, want)
+	}
+}
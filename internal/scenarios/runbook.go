@@ -0,0 +1,63 @@
+package scenarios
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/runbooks"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// RunRunbookStep executes the given runbook on behalf of a scenario step
+// and returns its outcome as a step result: each runbook step's action
+// result becomes an output under that step's name, so later scenario
+// steps and assertions can refer to them as step.<name>.outputs.<step>.
+// The engine decides what the runbook's actions actually do, so a caller
+// can back them with the scenario's mock providers to keep the run
+// offline.
+func RunRunbookStep(engine *runbooks.Engine, rb *runbooks.Runbook) (*StepResult, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	collector := &runbookOutputsHook{
+		outputs: make(map[string]cty.Value),
+	}
+	eng := *engine
+	eng.Hooks = append(append([]runbooks.Hook(nil), engine.Hooks...), collector)
+
+	if err := eng.Run(rb); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			fmt.Sprintf("Runbook %q failed", rb.Name),
+			fmt.Sprintf("The runbook did not run to completion: %s.", err),
+		))
+		return nil, diags
+	}
+
+	return &StepResult{
+		Outputs: collector.outputs,
+	}, diags
+}
+
+// runbookOutputsHook collects the result of every successful action
+// invocation, keyed by the invoking runbook step's name. For plan-only
+// and dry-run steps the planned action data stands in for the result, so
+// an offline scenario run still has something to assert against.
+type runbookOutputsHook struct {
+	runbooks.NilHook
+	outputs map[string]cty.Value
+}
+
+func (h *runbookOutputsHook) PostInvokeAction(runbook string, step *runbooks.Step, actionType string, result cty.Value, invokeErr error) {
+	if invokeErr != nil || result == cty.NilVal {
+		return
+	}
+	h.outputs[step.Name] = result
+}
+
+func (h *runbookOutputsHook) PostPlanAction(runbook string, step *runbooks.Step, actionType string, planned cty.Value, planErr error) {
+	if planErr != nil || planned == cty.NilVal {
+		return
+	}
+	h.outputs[step.Name] = planned
+}
@@ -0,0 +1,131 @@
+package scenarios
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/providermocks"
+	"github.com/hashicorp/terraform/internal/runbooks"
+)
+
+func TestRunRunbookStep(t *testing.T) {
+	engine := &runbooks.Engine{
+		Actions: map[string]runbooks.ActionFunc{
+			"aws_lambda_invoke": func(config cty.Value, meta runbooks.ActionMeta) (cty.Value, error) {
+				return cty.ObjectVal(map[string]cty.Value{
+					"status": cty.StringVal("ok"),
+				}), nil
+			},
+		},
+	}
+	rb := &runbooks.Runbook{
+		Name: "rotate-keys",
+		Steps: []*runbooks.Step{
+			{Name: "notify", ActionType: "aws_lambda_invoke", Config: cty.EmptyObjectVal},
+		},
+	}
+
+	result, diags := RunRunbookStep(engine, rb)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	got, exists := result.Outputs["notify"]
+	if !exists {
+		t.Fatalf("no output recorded for the notify step")
+	}
+	if want := cty.StringVal("ok"); !got.GetAttr("status").RawEquals(want) {
+		t.Errorf("wrong output %#v; want status %#v", got, want)
+	}
+
+	// A failing action surfaces as a diagnostic, not a silent pass.
+	engine.Actions["aws_lambda_invoke"] = func(config cty.Value, meta runbooks.ActionMeta) (cty.Value, error) {
+		return cty.NilVal, errors.New("function not found")
+	}
+	if _, diags := RunRunbookStep(engine, rb); !diags.HasErrors() {
+		t.Fatalf("no error for failing runbook")
+	}
+}
+
+func TestRunRunbookStepDryRun(t *testing.T) {
+	scenario := &Scenario{
+		Name: "offline",
+		MockProviders: map[string]*providermocks.MockProvider{
+			"aws": {
+				Name: "aws",
+				DataSources: map[string]*providermocks.MockResource{
+					"aws_instance": {
+						Type: "aws_instance",
+						Defaults: cty.ObjectVal(map[string]cty.Value{
+							"id": cty.StringVal("i-mock"),
+						}),
+					},
+				},
+			},
+		},
+	}
+
+	invoked := false
+	engine := &runbooks.Engine{
+		DryRun: true,
+		Actions: map[string]runbooks.ActionFunc{
+			"aws_instance_reboot": func(config cty.Value, meta runbooks.ActionMeta) (cty.Value, error) {
+				invoked = true
+				return cty.NilVal, errors.New("must not run in a dry run")
+			},
+		},
+		Readers:         scenario.MockReaders(),
+		ReadParallelism: 1,
+	}
+	rb := &runbooks.Runbook{
+		Name: "reboot",
+		Steps: []*runbooks.Step{
+			{
+				Name: "reboot-primary",
+				Reads: []*runbooks.Read{
+					{Name: "primary", ReadType: "aws_instance", Config: cty.EmptyObjectVal},
+				},
+				ActionType: "aws_instance_reboot",
+				Config: cty.ObjectVal(map[string]cty.Value{
+					"instance_id": cty.StringVal("i-mock"),
+				}),
+			},
+		},
+	}
+
+	result, diags := RunRunbookStep(engine, rb)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if invoked {
+		t.Error("the action ran; a dry run must only plan it")
+	}
+
+	// The planned action data stands in for the step's result, and the
+	// read answered from the mock's defaults.
+	got, exists := result.Outputs["reboot-primary"]
+	if !exists {
+		t.Fatalf("no output recorded for the reboot-primary step")
+	}
+	if want := cty.StringVal("i-mock"); !got.GetAttr("instance_id").RawEquals(want) {
+		t.Errorf("wrong planned output %#v; want instance_id %#v", got, want)
+	}
+	if want := cty.StringVal("i-mock"); !rb.Steps[0].Reads[0].Result.GetAttr("id").RawEquals(want) {
+		t.Errorf("wrong read result %#v; want id %#v", rb.Steps[0].Reads[0].Result, want)
+	}
+}
+
+func TestRunbookStepDecoding(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/runbooks")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	step := scenarios[0].Steps[0]
+	if got, want := step.Runbook, "rotate-keys"; got != want {
+		t.Errorf("wrong runbook %q; want %q", got, want)
+	}
+	if len(step.Asserts) != 1 {
+		t.Errorf("wrong number of asserts %d; want 1", len(step.Asserts))
+	}
+}
@@ -0,0 +1,608 @@
+package scenarios
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// DefaultParallelism is how many scenarios a Runner executes concurrently
+// when no explicit parallelism is set, matching the default used for
+// resource operations within a single Terraform run.
+const DefaultParallelism = 10
+
+// StepFunc executes one step of a scenario and returns its result. The
+// results map gives the outcomes of the steps that have already finished,
+// keyed by step name, and must not be modified. The context is cancelled
+// when the step's timeout elapses, and implementations should stop work
+// and return promptly when it is.
+type StepFunc func(ctx context.Context, scenario *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics)
+
+// Runner executes scenarios, running independent scenarios concurrently
+// while keeping each scenario's own steps in order.
+type Runner struct {
+	// RunStep performs the actual Terraform operations for one step.
+	RunStep StepFunc
+
+	// Parallelism bounds how many scenarios run at once. Zero means
+	// DefaultParallelism; one disables concurrency entirely.
+	Parallelism int
+
+	// PreserveOnFailure keeps every failed scenario's resources instead
+	// of running its cleanup steps, as if each scenario had set
+	// preserve_on_failure = true. It corresponds to the
+	// -no-cleanup-on-failure command line option.
+	PreserveOnFailure bool
+
+	// Verbose streams each step's plan and apply output to Output as the
+	// step runs, prefixed with the scenario and step names, instead of
+	// only reporting statuses at the end. It corresponds to the -verbose
+	// command line option. Step implementations obtain their writer from
+	// StepOutput.
+	Verbose bool
+
+	// Output is where verbose step output goes. Ignored unless Verbose
+	// is set.
+	Output io.Writer
+
+	// Hooks are notified of step lifecycle events as the run progresses,
+	// so external reporting systems can follow along. See Hook for the
+	// exact contract.
+	Hooks []Hook
+
+	// Recorded supplies results carried over from an earlier run, keyed
+	// by scenario name, as rebuilt by RunRecord.Result. A scenario with
+	// a recorded result is not executed: the recorded result stands in
+	// for it, both in the returned results and for its dependents. It
+	// corresponds to the -rerun-failed command line option.
+	Recorded map[string]*ScenarioResult
+
+	// outputMu serializes line writes to Output across concurrently
+	// running steps.
+	outputMu sync.Mutex
+}
+
+// ScenarioResult is the outcome of running one scenario.
+type ScenarioResult struct {
+	Scenario *Scenario
+
+	// Steps records how each of the scenario's steps went, in
+	// declaration order, including steps that never ran because an
+	// earlier one failed.
+	Steps []*StepStatus
+
+	// Skipped is true when the scenario's skip condition held and none of
+	// its steps ran, with SkipReason carrying the configured explanation.
+	Skipped    bool
+	SkipReason string
+
+	// Reused is true when the scenario did not run this time and the
+	// result was instead rebuilt from the record of an earlier run, via
+	// Runner.Recorded.
+	Reused bool
+
+	// Preserved is true when the scenario failed and its cleanup steps
+	// were skipped to keep the resources around for debugging. The
+	// caller is responsible for writing out the final states and
+	// telling the user how to destroy what is left.
+	Preserved bool
+
+	// StepResults are the results of the steps that ran, keyed by step
+	// name. A step that failed or never ran has no entry.
+	StepResults map[string]*StepResult
+
+	// Duration is the total wall-clock time spent running the scenario.
+	Duration time.Duration
+
+	// Diagnostics are all diagnostics from the scenario's steps, in the
+	// order the steps are declared regardless of how they were
+	// scheduled.
+	Diagnostics tfdiags.Diagnostics
+}
+
+// StepStatus records how a single step went.
+type StepStatus struct {
+	Step *Step
+
+	// Status is StepPassed or StepFailed for steps that ran, or
+	// StepSkipped for steps abandoned after an earlier failure.
+	Status Status
+
+	// Duration is how long the step took to run, across all attempts.
+	// It is zero for skipped steps.
+	Duration time.Duration
+
+	// Attempts is how many times the step ran. It exceeds 1 only when a
+	// retry block allowed failed attempts to run again.
+	Attempts int
+
+	// Diagnostics are the step's own diagnostics, including any failed
+	// assertions.
+	Diagnostics tfdiags.Diagnostics
+
+	// Stats are the step's resource-level costs, copied from the result
+	// of its successful attempt. Nil when the step failed, was skipped,
+	// or recorded no stats.
+	Stats *StepStats
+
+	// Artifacts are the paths of the files collected for the step's
+	// artifact patterns, copied from the result of its successful
+	// attempt.
+	Artifacts []string
+
+	// SkipReason explains why a step with StepSkipped status was skipped
+	// by its own skip condition. It is empty for steps abandoned after an
+	// earlier failure.
+	SkipReason string
+}
+
+// Status describes the outcome of a single step.
+type Status rune
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=Status runner.go
+
+const (
+	// StepPassed means the step ran to completion and all of its
+	// assertions held.
+	StepPassed Status = 'P'
+
+	// StepFailed means the step produced at least one error.
+	StepFailed Status = 'F'
+
+	// StepSkipped means the step never ran, either because an earlier
+	// step in the same scenario failed or because the step's own skip
+	// condition held.
+	StepSkipped Status = 'S'
+)
+
+// Run executes the given scenarios and returns their results in the same
+// order. Scenarios run concurrently up to the runner's parallelism, since
+// each one carries its own state and cannot observe the others. Within a
+// scenario steps run in declaration order, except that an unbroken run of
+// steps marked parallel = true is executed concurrently; execution stops
+// at the first step that produces an error. Setup steps run first,
+// cleanup steps run last in reverse order even after a failure, and the
+// main steps run in between.
+//
+// Each scenario's diagnostics are collected into its own result, so
+// output remains coherent per scenario no matter how the work was
+// interleaved.
+func (r *Runner) Run(scenarios []*Scenario) []*ScenarioResult {
+	parallelism := r.Parallelism
+	if parallelism < 1 {
+		parallelism = DefaultParallelism
+	}
+
+	byName := make(map[string]int, len(scenarios))
+	for i, scenario := range scenarios {
+		byName[scenario.Name] = i
+	}
+	invalid := validateDependencies(scenarios, byName)
+
+	results := make([]*ScenarioResult, len(scenarios))
+	done := make([]chan struct{}, len(scenarios))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, scenario := range scenarios {
+		wg.Add(1)
+		go func(i int, scenario *Scenario) {
+			defer wg.Done()
+			defer close(done[i])
+
+			// A scenario with a recorded result does not run at all: the
+			// earlier run's result stands in for it.
+			if recorded, exists := r.Recorded[scenario.Name]; exists {
+				results[i] = recorded
+				return
+			}
+
+			if diags, isInvalid := invalid[i]; isInvalid {
+				results[i] = &ScenarioResult{Scenario: scenario, Diagnostics: diags}
+				return
+			}
+
+			// Dependencies must finish before we take a semaphore slot,
+			// so a waiting dependent cannot starve the scenario it waits
+			// for.
+			deps := make(map[string]*ScenarioResult, len(scenario.DependsOn))
+			for _, name := range scenario.DependsOn {
+				j := byName[name]
+				<-done[j]
+				dep := results[j]
+				if dep.Skipped || dep.Diagnostics.HasErrors() {
+					reason := fmt.Sprintf("dependency scenario %q did not succeed", name)
+					result := &ScenarioResult{
+						Scenario:   scenario,
+						Skipped:    true,
+						SkipReason: reason,
+					}
+					for _, step := range scenario.Steps {
+						result.Steps = append(result.Steps, &StepStatus{
+							Step:       step,
+							Status:     StepSkipped,
+							SkipReason: reason,
+						})
+					}
+					results[i] = result
+					return
+				}
+				deps[name] = dep
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = r.runScenario(scenario, deps)
+		}(i, scenario)
+	}
+	wg.Wait()
+	return results
+}
+
+// validateDependencies finds the scenarios whose depends_on lists cannot
+// be satisfied — unknown scenario names, self-references, and dependency
+// cycles — and returns the diagnostics to report for each, keyed by
+// scenario index. Their dependents are then skipped like the dependents
+// of any other failed scenario.
+func validateDependencies(scenarios []*Scenario, byName map[string]int) map[int]tfdiags.Diagnostics {
+	invalid := make(map[int]tfdiags.Diagnostics)
+
+	for i, scenario := range scenarios {
+		var diags tfdiags.Diagnostics
+		for _, name := range scenario.DependsOn {
+			if _, exists := byName[name]; !exists {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Unknown scenario dependency",
+					fmt.Sprintf("Scenario %q depends on scenario %q, but there is no scenario of that name in the suite.", scenario.Name, name),
+				))
+			}
+		}
+		if diags.HasErrors() {
+			invalid[i] = diags
+		}
+	}
+
+	// A depth-first walk finds cycles among the remaining scenarios.
+	const (
+		unvisited = 0
+		visiting  = 1
+		finished  = 2
+	)
+	state := make([]int, len(scenarios))
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		switch state[i] {
+		case visiting:
+			return false
+		case finished:
+			return true
+		}
+		state[i] = visiting
+		for _, name := range scenarios[i].DependsOn {
+			j, exists := byName[name]
+			if !exists {
+				continue
+			}
+			if !visit(j) {
+				var diags tfdiags.Diagnostics
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Scenario dependency cycle",
+					fmt.Sprintf("Scenario %q is part of a dependency cycle through scenario %q, so neither can run.", scenarios[i].Name, name),
+				))
+				invalid[i] = diags
+			}
+		}
+		state[i] = finished
+		return invalid[i] == nil
+	}
+	for i := range scenarios {
+		visit(i)
+	}
+
+	return invalid
+}
+
+// stepPhase is Step.Phase with the zero value normalized to PhaseMain,
+// so that steps constructed directly rather than by the loader behave
+// like ordinary ones.
+func stepPhase(step *Step) string {
+	if step.Phase == "" {
+		return PhaseMain
+	}
+	return step.Phase
+}
+
+func (r *Runner) runScenario(scenario *Scenario, deps map[string]*ScenarioResult) *ScenarioResult {
+	result := &ScenarioResult{
+		Scenario:    scenario,
+		StepResults: make(map[string]*StepResult),
+	}
+
+	// The outputs of each dependency scenario appear in the results map
+	// under the dependency's name, as if it were a prior step, so
+	// expressions can refer to step.<dependency>.outputs.<name>. The
+	// merged value is a copy: a dependent cannot write back into its
+	// fixture.
+	for name, dep := range deps {
+		merged := make(map[string]cty.Value)
+		for _, status := range dep.Steps {
+			stepResult, exists := dep.StepResults[status.Step.Name]
+			if !exists {
+				continue
+			}
+			for outName, val := range stepResult.Outputs {
+				merged[outName] = val
+			}
+		}
+		result.StepResults[name] = &StepResult{Outputs: merged}
+	}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	// A scenario whose skip condition holds reports all of its steps as
+	// skipped without running any of them, cleanup included: nothing was
+	// created, so there is nothing to tear down.
+	if skip, reason, diags := scenario.ShouldSkip(); skip || diags.HasErrors() {
+		result.Diagnostics = result.Diagnostics.Append(diags)
+		if skip {
+			result.Skipped = true
+			result.SkipReason = reason
+			for _, step := range scenario.Steps {
+				result.Steps = append(result.Steps, &StepStatus{
+					Step:       step,
+					Status:     StepSkipped,
+					SkipReason: reason,
+				})
+			}
+		}
+		return result
+	}
+
+	// Setup steps run before the main steps regardless of where they
+	// were declared, and cleanup steps are held back until the end.
+	var steps, cleanup []*Step
+	for _, phase := range []string{PhaseSetup, PhaseMain} {
+		for _, step := range scenario.Steps {
+			if stepPhase(step) == phase {
+				steps = append(steps, step)
+			}
+		}
+	}
+	for _, step := range scenario.Steps {
+		if stepPhase(step) == PhaseCleanup {
+			cleanup = append(cleanup, step)
+		}
+	}
+
+	for len(steps) > 0 {
+		// Take the next group of steps to run together: either a single
+		// sequential step, or an unbroken run of parallel ones.
+		group := steps[:1]
+		if steps[0].Parallel {
+			n := 1
+			for n < len(steps) && steps[n].Parallel {
+				n++
+			}
+			group = steps[:n]
+		}
+		steps = steps[len(group):]
+
+		stepResults := make([]*StepResult, len(group))
+		statuses := make([]*StepStatus, len(group))
+		var wg sync.WaitGroup
+		for i, step := range group {
+			wg.Add(1)
+			go func(i int, step *Step) {
+				defer wg.Done()
+				statuses[i], stepResults[i] = r.executeStep(scenario, step, result.StepResults)
+			}(i, step)
+		}
+		wg.Wait()
+
+		failed := false
+		for i, step := range group {
+			result.Steps = append(result.Steps, statuses[i])
+			result.Diagnostics = result.Diagnostics.Append(statuses[i].Diagnostics)
+			if statuses[i].Status == StepFailed {
+				failed = true
+				continue
+			}
+			if stepResults[i] != nil {
+				result.StepResults[step.Name] = stepResults[i]
+			}
+		}
+		if failed {
+			// The remaining steps never run, but still appear in the
+			// report as skipped.
+			for _, step := range steps {
+				result.Steps = append(result.Steps, &StepStatus{
+					Step:   step,
+					Status: StepSkipped,
+				})
+			}
+			break
+		}
+	}
+
+	// When the scenario failed and preservation is on, the cleanup
+	// steps are skipped so the resources stay around for inspection.
+	if result.Diagnostics.HasErrors() && (r.PreserveOnFailure || scenario.PreserveOnFailure) {
+		result.Preserved = true
+		for i := len(cleanup) - 1; i >= 0; i-- {
+			result.Steps = append(result.Steps, &StepStatus{
+				Step:   cleanup[i],
+				Status: StepSkipped,
+			})
+		}
+		return result
+	}
+
+	// Cleanup steps always run, in reverse declaration order, even when
+	// a setup or main step failed, and a failing cleanup step does not
+	// keep the remaining ones from running: fixtures like buckets and
+	// service accounts need teardown to be reliable.
+	for i := len(cleanup) - 1; i >= 0; i-- {
+		status, stepResult := r.executeStep(scenario, cleanup[i], result.StepResults)
+		if stepResult != nil {
+			result.StepResults[cleanup[i].Name] = stepResult
+		}
+		result.Steps = append(result.Steps, status)
+		result.Diagnostics = result.Diagnostics.Append(status.Diagnostics)
+	}
+
+	return result
+}
+
+// executeStep runs one step, enforcing its timeout and retry policy, and
+// returns its status along with the result of the successful attempt, if
+// any. When a retry attempt succeeds, the diagnostics of the earlier
+// failed attempts are discarded so the scenario is not marked as failed
+// by a flake it recovered from.
+func (r *Runner) executeStep(scenario *Scenario, step *Step, results map[string]*StepResult) (*StepStatus, *StepResult) {
+	// A step whose own skip condition holds is reported as skipped and
+	// does not keep the steps after it from running.
+	if skip, reason, diags := scenario.StepShouldSkip(step); skip || diags.HasErrors() {
+		status := &StepStatus{Step: step, Diagnostics: diags}
+		if diags.HasErrors() {
+			status.Status = StepFailed
+		} else {
+			status.Status = StepSkipped
+			status.SkipReason = reason
+		}
+		r.stepFinished(scenario, status)
+		return status, nil
+	}
+
+	attempts := 1
+	if step.Retry != nil {
+		attempts += step.Retry.Attempts
+	}
+
+	status := &StepStatus{Step: step}
+	r.stepStarted(scenario, step)
+	start := time.Now()
+	defer func() {
+		status.Duration = time.Since(start)
+		r.stepFinished(scenario, status)
+	}()
+
+	for {
+		status.Attempts++
+		stepResult, diags := r.runStepOnce(scenario, step, results)
+		status.Diagnostics = diags
+		if !diags.HasErrors() {
+			status.Status = StepPassed
+			if stepResult != nil {
+				status.Stats = stepResult.Stats
+				status.Artifacts = stepResult.Artifacts
+			}
+			return status, stepResult
+		}
+		if status.Attempts >= attempts || !retryableFailure(step, diags) {
+			status.Status = StepFailed
+			return status, nil
+		}
+	}
+}
+
+// runStepOnce performs a single attempt of a step, imposing the step's
+// timeout if it has one. A timed-out attempt has its context cancelled
+// and reports a "Step timed out" error diagnostic instead of its result,
+// but only once the cancelled operation has actually returned: the
+// attempt holds the step's state lock until then, and a retry starting
+// while the old attempt was still unwinding would collide with it.
+func (r *Runner) runStepOnce(scenario *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+	ctx := context.Background()
+	if step.Timeout <= 0 {
+		return r.RunStep(ctx, scenario, step, results)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		result *StepResult
+		diags  tfdiags.Diagnostics
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, diags := r.RunStep(ctx, scenario, step, results)
+		done <- outcome{result, diags}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.diags
+	case <-ctx.Done():
+		// The cancelled attempt still owns the step's state lock and may
+		// still write state while it unwinds, so block here until it has
+		// returned before reporting the timeout and allowing a retry.
+		<-done
+		var diags tfdiags.Diagnostics
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Step timed out",
+			fmt.Sprintf("Step %q did not complete within its timeout of %s.", step.Name, step.Timeout),
+		))
+		return nil, diags
+	}
+}
+
+// retryableFailure reports whether the given failure is one the step's
+// retry policy covers. With no "on" restriction any failure is
+// retryable; otherwise the failure's kinds — "timeout" for a timed-out
+// attempt, "assert_error" for failed assertions, and "plan_error" or
+// "apply_error" for everything else depending on the step's command —
+// must intersect the policy.
+func retryableFailure(step *Step, diags tfdiags.Diagnostics) bool {
+	if step.Retry == nil {
+		return false
+	}
+	if len(step.Retry.On) == 0 {
+		return true
+	}
+
+	covered := func(kind string) bool {
+		for _, want := range step.Retry.On {
+			if want == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, diag := range diags {
+		if diag.Severity() != tfdiags.Error {
+			continue
+		}
+		kind := "apply_error"
+		if step.Command == CommandPlan {
+			kind = "plan_error"
+		}
+		summary := diag.Description().Summary
+		switch {
+		case summary == "Step timed out":
+			kind = "timeout"
+		case strings.HasPrefix(summary, "Assertion failed"):
+			kind = "assert_error"
+		}
+		if covered(kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package scenarios
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestRunnerOrdering(t *testing.T) {
+	scenario := &Scenario{
+		Name: "ordering",
+		Steps: []*Step{
+			{Name: "first"},
+			{Name: "second"},
+		},
+	}
+
+	var mu sync.Mutex
+	var order []string
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			mu.Lock()
+			order = append(order, step.Name)
+			mu.Unlock()
+			if step.Name == "second" {
+				// The second step must see the first step's result.
+				if _, ok := results["first"]; !ok {
+					t.Errorf("second step cannot see result of first")
+				}
+			}
+			return &StepResult{
+				Outputs: map[string]cty.Value{"name": cty.StringVal(step.Name)},
+			}, nil
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	if len(results) != 1 {
+		t.Fatalf("wrong number of results %d; want 1", len(results))
+	}
+	if diags := results[0].Diagnostics; diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if got, want := fmt.Sprintf("%v", order), "[first second]"; got != want {
+		t.Errorf("steps ran in wrong order %s; want %s", got, want)
+	}
+	if len(results[0].StepResults) != 2 {
+		t.Errorf("wrong number of step results %d; want 2", len(results[0].StepResults))
+	}
+}
+
+func TestRunnerParallelSteps(t *testing.T) {
+	scenario := &Scenario{
+		Name: "parallel",
+		Steps: []*Step{
+			{Name: "a", Parallel: true},
+			{Name: "b", Parallel: true},
+			{Name: "last"},
+		},
+	}
+
+	// Steps a and b rendezvous with each other, so the test deadlocks
+	// unless the runner really runs them concurrently.
+	rendezvous := make(chan struct{}, 2)
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			if step.Parallel {
+				rendezvous <- struct{}{}
+				<-rendezvous
+			} else {
+				// The sequential step runs only after both parallel
+				// steps are done.
+				if len(results) != 2 {
+					t.Errorf("final step saw %d results; want 2", len(results))
+				}
+			}
+			return &StepResult{}, nil
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	if diags := results[0].Diagnostics; diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+}
+
+func TestRunnerStopsOnError(t *testing.T) {
+	scenario := &Scenario{
+		Name: "failing",
+		Steps: []*Step{
+			{Name: "boom"},
+			{Name: "never"},
+		},
+	}
+
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			var diags tfdiags.Diagnostics
+			if step.Name == "boom" {
+				diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "step failed", ""))
+				return nil, diags
+			}
+			t.Errorf("step %q ran after a failed step", step.Name)
+			return &StepResult{}, diags
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	if diags := results[0].Diagnostics; !diags.HasErrors() {
+		t.Fatalf("no error diagnostics from failed step")
+	}
+	if len(results[0].StepResults) != 0 {
+		t.Errorf("unexpected step results %v", results[0].StepResults)
+	}
+}
+
+func TestRunnerCleanupPhase(t *testing.T) {
+	scenario := &Scenario{
+		Name: "phases",
+		Steps: []*Step{
+			{Name: "teardown_bucket", Phase: PhaseCleanup},
+			{Name: "fixture", Phase: PhaseSetup},
+			{Name: "boom", Phase: PhaseMain},
+			{Name: "teardown_account", Phase: PhaseCleanup},
+		},
+	}
+
+	var mu sync.Mutex
+	var order []string
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			mu.Lock()
+			order = append(order, step.Name)
+			mu.Unlock()
+			var diags tfdiags.Diagnostics
+			if step.Name == "boom" {
+				diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "step failed", ""))
+				return nil, diags
+			}
+			return &StepResult{}, diags
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	if diags := results[0].Diagnostics; !diags.HasErrors() {
+		t.Fatalf("no error diagnostics from failed step")
+	}
+
+	// Setup runs first even though it was declared second, and both
+	// cleanup steps still run after the failure, in reverse order.
+	want := "[fixture boom teardown_account teardown_bucket]"
+	if got := fmt.Sprintf("%v", order); got != want {
+		t.Errorf("steps ran in wrong order %s; want %s", got, want)
+	}
+}
+
+func TestRunnerRetry(t *testing.T) {
+	scenario := &Scenario{
+		Name: "flaky",
+		Steps: []*Step{
+			{Name: "apply", Retry: &Retry{Attempts: 2}},
+		},
+	}
+
+	calls := 0
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			calls++
+			var diags tfdiags.Diagnostics
+			if calls < 3 {
+				diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "rate limited", ""))
+				return nil, diags
+			}
+			return &StepResult{}, diags
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	if diags := results[0].Diagnostics; diags.HasErrors() {
+		t.Fatalf("unexpected errors after successful retry: %s", diags.Err())
+	}
+	if got, want := results[0].Steps[0].Attempts, 3; got != want {
+		t.Errorf("wrong number of attempts %d; want %d", got, want)
+	}
+	if got, want := results[0].Steps[0].Status, StepPassed; got != want {
+		t.Errorf("wrong status %s; want %s", got, want)
+	}
+
+	// With the retry policy limited to timeouts, the same failure is
+	// final on the first attempt.
+	scenario.Steps[0].Retry.On = []string{"timeout"}
+	calls = 0
+	results = runner.Run([]*Scenario{scenario})
+	if diags := results[0].Diagnostics; !diags.HasErrors() {
+		t.Fatalf("no error diagnostics for non-retryable failure")
+	}
+	if got, want := results[0].Steps[0].Attempts, 1; got != want {
+		t.Errorf("wrong number of attempts %d; want %d", got, want)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	scenario := &Scenario{
+		Name: "slow",
+		Steps: []*Step{
+			{Name: "apply", Timeout: 10 * time.Millisecond},
+		},
+	}
+
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			<-ctx.Done()
+			return &StepResult{}, nil
+		},
+	}
+
+	results := runner.Run([]*Scenario{scenario})
+	diags := results[0].Diagnostics
+	if !diags.HasErrors() {
+		t.Fatalf("no error diagnostics from timed-out step")
+	}
+	if got, want := diags[0].Description().Summary, "Step timed out"; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+}
+
+func TestRunnerScenarioParallelism(t *testing.T) {
+	var scenarios []*Scenario
+	for i := 0; i < 4; i++ {
+		scenarios = append(scenarios, &Scenario{
+			Name:  fmt.Sprintf("s%d", i),
+			Steps: []*Step{{Name: "only"}},
+		})
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	runner := &Runner{
+		Parallelism: 2,
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+			return &StepResult{}, nil
+		},
+	}
+
+	results := runner.Run(scenarios)
+	if len(results) != 4 {
+		t.Fatalf("wrong number of results %d; want 4", len(results))
+	}
+	for i, result := range results {
+		if got, want := result.Scenario.Name, scenarios[i].Name; got != want {
+			t.Errorf("result %d is for scenario %q; want %q", i, got, want)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("%d scenarios ran at once; parallelism is 2", peak)
+	}
+}
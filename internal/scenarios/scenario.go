@@ -0,0 +1,317 @@
+package scenarios
+
+import (
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/providermocks"
+	"github.com/hashicorp/terraform/states"
+)
+
+// Scenario is one test scenario: an ordered sequence of steps to run
+// against a configuration, loaded from a single scenario file.
+type Scenario struct {
+	// Name is the scenario's name, derived from its filename with the
+	// scenario suffix removed.
+	Name string
+
+	// SourceFile is the path of the file the scenario was loaded from,
+	// for use in diagnostics.
+	SourceFile string
+
+	// Steps are the scenario's steps, in the order they are declared.
+	Steps []*Step
+
+	// Tags are free-form labels from the scenario's "tags" attribute,
+	// used to select subsets of a suite on the command line.
+	Tags []string
+
+	// PreserveOnFailure keeps the scenario's resources and state when a
+	// step fails, skipping the cleanup steps, so the failure can be
+	// inspected. The -no-cleanup-on-failure option does the same for
+	// every scenario in a run.
+	PreserveOnFailure bool
+
+	// VerifyCleanup inspects the scenario's states after its cleanup
+	// steps have run and fails the scenario if any resource instances
+	// are still recorded, reporting the leaked addresses. See
+	// CheckCleanup. Leaked test infrastructure otherwise accrues cost
+	// silently.
+	VerifyCleanup bool
+
+	// Env gives environment variables to set for the provider
+	// subprocesses launched for this scenario, so one suite can test
+	// region or credential permutations without separate CI jobs. The
+	// variables apply only to this scenario's processes, not to
+	// Terraform itself.
+	Env map[string]string
+
+	// RequiredVersion is an optional version constraint on the Terraform
+	// CLI running the scenario, and RequiredFeatures optionally names the
+	// test runner features the scenario depends on. CheckCompatibility
+	// turns an unsatisfied requirement into one clear error before
+	// anything runs.
+	RequiredVersion       string
+	RequiredVersionRange  hcl.Range
+	RequiredFeatures      []string
+	RequiredFeaturesRange hcl.Range
+
+	// DependsOn names fixture scenarios in the same suite whose resources
+	// this scenario builds on, from a depends_on list of scenario.<name>
+	// references. A dependency runs once per suite before its dependents,
+	// and its steps' outputs are available to them read-only under the
+	// dependency's name, so expensive fixtures like a base network are
+	// not re-created for every scenario.
+	DependsOn []string
+
+	// Skip is an optional condition that, when true, reports the whole
+	// scenario as skipped instead of running it. SkipReason optionally
+	// explains why in the reports. The expressions are evaluated at run
+	// time against the execution environment; see evalSkip.
+	Skip       hcl.Expression
+	SkipReason hcl.Expression
+
+	// Variables are the input variable values from the scenario's
+	// top-level "variables" block, as unevaluated expressions. They apply
+	// to every step unless overridden by the step's own Variables.
+	Variables map[string]hcl.Expression
+
+	// MockProviders are the scenario's mock_provider blocks, keyed by the
+	// provider's local name. Steps run against these mocks instead of the
+	// real providers they stand in for.
+	MockProviders map[string]*providermocks.MockProvider
+
+	// Overrides are the scenario's override_resource and override_module
+	// blocks, which replace individual resources or module calls with
+	// static values while everything else runs for real.
+	Overrides []*Override
+}
+
+// Override replaces one resource or one module call with static values
+// for the duration of the scenario, without mocking the whole provider.
+type Override struct {
+	// Target is the address of the resource or module call to replace.
+	Target addrs.Targetable
+
+	// Values are the attribute values the overridden resource reports,
+	// or for a module call the output values it returns. Anything not
+	// given here is left null.
+	Values cty.Value
+
+	DeclRange hcl.Range
+}
+
+// Step is a single step within a scenario. Each step performs one
+// Terraform operation against the configuration under test.
+type Step struct {
+	Name string
+
+	// Module optionally gives the directory of the configuration this
+	// step operates on, relative to the directory containing the
+	// scenario file, set either by the module attribute or by the
+	// source argument of a module block. An empty string means the
+	// configuration under test. Fixture and helper modules named here
+	// let a step seed infrastructure that the module under test needs.
+	Module string
+
+	// Runbook optionally names a runbook for the step to execute instead
+	// of a Terraform operation. The results of the runbook's actions
+	// become the step's outputs, keyed by runbook step name, so a
+	// scenario can assert on what an operational procedure did. A runbook
+	// step has no module or command of its own.
+	Runbook string
+
+	// Providers maps provider configuration names inside the step's
+	// module to provider configurations of the suite, like the
+	// providers argument of a module call. When empty, the suite's
+	// default provider configurations pass through under their own
+	// names.
+	Providers map[string]string
+
+	// Variables are input variable values from the step's own "variables"
+	// block, which override any scenario-level values of the same name.
+	// The expressions are not evaluated until the step runs, so they may
+	// refer to the outputs of prior steps.
+	Variables map[string]hcl.Expression
+
+	// Command is the Terraform operation the step performs: CommandPlan
+	// creates a plan without applying it, while CommandApply creates and
+	// then applies one. Steps apply by default.
+	Command string
+
+	// State controls which state the step operates on: StateInherit
+	// chains the state left behind by the steps before it, StateFresh
+	// starts from an empty state private to the step, and any other
+	// value names a state shared with exactly the other steps naming
+	// it. Steps inherit by default.
+	State string
+
+	// Phase assigns the step to a phase of the scenario: setup steps run
+	// before the main steps, and cleanup steps run last, in reverse
+	// declaration order, even when an earlier step has failed. Most
+	// steps are in PhaseMain.
+	Phase string
+
+	// Parallel marks a step as safe to run concurrently with adjacent
+	// parallel steps. Only steps with disjoint state should set this;
+	// a parallel step cannot refer to the outputs of the other steps
+	// running alongside it.
+	Parallel bool
+
+	// PlanOptions customizes how the step's plan is created. It is nil
+	// when the step has no plan_options block, meaning a normal plan with
+	// refreshing enabled and no targets.
+	PlanOptions *PlanOptions
+
+	// Asserts are the step's assertions, checked in order after the step
+	// has run.
+	Asserts []*Assert
+
+	// Timeout bounds how long the step may run before the runner cancels
+	// it and records a failure. Zero means no limit.
+	Timeout time.Duration
+
+	// Retry lets a failed step run again, for riding out flaky cloud
+	// APIs. Nil means a failure is final on the first attempt.
+	Retry *Retry
+
+	// Env gives environment variables for the provider subprocesses of
+	// this step only, overriding the scenario's Env name by name.
+	Env map[string]string
+
+	// Skip and SkipReason are the step's own skip condition, like the
+	// scenario-level attributes of the same names. A skipped step does
+	// not keep the steps after it from running.
+	Skip       hcl.Expression
+	SkipReason hcl.Expression
+
+	// Snapshot optionally names a golden file, relative to the directory
+	// containing the scenario file, holding the plan JSON this step is
+	// expected to produce. After the step runs, its plan is normalized
+	// and compared against the file; the -update-snapshots option
+	// rewrites the file from the current plan instead.
+	Snapshot string
+
+	// Artifacts are glob patterns, relative to the directory containing
+	// the scenario file, naming files the step is expected to produce —
+	// e.g. rendered templates. Matched files are copied into the run's
+	// artifacts directory after the step, so they can be inspected once
+	// the run is over; see Scenario.CollectArtifacts.
+	Artifacts []string
+
+	DeclRange hcl.Range
+}
+
+// Retry is a step's retry policy.
+type Retry struct {
+	// Attempts is how many more times to try the step after its first
+	// failure.
+	Attempts int
+
+	// On restricts which kinds of failure are retried: "plan_error",
+	// "apply_error", "assert_error", or "timeout". Empty retries any
+	// failure.
+	On []string
+}
+
+// The allowed values for Step.Command.
+const (
+	CommandPlan  = "plan"
+	CommandApply = "apply"
+)
+
+// The allowed values for Step.Phase.
+const (
+	PhaseSetup   = "setup"
+	PhaseMain    = "main"
+	PhaseCleanup = "cleanup"
+)
+
+// The predefined values for Step.State. Any other valid identifier names
+// a shared state instead.
+const (
+	StateInherit = "inherit"
+	StateFresh   = "fresh"
+)
+
+// StepEnv returns the environment variables to set for the provider
+// subprocesses of the given step, merging the scenario's env with the
+// step's own. Step-level entries override scenario-level ones of the
+// same name.
+func (s *Scenario) StepEnv(step *Step) map[string]string {
+	if len(s.Env) == 0 && len(step.Env) == 0 {
+		return nil
+	}
+
+	ret := make(map[string]string, len(s.Env)+len(step.Env))
+	for name, value := range s.Env {
+		ret[name] = value
+	}
+	for name, value := range step.Env {
+		ret[name] = value
+	}
+	return ret
+}
+
+// StateKey returns the identity of the state the step operates on. Two
+// steps with the same key share state: inheriting steps all continue the
+// scenario's default state, a fresh step gets a state of its own, and a
+// named state belongs to exactly the steps naming it.
+func (s *Step) StateKey() string {
+	switch s.State {
+	case "", StateInherit:
+		return "default"
+	case StateFresh:
+		return "fresh:" + s.Name
+	default:
+		return "named:" + s.State
+	}
+}
+
+// PlanOptions are the settings from a step's plan_options block.
+type PlanOptions struct {
+	// Mode is the planning mode, either ModeNormal or ModeRefreshOnly.
+	Mode string
+
+	// Refresh is false if the step should skip refreshing prior state
+	// before planning.
+	Refresh bool
+
+	// Targets optionally restricts the plan to only the given resources
+	// and their dependencies, like the -target command line option.
+	Targets []addrs.Targetable
+}
+
+// The allowed values for PlanOptions.Mode.
+const (
+	ModeNormal      = "normal"
+	ModeRefreshOnly = "refresh-only"
+)
+
+// StepResult records the outcome of a step that has already run, for use
+// when evaluating expressions in later steps of the same scenario.
+type StepResult struct {
+	// Outputs are the root module output values after the step ran.
+	Outputs map[string]cty.Value
+
+	// PlanJSON is the step's plan rendered in the external JSON plan
+	// format, as produced by jsonplan.Marshal. Assertion expressions
+	// inspect it through the "plan" symbol.
+	PlanJSON []byte
+
+	// State is a snapshot of the state the step left behind, exposed
+	// read-only to later steps' expressions as step.<name>.state.
+	State *states.State
+
+	// Stats are the step's resource-level costs, if the step function
+	// recorded them.
+	Stats *StepStats
+
+	// Artifacts are the paths of the files collected for the step's
+	// artifact patterns, relative to the process working directory, for
+	// the run reports to point at.
+	Artifacts []string
+}
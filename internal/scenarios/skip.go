@@ -0,0 +1,114 @@
+package scenarios
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ShouldSkip evaluates the scenario's skip condition, returning whether
+// the whole scenario should be reported as skipped and the reason given
+// by its skip_reason attribute, if any.
+func (s *Scenario) ShouldSkip() (bool, string, tfdiags.Diagnostics) {
+	return evalSkip(s.Skip, s.SkipReason)
+}
+
+// StepShouldSkip is like ShouldSkip but for the given step's own skip
+// condition. A skipped step does not stop the steps after it.
+func (s *Scenario) StepShouldSkip(step *Step) (bool, string, tfdiags.Diagnostics) {
+	return evalSkip(step.Skip, step.SkipReason)
+}
+
+// evalSkip evaluates a skip condition and its reason against the ambient
+// execution environment. The expressions can refer to "env", an object of
+// the process environment variables, and "platform", an object with the
+// running os and arch, so suites shared across teams can degrade
+// gracefully when a credential is absent or a platform is unsupported.
+func evalSkip(skip, reason hcl.Expression) (bool, string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if skip == nil {
+		return false, "", diags
+	}
+
+	ctx := skipEvalContext()
+
+	val, hclDiags := skip.Value(ctx)
+	diags = diags.Append(hclDiags)
+	if hclDiags.HasErrors() {
+		return false, "", diags
+	}
+	val, err := convert.Convert(val, cty.Bool)
+	if err != nil || val.IsNull() {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid skip condition",
+			Detail:   "The skip expression must produce a boolean value.",
+			Subject:  skip.Range().Ptr(),
+		})
+		return false, "", diags
+	}
+	if val.False() {
+		return false, "", diags
+	}
+
+	reasonStr := "skip condition was true"
+	if reason != nil {
+		reasonVal, hclDiags := reason.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			reasonVal, err := convert.Convert(reasonVal, cty.String)
+			if err != nil || reasonVal.IsNull() {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid skip reason",
+					Detail:   "The skip_reason expression must produce a string.",
+					Subject:  reason.Range().Ptr(),
+				})
+			} else {
+				reasonStr = reasonVal.AsString()
+			}
+		}
+	}
+	return true, reasonStr, diags
+}
+
+// skipEvalContext builds the evaluation context for skip conditions.
+func skipEvalContext() *hcl.EvalContext {
+	envVars := make(map[string]cty.Value)
+	for _, entry := range os.Environ() {
+		eq := strings.Index(entry, "=")
+		if eq < 1 {
+			continue
+		}
+		envVars[entry[:eq]] = cty.StringVal(entry[eq+1:])
+	}
+	env := cty.EmptyObjectVal
+	if len(envVars) > 0 {
+		env = cty.ObjectVal(envVars)
+	}
+
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"env": env,
+			"platform": cty.ObjectVal(map[string]cty.Value{
+				"os":   cty.StringVal(runtime.GOOS),
+				"arch": cty.StringVal(runtime.GOARCH),
+			}),
+		},
+		// can() and try() let a condition probe for environment
+		// variables that may be absent, like
+		// skip = !can(env.AWS_ACCESS_KEY_ID).
+		Functions: map[string]function.Function{
+			"can": tryfunc.CanFunc,
+			"try": tryfunc.TryFunc,
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package scenarios
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func parseSkipExpr(t *testing.T, src string) hcl.Expression {
+	t.Helper()
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid test expression %q: %s", src, diags.Error())
+	}
+	return expr
+}
+
+func TestShouldSkip(t *testing.T) {
+	os.Setenv("TF_SCENARIO_TEST_PRESENT", "yes")
+	defer os.Unsetenv("TF_SCENARIO_TEST_PRESENT")
+
+	scenario := &Scenario{Name: "conditional"}
+
+	// No condition means no skipping.
+	if skip, _, diags := scenario.ShouldSkip(); skip || diags.HasErrors() {
+		t.Fatalf("unexpected skip or errors with no condition: %s", diags.Err())
+	}
+
+	// Conditions can probe the environment with can().
+	scenario.Skip = parseSkipExpr(t, `!can(env.TF_SCENARIO_TEST_PRESENT)`)
+	if skip, _, diags := scenario.ShouldSkip(); skip || diags.HasErrors() {
+		t.Fatalf("skipped despite present variable: %s", diags.Err())
+	}
+	scenario.Skip = parseSkipExpr(t, `!can(env.TF_SCENARIO_TEST_ABSENT)`)
+	scenario.SkipReason = parseSkipExpr(t, `"credentials not available"`)
+	skip, reason, diags := scenario.ShouldSkip()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if !skip {
+		t.Fatalf("not skipped despite absent variable")
+	}
+	if got, want := reason, "credentials not available"; got != want {
+		t.Errorf("wrong reason %q; want %q", got, want)
+	}
+
+	// Platform conditions work too, and a non-boolean condition is an
+	// error rather than a silent pass.
+	scenario.Skip = parseSkipExpr(t, `platform.os == "plan9"`)
+	if skip, _, diags := scenario.ShouldSkip(); skip || diags.HasErrors() {
+		t.Fatalf("unexpected skip or errors for other platform: %s", diags.Err())
+	}
+	scenario.Skip = parseSkipExpr(t, `"not a bool at all"`)
+	if _, _, diags := scenario.ShouldSkip(); !diags.HasErrors() {
+		t.Errorf("no error for non-boolean condition")
+	}
+}
+
+func TestRunnerSkips(t *testing.T) {
+	scenario := &Scenario{
+		Name: "partial",
+		Steps: []*Step{
+			{Name: "first"},
+			{Name: "second", Skip: parseSkipExpr(t, `true`), SkipReason: parseSkipExpr(t, `"not today"`)},
+			{Name: "third"},
+		},
+	}
+	var ran []string
+	runner := &Runner{
+		RunStep: func(ctx context.Context, s *Scenario, step *Step, results map[string]*StepResult) (*StepResult, tfdiags.Diagnostics) {
+			ran = append(ran, step.Name)
+			return &StepResult{}, nil
+		},
+	}
+
+	// A skipped step does not stop the steps after it.
+	result := runner.Run([]*Scenario{scenario})[0]
+	if got, want := len(ran), 2; got != want {
+		t.Fatalf("wrong steps ran %#v; want first and third", ran)
+	}
+	second := result.Steps[1]
+	if second.Status != StepSkipped || second.SkipReason != "not today" {
+		t.Errorf("wrong status for skipped step: %#v", second)
+	}
+	if result.Diagnostics.HasErrors() {
+		t.Errorf("unexpected errors: %s", result.Diagnostics.Err())
+	}
+
+	// A scenario-level condition skips everything.
+	ran = nil
+	scenario.Skip = parseSkipExpr(t, `true`)
+	scenario.SkipReason = parseSkipExpr(t, `"entire scenario"`)
+	result = runner.Run([]*Scenario{scenario})[0]
+	if len(ran) != 0 {
+		t.Fatalf("steps ran for skipped scenario: %#v", ran)
+	}
+	if !result.Skipped || result.SkipReason != "entire scenario" {
+		t.Errorf("scenario not reported as skipped: %#v", result)
+	}
+	for _, status := range result.Steps {
+		if status.Status != StepSkipped {
+			t.Errorf("step %q not skipped", status.Step.Name)
+		}
+	}
+}
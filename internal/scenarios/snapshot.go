@@ -0,0 +1,165 @@
+package scenarios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// snapshotMask replaces the volatile fields of a plan in its snapshot, so
+// that golden files don't churn with every Terraform release.
+const snapshotMask = "(masked)"
+
+// snapshotVolatileFields are the plan JSON property names whose values
+// vary between runs or releases without the plan itself changing, masked
+// wherever they appear.
+var snapshotVolatileFields = map[string]bool{
+	"terraform_version": true,
+	"timestamp":         true,
+}
+
+// CheckSnapshot compares the plan produced by the given step against the
+// golden file named by the step's snapshot attribute, as a path relative
+// to baseDir. When update is true the golden file is written or rewritten
+// from the current plan instead, as requested by the -update-snapshots
+// command line option. Steps without a snapshot attribute always pass.
+func (s *Scenario) CheckSnapshot(step *Step, result *StepResult, baseDir string, update bool) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if step.Snapshot == "" {
+		return diags
+	}
+
+	got, err := normalizePlanJSON(result.PlanJSON)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			fmt.Sprintf("Invalid plan for step %q", step.Name),
+			fmt.Sprintf("The plan produced by step %q of scenario %q could not be prepared for snapshot comparison: %s.", step.Name, s.Name, err),
+		))
+		return diags
+	}
+
+	path := filepath.Join(baseDir, step.Snapshot)
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = ioutil.WriteFile(path, got, 0644)
+		}
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to update plan snapshot",
+				fmt.Sprintf("Terraform could not write the snapshot for step %q of scenario %q: %s.", step.Name, s.Name, err),
+			))
+		}
+		return diags
+	}
+
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Missing plan snapshot for step %q", step.Name),
+				fmt.Sprintf("Step %q of scenario %q expects a plan snapshot at %s, but there is no file there. Run again with -update-snapshots to create it.", step.Name, s.Name, path),
+			))
+			return diags
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read plan snapshot",
+			fmt.Sprintf("Terraform could not read the snapshot for step %q of scenario %q: %s.", step.Name, s.Name, err),
+		))
+		return diags
+	}
+
+	// The golden file normalizes again before comparing, so hand-edits
+	// and older formatting don't cause spurious mismatches.
+	want, err := normalizePlanJSON(golden)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid plan snapshot",
+			fmt.Sprintf("The snapshot file %s is not valid JSON: %s.", path, err),
+		))
+		return diags
+	}
+
+	if !bytes.Equal(got, want) {
+		detail := fmt.Sprintf("The plan produced by step %q of scenario %q no longer matches the snapshot at %s.", step.Name, s.Name, path)
+		if line, gotLine, wantLine := firstDiffLine(got, want); line > 0 {
+			detail += fmt.Sprintf("\n\nFirst difference at line %d:\n  got:  %s\n  want: %s", line, gotLine, wantLine)
+		}
+		detail += "\n\nIf the new plan is intended, run again with -update-snapshots to accept it."
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			fmt.Sprintf("Plan snapshot mismatch in step %q", step.Name),
+			detail,
+		))
+	}
+	return diags
+}
+
+// normalizePlanJSON rewrites the given plan JSON into a stable form for
+// snapshot comparison: object keys are sorted, the output is consistently
+// indented, and volatile fields are masked.
+func normalizePlanJSON(src []byte) ([]byte, error) {
+	var raw interface{}
+	if err := json.Unmarshal(src, &raw); err != nil {
+		return nil, err
+	}
+	maskVolatile(raw)
+
+	// encoding/json writes object properties in sorted key order, which
+	// together with the fixed indentation gives a canonical layout.
+	buf, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
+
+// maskVolatile replaces the values of volatile fields throughout the
+// given decoded JSON structure, in place.
+func maskVolatile(raw interface{}) {
+	switch raw := raw.(type) {
+	case map[string]interface{}:
+		for key, value := range raw {
+			if snapshotVolatileFields[key] {
+				raw[key] = snapshotMask
+				continue
+			}
+			maskVolatile(value)
+		}
+	case []interface{}:
+		for _, value := range raw {
+			maskVolatile(value)
+		}
+	}
+}
+
+// firstDiffLine returns the 1-based number of the first line where the
+// two normalized documents differ, along with both versions of that line.
+// It returns zero if the documents are equal.
+func firstDiffLine(got, want []byte) (int, string, string) {
+	gotLines := bytes.Split(got, []byte("\n"))
+	wantLines := bytes.Split(want, []byte("\n"))
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var gotLine, wantLine []byte
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if !bytes.Equal(gotLine, wantLine) {
+			return i + 1, string(gotLine), string(wantLine)
+		}
+	}
+	return 0, "", ""
+}
@@ -0,0 +1,71 @@
+package scenarios
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckSnapshot(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "tf-scenario-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	scenario := &Scenario{Name: "golden"}
+	step := &Step{
+		Name:     "plan",
+		Snapshot: "snapshots/plan.golden.json",
+	}
+	result := &StepResult{PlanJSON: []byte(planValueTestJSON)}
+
+	// With no golden file yet, the comparison fails and suggests
+	// -update-snapshots.
+	diags := scenario.CheckSnapshot(step, result, baseDir, false)
+	if !diags.HasErrors() {
+		t.Fatalf("unexpected success with no golden file")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, "-update-snapshots") {
+		t.Errorf("missing update hint in detail:\n%s", detail)
+	}
+
+	// Updating writes the golden file, after which the same plan passes.
+	if diags := scenario.CheckSnapshot(step, result, baseDir, true); diags.HasErrors() {
+		t.Fatalf("unexpected errors updating: %s", diags.Err())
+	}
+	if diags := scenario.CheckSnapshot(step, result, baseDir, false); diags.HasErrors() {
+		t.Fatalf("unexpected errors after update: %s", diags.Err())
+	}
+
+	// Volatile fields don't cause mismatches: snapshot one version and
+	// compare another.
+	versioned := func(v string) []byte {
+		return []byte(strings.Replace(planValueTestJSON, `"format_version": "0.1"`, `"format_version": "0.1", "terraform_version": "`+v+`"`, 1))
+	}
+	if diags := scenario.CheckSnapshot(step, &StepResult{PlanJSON: versioned("0.15.0")}, baseDir, true); diags.HasErrors() {
+		t.Fatalf("unexpected errors updating: %s", diags.Err())
+	}
+	if diags := scenario.CheckSnapshot(step, &StepResult{PlanJSON: versioned("0.15.99")}, baseDir, false); diags.HasErrors() {
+		t.Fatalf("unexpected errors for volatile change: %s", diags.Err())
+	}
+	if diags := scenario.CheckSnapshot(step, result, baseDir, true); diags.HasErrors() {
+		t.Fatalf("unexpected errors restoring snapshot: %s", diags.Err())
+	}
+
+	// A real change in the plan does.
+	changed := strings.Replace(planValueTestJSON, `"actions": ["update"]`, `"actions": ["delete"]`, 1)
+	diags = scenario.CheckSnapshot(step, &StepResult{PlanJSON: []byte(changed)}, baseDir, false)
+	if !diags.HasErrors() {
+		t.Fatalf("unexpected success for changed plan")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, "First difference at line") {
+		t.Errorf("missing difference context in detail:\n%s", detail)
+	}
+
+	// Steps without a snapshot attribute always pass.
+	if diags := scenario.CheckSnapshot(&Step{Name: "plain"}, result, baseDir, false); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics for step without snapshot: %s", diags.Err())
+	}
+}
@@ -0,0 +1,212 @@
+package scenarios
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/states"
+)
+
+// stateFunctions returns the helper functions available to a step's
+// assertion expressions for querying the state the step left behind:
+//
+//	state_resources("aws_instance")     all instances of the type
+//	output("endpoint")                  a root module output value
+//	resource_attr("aws_instance.web", "id")   one attribute of one instance
+//
+// Raw references into step.<name>.state work too, but fail with opaque
+// missing-attribute errors; these functions instead say precisely which
+// address or name does not exist and what is available.
+func stateFunctions(step *Step, result *StepResult) map[string]function.Function {
+	var state *states.State
+	if result != nil {
+		state = result.State
+	}
+
+	return map[string]function.Function{
+		"state_resources": stateResourcesFunc(step, state),
+		"output":          outputFunc(step, result),
+		"resource_attr":   resourceAttrFunc(step, state),
+	}
+}
+
+// stateResourcesFunc returns a function producing all instances of the
+// given managed resource or data source type as a tuple of attribute
+// objects, in address order. A type with no instances yields an empty
+// tuple, so assertions can check for absence too.
+func stateResourcesFunc(step *Step, state *states.State) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "type",
+				Type: cty.String,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if state == nil {
+				return cty.NilVal, stateMissingError(step)
+			}
+			typeName := args[0].AsString()
+
+			addrs, instances, err := stateInstances(state)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			var elems []cty.Value
+			for _, addr := range addrs {
+				inst := instances[addr]
+				if inst.rs.Addr.Resource.Type != typeName {
+					continue
+				}
+				elems = append(elems, inst.attrs)
+			}
+			if len(elems) == 0 {
+				return cty.EmptyTupleVal, nil
+			}
+			return cty.TupleVal(elems), nil
+		},
+	})
+}
+
+// outputFunc returns a function producing the value of one root module
+// output of the step, failing with the available names when it does not
+// exist.
+func outputFunc(step *Step, result *StepResult) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "name",
+				Type: cty.String,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := args[0].AsString()
+			if result == nil || len(result.Outputs) == 0 {
+				return cty.NilVal, fmt.Errorf("step %q produced no outputs", step.Name)
+			}
+			if val, ok := result.Outputs[name]; ok {
+				return val, nil
+			}
+			names := make([]string, 0, len(result.Outputs))
+			for n := range result.Outputs {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return cty.NilVal, fmt.Errorf("step %q has no output named %q; its outputs are %s", step.Name, name, quotedNames(names))
+		},
+	})
+}
+
+// resourceAttrFunc returns a function producing one attribute of one
+// resource instance, by address, failing precisely when either the
+// address or the attribute does not exist.
+func resourceAttrFunc(step *Step, state *states.State) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "addr",
+				Type: cty.String,
+			},
+			{
+				Name: "attr",
+				Type: cty.String,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if state == nil {
+				return cty.NilVal, stateMissingError(step)
+			}
+			addr := args[0].AsString()
+			attrName := args[1].AsString()
+
+			addrs, instances, err := stateInstances(state)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			inst, ok := instances[addr]
+			if !ok {
+				return cty.NilVal, fmt.Errorf("no resource %q in the state left by step %q; the state has %s", addr, step.Name, quotedNames(addrs))
+			}
+			if !inst.attrs.Type().HasAttribute(attrName) {
+				attrNames := make([]string, 0)
+				for name := range inst.attrs.Type().AttributeTypes() {
+					attrNames = append(attrNames, name)
+				}
+				sort.Strings(attrNames)
+				return cty.NilVal, fmt.Errorf("resource %q has no attribute %q; it has %s", addr, attrName, quotedNames(attrNames))
+			}
+			return inst.attrs.GetAttr(attrName), nil
+		},
+	})
+}
+
+type stateInstance struct {
+	rs    *states.Resource
+	attrs cty.Value
+}
+
+// stateInstances decodes every resource instance in the state into a cty
+// object, returning the instance addresses in sorted order along with a
+// lookup by address.
+func stateInstances(state *states.State) ([]string, map[string]stateInstance, error) {
+	instances := make(map[string]stateInstance)
+	for _, module := range state.Modules {
+		for _, rs := range module.Resources {
+			for key, inst := range rs.Instances {
+				if inst.Current == nil || len(inst.Current.AttrsJSON) == 0 {
+					continue
+				}
+				addr := rs.Addr.Instance(key).String()
+				ty, err := ctyjson.ImpliedType(inst.Current.AttrsJSON)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid attributes for %s: %s", addr, err)
+				}
+				attrs, err := ctyjson.Unmarshal(inst.Current.AttrsJSON, ty)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid attributes for %s: %s", addr, err)
+				}
+				instances[addr] = stateInstance{rs: rs, attrs: attrs}
+			}
+		}
+	}
+
+	addrs := make([]string, 0, len(instances))
+	for addr := range instances {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs, instances, nil
+}
+
+func stateMissingError(step *Step) error {
+	return fmt.Errorf("step %q left no state; only apply steps record one", step.Name)
+}
+
+// quotedNames formats a name list for an error message, quoting each
+// entry.
+func quotedNames(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	ret := ""
+	for i, name := range names {
+		if i > 0 {
+			ret += ", "
+		}
+		ret += fmt.Sprintf("%q", name)
+	}
+	return ret
+}
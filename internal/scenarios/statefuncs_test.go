@@ -0,0 +1,119 @@
+package scenarios
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestStateFunctions(t *testing.T) {
+	state := states.NewState()
+	root := state.EnsureModule(addrs.RootModuleInstance)
+	providerConfig := addrs.AbsProviderConfig{
+		Module:   addrs.RootModule,
+		Provider: addrs.NewDefaultProvider("aws"),
+	}
+	root.SetResourceInstanceCurrent(
+		addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: "web",
+		}.Instance(addrs.IntKey(0)),
+		&states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"id":"i-123","instance_type":"t2.micro"}`),
+		},
+		providerConfig,
+	)
+	root.SetResourceInstanceCurrent(
+		addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "aws_eip",
+			Name: "lb",
+		}.Instance(addrs.NoKey),
+		&states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"id":"eip-1"}`),
+		},
+		providerConfig,
+	)
+
+	step := &Step{Name: "apply"}
+	result := &StepResult{
+		State: state,
+		Outputs: map[string]cty.Value{
+			"endpoint": cty.StringVal("example.com"),
+		},
+	}
+	funcs := stateFunctions(step, result)
+
+	// state_resources returns all instances of a type, and an empty
+	// tuple for a type with none.
+	instances, err := funcs["state_resources"].Call([]cty.Value{cty.StringVal("aws_instance")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := instances.LengthInt(); got != 1 {
+		t.Fatalf("wrong number of aws_instance instances %d; want 1", got)
+	}
+	none, err := funcs["state_resources"].Call([]cty.Value{cty.StringVal("aws_lb")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := none.LengthInt(); got != 0 {
+		t.Errorf("wrong number of aws_lb instances %d; want 0", got)
+	}
+
+	// output returns a root output, and names the available outputs when
+	// asked for one that does not exist.
+	endpoint, err := funcs["output"].Call([]cty.Value{cty.StringVal("endpoint")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := cty.StringVal("example.com"); !endpoint.RawEquals(want) {
+		t.Errorf("wrong endpoint %#v; want %#v", endpoint, want)
+	}
+	_, err = funcs["output"].Call([]cty.Value{cty.StringVal("address")})
+	if err == nil {
+		t.Fatalf("no error for missing output")
+	}
+	if !strings.Contains(err.Error(), `"endpoint"`) {
+		t.Errorf("error does not name the available outputs: %s", err)
+	}
+
+	// resource_attr reads one attribute by address, and is precise about
+	// which part of the lookup failed.
+	id, err := funcs["resource_attr"].Call([]cty.Value{
+		cty.StringVal("aws_instance.web[0]"), cty.StringVal("id"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := cty.StringVal("i-123"); !id.RawEquals(want) {
+		t.Errorf("wrong id %#v; want %#v", id, want)
+	}
+	_, err = funcs["resource_attr"].Call([]cty.Value{
+		cty.StringVal("aws_instance.db"), cty.StringVal("id"),
+	})
+	if err == nil || !strings.Contains(err.Error(), `"aws_eip.lb"`) {
+		t.Errorf("missing-address error does not list the state's addresses: %s", err)
+	}
+	_, err = funcs["resource_attr"].Call([]cty.Value{
+		cty.StringVal("aws_eip.lb"), cty.StringVal("arn"),
+	})
+	if err == nil || !strings.Contains(err.Error(), `"arn"`) {
+		t.Errorf("missing-attribute error does not name the attribute: %s", err)
+	}
+
+	// Without a recorded state, the state-backed helpers say so rather
+	// than failing opaquely.
+	planOnly := stateFunctions(&Step{Name: "plan"}, &StepResult{})
+	_, err = planOnly["state_resources"].Call([]cty.Value{cty.StringVal("aws_instance")})
+	if err == nil || !strings.Contains(err.Error(), "left no state") {
+		t.Errorf("wrong error for missing state: %s", err)
+	}
+}
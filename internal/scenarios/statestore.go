@@ -0,0 +1,162 @@
+package scenarios
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statemgr"
+)
+
+// StateStore holds every state of one scenario execution entirely in
+// memory, keyed by the step state keys from Step.StateKey. Steps operate
+// through the statemgr.Full managers it hands out, so a scenario run can
+// chain and branch states with the usual locking discipline while never
+// touching the backend configured in the user's working directory.
+type StateStore struct {
+	mu   sync.Mutex
+	mgrs map[string]*storedState
+}
+
+// NewStateStore returns an empty state store: every state key starts as
+// an empty state.
+func NewStateStore() *StateStore {
+	return &StateStore{
+		mgrs: make(map[string]*storedState),
+	}
+}
+
+// Manager returns the state manager for the given state key, creating an
+// empty one on first use. Calls with the same key return the same
+// manager, so steps sharing a key share state.
+func (s *StateStore) Manager(key string) statemgr.Full {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mgr, ok := s.mgrs[key]
+	if !ok {
+		mgr = &storedState{
+			key:     key,
+			current: states.NewState(),
+		}
+		s.mgrs[key] = mgr
+	}
+	return mgr
+}
+
+// State returns a snapshot of the current state for the given key, or nil
+// if no step has used the key yet.
+func (s *StateStore) State(key string) *states.State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mgr, ok := s.mgrs[key]
+	if !ok {
+		return nil
+	}
+	return mgr.State()
+}
+
+// States returns snapshots of all of the store's non-empty states, keyed
+// by state key, in the form PreserveStates and final assertions expect.
+func (s *StateStore) States() map[string]*states.State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make(map[string]*states.State, len(s.mgrs))
+	for key, mgr := range s.mgrs {
+		state := mgr.State()
+		if state.Empty() {
+			continue
+		}
+		ret[key] = state
+	}
+	return ret
+}
+
+// Keys returns the state keys that have been used so far, sorted, mostly
+// for tests and diagnostics.
+func (s *StateStore) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]string, 0, len(s.mgrs))
+	for key := range s.mgrs {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// storedState is the statemgr.Full implementation behind one state key of
+// a StateStore. Both its transient and persistent layers are the same
+// in-memory snapshot, and its locks are plain mutex-guarded flags: the
+// states of a scenario live and die with the test process.
+type storedState struct {
+	key string
+
+	mu       sync.Mutex
+	current  *states.State
+	locked   bool
+	lockInfo *statemgr.LockInfo
+}
+
+var _ statemgr.Full = (*storedState)(nil)
+
+func (m *storedState) State() *states.State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current.DeepCopy()
+}
+
+func (m *storedState) WriteState(new *states.State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.current = new.DeepCopy()
+	return nil
+}
+
+func (m *storedState) RefreshState() error {
+	// There is no secondary storage to refresh from.
+	return nil
+}
+
+func (m *storedState) PersistState() error {
+	// There is no secondary storage to persist to.
+	return nil
+}
+
+func (m *storedState) Lock(info *statemgr.LockInfo) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locked {
+		return "", &statemgr.LockError{
+			Err:  fmt.Errorf("scenario state %q is already locked; two steps sharing a state cannot run in parallel", m.key),
+			Info: m.lockInfo,
+		}
+	}
+
+	m.locked = true
+	m.lockInfo = info
+	return info.ID, nil
+}
+
+func (m *storedState) Unlock(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.locked {
+		return fmt.Errorf("scenario state %q is not locked", m.key)
+	}
+	if m.lockInfo != nil && m.lockInfo.ID != id {
+		return fmt.Errorf("wrong lock id %q for scenario state %q", id, m.key)
+	}
+
+	m.locked = false
+	m.lockInfo = nil
+	return nil
+}
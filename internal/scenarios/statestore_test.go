@@ -0,0 +1,69 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statemgr"
+)
+
+func TestStateStore(t *testing.T) {
+	store := NewStateStore()
+
+	// The same key always yields the same manager; different keys don't.
+	def := store.Manager("default")
+	if store.Manager("default") != def {
+		t.Fatalf("same key gave a different manager")
+	}
+	fresh := store.Manager("fresh:drift")
+	if fresh == def {
+		t.Fatalf("different keys share a manager")
+	}
+
+	// States written through a manager come back out of the store.
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetOutputValue(
+			addrs.OutputValue{Name: "id"}.Absolute(addrs.RootModuleInstance),
+			cty.StringVal("i-123"), false,
+		)
+	})
+	if err := def.WriteState(state); err != nil {
+		t.Fatal(err)
+	}
+	got := store.State("default")
+	if got == nil || got.RootModule().OutputValues["id"] == nil {
+		t.Fatalf("written state not visible through the store: %#v", got)
+	}
+	if store.State("unused") != nil {
+		t.Errorf("state reported for an unused key")
+	}
+
+	// Only non-empty states appear in the final snapshot, as
+	// PreserveStates expects.
+	finals := store.States()
+	if len(finals) != 1 {
+		t.Fatalf("wrong final states %#v; want only \"default\"", finals)
+	}
+	if _, exists := finals["default"]; !exists {
+		t.Fatalf("no final state for \"default\"")
+	}
+
+	// A held lock blocks a second locker until released.
+	info := statemgr.NewLockInfo()
+	id, err := def.Lock(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := def.Lock(statemgr.NewLockInfo()); err == nil {
+		t.Fatalf("no error locking an already-locked state")
+	}
+	if err := def.Unlock(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := def.Lock(statemgr.NewLockInfo()); err != nil {
+		t.Fatalf("unexpected error after unlock: %s", err)
+	}
+}
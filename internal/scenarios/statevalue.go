@@ -0,0 +1,55 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/states"
+)
+
+// stateSymbol builds the value bound to step.<name>.state in scenario
+// expressions from the state a step left behind. The result gives a
+// read-only view of the state's resources keyed by address, with each
+// instance's attributes directly underneath:
+//
+//	step.v1.state.resources["aws_instance.web"].id
+//	step.v1.state.resource_count
+//
+// Chained upgrade tests compare these against the plan of a later step,
+// e.g. to assert that applying a new module version does not replace what
+// the old version created.
+func stateSymbol(state *states.State) (cty.Value, error) {
+	resources := make(map[string]interface{})
+	for _, module := range state.Modules {
+		for _, rs := range module.Resources {
+			for key, inst := range rs.Instances {
+				if inst.Current == nil || len(inst.Current.AttrsJSON) == 0 {
+					continue
+				}
+				var attrs map[string]interface{}
+				if err := json.Unmarshal(inst.Current.AttrsJSON, &attrs); err != nil {
+					return cty.NilVal, fmt.Errorf("invalid attributes for %s: %s", rs.Addr.Instance(key), err)
+				}
+				resources[rs.Addr.Instance(key).String()] = attrs
+			}
+		}
+	}
+
+	// Like planSymbol, a JSON round trip yields an object type matching
+	// the actual shape of this particular state.
+	reshaped, err := json.Marshal(map[string]interface{}{
+		"resources":      resources,
+		"resource_count": len(resources),
+	})
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(reshaped)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(reshaped, ty)
+}
@@ -0,0 +1,53 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+)
+
+func TestStateSymbol(t *testing.T) {
+	state := states.NewState()
+	root := state.EnsureModule(addrs.RootModuleInstance)
+	root.SetResourceInstanceCurrent(
+		addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: "web",
+		}.Instance(addrs.NoKey),
+		&states.ResourceInstanceObjectSrc{
+			Status:    states.ObjectReady,
+			AttrsJSON: []byte(`{"id":"i-123","instance_type":"t2.micro"}`),
+		},
+		addrs.AbsProviderConfig{
+			Module:   addrs.RootModule,
+			Provider: addrs.NewDefaultProvider("aws"),
+		},
+	)
+
+	val, err := stateSymbol(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web := val.GetAttr("resources").GetAttr("aws_instance.web")
+	if got, want := web.GetAttr("id"), cty.StringVal("i-123"); !got.RawEquals(want) {
+		t.Errorf("wrong id %#v; want %#v", got, want)
+	}
+	if got := val.GetAttr("resource_count"); got.AsBigFloat().String() != "1" {
+		t.Errorf("wrong resource_count %#v; want 1", got)
+	}
+
+	// The symbol is reachable from step expressions through a step
+	// result's State snapshot.
+	ctx := stepResultsEvalContext(map[string]*StepResult{
+		"v1": {State: state},
+	}, nil)
+	stepVal := ctx.Variables["step"].GetAttr("v1").GetAttr("state")
+	if got, want := stepVal.GetAttr("resources").GetAttr("aws_instance.web").GetAttr("instance_type"), cty.StringVal("t2.micro"); !got.RawEquals(want) {
+		t.Errorf("wrong instance_type via step reference %#v; want %#v", got, want)
+	}
+}
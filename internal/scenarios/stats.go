@@ -0,0 +1,91 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// StepStats are the resource-level costs of running one step, recorded so
+// teams can see which scenarios are consuming CI time and cloud spend.
+type StepStats struct {
+	// ProviderCalls is how many calls the step made against its providers,
+	// mocked or real.
+	ProviderCalls int
+
+	// ResourcesCreated and ResourcesDestroyed count the resource instances
+	// the step planned or applied to create and destroy. A replacement
+	// counts as one of each.
+	ResourcesCreated   int
+	ResourcesDestroyed int
+}
+
+// CountPlanChanges derives create and destroy counts from a plan rendered
+// in the external JSON plan format, for filling in StepStats when no
+// richer source is available.
+func CountPlanChanges(planJSON []byte) (created, destroyed int, err error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Change struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return 0, 0, err
+	}
+	for _, rc := range plan.ResourceChanges {
+		for _, action := range rc.Change.Actions {
+			switch action {
+			case "create":
+				created++
+			case "delete":
+				destroyed++
+			}
+		}
+	}
+	return created, destroyed, nil
+}
+
+// WriteStatsTable writes a plain-text summary table of the given results,
+// one row per step with its duration, provider calls, and resource
+// counts, followed by a totals row. It is the human-readable counterpart
+// of the stats carried in the JSON and JUnit reports.
+func WriteStatsTable(w io.Writer, results []*ScenarioResult) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCENARIO / STEP\tSTATUS\tDURATION\tCALLS\tCREATED\tDESTROYED")
+
+	var totalDuration time.Duration
+	var totalCalls, totalCreated, totalDestroyed int
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t\t%s\t\t\t\n", result.Scenario.Name, round(result.Duration))
+		totalDuration += result.Duration
+		for _, status := range result.Steps {
+			calls, created, destroyed := "-", "-", "-"
+			if stats := status.Stats; stats != nil {
+				calls = fmt.Sprintf("%d", stats.ProviderCalls)
+				created = fmt.Sprintf("%d", stats.ResourcesCreated)
+				destroyed = fmt.Sprintf("%d", stats.ResourcesDestroyed)
+				totalCalls += stats.ProviderCalls
+				totalCreated += stats.ResourcesCreated
+				totalDestroyed += stats.ResourcesDestroyed
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n",
+				status.Step.Name, status.Status, round(status.Duration),
+				calls, created, destroyed,
+			)
+		}
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%s\t%d\t%d\t%d\n",
+		round(totalDuration), totalCalls, totalCreated, totalDestroyed,
+	)
+	return tw.Flush()
+}
+
+// round trims a duration to milliseconds, which is plenty of precision
+// for a summary table.
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
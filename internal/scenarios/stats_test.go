@@ -0,0 +1,67 @@
+package scenarios
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountPlanChanges(t *testing.T) {
+	created, destroyed, err := CountPlanChanges([]byte(planValueTestJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fixture updates one instance and replaces an EIP.
+	if created != 1 || destroyed != 1 {
+		t.Errorf("wrong counts %d created, %d destroyed; want 1 and 1", created, destroyed)
+	}
+}
+
+func TestWriteStatsTable(t *testing.T) {
+	results := []*ScenarioResult{
+		{
+			Scenario: &Scenario{Name: "defaults"},
+			Duration: 1500 * time.Millisecond,
+			Steps: []*StepStatus{
+				{
+					Step:     &Step{Name: "apply"},
+					Status:   StepPassed,
+					Duration: 1200 * time.Millisecond,
+					Stats: &StepStats{
+						ProviderCalls:    4,
+						ResourcesCreated: 2,
+					},
+				},
+				{
+					Step:   &Step{Name: "verify"},
+					Status: StepSkipped,
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStatsTable(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"defaults", "apply", "1.2s", "verify", "TOTAL"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not mention %q:\n%s", want, out)
+		}
+	}
+	// The skipped step has no stats and shows placeholders, while the
+	// totals row sums what was recorded.
+	lines := strings.Split(out, "\n")
+	var totalLine string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "TOTAL") {
+			totalLine = line
+		}
+	}
+	if fields := strings.Fields(totalLine); len(fields) != 5 || fields[2] != "4" || fields[3] != "2" || fields[4] != "0" {
+		t.Errorf("wrong totals line %q", totalLine)
+	}
+}
@@ -0,0 +1,123 @@
+package scenarios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ParseSuiteVars interprets the -var and -var-file options given to the
+// test command into variable values that act as suite-level fallbacks: a
+// step uses them only for variables its scenario does not set itself.
+// Files apply first and in order, then the individual -var options, so
+// the more specific source wins within the fallbacks too.
+func ParseSuiteVars(vars []string, varFiles []string) (map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	ret := make(map[string]cty.Value)
+
+	for _, filename := range varFiles {
+		var file *hcl.File
+		var hclDiags hcl.Diagnostics
+		if strings.HasSuffix(filename, ".json") {
+			file, hclDiags = hcljson.ParseFile(filename)
+		} else {
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failed to read variables file",
+					fmt.Sprintf("Could not read %s: %s.", filename, err),
+				))
+				continue
+			}
+			file, hclDiags = hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+		}
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			continue
+		}
+
+		attrs, hclDiags := file.Body.JustAttributes()
+		diags = diags.Append(hclDiags)
+		for name, attr := range attrs {
+			val, hclDiags := attr.Expr.Value(nil)
+			diags = diags.Append(hclDiags)
+			if !hclDiags.HasErrors() {
+				ret[name] = val
+			}
+		}
+	}
+
+	for _, raw := range vars {
+		eq := strings.Index(raw, "=")
+		if eq == -1 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -var option",
+				fmt.Sprintf("The value %q is not valid: must be in NAME=VALUE format.", raw),
+			))
+			continue
+		}
+		name := raw[:eq]
+		rawVal := raw[eq+1:]
+
+		// The value is an HCL expression when it parses and evaluates as
+		// one, and a literal string otherwise, so both -var='ports=[80]'
+		// and -var=region=us-west-2 mean what they look like they mean.
+		expr, hclDiags := hclsyntax.ParseExpression([]byte(rawVal), "<var argument>", hcl.InitialPos)
+		if !hclDiags.HasErrors() {
+			if val, valDiags := expr.Value(nil); !valDiags.HasErrors() {
+				ret[name] = val
+				continue
+			}
+		}
+		ret[name] = cty.StringVal(rawVal)
+	}
+
+	return ret, diags
+}
+
+// CheckRequiredVariables verifies that every required input variable of
+// the step's module has a value, before any operation that would
+// otherwise try to prompt for one. Input prompting is disabled during
+// test runs — a CI run must never block on it — so a missing value is a
+// structured error naming the scenario, step, and variable instead.
+func (s *Scenario) CheckRequiredVariables(step *Step, mod *configs.Module, values map[string]cty.Value) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	names := make([]string, 0, len(mod.Variables))
+	for name := range mod.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := mod.Variables[name]
+		if !v.Required() {
+			continue
+		}
+		if val, ok := values[name]; ok && val != cty.NilVal && !val.IsNull() {
+			continue
+		}
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing value for required variable",
+			Detail: fmt.Sprintf(
+				"Step %q of scenario %q requires a value for variable %q, and input prompting is disabled during test runs. Set it in a variables block, or pass a fallback with the -var or -var-file options.",
+				step.Name, s.Name, name,
+			),
+			Subject: v.DeclRange.Ptr(),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,96 @@
+package scenarios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/configs"
+)
+
+func TestParseSuiteVars(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tf-suitevars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	varFile := filepath.Join(tmpDir, "ci.tfvars")
+	src := "region = \"us-east-1\"\nreplicas = 2\n"
+	if err := ioutil.WriteFile(varFile, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vals, diags := ParseSuiteVars(
+		[]string{"region=us-west-2", "ports=[80, 443]"},
+		[]string{varFile},
+	)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// -var options override values from the file.
+	if got, want := vals["region"], cty.StringVal("us-west-2"); !got.RawEquals(want) {
+		t.Errorf("wrong region %#v; want %#v", got, want)
+	}
+	if got, want := vals["replicas"], cty.NumberIntVal(2); !got.RawEquals(want) {
+		t.Errorf("wrong replicas %#v; want %#v", got, want)
+	}
+
+	// A -var value that parses as an expression keeps its structure.
+	ports := vals["ports"]
+	if !ports.Type().IsTupleType() || ports.LengthInt() != 2 {
+		t.Errorf("wrong ports %#v; want a two-element tuple", ports)
+	}
+
+	// Values in NAME only form are rejected.
+	_, diags = ParseSuiteVars([]string{"region"}, nil)
+	if !diags.HasErrors() {
+		t.Fatalf("no error for a -var without a value")
+	}
+}
+
+func TestCheckRequiredVariables(t *testing.T) {
+	parser := configs.NewParser(nil)
+	mod, hclDiags := parser.LoadConfigDir("testdata/coverage")
+	if hclDiags.HasErrors() {
+		t.Fatalf("unexpected errors loading module: %s", hclDiags.Error())
+	}
+
+	scenario := &Scenario{Name: "regions"}
+	step := &Step{Name: "plan"}
+
+	// "region" has no default, so a step without a value for it fails
+	// with a diagnostic naming the scenario, step, and variable.
+	diags := scenario.CheckRequiredVariables(step, mod, nil)
+	if !diags.HasErrors() {
+		t.Fatalf("no error for missing required variable")
+	}
+	detail := diags[0].Description().Detail
+	for _, want := range []string{`"plan"`, `"regions"`, `"region"`, "-var"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("detail does not mention %s:\n%s", want, detail)
+		}
+	}
+
+	// A value satisfies the requirement; "instance_type" has a default
+	// and never needs one.
+	diags = scenario.CheckRequiredVariables(step, mod, map[string]cty.Value{
+		"region": cty.StringVal("us-west-2"),
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// A null value is as good as no value.
+	diags = scenario.CheckRequiredVariables(step, mod, map[string]cty.Value{
+		"region": cty.NullVal(cty.String),
+	})
+	if !diags.HasErrors() {
+		t.Fatalf("no error for null required variable")
+	}
+}
@@ -0,0 +1,30 @@
+variable "region" {
+  type = string
+}
+
+variable "instance_type" {
+  type    = string
+  default = "t2.micro"
+}
+
+data "aws_ami" "base" {
+}
+
+resource "aws_instance" "web" {
+  count = 2
+}
+
+resource "aws_eip" "lb" {
+}
+
+module "network" {
+  source = "./modules/network"
+}
+
+output "endpoint" {
+  value = aws_instance.web[0].public_dns
+}
+
+output "vpc_id" {
+  value = module.network.vpc_id
+}
@@ -0,0 +1,6 @@
+resource "aws_vpc" "main" {
+}
+
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
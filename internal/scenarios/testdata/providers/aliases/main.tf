@@ -0,0 +1,15 @@
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.east]
+    }
+  }
+}
+
+resource "aws_instance" "west" {
+}
+
+resource "aws_instance" "east" {
+  provider = aws.east
+}
@@ -0,0 +1,103 @@
+package scenarios
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// VariableExprs returns the input variable expressions that apply to the
+// given step, merging the scenario's top-level variables block with the
+// step's own. Step-level declarations override scenario-level ones of the
+// same name.
+func (s *Scenario) VariableExprs(step *Step) map[string]hcl.Expression {
+	if len(s.Variables) == 0 && len(step.Variables) == 0 {
+		return nil
+	}
+
+	ret := make(map[string]hcl.Expression, len(s.Variables)+len(step.Variables))
+	for name, expr := range s.Variables {
+		ret[name] = expr
+	}
+	for name, expr := range step.Variables {
+		ret[name] = expr
+	}
+	return ret
+}
+
+// StepVariables evaluates the input variable expressions for the given
+// step. The results map gives the outcomes of the steps that have already
+// run, keyed by step name, so expressions may refer to the outputs of a
+// prior step as step.<name>.outputs.<output>. A non-nil run additionally
+// exposes the run symbols and random helpers.
+func (s *Scenario) StepVariables(step *Step, results map[string]*StepResult, run *Run) (map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	exprs := s.VariableExprs(step)
+	if len(exprs) == 0 {
+		return nil, diags
+	}
+
+	ctx := stepResultsEvalContext(results, run)
+
+	// Functions stubbed by the scenario's mock providers are callable
+	// from variable expressions too, so a variable can be derived the
+	// same way the module would derive it.
+	if fns := s.MockFunctions(); len(fns) > 0 {
+		if ctx.Functions == nil {
+			ctx.Functions = make(map[string]function.Function)
+		}
+		for name, fn := range fns {
+			ctx.Functions[name] = fn
+		}
+	}
+
+	ret := make(map[string]cty.Value, len(exprs))
+	for name, expr := range exprs {
+		val, hclDiags := expr.Value(ctx)
+		diags = diags.Append(hclDiags)
+		if hclDiags.HasErrors() {
+			continue
+		}
+		ret[name] = val
+	}
+	return ret, diags
+}
+
+// stepResultsEvalContext constructs the evaluation context for expressions
+// in a scenario file, exposing the outputs and resulting state of
+// previously-run steps under a "step" variable, and, when a run identity
+// is given, the "run" symbols and deterministic random helpers.
+func stepResultsEvalContext(results map[string]*StepResult, run *Run) *hcl.EvalContext {
+	steps := make(map[string]cty.Value, len(results))
+	for name, result := range results {
+		outputs := make(map[string]cty.Value, len(result.Outputs))
+		for outName, outVal := range result.Outputs {
+			outputs[outName] = outVal
+		}
+		attrs := map[string]cty.Value{
+			"outputs": cty.ObjectVal(outputs),
+		}
+		if result.State != nil {
+			// A state that cannot be reshaped is simply absent, so a
+			// reference to it fails with the usual missing-attribute
+			// error.
+			if stateVal, err := stateSymbol(result.State); err == nil {
+				attrs["state"] = stateVal
+			}
+		}
+		steps[name] = cty.ObjectVal(attrs)
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"step": cty.ObjectVal(steps),
+		},
+	}
+	if run != nil {
+		run.addToContext(ctx)
+	}
+	return ctx
+}
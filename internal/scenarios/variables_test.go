@@ -0,0 +1,82 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStepVariables(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/variables")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if len(scenarios) != 1 {
+		t.Fatalf("wrong number of scenarios %d; want 1", len(scenarios))
+	}
+	scenario := scenarios[0]
+
+	// The first step overrides one of the scenario-level variables.
+	plan := scenario.Steps[0]
+	got, diags := scenario.StepVariables(plan, nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	want := map[string]cty.Value{
+		"region":        cty.StringVal("us-east-1"),
+		"instance_type": cty.StringVal("t2.micro"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of variables %d; want %d", len(got), len(want))
+	}
+	for name, wantVal := range want {
+		if gotVal, exists := got[name]; !exists || !gotVal.RawEquals(wantVal) {
+			t.Errorf("wrong value for %q\ngot:  %#v\nwant: %#v", name, gotVal, wantVal)
+		}
+	}
+
+	// The second step refers to an output of the first.
+	apply := scenario.Steps[1]
+	results := map[string]*StepResult{
+		"plan": {
+			Outputs: map[string]cty.Value{
+				"image_id": cty.StringVal("ami-12345"),
+			},
+		},
+	}
+	got, diags = scenario.StepVariables(apply, results, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if gotVal, want := got["image_id"], cty.StringVal("ami-12345"); !gotVal.RawEquals(want) {
+		t.Errorf("wrong value for image_id\ngot:  %#v\nwant: %#v", gotVal, want)
+	}
+	if gotVal, want := got["region"], cty.StringVal("us-west-2"); !gotVal.RawEquals(want) {
+		t.Errorf("wrong value for region\ngot:  %#v\nwant: %#v", gotVal, want)
+	}
+
+	// Without the first step's result the reference cannot be resolved.
+	if _, diags := scenario.StepVariables(apply, nil, nil); !diags.HasErrors() {
+		t.Errorf("unexpected success evaluating step.plan reference with no results")
+	}
+}
+
+func TestStepEnv(t *testing.T) {
+	scenarios, diags := LoadScenariosDir("testdata/variables")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	scenario := scenarios[0]
+
+	// The plan step overrides the scenario-level region.
+	env := scenario.StepEnv(scenario.Steps[0])
+	if got, want := env["AWS_DEFAULT_REGION"], "us-east-1"; got != want {
+		t.Errorf("wrong region for plan step %q; want %q", got, want)
+	}
+
+	// The apply step only has the scenario-level env.
+	env = scenario.StepEnv(scenario.Steps[1])
+	if got, want := env["AWS_DEFAULT_REGION"], "us-west-2"; got != want {
+		t.Errorf("wrong region for apply step %q; want %q", got, want)
+	}
+}
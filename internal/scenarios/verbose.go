@@ -0,0 +1,82 @@
+package scenarios
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// StepOutput returns the writer a step implementation should stream its
+// plan diff and apply progress to. In verbose mode it yields a writer
+// that prefixes every line with "scenario/step: " and serializes writes
+// against the other steps, so the interleaved output of concurrent
+// scenarios stays attributable; otherwise the output is discarded and
+// only the final statuses are reported. Callers should Close the writer
+// when the step finishes to flush any unterminated line.
+func (r *Runner) StepOutput(scenario *Scenario, step *Step) io.WriteCloser {
+	if !r.Verbose || r.Output == nil {
+		return nopWriteCloser{ioutil.Discard}
+	}
+	return &prefixWriter{
+		w:      r.Output,
+		mu:     &r.outputMu,
+		prefix: fmt.Sprintf("%s/%s: ", scenario.Name, step.Name),
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// prefixWriter inserts a fixed prefix at the start of every line written
+// through it. Partial lines are buffered until their newline arrives, so
+// a line is never emitted with another step's output spliced into it.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.buf.Write(p)
+
+	var out bytes.Buffer
+	for {
+		line, err := pw.buf.ReadString('\n')
+		if err != nil {
+			// The remainder has no newline yet; keep it for next time.
+			pw.buf.Reset()
+			pw.buf.WriteString(line)
+			break
+		}
+		out.WriteString(pw.prefix)
+		out.WriteString(line)
+	}
+	if out.Len() > 0 {
+		pw.mu.Lock()
+		_, err := pw.w.Write(out.Bytes())
+		pw.mu.Unlock()
+		if err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Close flushes any buffered partial line, terminating it with a newline
+// so the next writer starts cleanly.
+func (pw *prefixWriter) Close() error {
+	if pw.buf.Len() == 0 {
+		return nil
+	}
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	_, err := fmt.Fprintf(pw.w, "%s%s\n", pw.prefix, pw.buf.String())
+	pw.buf.Reset()
+	return err
+}
@@ -0,0 +1,66 @@
+package scenarios
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStepOutput(t *testing.T) {
+	scenario := &Scenario{Name: "defaults"}
+	plan := &Step{Name: "plan"}
+	apply := &Step{Name: "apply"}
+
+	// Without -verbose, step output goes nowhere.
+	var buf bytes.Buffer
+	runner := &Runner{Output: &buf}
+	w := runner.StepOutput(scenario, plan)
+	io.WriteString(w, "should not appear\n")
+	w.Close()
+	if buf.Len() != 0 {
+		t.Fatalf("non-verbose runner wrote output: %q", buf.String())
+	}
+
+	// In verbose mode every line carries its scenario/step prefix, even
+	// when written in fragments or interleaved with another step.
+	runner.Verbose = true
+	pw := runner.StepOutput(scenario, plan)
+	aw := runner.StepOutput(scenario, apply)
+	io.WriteString(pw, "  # aws_instance.web will be ")
+	io.WriteString(aw, "aws_instance.web: Creating...\n")
+	io.WriteString(pw, "updated in-place\n")
+	io.WriteString(pw, "no newline at end")
+	pw.Close()
+	aw.Close()
+
+	got := buf.String()
+	want := []string{
+		"defaults/apply: aws_instance.web: Creating...",
+		"defaults/plan:   # aws_instance.web will be updated in-place",
+		"defaults/plan: no newline at end",
+	}
+	for _, line := range want {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("missing line %q in output:\n%s", line, got)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		if !strings.HasPrefix(line, "defaults/") {
+			t.Errorf("line without prefix: %q", line)
+		}
+	}
+}
+
+func TestStepOutputNoWriter(t *testing.T) {
+	// A verbose runner with no output writer still hands out a usable
+	// writer rather than panicking.
+	runner := &Runner{Verbose: true}
+	w := runner.StepOutput(&Scenario{Name: "x"}, &Step{Name: "y"})
+	if _, err := io.Copy(w, strings.NewReader("boop\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,72 @@
+package scenarios
+
+import (
+	"fmt"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/hashicorp/terraform/tfdiags"
+	tfversion "github.com/hashicorp/terraform/version"
+)
+
+// supportedFeatures are the test runner features this version of
+// Terraform implements, for required_features gating. A scenario naming
+// anything else fails fast with a clear message instead of misbehaving
+// part-way through a run on an older CLI.
+var supportedFeatures = map[string]bool{
+	"mock_providers":   true,
+	"mock_functions":   true,
+	"overrides":        true,
+	"parallel_steps":   true,
+	"retries":          true,
+	"runbook_steps":    true,
+	"skip_conditions":  true,
+	"snapshots":        true,
+	"state_references": true,
+	"depends_on":       true,
+	"coverage":         true,
+}
+
+// CheckCompatibility verifies that this version of Terraform can run the
+// scenario: its required_version constraint must match the running
+// version and every entry of required_features must be a feature this
+// test runner implements. Suites shared across teams set these so that
+// users on older CLIs get one actionable error rather than confusing
+// failures from half-understood scenario files.
+func (s *Scenario) CheckCompatibility() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if s.RequiredVersion != "" {
+		constraint, err := goversion.NewConstraint(s.RequiredVersion)
+		if err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid required_version constraint",
+				Detail:   fmt.Sprintf("The constraint %q is not valid: %s.", s.RequiredVersion, err),
+				Subject:  &s.RequiredVersionRange,
+			})
+		} else if !constraint.Check(tfversion.SemVer) {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported Terraform version",
+				Detail:   fmt.Sprintf("Scenario %q requires Terraform %s, but this is Terraform %s. Upgrade Terraform to run this suite.", s.Name, s.RequiredVersion, tfversion.SemVer),
+				Subject:  &s.RequiredVersionRange,
+			})
+		}
+	}
+
+	for _, feature := range s.RequiredFeatures {
+		if supportedFeatures[feature] {
+			continue
+		}
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported test runner feature",
+			Detail:   fmt.Sprintf("Scenario %q requires the test runner feature %q, which this version of Terraform does not implement. Upgrade Terraform to run this suite.", s.Name, feature),
+			Subject:  &s.RequiredFeaturesRange,
+		})
+	}
+
+	return diags
+}
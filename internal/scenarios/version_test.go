@@ -0,0 +1,47 @@
+package scenarios
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	scenario := &Scenario{Name: "gated"}
+
+	// No requirements means always compatible.
+	if diags := scenario.CheckCompatibility(); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// A satisfiable constraint and supported features pass.
+	scenario.RequiredVersion = ">= 0.1.0"
+	scenario.RequiredFeatures = []string{"mock_providers", "snapshots"}
+	if diags := scenario.CheckCompatibility(); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// An unsatisfiable constraint fails with an actionable message.
+	scenario.RequiredVersion = ">= 99.0.0"
+	diags := scenario.CheckCompatibility()
+	if !diags.HasErrors() {
+		t.Fatalf("no error for future version requirement")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, "Upgrade Terraform") {
+		t.Errorf("detail not actionable:\n%s", detail)
+	}
+
+	// So does an invalid constraint, and an unknown feature.
+	scenario.RequiredVersion = "not a constraint"
+	if diags := scenario.CheckCompatibility(); !diags.HasErrors() {
+		t.Errorf("no error for invalid constraint")
+	}
+	scenario.RequiredVersion = ""
+	scenario.RequiredFeatures = []string{"time_travel"}
+	diags = scenario.CheckCompatibility()
+	if !diags.HasErrors() {
+		t.Fatalf("no error for unknown feature")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, `"time_travel"`) {
+		t.Errorf("detail does not name the feature:\n%s", detail)
+	}
+}
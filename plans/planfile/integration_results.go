@@ -0,0 +1,144 @@
+package planfile
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+const tfintegrationsFilename = "tfintegrations.json"
+
+// IntegrationResults captures what the configured integrations reported
+// while a plan was being created, so that someone reviewing the saved plan
+// file later can see the same policy results that were shown at plan time.
+//
+// These types deliberately mirror the wire format of the integrations
+// protocol rather than referring to it directly, so that the saved
+// representation can stay stable even if the protocol changes.
+type IntegrationResults struct {
+	// Integrations describes the set of integrations that were running
+	// when the plan was created, so that applying the plan can detect if
+	// the set has changed in the meantime.
+	Integrations []IntegrationDescriptor `json:"integrations,omitempty"`
+
+	// Annotations are the remarks integrations attached to planned
+	// changes, keyed by resource instance address.
+	Annotations map[string][]IntegrationAnnotation `json:"annotations,omitempty"`
+
+	// Findings are the problems integrations reported during planning.
+	Findings []IntegrationFinding `json:"findings,omitempty"`
+}
+
+// IntegrationDescriptor identifies one integration that was running when a
+// plan was created.
+type IntegrationDescriptor struct {
+	// Name is the label of the integration block that configured the
+	// integration.
+	Name string `json:"name"`
+
+	// Source is the registry-style address of a source-addressed
+	// integration, and Version is the installed version that was selected.
+	// Both are empty for integrations configured with a local command.
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// ExecutableHash is the lowercase hex SHA-256 hash of the
+	// integration's executable, when it could be determined.
+	ExecutableHash string `json:"executable_hash,omitempty"`
+
+	// EnforceAtApply records the integration's enforce_at_apply setting,
+	// which decides whether a difference in this integration at apply time
+	// is an error or just a warning.
+	EnforceAtApply bool `json:"enforce_at_apply,omitempty"`
+}
+
+// IntegrationAnnotation is one remark an integration attached to a planned
+// change, for display under the corresponding resource diff.
+type IntegrationAnnotation struct {
+	// Integration is the name of the integration that produced the
+	// annotation.
+	Integration string `json:"integration"`
+
+	// Message is a short human-oriented remark.
+	Message string `json:"message"`
+
+	// Severity is "error", "warning", or "info". An unrecognized or empty
+	// severity is treated as "info".
+	Severity string `json:"severity,omitempty"`
+
+	// URL optionally links to documentation about the remark.
+	URL string `json:"url,omitempty"`
+}
+
+// IntegrationFinding is one problem an integration reported during
+// planning.
+type IntegrationFinding struct {
+	// Integration is the name of the integration that reported the
+	// finding.
+	Integration string `json:"integration"`
+
+	// Address names the resource instance the finding is about, if any.
+	Address string `json:"address,omitempty"`
+
+	// Severity is "error" or "warning". An unrecognized or empty severity
+	// is treated as "warning".
+	Severity string `json:"severity,omitempty"`
+
+	// Summary is a short description of the problem, and Detail optionally
+	// elaborates on it.
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Empty returns true if the results contain nothing at all, in which case
+// there is nothing worth recording in the plan file.
+func (r *IntegrationResults) Empty() bool {
+	return r == nil || (len(r.Integrations) == 0 && len(r.Annotations) == 0 && len(r.Findings) == 0)
+}
+
+// writeIntegrationResults adds a file to the given zip writer recording the
+// given integration results.
+func writeIntegrationResults(results *IntegrationResults, z *zip.Writer) error {
+	w, err := z.CreateHeader(&zip.FileHeader{
+		Name:     tfintegrationsFilename,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// ReadIntegrationResults reads the integration results embedded in the plan
+// file, if any. Plan files created without integrations configured have no
+// results, in which case both return values are nil.
+func (r *Reader) ReadIntegrationResults() (*IntegrationResults, error) {
+	for _, file := range r.zip.File {
+		if file.Name != tfintegrationsFilename {
+			continue
+		}
+		fr, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract integration results from plan file: %s", err)
+		}
+		defer fr.Close()
+		buf, err := ioutil.ReadAll(fr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract integration results from plan file: %s", err)
+		}
+		var results IntegrationResults
+		if err := json.Unmarshal(buf, &results); err != nil {
+			return nil, fmt.Errorf("the integration results in the plan file could not be parsed: %s", err)
+		}
+		return &results, nil
+	}
+	return nil, nil
+}
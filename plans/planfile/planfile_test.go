@@ -63,7 +63,7 @@ func TestRoundtrip(t *testing.T) {
 	}
 	planFn := filepath.Join(workDir, "tfplan")
 
-	err = Create(planFn, snapIn, stateFileIn, planIn)
+	err = Create(planFn, snapIn, stateFileIn, planIn, nil)
 	if err != nil {
 		t.Fatalf("failed to create plan file: %s", err)
 	}
@@ -112,4 +112,96 @@ func TestRoundtrip(t *testing.T) {
 			t.Errorf("when reading config: %s", diags.Err())
 		}
 	})
+
+	t.Run("ReadIntegrationResults", func(t *testing.T) {
+		// This plan file was created without integration results, so
+		// reading them back should succeed with nothing.
+		results, err := pr.ReadIntegrationResults()
+		if err != nil {
+			t.Fatalf("failed to read integration results: %s", err)
+		}
+		if results != nil {
+			t.Errorf("unexpected integration results: %s", spew.Sdump(results))
+		}
+	})
+}
+
+func TestRoundtripIntegrationResults(t *testing.T) {
+	fixtureDir := filepath.Join("testdata", "test-config")
+	loader, err := configload.NewLoader(&configload.Config{
+		ModulesDir: filepath.Join(fixtureDir, ".terraform", "modules"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, snapIn, diags := loader.LoadConfigWithSnapshot(fixtureDir)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	stateFileIn := &statefile.File{
+		TerraformVersion: tfversion.SemVer,
+		Serial:           1,
+		Lineage:          "abc123",
+		State:            states.NewState(),
+	}
+	planIn := &plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{},
+			Outputs:   []*plans.OutputChangeSrc{},
+		},
+		ProviderSHA256s: map[string][]byte{},
+		VariableValues:  map[string]plans.DynamicValue{},
+		Backend: plans.Backend{
+			Type:      "local",
+			Config:    plans.DynamicValue([]byte("config placeholder")),
+			Workspace: "default",
+		},
+	}
+
+	resultsIn := &IntegrationResults{
+		Annotations: map[string][]IntegrationAnnotation{
+			"test_instance.foo": {
+				{
+					Integration: "cost",
+					Message:     "adds $12/mo",
+					Severity:    "info",
+				},
+			},
+		},
+		Findings: []IntegrationFinding{
+			{
+				Integration: "policy",
+				Address:     "test_instance.foo",
+				Severity:    "warning",
+				Summary:     "Instance is not tagged",
+				Detail:      "All instances must carry a cost-center tag.",
+			},
+		},
+	}
+
+	workDir, err := ioutil.TempDir("", "tf-planfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	planFn := filepath.Join(workDir, "tfplan")
+
+	err = Create(planFn, snapIn, stateFileIn, planIn, resultsIn)
+	if err != nil {
+		t.Fatalf("failed to create plan file: %s", err)
+	}
+
+	pr, err := Open(planFn)
+	if err != nil {
+		t.Fatalf("failed to open plan file for reading: %s", err)
+	}
+
+	resultsOut, err := pr.ReadIntegrationResults()
+	if err != nil {
+		t.Fatalf("failed to read integration results: %s", err)
+	}
+	if !reflect.DeepEqual(resultsIn, resultsOut) {
+		t.Errorf("integration results did not survive round-trip\nresult: %sinput: %s", spew.Sdump(resultsOut), spew.Sdump(resultsIn))
+	}
 }
@@ -18,7 +18,11 @@ import (
 // state file in addition to the plan itself, so that Terraform can detect
 // if the world has changed since the plan was created and thus refuse to
 // apply it.
-func Create(filename string, configSnap *configload.Snapshot, stateFile *statefile.File, plan *plans.Plan) error {
+//
+// integrationResults optionally records what the configured integrations
+// reported while the plan was being created; pass nil when there are no
+// integrations configured.
+func Create(filename string, configSnap *configload.Snapshot, stateFile *statefile.File, plan *plans.Plan, integrationResults *IntegrationResults) error {
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -68,5 +72,13 @@ func Create(filename string, configSnap *configload.Snapshot, stateFile *statefi
 		}
 	}
 
+	// tfintegrations file, only if there's anything to record
+	if !integrationResults.Empty() {
+		err := writeIntegrationResults(integrationResults, zw)
+		if err != nil {
+			return fmt.Errorf("failed to write integration results: %s", err)
+		}
+	}
+
 	return nil
 }
@@ -746,7 +746,7 @@ func contextOptsForPlanViaFile(configSnap *configload.Snapshot, plan *plans.Plan
 	}
 
 	filename := filepath.Join(dir, "tfplan")
-	err = planfile.Create(filename, configSnap, stateFile, plan)
+	err = planfile.Create(filename, configSnap, stateFile, plan, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,52 @@
+package terraform
+
+import (
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/states"
+)
+
+// HookWithConfig is an optional extension of Hook for implementations that
+// want to receive the configuration of a resource along with the usual
+// hook arguments.
+//
+// Hook call sites test for this interface dynamically, so implementing it
+// is optional. If a registered hook does not implement HookWithConfig then
+// the corresponding base Hook method is called instead.
+type HookWithConfig interface {
+	Hook
+
+	// PreDiffWithConfig is like PreDiff but additionally receives the
+	// configuration of the resource whose instance is about to be planned,
+	// allowing a hook to act on the configuration before the provider is
+	// asked to produce a planned new state.
+	PreDiffWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, priorState, proposedNewState cty.Value, config *configs.Resource) (HookAction, error)
+
+	// PostDiffWithConfig is like PostDiff but additionally receives the
+	// configuration of the resource whose instance was planned. The config
+	// may be nil in situations where no configuration is available, such
+	// as when planning to destroy.
+	PostDiffWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value, config *configs.Resource) (HookAction, error)
+
+	// PreApplyWithConfig is like PreApply but additionally receives the
+	// configuration of the resource being applied, both as declared and as
+	// its evaluated value. Either may be absent (nil and cty.NilVal
+	// respectively), such as when destroying or when evaluation fails.
+	PreApplyWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, action plans.Action, priorState, plannedNewState cty.Value, config *configs.Resource, configVal cty.Value) (HookAction, error)
+
+	// PostApplyWithConfig is like PostApply but additionally receives the
+	// configuration of the resource that was applied, both as declared and
+	// as its evaluated value, subject to the same caveats as
+	// PreApplyWithConfig.
+	PostApplyWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, newState cty.Value, err error, config *configs.Resource, configVal cty.Value) (HookAction, error)
+
+	// PreRefreshWithConfig and PostRefreshWithConfig are like PreRefresh
+	// and PostRefresh but additionally receive the configuration of the
+	// resource being refreshed, which may be nil for resources that are in
+	// state but no longer in configuration.
+	PreRefreshWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, priorState cty.Value, config *configs.Resource) (HookAction, error)
+	PostRefreshWithConfig(addr addrs.AbsResourceInstance, gen states.Generation, priorState cty.Value, newState cty.Value, config *configs.Resource) (HookAction, error)
+}
@@ -0,0 +1,21 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// HookDeferred is an optional extension of Hook for implementations that
+// want to know when Terraform could not fully plan a resource, such as when
+// its count or for_each value won't be known until apply.
+//
+// Hook call sites test for this interface dynamically, so implementing it
+// is optional. Terraform currently has no way to continue past such a
+// resource, so this event always accompanies an error; it exists so that
+// observers can distinguish "couldn't be planned" from a normal change.
+type HookDeferred interface {
+	Hook
+
+	// PostPlanDeferred is called when planning for the given resource was
+	// deferred for the given reason.
+	PostPlanDeferred(addr addrs.AbsResource, reason string) (HookAction, error)
+}
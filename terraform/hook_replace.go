@@ -0,0 +1,27 @@
+package terraform
+
+import (
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+)
+
+// HookReplacement is an optional extension of Hook for implementations
+// that want the replacement context of an apply: which deposed object an
+// event concerns, whether the resource creates its replacement before
+// destroying the old object, and which attribute changes required the
+// replacement in the first place. Without this context the create and
+// destroy halves of a replacement look like two unrelated events.
+//
+// Hook call sites test for this interface dynamically, so implementing
+// it is optional.
+type HookReplacement interface {
+	Hook
+
+	// ApplyingReplacement is called just before the PreApply event of a
+	// change that is part of a replacement, or that acts on a deposed
+	// object. requiredReplace lists the paths whose changes forced the
+	// replacement, and may be empty for the deposed-destroy half.
+	ApplyingReplacement(addr addrs.AbsResourceInstance, dk states.DeposedKey, requiredReplace cty.PathSet, createBeforeDestroy bool)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/terraform/lang"
 	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ConcreteResourceNodeFunc is a callback type used to convert an
@@ -319,6 +320,13 @@ func (n *NodeAbstractResource) writeResourceState(ctx EvalContext, addr addrs.Ab
 		count, countDiags := evaluateCountExpression(n.Config.Count, ctx)
 		diags = diags.Append(countDiags)
 		if countDiags.HasErrors() {
+			// If the problem was that the count isn't known yet then we
+			// notify any hooks that care about deferred planning before we
+			// fail. (We're on an error path already, so re-evaluating the
+			// expression here is harmless.)
+			if countVal, _ := evaluateCountExpressionValue(n.Config.Count, ctx); countVal != cty.NilVal && !countVal.IsNull() && !countVal.IsKnown() {
+				n.notifyDeferredExpansion(ctx, addr, `the "count" value depends on values not known until apply`)
+			}
 			return diags
 		}
 
@@ -329,6 +337,9 @@ func (n *NodeAbstractResource) writeResourceState(ctx EvalContext, addr addrs.Ab
 		forEach, forEachDiags := evaluateForEachExpression(n.Config.ForEach, ctx)
 		diags = diags.Append(forEachDiags)
 		if forEachDiags.HasErrors() {
+			if forEachVal, _ := evaluateForEachExpressionValue(n.Config.ForEach, ctx, true); forEachVal != cty.NilVal && !forEachVal.IsNull() && !forEachVal.IsKnown() {
+				n.notifyDeferredExpansion(ctx, addr, `the "for_each" value depends on values not known until apply`)
+			}
 			return diags
 		}
 
@@ -345,6 +356,19 @@ func (n *NodeAbstractResource) writeResourceState(ctx EvalContext, addr addrs.Ab
 	return diags
 }
 
+// notifyDeferredExpansion tells any hooks that implement HookDeferred that
+// the given resource couldn't be expanded during planning. The hooks'
+// verdicts are ignored because the caller is already on its way to
+// returning an error.
+func (n *NodeAbstractResource) notifyDeferredExpansion(ctx EvalContext, addr addrs.AbsResource, reason string) {
+	ctx.Hook(func(h Hook) (HookAction, error) {
+		if hd, ok := h.(HookDeferred); ok {
+			return hd.PostPlanDeferred(addr, reason)
+		}
+		return HookActionContinue, nil
+	})
+}
+
 // readResourceInstanceState reads the current object for a specific instance in
 // the state.
 func (n *NodeAbstractResource) readResourceInstanceState(ctx EvalContext, addr addrs.AbsResourceInstance) (*states.ResourceInstanceObject, error) {
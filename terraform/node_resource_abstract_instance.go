@@ -220,7 +220,28 @@ func (n *NodeAbstractResourceInstance) preApplyHook(ctx EvalContext, change *pla
 		priorState := change.Before
 		plannedNewState := change.After
 
+		// The evaluated configuration is only needed by config-aware hooks,
+		// so we evaluate it lazily in case none are registered.
+		configVal := cty.NilVal
+		configEvaled := false
+
 		diags = diags.Append(ctx.Hook(func(h Hook) (HookAction, error) {
+			if hr, ok := h.(HookReplacement); ok {
+				if change.Action.IsReplace() || change.DeposedKey != states.NotDeposed {
+					cbd := false
+					if n.Config != nil && n.Config.Managed != nil {
+						cbd = n.Config.Managed.CreateBeforeDestroy
+					}
+					hr.ApplyingReplacement(n.Addr, change.DeposedKey, change.RequiredReplace, cbd)
+				}
+			}
+			if hc, ok := h.(HookWithConfig); ok {
+				if !configEvaled {
+					configVal = n.hookConfigValue(ctx)
+					configEvaled = true
+				}
+				return hc.PreApplyWithConfig(n.Addr, change.DeposedKey.Generation(), change.Action, priorState, plannedNewState, n.Config, configVal)
+			}
 			return h.PreApply(n.Addr, change.DeposedKey.Generation(), change.Action, priorState, plannedNewState)
 		}))
 		if diags.HasErrors() {
@@ -243,7 +264,16 @@ func (n *NodeAbstractResourceInstance) postApplyHook(ctx EvalContext, state *sta
 		} else {
 			newState = cty.NullVal(cty.DynamicPseudoType)
 		}
+		configVal := cty.NilVal
+		configEvaled := false
 		diags = diags.Append(ctx.Hook(func(h Hook) (HookAction, error) {
+			if hc, ok := h.(HookWithConfig); ok {
+				if !configEvaled {
+					configVal = n.hookConfigValue(ctx)
+					configEvaled = true
+				}
+				return hc.PostApplyWithConfig(n.Addr, nil, newState, err, n.Config, configVal)
+			}
 			return h.PostApply(n.Addr, nil, newState, err)
 		}))
 	}
@@ -251,6 +281,31 @@ func (n *NodeAbstractResourceInstance) postApplyHook(ctx EvalContext, state *sta
 	return diags
 }
 
+// hookConfigValue evaluates the resource's configuration for delivery to
+// config-aware hooks. The result is advisory, so any failure to evaluate
+// here just yields cty.NilVal rather than a diagnostic; the main codepaths
+// evaluate the configuration themselves and report errors properly.
+func (n *NodeAbstractResourceInstance) hookConfigValue(ctx EvalContext) cty.Value {
+	if n.Config == nil {
+		return cty.NilVal
+	}
+	_, providerSchema, err := getProvider(ctx, n.ResolvedProvider)
+	if err != nil || providerSchema == nil {
+		return cty.NilVal
+	}
+	schema, _ := providerSchema.SchemaForResourceAddr(n.Addr.ContainingResource().Resource)
+	if schema == nil {
+		return cty.NilVal
+	}
+	forEach, _ := evaluateForEachExpression(n.Config.ForEach, ctx)
+	keyData := EvalDataForInstanceKey(n.Addr.Resource.Key, forEach)
+	configVal, _, configDiags := ctx.EvaluateBlock(n.Config.Config, schema, nil, keyData)
+	if configDiags.HasErrors() {
+		return cty.NilVal
+	}
+	return configVal
+}
+
 type phaseState int
 
 const (
@@ -461,6 +516,9 @@ func (n *NodeAbstractResourceInstance) refresh(ctx EvalContext, state *states.Re
 
 	// Call pre-refresh hook
 	diags = diags.Append(ctx.Hook(func(h Hook) (HookAction, error) {
+		if hc, ok := h.(HookWithConfig); ok {
+			return hc.PreRefreshWithConfig(absAddr, states.CurrentGen, state.Value, n.Config)
+		}
 		return h.PreRefresh(absAddr, states.CurrentGen, state.Value)
 	}))
 	if diags.HasErrors() {
@@ -531,6 +589,9 @@ func (n *NodeAbstractResourceInstance) refresh(ctx EvalContext, state *states.Re
 
 	// Call post-refresh hook
 	diags = diags.Append(ctx.Hook(func(h Hook) (HookAction, error) {
+		if hc, ok := h.(HookWithConfig); ok {
+			return hc.PostRefreshWithConfig(absAddr, states.CurrentGen, priorVal, ret.Value, n.Config)
+		}
 		return h.PostRefresh(absAddr, states.CurrentGen, priorVal, ret.Value)
 	}))
 	if diags.HasErrors() {
@@ -653,6 +714,9 @@ func (n *NodeAbstractResourceInstance) plan(
 
 	// Call pre-diff hook
 	diags = diags.Append(ctx.Hook(func(h Hook) (HookAction, error) {
+		if hc, ok := h.(HookWithConfig); ok {
+			return hc.PreDiffWithConfig(n.Addr, states.CurrentGen, priorVal, proposedNewVal, n.Config)
+		}
 		return h.PreDiff(n.Addr, states.CurrentGen, priorVal, proposedNewVal)
 	}))
 	if diags.HasErrors() {
@@ -937,6 +1001,9 @@ func (n *NodeAbstractResourceInstance) plan(
 
 	// Call post-refresh hook
 	diags = diags.Append(ctx.Hook(func(h Hook) (HookAction, error) {
+		if hc, ok := h.(HookWithConfig); ok {
+			return hc.PostDiffWithConfig(n.Addr, states.CurrentGen, action, priorVal, plannedNewVal, n.Config)
+		}
 		return h.PostDiff(n.Addr, states.CurrentGen, action, priorVal, plannedNewVal)
 	}))
 	if diags.HasErrors() {